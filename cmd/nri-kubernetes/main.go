@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"path"
 	"runtime"
@@ -10,23 +12,41 @@ import (
 	"time"
 
 	sdk "github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/newrelic/infra-integrations-sdk/persist"
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 
+	"github.com/newrelic/nri-kubernetes/v3/internal/aggregate"
+	"github.com/newrelic/nri-kubernetes/v3/internal/attributefilter"
 	"github.com/newrelic/nri-kubernetes/v3/internal/cloud"
 	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/cost"
 	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
+	"github.com/newrelic/nri-kubernetes/v3/internal/owner"
+	"github.com/newrelic/nri-kubernetes/v3/internal/relabel"
+	"github.com/newrelic/nri-kubernetes/v3/internal/rightsizing"
+	"github.com/newrelic/nri-kubernetes/v3/internal/startup"
+	"github.com/newrelic/nri-kubernetes/v3/internal/storer"
 	"github.com/newrelic/nri-kubernetes/v3/src/client"
 	"github.com/newrelic/nri-kubernetes/v3/src/controlplane"
+	"github.com/newrelic/nri-kubernetes/v3/src/controlplane/client/authenticator"
 	"github.com/newrelic/nri-kubernetes/v3/src/integration"
 	"github.com/newrelic/nri-kubernetes/v3/src/ksm"
 	ksmClient "github.com/newrelic/nri-kubernetes/v3/src/ksm/client"
 	"github.com/newrelic/nri-kubernetes/v3/src/kubelet"
 	kubeletClient "github.com/newrelic/nri-kubernetes/v3/src/kubelet/client"
 	kubeletMetric "github.com/newrelic/nri-kubernetes/v3/src/kubelet/metric"
+	"github.com/newrelic/nri-kubernetes/v3/src/metric"
 	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
 )
 
@@ -54,6 +74,8 @@ var (
 
 var logger *log.Logger
 
+var dryRun = flag.Bool("dry-run", false, "Scrape once and print the samples, after applying the relabel rules, to stdout instead of publishing them") //nolint: gochecknoglobals
+
 type clusterClients struct {
 	k8s      kubernetes.Interface
 	ksm      prometheus.MetricFamiliesGetFunc
@@ -62,6 +84,7 @@ type clusterClients struct {
 }
 
 func main() {
+	flag.Parse()
 	logger = log.StandardLogger()
 
 	c, err := config.LoadConfig(config.DefaultConfigFolderName, config.DefaultConfigFileName)
@@ -91,10 +114,12 @@ func main() {
 		}),
 	}
 
-	switch c.Sink.Type {
-	case config.SinkTypeHTTP:
+	switch {
+	case *dryRun:
+		logger.Info("Running once without publishing, samples are printed to stdout")
+	case c.Sink.Type == config.SinkTypeHTTP:
 		integrationOptions = append(integrationOptions, integration.WithHTTPSink(c.Sink.HTTP))
-	case config.SinkTypeStdout:
+	case c.Sink.Type == config.SinkTypeStdout:
 		// We don't need to do anything here to sink to stdout, as it's the default behavior of integration.Wrapper.
 		logger.Warn("Sinking metrics to stdout")
 	default:
@@ -138,9 +163,27 @@ func main() {
 	// Emitted as the cloud.resource_id attribute.
 	cloudClusterID := detectCloudClusterID(c, clients.k8s)
 
+	ownerResolver, ownerResolverCloser, err := setupOwnerResolver(c, clients)
+	if err != nil {
+		logger.Errorf("setting up owner resolver: %v", err)
+		os.Exit(exitSetup)
+	}
+	if ownerResolverCloser != nil {
+		defer close(ownerResolverCloser)
+	}
+
+	var extraSpecs metric.ExtraSpecs
+	if c.ExtraSpecsFile != "" {
+		extraSpecs, err = metric.LoadExtraSpecs(c.ExtraSpecsFile)
+		if err != nil {
+			logger.Errorf("loading extra specs: %v", err)
+			os.Exit(exitSetup)
+		}
+	}
+
 	var kubeletScraper *kubelet.Scraper
 	if c.Kubelet.Enabled {
-		kubeletScraper, err = setupKubelet(c, clients, namespaceCache, interfaceCache, cloudClusterID)
+		kubeletScraper, err = setupKubelet(c, clients, namespaceCache, interfaceCache, ownerResolver, cloudClusterID, extraSpecs.Kubelet)
 		if err != nil {
 			logger.Errorf("setting up kubelet scraper: %v", err)
 			os.Exit(exitSetup)
@@ -150,7 +193,7 @@ func main() {
 
 	var ksmScraper *ksm.Scraper
 	if c.KSM.Enabled {
-		ksmScraper, err = setupKSM(c, clients, namespaceCache, cloudClusterID)
+		ksmScraper, err = setupKSM(c, clients, namespaceCache, ownerResolver, cloudClusterID, extraSpecs.KSM)
 		if err != nil {
 			logger.Errorf("setting up ksm scraper: %v", err)
 			os.Exit(exitSetup)
@@ -168,6 +211,30 @@ func main() {
 		defer controlplaneScraper.Close()
 	}
 
+	costAllocator, costCloser, err := setupCost(c, clients)
+	if err != nil {
+		logger.Errorf("setting up cost allocation: %v", err)
+		os.Exit(exitSetup)
+	}
+	if costCloser != nil {
+		defer close(costCloser)
+	}
+
+	recommender, err := setupRightsizing(c)
+	if err != nil {
+		logger.Errorf("setting up rightsizing: %v", err)
+		os.Exit(exitSetup)
+	}
+
+	aggregationStage := setupAggregation(c, clients, recommender)
+	startupReporter := setupStartup(c)
+
+	relabeler, err := relabel.New(c.Relabel)
+	if err != nil {
+		logger.Errorf("building relabel rules: %v", err)
+		os.Exit(exitConfig)
+	}
+
 	var scrapeCount uint64
 	for {
 		scrapeCount++
@@ -185,6 +252,40 @@ func main() {
 			os.Exit(exitLoop)
 		}
 
+		if costAllocator != nil {
+			if err := costAllocator.Apply(i); err != nil {
+				logger.Warnf("allocating costs: %v", err)
+			}
+		}
+
+		// With aggregation, the recommender consumes the partials of all nodes instead.
+		if recommender != nil && !c.Aggregation.Enabled {
+			if err := recommender.Apply(i); err != nil {
+				logger.Warnf("recommending container resources: %v", err)
+			}
+		}
+
+		if aggregationStage != nil {
+			if err := runAggregation(c, aggregationStage, i); err != nil {
+				logger.Warnf("aggregating usage: %v", err)
+			}
+		}
+
+		if startupReporter != nil {
+			if err := startupReporter.Apply(i); err != nil {
+				logger.Warnf("reporting startup latencies: %v", err)
+			}
+		}
+
+		relabelResult := relabeler.Apply(i)
+		if *dryRun {
+			logRelabelResult(relabelResult)
+			if err := i.Publish(); err != nil {
+				logger.Errorf("printing integration: %v", err)
+			}
+			return
+		}
+
 		logger.Debugf("publishing data")
 		publishTime := measureTime(func() {
 			err = i.Publish()
@@ -216,6 +317,18 @@ func main() {
 	}
 }
 
+// logRelabelResult logs the number of samples each relabel rule changed or dropped.
+func logRelabelResult(result relabel.Result) {
+	if len(result.Rules) == 0 {
+		logger.Info("No relabel rules configured")
+		return
+	}
+
+	for n, r := range result.Rules {
+		logger.Infof("Relabel rule #%d (%s %q, event types %v) changed or dropped %d samples", n, r.Action, r.Attribute, r.EventTypes, r.Samples)
+	}
+}
+
 func measureTime(fn func()) time.Duration {
 	start := time.Now()
 	fn()
@@ -251,6 +364,20 @@ func runScrapers(c *config.Config, ksmScraper *ksm.Scraper, kubeletScraper *kube
 	return nil
 }
 
+// rollsUpPartials returns whether the integration rolls up the partials of all nodes when aggregation is enabled, which
+// is done by the KSM scraper unless it only scrapes the KSM pod in its own node.
+func rollsUpPartials(c *config.Config) bool {
+	return c.KSM.Enabled && !c.KSM.NodeLocal.Enabled
+}
+
+// runAggregation runs the aggregation stage, giving up after the scrape interval.
+func runAggregation(c *config.Config, stage *aggregate.Stage, i *sdk.Integration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Interval)
+	defer cancel()
+
+	return stage.Run(ctx, i)
+}
+
 // detectCloudClusterID attempts to auto-detect the cluster id from the cloud
 // provider hosting this node. It is best-effort: on failure (or when disabled) it
 // returns an empty string and the integration proceeds without the attribute.
@@ -274,13 +401,17 @@ func detectCloudClusterID(c *config.Config, k8s kubernetes.Interface) string {
 	return id
 }
 
-func setupKSM(c *config.Config, clients *clusterClients, namespaceCache *discovery.NamespaceInMemoryStore, cloudClusterID string) (*ksm.Scraper, error) {
+func setupKSM(c *config.Config, clients *clusterClients, namespaceCache *discovery.NamespaceInMemoryStore, ownerResolver owner.Resolver, cloudClusterID string, extra metric.ExtraSource) (*ksm.Scraper, error) {
 	providers := ksm.Providers{
 		K8s: clients.k8s,
 		KSM: clients.ksm,
 	}
 
-	scraperOpts := []ksm.ScraperOpt{ksm.WithLogger(logger), ksm.WithCloudClusterID(cloudClusterID)}
+	scraperOpts := []ksm.ScraperOpt{ksm.WithLogger(logger), ksm.WithCloudClusterID(cloudClusterID), ksm.WithExtraSpecs(extra)}
+
+	if ownerResolver != nil {
+		scraperOpts = append(scraperOpts, ksm.WithOwnerResolver(ownerResolver))
+	}
 
 	if c.NamespaceSelector != nil {
 		nsFilter := discovery.NewNamespaceFilter(c.NamespaceSelector, clients.k8s, logger)
@@ -290,6 +421,14 @@ func setupKSM(c *config.Config, clients *clusterClients, namespaceCache *discove
 		)
 	}
 
+	if c.AttributeFilter != nil {
+		attributeFilter, err := attributefilter.New(c.AttributeFilter)
+		if err != nil {
+			return nil, fmt.Errorf("building attribute filter: %w", err)
+		}
+		scraperOpts = append(scraperOpts, ksm.WithAttributeFilter(attributeFilter))
+	}
+
 	ksmScraper, err := ksm.NewScraper(c, providers, scraperOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("building KSM scraper: %w", err)
@@ -298,6 +437,132 @@ func setupKSM(c *config.Config, clients *clusterClients, namespaceCache *discove
 	return ksmScraper, nil
 }
 
+// setupOwnerResolver returns the resolver shared by the scrapers to find the workloads owning pods, and the channel to
+// close to stop its informers. It returns a nil resolver if owner resolution is disabled or not needed.
+//
+//nolint:ireturn // A nil Resolver means only the direct owner of pods is known.
+func setupOwnerResolver(c *config.Config, clients *clusterClients) (owner.Resolver, chan<- struct{}, error) {
+	if !c.OwnerResolution.Enabled || (!c.Kubelet.Enabled && !c.KSM.Enabled) {
+		return nil, nil, nil
+	}
+
+	restConfig, err := getK8sConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadataClient, err := metadata.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("building metadata client: %w", err)
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clients.k8s.Discovery()))
+
+	resolver, closer, err := owner.NewMetadataResolver(metadataClient, mapper, owner.WithLogger(logger))
+	if err != nil {
+		return nil, nil, fmt.Errorf("building owner resolver: %w", err)
+	}
+
+	return resolver, closer, nil
+}
+
+// setupAggregation returns the stage rolling up the usage of containers per workload and namespace, or nil if
+// aggregation is disabled. Kubelet scrapers collect the partial of their node, which is rolled up by the KSM scraper,
+// unless it only scrapes the KSM pod in its own node.
+func setupAggregation(c *config.Config, clients *clusterClients, recommender *rightsizing.Recommender) *aggregate.Stage {
+	if !c.Aggregation.Enabled {
+		return nil
+	}
+
+	opts := []aggregate.StageOpt{
+		aggregate.WithLogger(logger),
+		aggregate.WithMaxPartialAge(c.Aggregation.MaxPartialAge),
+		aggregate.WithCustomAttributes(c.CustomAttributes),
+	}
+
+	if c.Kubelet.Enabled {
+		opts = append(opts, aggregate.WithCollector(c.NodeName), aggregate.WithStartupLatencyWindow(c.Kubelet.StartupLatencyWindow))
+	}
+
+	if rollsUpPartials(c) {
+		opts = append(opts, aggregate.WithRollup())
+		if recommender != nil {
+			opts = append(opts, aggregate.WithConsumer(recommender))
+		}
+	}
+
+	if c.Aggregation.Namespace != "" {
+		opts = append(opts, aggregate.WithStore(aggregate.NewConfigMapStore(clients.k8s, c.Aggregation.Namespace), *dryRun))
+	}
+
+	return aggregate.NewStage(c.ClusterName, opts...)
+}
+
+// setupStartup returns the reporter rolling up the startup latencies of the pods in the node the kubelet scraper runs
+// in, or nil if they are rolled up across nodes by the aggregation stage, the rollup is disabled, or the kubelet is not
+// scraped.
+func setupStartup(c *config.Config) *startup.Reporter {
+	if c.Aggregation.Enabled || !c.Kubelet.Enabled || c.Kubelet.StartupLatencyWindow <= 0 {
+		return nil
+	}
+
+	return startup.NewReporter(c.ClusterName, c.NodeName, c.Kubelet.StartupLatencyWindow, c.CustomAttributes)
+}
+
+// setupCost returns the allocator of the costs of the node the kubelet scraper runs in, or nil if it is disabled or the
+// kubelet is not scraped.
+func setupCost(c *config.Config, clients *clusterClients) (*cost.Allocator, chan<- struct{}, error) {
+	if !c.Cost.Enabled || !c.Kubelet.Enabled {
+		return nil, nil, nil
+	}
+
+	table, err := cost.LoadPriceTable(c.Cost.PriceTableFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading price table: %w", err)
+	}
+
+	nodeLister, nodeCloser := discovery.NewNodeLister(clients.k8s, informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", c.NodeName).String()
+	}))
+
+	allocator := cost.NewAllocator(table, nodeLister, c.NodeName, c.ClusterName, c.Interval, cost.WithCustomAttributes(c.CustomAttributes))
+
+	return allocator, nodeCloser, nil
+}
+
+// setupRightsizing returns a rightsizing.Recommender keeping the usage histories in the configured file, or in memory
+// if there is none or in dry runs. If aggregation is enabled, it is only built where partials are rolled up, and
+// recommends for the containers in all nodes. Otherwise, kubelet scrapers build one for the containers in their node.
+func setupRightsizing(c *config.Config) (*rightsizing.Recommender, error) {
+	nodeName := c.NodeName
+
+	switch {
+	case !c.Rightsizing.Enabled:
+		return nil, nil
+	case c.Aggregation.Enabled:
+		if !rollsUpPartials(c) {
+			return nil, nil
+		}
+		nodeName = ""
+	case !c.Kubelet.Enabled:
+		return nil, nil
+	}
+
+	var store storer.Storer = storer.NewInMemoryStore(rightsizing.HistoryRetention, storer.DefaultInterval, logger)
+	if c.Rightsizing.StoreFile != "" && !*dryRun {
+		fileStore, err := persist.NewFileStore(c.Rightsizing.StoreFile, logger, rightsizing.HistoryRetention)
+		if err != nil {
+			return nil, fmt.Errorf("opening usage histories store %q: %w", c.Rightsizing.StoreFile, err)
+		}
+		store = fileStore
+	}
+
+	return rightsizing.NewRecommender(store, c.ClusterName, nodeName, c.Rightsizing,
+		rightsizing.WithLogger(logger),
+		rightsizing.WithCustomAttributes(c.CustomAttributes),
+	), nil
+}
+
 func setupControlPlane(c *config.Config, clients *clusterClients, cloudClusterID string) (*controlplane.Scraper, error) {
 	providers := controlplane.Providers{
 		K8s: clients.k8s,
@@ -322,7 +587,7 @@ func setupControlPlane(c *config.Config, clients *clusterClients, cloudClusterID
 	return controlplaneScraper, nil
 }
 
-func setupKubelet(c *config.Config, clients *clusterClients, namespaceCache *discovery.NamespaceInMemoryStore, interfaceCache *kubeletMetric.InterfaceCache, cloudClusterID string) (*kubelet.Scraper, error) {
+func setupKubelet(c *config.Config, clients *clusterClients, namespaceCache *discovery.NamespaceInMemoryStore, interfaceCache *kubeletMetric.InterfaceCache, ownerResolver owner.Resolver, cloudClusterID string, extra metric.ExtraSource) (*kubelet.Scraper, error) {
 	providers := kubelet.Providers{
 		K8s:      clients.k8s,
 		Kubelet:  clients.kubelet,
@@ -333,6 +598,11 @@ func setupKubelet(c *config.Config, clients *clusterClients, namespaceCache *dis
 		kubelet.WithLogger(logger),
 		kubelet.WithInterfaceCache(interfaceCache),
 		kubelet.WithCloudClusterID(cloudClusterID),
+		kubelet.WithExtraSpecs(extra),
+	}
+
+	if ownerResolver != nil {
+		scraperOpts = append(scraperOpts, kubelet.WithOwnerResolver(ownerResolver))
 	}
 
 	if c.NamespaceSelector != nil {
@@ -340,9 +610,19 @@ func setupKubelet(c *config.Config, clients *clusterClients, namespaceCache *dis
 		scraperOpts = append(
 			scraperOpts,
 			kubelet.WithFilterer(discovery.NewCachedNamespaceFilter(nsFilter, namespaceCache)),
+			// Labels of namespaces are propagated from the informer the filter already has.
+			kubelet.WithNamespaceLister(nsFilter.Lister()),
 		)
 	}
 
+	if c.AttributeFilter != nil {
+		attributeFilter, err := attributefilter.New(c.AttributeFilter)
+		if err != nil {
+			return nil, fmt.Errorf("building attribute filter: %w", err)
+		}
+		scraperOpts = append(scraperOpts, kubelet.WithAttributeFilter(attributeFilter))
+	}
+
 	ksmScraper, err := kubelet.NewScraper(c, providers, scraperOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("building kubelet scraper: %w", err)
@@ -364,11 +644,22 @@ func buildClients(c *config.Config) (*clusterClients, error) {
 
 	var ksmCli *ksmClient.Client
 	if c.KSM.Enabled {
-		ksmCli, err = ksmClient.New(
+		ksmTransport, err := buildKSMTransport(c, k8s, k8sConfig)
+		if err != nil {
+			return nil, fmt.Errorf("building KSM transport: %w", err)
+		}
+
+		ksmOpts := []ksmClient.OptionFunc{
 			ksmClient.WithLogger(logger),
 			ksmClient.WithTimeout(c.KSM.Timeout),
 			ksmClient.WithMaxRetries(c.KSM.Retries),
-		)
+			ksmClient.WithTransport(ksmTransport),
+		}
+		if c.DisablePrometheusProtobuf {
+			ksmOpts = append(ksmOpts, ksmClient.WithTextFormat())
+		}
+
+		ksmCli, err = ksmClient.New(ksmOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("building KSM client: %w", err)
 		}
@@ -376,11 +667,15 @@ func buildClients(c *config.Config) (*clusterClients, error) {
 
 	var kubeletCli *kubeletClient.Client
 	if c.Kubelet.Enabled {
-		kubeletCli, err = kubeletClient.New(
-			kubeletClient.DefaultConnector(k8s, c, k8sConfig, logger),
+		kubeletOpts := []kubeletClient.OptionFunc{
 			kubeletClient.WithLogger(logger),
 			kubeletClient.WithMaxRetries(c.Kubelet.Retries),
-		)
+		}
+		if c.DisablePrometheusProtobuf {
+			kubeletOpts = append(kubeletOpts, kubeletClient.WithTextFormat())
+		}
+
+		kubeletCli, err = kubeletClient.New(kubeletClient.DefaultConnector(k8s, c, k8sConfig, logger), kubeletOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("building Kubelet client: %w", err)
 		}
@@ -394,6 +689,51 @@ func buildClients(c *config.Config) (*clusterClients, error) {
 	}, nil
 }
 
+// buildKSMTransport returns a RoundTripper implementing the TLS and authentication settings configured for KSM, or nil
+// if none is configured.
+//
+//nolint:nilnil,ireturn // A nil RoundTripper means the default one will be used.
+func buildKSMTransport(c *config.Config, k8s kubernetes.Interface, k8sConfig *rest.Config) (http.RoundTripper, error) {
+	if c.KSM.Auth == nil && !c.KSM.InsecureSkipVerify && c.KSM.CABundlePath == "" {
+		return nil, nil
+	}
+
+	// Certificates are read once when building the transport, so the secret holding them is fetched by name, which only
+	// requires permissions to get it.
+	var secrets []*corev1.Secret
+	if c.KSM.Auth != nil && c.KSM.Auth.MTLS != nil && c.KSM.Auth.MTLS.TLSSecretName != "" {
+		mtls := c.KSM.Auth.MTLS
+		secret, err := k8s.CoreV1().Secrets(mtls.TLSSecretNamespace).Get(context.Background(), mtls.TLSSecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("getting KSM mTLS secret %s/%s: %w", mtls.TLSSecretNamespace, mtls.TLSSecretName, err)
+		}
+		secrets = append(secrets, secret)
+	}
+
+	secretListerer, err := discovery.NewStaticSecretListerer(secrets...)
+	if err != nil {
+		return nil, fmt.Errorf("building KSM secret listerer: %w", err)
+	}
+
+	auth, err := authenticator.New(
+		authenticator.Config{
+			SecretListerer:  secretListerer,
+			InClusterConfig: k8sConfig,
+		},
+		authenticator.WithLogger(logger),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating authenticator: %w", err)
+	}
+
+	return auth.AuthenticatedTransport(config.Endpoint{
+		URL:                "kube-state-metrics",
+		Auth:               c.KSM.Auth,
+		InsecureSkipVerify: c.KSM.InsecureSkipVerify,
+		CABundlePath:       c.KSM.CABundlePath,
+	})
+}
+
 func getK8sConfig(c *config.Config) (*rest.Config, error) {
 	inclusterConfig, err := rest.InClusterConfig()
 	if err == nil {
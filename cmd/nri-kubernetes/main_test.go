@@ -11,6 +11,7 @@ import (
 	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
 	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
 	kubeletMetric "github.com/newrelic/nri-kubernetes/v3/src/kubelet/metric"
+	"github.com/newrelic/nri-kubernetes/v3/src/metric"
 )
 
 func TestSetupKubelet(t *testing.T) {
@@ -30,7 +31,7 @@ func TestSetupKubelet(t *testing.T) {
 	providers := clusterClients{
 		k8s: fake.NewSimpleClientset(),
 	}
-	scraper, err := setupKubelet(&c, &providers, namespaceCache, interfaceCache, "")
+	scraper, err := setupKubelet(&c, &providers, namespaceCache, interfaceCache, nil, "", metric.ExtraSource{})
 	assert.NoError(t, err)
 	assert.NotEmpty(t, scraper)
 	assert.NotEmpty(t, scraper.Filterer)
@@ -52,7 +53,7 @@ func TestSetupKSM(t *testing.T) {
 	providers := clusterClients{
 		k8s: fake.NewSimpleClientset(),
 	}
-	scraper, err := setupKSM(&c, &providers, namespaceCache, "")
+	scraper, err := setupKSM(&c, &providers, namespaceCache, nil, "", metric.ExtraSource{})
 	assert.NoError(t, err)
 	assert.NotEmpty(t, scraper)
 	assert.NotEmpty(t, scraper.Filterer)
@@ -0,0 +1,414 @@
+// Package aggregate rolls up the usage of containers per workload and namespace, joining the usage reported by the
+// kubelet scrapers with the workload state reported by the KSM scraper.
+//
+// Kubelet scrapers only see the containers in their own node, so each of them collects a Partial with the usage of
+// its containers and the startup latencies of its recently created pods. Partials are shared through a Store, and the
+// KSM scraper, which runs once per cluster, merges them into K8sWorkloadUsageSample and K8sNamespaceUsageSample
+// samples, and rolls up the startup latencies across nodes through the startup package.
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/sample"
+	"github.com/newrelic/nri-kubernetes/v3/internal/startup"
+)
+
+// Event types of the rolled up samples.
+const (
+	WorkloadUsageEventType  = "K8sWorkloadUsageSample"
+	NamespaceUsageEventType = "K8sNamespaceUsageSample"
+)
+
+// runningStatus is the status of the container samples of running containers.
+const runningStatus = "Running"
+
+// containerMetrics are the metrics of container samples collected in partials. Their sum is reported, along with the
+// percentiles of the ones in percentileMetrics.
+var containerMetrics = []string{ //nolint: gochecknoglobals // read-only list.
+	"cpuUsedCores",
+	"memoryWorkingSetBytes",
+	"restartCountDelta",
+	"containerCpuCfsThrottledPeriodsDelta",
+	"containerCpuCfsThrottledSecondsDelta",
+}
+
+// containerResources are the requests and limits of container samples collected in partials. They are not rolled up,
+// but kept for the consumers of partials, like rightsizing.
+var containerResources = []string{ //nolint: gochecknoglobals // read-only list.
+	"cpuRequestedCores",
+	"cpuLimitCores",
+	"memoryRequestedBytes",
+	"memoryLimitBytes",
+}
+
+// podMetrics are the metrics of pod samples collected in partials. Network usage is only known per pod.
+var podMetrics = []string{ //nolint: gochecknoglobals // read-only list.
+	"net.rxBytesPerSecond",
+	"net.txBytesPerSecond",
+	"net.errorsPerSecond",
+}
+
+// percentileMetrics are the metrics whose p50, p95 and max across containers are reported.
+var percentileMetrics = map[string]bool{ //nolint: gochecknoglobals // read-only map.
+	"cpuUsedCores":          true,
+	"memoryWorkingSetBytes": true,
+}
+
+// workloadSampleTypes maps the kinds of the workloads usage is rolled up for to the KSM sample holding their state
+// and the attribute holding their name in it.
+var workloadSampleTypes = map[string]struct { //nolint: gochecknoglobals // read-only map.
+	eventType     string
+	nameAttribute string
+}{
+	"Deployment":  {eventType: "K8sDeploymentSample", nameAttribute: "deploymentName"},
+	"StatefulSet": {eventType: "K8sStatefulsetSample", nameAttribute: "statefulsetName"},
+	"DaemonSet":   {eventType: "K8sDaemonsetSample", nameAttribute: "daemonsetName"},
+	"CronJob":     {eventType: "K8sCronjobSample", nameAttribute: "cronjobName"},
+}
+
+// jobSampleType is the KSM sample of Jobs, whose owner is used to account the usage of the pods of Jobs to their
+// CronJob when owners are not resolved.
+const jobSampleType = "K8sJobSample"
+
+// workloadStateMetrics are the metrics copied from the KSM workload samples to the usage ones.
+var workloadStateMetrics = []string{ //nolint: gochecknoglobals // read-only list.
+	"podsDesired",
+	"podsReady",
+	"podsAvailable",
+	"isActive",
+}
+
+// Partial holds the usage of the containers and pods of a node, and the startup latencies of its recently created pods.
+type Partial struct {
+	NodeName   string        `json:"nodeName"`
+	Timestamp  time.Time     `json:"timestamp"`
+	Containers []Usage       `json:"containers,omitempty"`
+	Pods       []Usage       `json:"pods,omitempty"`
+	Startups   []startup.Pod `json:"startups,omitempty"`
+}
+
+// Usage holds the metrics of a container or pod.
+type Usage struct {
+	Namespace     string             `json:"namespace"`
+	PodName       string             `json:"podName"`
+	ContainerName string             `json:"containerName,omitempty"`
+	WorkloadKind  string             `json:"workloadKind,omitempty"`
+	WorkloadName  string             `json:"workloadName,omitempty"`
+	Values        map[string]float64 `json:"values,omitempty"`
+	// Resources are the requests and limits of containers.
+	Resources map[string]float64 `json:"resources,omitempty"`
+}
+
+// Collect returns the partial with the usage in the container and pod samples of i, and the startup latencies of the
+// pods created in the startupWindow before now, as collected by startup.Collect. Samples of the same container or pod,
+// like the ones populated by both the KSM and kubelet scrapers, are merged. Init, ephemeral and not running containers
+// are left out, as they would skew the usage of the workloads.
+func Collect(i *integration.Integration, nodeName string, now time.Time, startupWindow time.Duration) Partial {
+	p := Partial{NodeName: nodeName, Timestamp: now, Startups: startup.Collect(i, now, startupWindow)}
+
+	containers := sample.Containers(i)
+	for _, key := range sortedKeys(containers) {
+		ms := containers[key]
+		if !sample.AppContainer(ms) {
+			continue
+		}
+		if status := sample.StringAttribute(ms, "status"); status != "" && status != runningStatus {
+			continue
+		}
+
+		u := usage(ms, containerMetrics)
+		u.ContainerName = sample.StringAttribute(ms, "containerName")
+		for _, name := range containerResources {
+			if v, ok := ms.Metrics[name].(float64); ok {
+				if u.Resources == nil {
+					u.Resources = map[string]float64{}
+				}
+				u.Resources[name] = v
+			}
+		}
+		p.Containers = append(p.Containers, u)
+	}
+
+	pods := sample.Pods(i)
+	for _, key := range sortedKeys(pods) {
+		p.Pods = append(p.Pods, usage(pods[key], podMetrics))
+	}
+
+	return p
+}
+
+// usage returns the usage of the container or pod of ms, with the given metrics, accounted to the workload returned by
+// sample.Workload.
+func usage(ms *metric.Set, metrics []string) Usage {
+	u := Usage{
+		Namespace: sample.StringAttribute(ms, "namespaceName"),
+		PodName:   sample.StringAttribute(ms, "podName"),
+		Values:    map[string]float64{},
+	}
+	u.WorkloadKind, u.WorkloadName = sample.Workload(ms)
+
+	for _, name := range metrics {
+		if v, ok := ms.Metrics[name].(float64); ok {
+			u.Values[name] = v
+		}
+	}
+
+	return u
+}
+
+func sortedKeys(samples map[string]*metric.Set) []string {
+	keys := make([]string, 0, len(samples))
+	for key := range samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// rollup accumulates the usage of a workload or namespace.
+type rollup struct {
+	attributes []attribute.Attribute
+	nodes      map[string]bool
+	pods       map[string]bool
+	containers int
+	values     map[string][]float64
+}
+
+func newRollup(attributes ...attribute.Attribute) *rollup {
+	return &rollup{
+		attributes: attributes,
+		nodes:      map[string]bool{},
+		pods:       map[string]bool{},
+		values:     map[string][]float64{},
+	}
+}
+
+func (r *rollup) addContainer(nodeName string, u Usage) {
+	r.nodes[nodeName] = true
+	r.pods[u.PodName] = true
+	r.containers++
+	r.addValues(u)
+}
+
+func (r *rollup) addPod(nodeName string, u Usage) {
+	r.nodes[nodeName] = true
+	r.pods[u.PodName] = true
+	r.addValues(u)
+}
+
+func (r *rollup) addValues(u Usage) {
+	for name, v := range u.Values {
+		r.values[name] = append(r.values[name], v)
+	}
+}
+
+// metrics returns the sums and percentiles of the accumulated usage.
+func (r *rollup) metrics() map[string]float64 {
+	metrics := map[string]float64{
+		"nodeCount":      float64(len(r.nodes)),
+		"podCount":       float64(len(r.pods)),
+		"containerCount": float64(r.containers),
+	}
+
+	for name, values := range r.values {
+		sort.Float64s(values)
+
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		metrics[name+"Sum"] = sum
+
+		if percentileMetrics[name] {
+			metrics[name+"P50"] = percentile(values, 50)
+			metrics[name+"P95"] = percentile(values, 95)
+			metrics[name+"Max"] = values[len(values)-1]
+		}
+	}
+
+	return metrics
+}
+
+// Rollup merges partials into a K8sWorkloadUsageSample per Deployment, StatefulSet, DaemonSet and CronJob, and a
+// K8sNamespaceUsageSample per namespace, added to i. The state of workloads, like podsDesired, is copied from their KSM
+// samples in i, if any. Usage of Jobs owned by a CronJob, as reported when owners are not resolved, is accounted to
+// the CronJob, found in the KSM Job samples in i. Containers of other workloads, or not owned by any, are only
+// accounted in their namespace.
+// Startup latencies are rolled up by startup.Rollup.
+func Rollup(i *integration.Integration, clusterName string, partials []Partial, customAttributes []attribute.Attribute) error {
+	workloads := map[string]*rollup{}
+	namespaces := map[string]*rollup{}
+	var startups []startup.Pod
+	cronJobs := jobCronJobs(i)
+
+	add := func(nodeName string, u Usage, addUsage func(*rollup, string, Usage)) {
+		if u.Namespace == "" {
+			return
+		}
+
+		ns, ok := namespaces[u.Namespace]
+		if !ok {
+			ns = newRollup(attribute.Attr("namespaceName", u.Namespace))
+			namespaces[u.Namespace] = ns
+		}
+		addUsage(ns, nodeName, u)
+
+		if cronJob, ok := cronJobs[u.Namespace+"/"+u.WorkloadName]; ok && u.WorkloadKind == "Job" {
+			u.WorkloadKind, u.WorkloadName = "CronJob", cronJob
+		}
+
+		if _, ok := workloadSampleTypes[u.WorkloadKind]; !ok || u.WorkloadName == "" {
+			return
+		}
+
+		key := workloadKey(u.Namespace, u.WorkloadKind, u.WorkloadName)
+		w, ok := workloads[key]
+		if !ok {
+			w = newRollup(
+				attribute.Attr("namespaceName", u.Namespace),
+				attribute.Attr("workloadKind", u.WorkloadKind),
+				attribute.Attr("workloadName", u.WorkloadName),
+			)
+			workloads[key] = w
+		}
+		addUsage(w, nodeName, u)
+	}
+
+	for _, p := range partials {
+		for _, u := range p.Containers {
+			add(p.NodeName, u, (*rollup).addContainer)
+		}
+		for _, u := range p.Pods {
+			add(p.NodeName, u, (*rollup).addPod)
+		}
+		startups = append(startups, p.Startups...)
+	}
+
+	state := workloadState(i)
+
+	for key, w := range workloads {
+		namespace, kind, name := splitWorkloadKey(key)
+		entityType := fmt.Sprintf("k8s:%s:%s:%s", clusterName, namespace, strings.ToLower(kind))
+
+		metrics := w.metrics()
+		for metricName, v := range state[key] {
+			metrics[metricName] = v
+		}
+
+		if err := addSample(i, name, entityType, WorkloadUsageEventType, clusterName, w.attributes, customAttributes, metrics); err != nil {
+			return err
+		}
+	}
+
+	if err := startup.Rollup(i, clusterName, startups, customAttributes); err != nil {
+		return err
+	}
+
+	for namespace, ns := range namespaces {
+		entityType := fmt.Sprintf("k8s:%s:namespace", clusterName)
+		if err := addSample(i, namespace, entityType, NamespaceUsageEventType, clusterName, ns.attributes, customAttributes, ns.metrics()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addSample adds a rolled up sample with its attributes, followed by the custom ones.
+func addSample(
+	i *integration.Integration,
+	name, entityType, eventType, clusterName string,
+	attributes, customAttributes []attribute.Attribute,
+	metrics map[string]float64,
+) error {
+	attrs := make([]attribute.Attribute, 0, len(attributes)+len(customAttributes))
+	attrs = append(attrs, attributes...)
+	attrs = append(attrs, customAttributes...)
+
+	return sample.Add(i, name, entityType, eventType, clusterName, attrs, metrics)
+}
+
+// workloadState returns the metrics of the KSM workload samples in i copied to the usage ones, by workload key.
+func workloadState(i *integration.Integration) map[string]map[string]float64 {
+	eventTypes := make(map[string]string, len(workloadSampleTypes))
+	for kind, t := range workloadSampleTypes {
+		eventTypes[t.eventType] = kind
+	}
+
+	state := map[string]map[string]float64{}
+	for _, e := range i.Entities {
+		for _, ms := range e.Metrics {
+			kind, ok := eventTypes[sample.StringAttribute(ms, sample.EventTypeAttribute)]
+			if !ok {
+				continue
+			}
+
+			name := sample.StringAttribute(ms, workloadSampleTypes[kind].nameAttribute)
+			namespace := sample.StringAttribute(ms, "namespaceName")
+			if name == "" || namespace == "" {
+				continue
+			}
+
+			metrics := map[string]float64{}
+			for _, metricName := range workloadStateMetrics {
+				if v, ok := ms.Metrics[metricName].(float64); ok {
+					metrics[metricName] = v
+				}
+			}
+			state[workloadKey(namespace, kind, name)] = metrics
+		}
+	}
+
+	return state
+}
+
+// jobCronJobs returns the names of the CronJobs owning the Jobs in the KSM Job samples in i, by the namespace and name of
+// the Job joined by a slash.
+func jobCronJobs(i *integration.Integration) map[string]string {
+	cronJobs := map[string]string{}
+	for _, e := range i.Entities {
+		for _, ms := range e.Metrics {
+			if sample.StringAttribute(ms, sample.EventTypeAttribute) != jobSampleType ||
+				sample.StringAttribute(ms, "ownerKind") != "CronJob" {
+				continue
+			}
+
+			cronJob := sample.StringAttribute(ms, "ownerName")
+			if cronJob == "" {
+				continue
+			}
+			cronJobs[sample.StringAttribute(ms, "namespaceName")+"/"+sample.StringAttribute(ms, "jobName")] = cronJob
+		}
+	}
+
+	return cronJobs
+}
+
+func workloadKey(namespace, kind, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+func splitWorkloadKey(key string) (string, string, string) {
+	parts := strings.SplitN(key, "/", 3)
+	return parts[0], parts[1], parts[2]
+}
+
+// percentile returns the nearest-rank percentile p of the sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
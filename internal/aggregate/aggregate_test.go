@@ -0,0 +1,360 @@
+package aggregate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/aggregate"
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/startup"
+	"github.com/newrelic/nri-kubernetes/v3/internal/testutil"
+)
+
+// kubeletIntegration returns an integration with the samples populated by the kubelet scraper of a node, with the
+// containers of the pods of the api deployment and a standalone one.
+func kubeletIntegration(t *testing.T, pods ...string) *integration.Integration {
+	t.Helper()
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	for n, pod := range pods {
+		testutil.AddContainer(t, i, pod, "app", map[string]interface{}{
+			"workloadKind":          "Deployment",
+			"workloadName":          "api",
+			"cpuUsedCores":          float64(n+1) / 10,
+			"memoryWorkingSetBytes": float64(n+1) * 100,
+			"restartCountDelta":     float64(1),
+		})
+		testutil.AddSample(t, i, "k8s:cluster:default:pod", pod, "K8sPodSample", map[string]interface{}{
+			"namespaceName":        "default",
+			"podName":              pod,
+			"workloadKind":         "Deployment",
+			"workloadName":         "api",
+			"net.rxBytesPerSecond": float64(1000),
+		})
+	}
+
+	testutil.AddContainer(t, i, "debug", "shell", map[string]interface{}{
+		"cpuUsedCores": 0.5,
+	})
+
+	return i
+}
+
+// ksmIntegration returns an integration with the samples populated by the KSM scraper.
+func ksmIntegration(t *testing.T) *integration.Integration {
+	t.Helper()
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	testutil.AddSample(t, i, "k8s:cluster:default:deployment", "api", "K8sDeploymentSample", map[string]interface{}{
+		"namespaceName":  "default",
+		"deploymentName": "api",
+		"podsDesired":    float64(3),
+		"podsReady":      float64(2),
+	})
+	// KSM also reports containers, without usage.
+	testutil.AddContainer(t, i, "api-1", "app", nil)
+
+	return i
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	i := kubeletIntegration(t, "api-1")
+
+	// Samples populated by KSM for the same container are merged.
+	testutil.AddContainer(t, i, "api-1", "app", map[string]interface{}{
+		"cpuRequestedCores": 0.5,
+	})
+
+	// Init, ephemeral and not running containers are left out.
+	testutil.AddContainer(t, i, "api-1", "migrate", map[string]interface{}{
+		"containerType": "init",
+		"status":        "Terminated",
+		"cpuUsedCores":  float64(2),
+	})
+	testutil.AddContainer(t, i, "api-1", "debugger", map[string]interface{}{
+		"containerType": "ephemeral",
+		"status":        "Running",
+		"cpuUsedCores":  float64(2),
+	})
+	testutil.AddContainer(t, i, "api-1", "crashing", map[string]interface{}{
+		"containerType": "regular",
+		"status":        "Waiting",
+		"cpuUsedCores":  float64(2),
+	})
+	testutil.AddContainer(t, i, "api-1", "proxy", map[string]interface{}{
+		"containerType": "sidecar",
+		"status":        "Running",
+		"cpuUsedCores":  0.2,
+	})
+
+	p := aggregate.Collect(i, "node-a", now, 0)
+
+	assert.Equal(t, "node-a", p.NodeName)
+	assert.Equal(t, now, p.Timestamp)
+	assert.ElementsMatch(t, []aggregate.Usage{
+		{
+			Namespace:     "default",
+			PodName:       "api-1",
+			ContainerName: "app",
+			WorkloadKind:  "Deployment",
+			WorkloadName:  "api",
+			Values:        map[string]float64{"cpuUsedCores": 0.1, "memoryWorkingSetBytes": 100, "restartCountDelta": 1},
+			Resources:     map[string]float64{"cpuRequestedCores": 0.5},
+		},
+		{
+			Namespace:     "default",
+			PodName:       "api-1",
+			ContainerName: "proxy",
+			Values:        map[string]float64{"cpuUsedCores": 0.2},
+		},
+		{
+			Namespace:     "default",
+			PodName:       "debug",
+			ContainerName: "shell",
+			Values:        map[string]float64{"cpuUsedCores": 0.5},
+		},
+	}, p.Containers)
+	assert.Equal(t, []aggregate.Usage{
+		{
+			Namespace:    "default",
+			PodName:      "api-1",
+			WorkloadKind: "Deployment",
+			WorkloadName: "api",
+			Values:       map[string]float64{"net.rxBytesPerSecond": 1000},
+		},
+	}, p.Pods)
+}
+
+func TestRollup(t *testing.T) {
+	t.Parallel()
+
+	partials := []aggregate.Partial{
+		aggregate.Collect(kubeletIntegration(t, "api-1"), "node-a", time.Now(), 0),
+		aggregate.Collect(kubeletIntegration(t, "api-2", "api-3"), "node-b", time.Now(), 0),
+	}
+
+	i := ksmIntegration(t)
+	err := aggregate.Rollup(i, "cluster", partials, []attribute.Attribute{attribute.Attr("environment", "production")})
+	require.NoError(t, err)
+
+	workloads := testutil.Samples(i, aggregate.WorkloadUsageEventType)
+	require.Len(t, workloads, 1)
+
+	api := workloads["api"]
+	assert.Equal(t, "cluster", api["clusterName"])
+	assert.Equal(t, "production", api["environment"])
+	assert.Equal(t, "Deployment", api["workloadKind"])
+	assert.Equal(t, "default", api["namespaceName"])
+	assert.Equal(t, float64(2), api["nodeCount"])
+	assert.Equal(t, float64(3), api["podCount"])
+	assert.Equal(t, float64(3), api["containerCount"])
+	// Both nodes report the first pod of their list with 0.1 cores, and the second with 0.2.
+	assert.InDelta(t, 0.4, api["cpuUsedCoresSum"], 1e-9)
+	assert.Equal(t, 0.1, api["cpuUsedCoresP50"])
+	assert.Equal(t, 0.2, api["cpuUsedCoresP95"])
+	assert.Equal(t, 0.2, api["cpuUsedCoresMax"])
+	assert.Equal(t, float64(400), api["memoryWorkingSetBytesSum"])
+	assert.Equal(t, float64(3), api["restartCountDeltaSum"])
+	assert.Equal(t, float64(3000), api["net.rxBytesPerSecondSum"])
+	assert.Equal(t, float64(3), api["podsDesired"], "state must be joined from the KSM sample")
+	assert.Equal(t, float64(2), api["podsReady"])
+
+	namespaces := testutil.Samples(i, aggregate.NamespaceUsageEventType)
+	require.Len(t, namespaces, 1)
+
+	ns := namespaces["default"]
+	assert.Equal(t, float64(5), ns["containerCount"], "containers without workload must be accounted in the namespace")
+	assert.Equal(t, float64(4), ns["podCount"], "pods with the same name in different nodes must be counted once")
+	assert.InDelta(t, 1.4, ns["cpuUsedCoresSum"], 1e-9)
+	assert.NotContains(t, ns, "podsDesired")
+}
+
+func TestRollup_UnresolvedOwners(t *testing.T) {
+	t.Parallel()
+
+	// With owner resolution disabled, as by default, samples have the names of the direct owners of pods, and of
+	// the Deployments guessed from their ReplicaSets, but no workload.
+	kubelet, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+	testutil.AddContainer(t, kubelet, "api-1", "app", map[string]interface{}{
+		"replicasetName": "api-7d9f",
+		"deploymentName": "api",
+		"cpuUsedCores":   0.1,
+	})
+	testutil.AddContainer(t, kubelet, "backup-28-x1", "app", map[string]interface{}{
+		"jobName":      "backup-28",
+		"cpuUsedCores": 0.2,
+	})
+	testutil.AddContainer(t, kubelet, "migrate-x2", "app", map[string]interface{}{
+		"jobName":      "migrate",
+		"cpuUsedCores": 0.3,
+	})
+
+	i := ksmIntegration(t)
+	testutil.AddSample(t, i, "k8s:cluster:default:job", "backup-28", "K8sJobSample", map[string]interface{}{
+		"namespaceName": "default",
+		"jobName":       "backup-28",
+		"ownerKind":     "CronJob",
+		"ownerName":     "backup",
+	})
+	testutil.AddSample(t, i, "k8s:cluster:default:job", "migrate", "K8sJobSample", map[string]interface{}{
+		"namespaceName": "default",
+		"jobName":       "migrate",
+		"ownerKind":     "<none>",
+	})
+	testutil.AddSample(t, i, "k8s:cluster:default:cronjob", "backup", "K8sCronjobSample", map[string]interface{}{
+		"namespaceName": "default",
+		"cronjobName":   "backup",
+		"isActive":      float64(1),
+	})
+
+	partials := []aggregate.Partial{aggregate.Collect(kubelet, "node-a", time.Now(), 0)}
+	require.NoError(t, aggregate.Rollup(i, "cluster", partials, nil))
+
+	workloads := testutil.Samples(i, aggregate.WorkloadUsageEventType)
+	require.Len(t, workloads, 2, "Jobs not owned by a CronJob must not be rolled up")
+
+	api := workloads["api"]
+	assert.Equal(t, "Deployment", api["workloadKind"])
+	assert.Equal(t, 0.1, api["cpuUsedCoresSum"])
+	assert.Equal(t, float64(3), api["podsDesired"])
+
+	// Jobs are accounted to the CronJob owning them in their KSM sample.
+	backup := workloads["backup"]
+	assert.Equal(t, "CronJob", backup["workloadKind"])
+	assert.Equal(t, 0.2, backup["cpuUsedCoresSum"])
+	assert.Equal(t, float64(1), backup["isActive"])
+}
+
+func TestRollup_Startup(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	startupIntegration := func(pod string) *integration.Integration {
+		i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+		require.NoError(t, err)
+		testutil.AddSample(t, i, "k8s:cluster:default:pod", pod, "K8sPodSample", map[string]interface{}{
+			"namespaceName":         "default",
+			"podName":               pod,
+			"workloadKind":          "Deployment",
+			"workloadName":          "web",
+			"createdAt":             float64(now.Add(-time.Minute).Unix()),
+			"startupLatencySeconds": 10.0,
+		})
+
+		return i
+	}
+
+	partials := []aggregate.Partial{
+		aggregate.Collect(startupIntegration("web-1"), "node-a", now, time.Hour),
+		aggregate.Collect(startupIntegration("web-2"), "node-b", now, time.Hour),
+	}
+	require.Len(t, partials[0].Startups, 1)
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+	require.NoError(t, aggregate.Rollup(i, "cluster", partials, nil))
+
+	// Startup latencies of the partials of all nodes are rolled up together.
+	web := testutil.Samples(i, startup.EventType)["web"]
+	require.NotNil(t, web)
+	assert.Equal(t, float64(2), web["podCount"])
+}
+
+func TestStage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := aggregate.NewConfigMapStore(fake.NewSimpleClientset(), "newrelic")
+	now := time.Now()
+
+	for node, pods := range map[string][]string{"node-a": {"api-1"}, "node-b": {"api-2"}} {
+		collector := aggregate.NewStage("cluster", aggregate.WithCollector(node), aggregate.WithStore(store, false))
+		require.NoError(t, collector.Run(ctx, kubeletIntegration(t, pods...)))
+
+		// Samples are only added by the stage rolling up partials.
+		i := kubeletIntegration(t, pods...)
+		require.NoError(t, collector.Run(ctx, i))
+		assert.Empty(t, testutil.Samples(i, aggregate.WorkloadUsageEventType))
+	}
+
+	// Partials of nodes which stopped reporting are skipped, and deleted after a while.
+	require.NoError(t, store.Put(ctx, aggregate.Partial{
+		NodeName:   "node-c",
+		Timestamp:  now.Add(-2 * time.Minute),
+		Containers: []aggregate.Usage{{Namespace: "other"}},
+	}))
+	require.NoError(t, store.Put(ctx, aggregate.Partial{NodeName: "node-d", Timestamp: now.Add(-2 * time.Hour)}))
+
+	partials, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, partials, 4)
+
+	i := ksmIntegration(t)
+	rollup := aggregate.NewStage("cluster", aggregate.WithRollup(), aggregate.WithStore(store, false))
+	require.NoError(t, rollup.Run(ctx, i))
+
+	api := testutil.Samples(i, aggregate.WorkloadUsageEventType)["api"]
+	require.NotNil(t, api)
+	assert.Equal(t, float64(2), api["nodeCount"])
+	assert.InDelta(t, 0.2, api["cpuUsedCoresSum"], 1e-9)
+	assert.NotContains(t, testutil.Samples(i, aggregate.NamespaceUsageEventType), "other")
+
+	partials, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, partials, 3)
+}
+
+func TestStage_PutInterval(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	store := aggregate.NewConfigMapStore(client, "newrelic")
+
+	collector := aggregate.NewStage("cluster", aggregate.WithCollector("node-a"), aggregate.WithStore(store, false))
+	require.NoError(t, collector.Run(ctx, kubeletIntegration(t, "api-1")))
+	writes := len(client.Actions())
+	require.NotZero(t, writes)
+
+	require.NoError(t, collector.Run(ctx, kubeletIntegration(t, "api-1")))
+	assert.Len(t, client.Actions(), writes, "partials must not be put again before half of the max partial age")
+
+	partials, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, partials, 1)
+}
+
+func TestStage_CollectorAndRollup(t *testing.T) {
+	t.Parallel()
+
+	// The kubelet and KSM scrapers run in the same integration, without sharing partials.
+	i := kubeletIntegration(t, "api-1")
+	ksm := ksmIntegration(t)
+	i.Entities = append(i.Entities, ksm.Entities...)
+
+	stage := aggregate.NewStage("cluster", aggregate.WithCollector("node-a"), aggregate.WithRollup(), aggregate.WithCustomAttributes([]config.CustomAttribute{
+		{Name: "environment", Value: "production"},
+	}))
+	require.NoError(t, stage.Run(context.Background(), i))
+
+	api := testutil.Samples(i, aggregate.WorkloadUsageEventType)["api"]
+	require.NotNil(t, api)
+	assert.Equal(t, float64(1), api["containerCount"], "containers reported by both scrapers must be counted once")
+	assert.Equal(t, float64(3), api["podsDesired"])
+	assert.Equal(t, "production", api["environment"])
+}
@@ -0,0 +1,216 @@
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/internal/sample"
+)
+
+// partialRetention is how old partials can be before they are deleted, as their node is likely gone.
+const partialRetention = time.Hour
+
+// Stage runs after the scrapers, collecting the partial of the node when the kubelet is scraped, and rolling up the
+// partials of all nodes when KSM is scraped.
+type Stage struct {
+	logger           *log.Logger
+	clusterName      string
+	nodeName         string
+	collect          bool
+	rollup           bool
+	store            Store
+	readOnly         bool
+	maxPartialAge    time.Duration
+	startupWindow    time.Duration
+	customAttributes []attribute.Attribute
+	consumer         Consumer
+	now              func() time.Time
+	// lastPut is when the partial was last put in the store.
+	lastPut time.Time
+}
+
+// Consumer uses the partials of all nodes after they are rolled up.
+type Consumer interface {
+	ConsumePartials(i *integration.Integration, partials []Partial) error
+}
+
+// StageOpt are options that can be used to configure the Stage.
+type StageOpt func(*Stage)
+
+// WithLogger returns an OptionFunc to change the logger from the default noop logger.
+func WithLogger(logger *log.Logger) StageOpt {
+	return func(s *Stage) {
+		s.logger = logger
+	}
+}
+
+// WithCollector returns an OptionFunc making the Stage collect the partial of the given node. If the Stage does not
+// roll up partials, it is put in the store every half of the max partial age, so it is rolled up before it gets too
+// old without writing to the store on every run.
+func WithCollector(nodeName string) StageOpt {
+	return func(s *Stage) {
+		s.collect = true
+		s.nodeName = nodeName
+	}
+}
+
+// WithRollup returns an OptionFunc making the Stage roll up the partials in the store, along with the one it collects,
+// if any.
+func WithRollup() StageOpt {
+	return func(s *Stage) {
+		s.rollup = true
+	}
+}
+
+// WithStore returns an OptionFunc to share partials through store. If readOnly, partials are not put nor deleted,
+// which is used for dry runs.
+func WithStore(store Store, readOnly bool) StageOpt {
+	return func(s *Stage) {
+		s.store = store
+		s.readOnly = readOnly
+	}
+}
+
+// WithMaxPartialAge returns an OptionFunc to change how old partials can be to be rolled up.
+func WithMaxPartialAge(age time.Duration) StageOpt {
+	return func(s *Stage) {
+		s.maxPartialAge = age
+	}
+}
+
+// WithStartupLatencyWindow returns an OptionFunc to collect the startup latencies of the pods created in the given
+// window. They are not collected if it is zero.
+func WithStartupLatencyWindow(window time.Duration) StageOpt {
+	return func(s *Stage) {
+		s.startupWindow = window
+	}
+}
+
+// WithCustomAttributes returns an OptionFunc to add static attributes to the rolled up samples.
+func WithCustomAttributes(attributes []config.CustomAttribute) StageOpt {
+	return func(s *Stage) {
+		s.customAttributes = sample.CustomAttributes(attributes)
+	}
+}
+
+// WithConsumer returns an OptionFunc making the Stage pass the partials it rolls up to consumer.
+func WithConsumer(consumer Consumer) StageOpt {
+	return func(s *Stage) {
+		s.consumer = consumer
+	}
+}
+
+// NewStage returns a Stage adding samples for the given cluster.
+func NewStage(clusterName string, opts ...StageOpt) *Stage {
+	s := &Stage{
+		logger:        logutil.Discard,
+		clusterName:   clusterName,
+		maxPartialAge: config.DefaultMaxPartialAge,
+		now:           time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Run collects the partial of the node from the samples in i and stores it, or rolls up the partials of all nodes into
+// i, depending on how the Stage was configured.
+func (s *Stage) Run(ctx context.Context, i *integration.Integration) error {
+	now := s.now()
+
+	var partials []Partial
+	if s.collect {
+		partial := Collect(i, s.nodeName, now, s.startupWindow)
+		s.logger.Debugf("Collected usage of %d containers and %d pods, and startup latencies of %d pods",
+			len(partial.Containers), len(partial.Pods), len(partial.Startups))
+
+		if !s.rollup {
+			return s.put(ctx, partial, now)
+		}
+
+		partials = append(partials, partial)
+	}
+
+	if !s.rollup {
+		return nil
+	}
+
+	if s.store != nil {
+		stored, err := s.storedPartials(ctx, now)
+		if err != nil {
+			return err
+		}
+		partials = append(partials, stored...)
+	}
+
+	s.logger.Debugf("Rolling up usage from %d nodes", len(partials))
+
+	if err := Rollup(i, s.clusterName, partials, s.customAttributes); err != nil {
+		return fmt.Errorf("rolling up usage: %w", err)
+	}
+
+	if s.consumer != nil {
+		if err := s.consumer.ConsumePartials(i, partials); err != nil {
+			return fmt.Errorf("consuming partials: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// put stores partial unless the last one was put less than half of maxPartialAge ago.
+func (s *Stage) put(ctx context.Context, partial Partial, now time.Time) error {
+	if s.store == nil || s.readOnly || now.Sub(s.lastPut) < s.maxPartialAge/2 {
+		return nil
+	}
+
+	if err := s.store.Put(ctx, partial); err != nil {
+		return err
+	}
+	s.lastPut = now
+
+	return nil
+}
+
+// storedPartials returns the partials in the store not older than maxPartialAge, skipping the one of the node the
+// Stage collects, and deleting the ones older than partialRetention.
+func (s *Stage) storedPartials(ctx context.Context, now time.Time) ([]Partial, error) {
+	stored, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	partials := make([]Partial, 0, len(stored))
+	for _, p := range stored {
+		age := now.Sub(p.Timestamp)
+
+		switch {
+		case s.collect && p.NodeName == s.nodeName:
+			// The partial just collected is used instead.
+		case age > partialRetention:
+			if s.readOnly {
+				continue
+			}
+			s.logger.Debugf("Deleting partial of node %q, last updated %s ago", p.NodeName, age)
+			if err := s.store.Delete(ctx, p.NodeName); err != nil {
+				s.logger.Warnf("Could not delete stale partial: %v", err)
+			}
+		case age > s.maxPartialAge:
+			s.logger.Debugf("Skipping partial of node %q, last updated %s ago", p.NodeName, age)
+		default:
+			partials = append(partials, p)
+		}
+	}
+
+	return partials, nil
+}
@@ -0,0 +1,99 @@
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// PartialLabel is the label of the ConfigMaps holding partials.
+	PartialLabel = "newrelic.com/nri-kubernetes-usage-partial"
+
+	partialConfigMapPrefix = "nri-kubernetes-usage-"
+	partialKey             = "partial.json"
+)
+
+// Store shares the partials of the nodes.
+type Store interface {
+	Put(ctx context.Context, p Partial) error
+	List(ctx context.Context) ([]Partial, error)
+	Delete(ctx context.Context, nodeName string) error
+}
+
+// ConfigMapStore stores the partial of each node in a ConfigMap.
+type ConfigMapStore struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// NewConfigMapStore returns a Store keeping partials in ConfigMaps in the given namespace.
+func NewConfigMapStore(client kubernetes.Interface, namespace string) *ConfigMapStore {
+	return &ConfigMapStore{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Put creates or updates the ConfigMap of the node of p.
+func (s *ConfigMapStore) Put(ctx context.Context, p Partial) error {
+	content, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshaling partial: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      partialConfigMapPrefix + p.NodeName,
+			Namespace: s.namespace,
+			Labels:    map[string]string{PartialLabel: "true"},
+		},
+		Data: map[string]string{partialKey: string(content)},
+	}
+
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("storing partial of node %q: %w", p.NodeName, err)
+	}
+
+	return nil
+}
+
+// List returns the partials of all nodes. ConfigMaps which cannot be parsed are skipped.
+func (s *ConfigMapStore) List(ctx context.Context) ([]Partial, error) {
+	list, err := s.client.CoreV1().ConfigMaps(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: PartialLabel})
+	if err != nil {
+		return nil, fmt.Errorf("listing partials: %w", err)
+	}
+
+	partials := make([]Partial, 0, len(list.Items))
+	for _, cm := range list.Items {
+		var p Partial
+		if err := json.Unmarshal([]byte(cm.Data[partialKey]), &p); err != nil {
+			continue
+		}
+		partials = append(partials, p)
+	}
+
+	return partials, nil
+}
+
+// Delete removes the ConfigMap of a node.
+func (s *ConfigMapStore) Delete(ctx context.Context, nodeName string) error {
+	err := s.client.CoreV1().ConfigMaps(s.namespace).Delete(ctx, partialConfigMapPrefix+nodeName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting partial of node %q: %w", nodeName, err)
+	}
+
+	return nil
+}
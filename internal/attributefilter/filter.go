@@ -0,0 +1,144 @@
+// Package attributefilter decides which of the attributes copied from Kubernetes labels and annotations are reported,
+// from allow and deny rules in the config, so clusters with many or high-cardinality labels can limit them.
+package attributefilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+)
+
+const regexPrefix = "regex:"
+
+// filteredPrefixes are the prefixes of the attributes copied from Kubernetes labels and annotations, which are the only
+// ones the filter applies to. They include the labels of namespaces and nodes propagated to the samples of the pods,
+// containers and volumes in them.
+var filteredPrefixes = []string{"label.", "annotation.", "namespaceLabel.", "nodeLabel."} //nolint: gochecknoglobals // read-only list.
+
+// Filterer provides an interface to decide whether an attribute is reported in the samples of a group.
+type Filterer interface {
+	IsAllowed(groupLabel, name string) bool
+}
+
+// Filter decides which label and annotation attributes, including the propagated namespace and node labels, are reported, from global and per entity type allow and deny
+// rules. An attribute is reported if it matches the allow rules, when there are any, and does not match any deny
+// rule. Allow rules of an entity type replace the global ones, while deny rules of both are applied.
+type Filter struct {
+	global      rules
+	entityTypes map[string]rules
+}
+
+type rules struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+}
+
+// New compiles the rules in c, which are globs where * matches any sequence of characters, or regular expressions if
+// prefixed with "regex:". Both must match the whole attribute name, e.g. label.app or annotation.*.
+func New(c *config.AttributeFilter) (*Filter, error) {
+	f := &Filter{entityTypes: map[string]rules{}}
+	if c == nil {
+		return f, nil
+	}
+
+	var err error
+	if f.global, err = compileRules(c.AttributeRules); err != nil {
+		return nil, err
+	}
+
+	for entityType, r := range c.EntityTypes {
+		if f.entityTypes[entityType], err = compileRules(r); err != nil {
+			return nil, fmt.Errorf("compiling rules of entity type %q: %w", entityType, err)
+		}
+	}
+
+	return f, nil
+}
+
+// IsAllowed returns whether the attribute with the given name is reported in the samples of a group. Attributes
+// which do not come from labels or annotations, either of the object or propagated from its namespace or node, are
+// always allowed.
+func (f *Filter) IsAllowed(groupLabel, name string) bool {
+	if !filtered(name) {
+		return true
+	}
+
+	entityRules := f.entityTypes[groupLabel]
+
+	allow := f.global.allow
+	if len(entityRules.allow) > 0 {
+		allow = entityRules.allow
+	}
+
+	if len(allow) > 0 && !matchAny(allow, name) {
+		return false
+	}
+
+	return !matchAny(f.global.deny, name) && !matchAny(entityRules.deny, name)
+}
+
+func filtered(name string) bool {
+	for _, prefix := range filteredPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchAny(patterns []*regexp.Regexp, name string) bool {
+	for _, p := range patterns {
+		if p.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compileRules(c config.AttributeRules) (rules, error) {
+	var r rules
+	var err error
+
+	if r.allow, err = compilePatterns(c.Allow); err != nil {
+		return rules{}, fmt.Errorf("compiling allow rules: %w", err)
+	}
+
+	if r.deny, err = compilePatterns(c.Deny); err != nil {
+		return rules{}, fmt.Errorf("compiling deny rules: %w", err)
+	}
+
+	return r, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		expr, isRegex := strings.CutPrefix(p, regexPrefix)
+		if !isRegex {
+			expr = globToRegex(p)
+		}
+
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("compiling pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
+
+// globToRegex translates a glob to a regular expression. Unlike path.Match, * also matches slashes, which are common
+// in label and annotation keys.
+func globToRegex(glob string) string {
+	parts := strings.Split(glob, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	return strings.Join(parts, ".*")
+}
@@ -0,0 +1,79 @@
+package attributefilter_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/attributefilter"
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+)
+
+func TestFilter_IsAllowed(t *testing.T) {
+	t.Parallel()
+
+	filter, err := attributefilter.New(&config.AttributeFilter{
+		AttributeRules: config.AttributeRules{
+			Deny: []string{"annotation.*", "regex:label\\.build-[0-9a-f]+", "nodeLabel.topology.kubernetes.io/*"},
+		},
+		EntityTypes: map[string]config.AttributeRules{
+			"pod": {
+				Allow: []string{"label.app", "label.team*", "annotation.example.com/*", "namespaceLabel.team"},
+			},
+			"deployment": {
+				Deny: []string{"label.pod-template-hash"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		groupLabel string
+		attribute  string
+		expected   bool
+	}{
+		{name: "not_label_nor_annotation", groupLabel: "pod", attribute: "podName", expected: true},
+		{name: "global_allowed", groupLabel: "node", attribute: "label.kubernetes.io/os", expected: true},
+		{name: "global_glob_deny", groupLabel: "node", attribute: "annotation.node.alpha.kubernetes.io/ttl", expected: false},
+		{name: "global_regex_deny", groupLabel: "node", attribute: "label.build-1a2b", expected: false},
+		{name: "regex_matches_whole_name", groupLabel: "node", attribute: "label.build-1a2b.extra", expected: true},
+		{name: "entity_type_allow", groupLabel: "pod", attribute: "label.team-name", expected: true},
+		{name: "entity_type_not_allowed", groupLabel: "pod", attribute: "label.version", expected: false},
+		{name: "global_deny_applies_to_entity_type", groupLabel: "pod", attribute: "annotation.example.com/owner", expected: false},
+		{name: "entity_type_deny", groupLabel: "deployment", attribute: "label.pod-template-hash", expected: false},
+		{name: "namespace_label_allowed", groupLabel: "pod", attribute: "namespaceLabel.team", expected: true},
+		{name: "namespace_label_not_allowed", groupLabel: "pod", attribute: "namespaceLabel.cost-center", expected: false},
+		{name: "node_label_deny", groupLabel: "container", attribute: "nodeLabel.topology.kubernetes.io/zone", expected: false},
+		{name: "node_label_allowed", groupLabel: "container", attribute: "nodeLabel.kubernetes.io/arch", expected: true},
+		{name: "entity_type_deny_other_type", groupLabel: "replicaset", attribute: "label.pod-template-hash", expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, filter.IsAllowed(tc.groupLabel, tc.attribute))
+		})
+	}
+}
+
+func TestNew_NilConfigAllowsAll(t *testing.T) {
+	t.Parallel()
+
+	filter, err := attributefilter.New(nil)
+	require.NoError(t, err)
+	assert.True(t, filter.IsAllowed("pod", "annotation.anything"))
+}
+
+func TestNew_InvalidRegex(t *testing.T) {
+	t.Parallel()
+
+	_, err := attributefilter.New(&config.AttributeFilter{
+		EntityTypes: map[string]config.AttributeRules{
+			"pod": {Deny: []string{"regex:label.(["}},
+		},
+	})
+	assert.Error(t, err)
+}
@@ -20,9 +20,26 @@ const (
 	DefaultProbeTimeout     = 90 * time.Second
 	DefaultProbeBackoff     = 5 * time.Second
 	DefaultNetworkRouteFile = "/host/proc/1/net/route"
+	// DefaultStartupLatencyWindow is how far back pods are considered when rolling up startup latencies per workload.
+	DefaultStartupLatencyWindow = time.Hour
+	// DefaultMaxPartialAge is how old the usage partials of nodes can be to be rolled up.
+	DefaultMaxPartialAge = time.Minute
+	// DefaultRightsizingReportInterval is how often rightsizing recommendations are reported.
+	DefaultRightsizingReportInterval = 15 * time.Minute
+	// DefaultRightsizingHalfLife is how long it takes for the weight of usage samples to halve in the histograms.
+	DefaultRightsizingHalfLife = 24 * time.Hour
+	// DefaultRightsizingRequestPercentile is the percentile of usage recommended as request.
+	DefaultRightsizingRequestPercentile = 0.95
+	// DefaultRightsizingLimitPercentile is the percentile of usage recommended as limit.
+	DefaultRightsizingLimitPercentile = 0.99
+	// DefaultRightsizingMargin is the fraction added to the percentiles of usage in recommendations.
+	DefaultRightsizingMargin = 0.15
 
 	SinkTypeHTTP   = "http"
 	SinkTypeStdout = "stdout"
+
+	KSMStateSourceKSM       = "ksm"
+	KSMStateSourceInformers = "informers"
 )
 
 type Config struct {
@@ -64,6 +81,50 @@ type Config struct {
 
 	// NamespaceSelector defines custom monitoring filtering for namespaces.
 	NamespaceSelector *NamespaceSelector `mapstructure:"namespaceSelector"`
+
+	// OwnerResolution defines how the workloads owning pods are found.
+	OwnerResolution struct {
+		// Enabled makes the integration walk the ownerReferences of pods up to their top-level owner using cached
+		// metadata from the API Server. Every instance scraping pods then watches all ReplicaSets and Jobs in the
+		// cluster, so it is disabled by default. Pods are then reported with the name of their direct owner, like
+		// replicasetName, but without a workload, as their direct owner may not be the top-level one.
+		Enabled bool `mapstructure:"enabled"`
+	} `mapstructure:"ownerResolution"`
+
+	// AttributeFilter defines which Kubernetes labels and annotations are reported as attributes of samples, including
+	// the namespace and node labels propagated to pods, containers and volumes. If nil, all of them are reported.
+	AttributeFilter *AttributeFilter `mapstructure:"attributeFilter"`
+
+	// CustomAttributes are static attributes added to all samples, like the environment or the team owning the cluster.
+	CustomAttributes []CustomAttribute `mapstructure:"customAttributes"`
+	// TopologyAttributes adds the zone and region of the node of each sample, taken from its topology.kubernetes.io
+	// labels, as the zone and region attributes.
+	TopologyAttributes bool `mapstructure:"topologyAttributes"`
+
+	// PopulateWorkers is the number of entities populated in parallel from the scraped metrics. If zero, it is the
+	// number of CPUs the integration can use.
+	PopulateWorkers int `mapstructure:"populateWorkers"`
+
+	// DisablePrometheusProtobuf makes the KSM, kubelet and control plane scrapers only accept the plain text exposition
+	// format, instead of preferring the protobuf one when endpoints support it.
+	DisablePrometheusProtobuf bool `mapstructure:"disablePrometheusProtobuf"`
+
+	// Relabel are rules transforming or dropping samples before they are published, applied in order.
+	Relabel []RelabelRule `mapstructure:"relabel"`
+
+	// Aggregation defines the rollup of the usage of containers per workload and namespace.
+	Aggregation Aggregation `mapstructure:"aggregation"`
+
+	// Cost defines the allocation of the cost of nodes to the namespaces and workloads running in them.
+	Cost Cost `mapstructure:"cost"`
+
+	// Rightsizing defines the recommendations of requests and limits from the usage history of containers.
+	Rightsizing Rightsizing `mapstructure:"rightsizing"`
+
+	// ExtraSpecsFile is the path to a YAML file with extra metric specs and queries to be added to the built-in ones
+	// for the KSM and kubelet scrapers. Extra kubelet specs can only be added to the container group. If empty, only the
+	// built-in ones are used.
+	ExtraSpecsFile string `mapstructure:"extraSpecsFile"`
 }
 
 // HTTPSink stores the configuration for the HTTP sink.
@@ -111,15 +172,51 @@ type KSM struct {
 	// Namespace allows limiting KSM autodiscovery to a particular namespace.
 	// If empty, the integration will look for KSM service endpoints matching the Selector above on all namespaces.
 	Namespace string `mapstructure:"namespace"`
-	// Distributed is an EXPERIMENTAL flag that will cause the integration to collect metrics from all autodiscovered
-	// KSM endpoints, instead of just the first one.
+	// Distributed will cause the integration to collect metrics from all autodiscovered KSM endpoints, instead of just
+	// the first one.
+	// Deprecated: use Sharding.Enabled instead, which this flag is now an alias of.
 	Distributed bool `mapstructure:"distributed"`
+	// Sharding configures scraping of a KSM deployment sharded with `--shard` and `--total-shards`, or automatically
+	// sharded as a StatefulSet.
+	Sharding struct {
+		// Enabled makes the integration scrape all discovered KSM shards concurrently and merge their data, dropping
+		// entities reported by more than one shard.
+		Enabled bool `mapstructure:"enabled"`
+		// TotalShards is the number of shards expected when it cannot be read from the `--total-shards` argument of
+		// the KSM pods. If zero, the number of discovered shards is assumed.
+		TotalShards int `mapstructure:"totalShards"`
+		// IndexLabel is the pod label holding the shard index, used when KSM pods do not have a `--shard` argument.
+		IndexLabel string `mapstructure:"indexLabel"`
+	} `mapstructure:"sharding"`
+	// NodeLocal configures scraping of a KSM DaemonSet sharded per node with `--node`.
+	NodeLocal struct {
+		// Enabled makes the integration scrape only the KSM pod running in its own node, as given by NodeName, and
+		// only the pod-scoped metrics it exposes. Cluster-scoped objects are expected to be handled by a separate
+		// instance of the integration scraping a KSM that is not sharded per node.
+		Enabled bool `mapstructure:"enabled"`
+	} `mapstructure:"nodeLocal"`
+	// ClusterScopedOnly makes the integration skip pod-scoped metrics, as they are scraped per node by the instances
+	// with NodeLocal enabled, and leave KSM pods sharded per node out of discovery.
+	ClusterScopedOnly bool `mapstructure:"clusterScopedOnly"`
 	// Timeout controls the timeout for the requests to the KSM service.
 	Timeout time.Duration `mapstructure:"timeout"`
 	// Retries controls how many times the integration will attempt to connect to the KSM endpoint before giving up.
 	Retries int `mapstructure:"retries"`
 	// Enable collection of ResourceQuota metrics as samples.
 	EnableResourceQuotaSamples bool `mapstructure:"enableResourceQuotaSamples"`
+	// Auth specifies if authentication will be attempted against KSM, e.g. when it is exposed through
+	// kube-rbac-proxy. It works the same way as it does for control plane endpoints.
+	Auth *Auth `mapstructure:"auth"`
+	// InsecureSkipVerify allows to skip verification of the TLS certificate presented by KSM.
+	// If Scheme is not https, this field is ignored.
+	InsecureSkipVerify bool `mapstructure:"insecureSkipVerify"`
+	// CABundlePath is the path to a PEM-encoded CA bundle used to verify the TLS certificate presented by KSM.
+	CABundlePath string `mapstructure:"caBundlePath"`
+	// StateSource selects where the state of workloads is read from. Supported values are `ksm` (default), which scrapes
+	// an autodiscovered kube-state-metrics instance, and `informers`, which builds the same data directly from shared
+	// informers on the API server, for clusters where KSM cannot be deployed. Informers report all the labels and
+	// annotations of objects, as KSM does only for the ones in its allowlists, which AttributeFilter can limit.
+	StateSource string `mapstructure:"stateSource"`
 	// Discovery allows to configure timing aspects of KSM discovery.
 	Discovery struct {
 		// BackoffDelay controls how much time to wait between attempts to find the KSM service in the cluster.
@@ -144,9 +241,14 @@ type Kubelet struct {
 	// If Scheme is not specified and the Port is non-standard, the integration will fail to connect.
 	Scheme string `mapstructure:"scheme"`
 	// Path to the file containing the network routes of the system, used to figure out the default network interface
-	// for which metrics will be collected.
+	// for which metrics will be collected. Routes are read through netlink in the network namespace of the process
+	// the file belongs to, and from the file only if that is not permitted.
 	// Defaults to /proc/net/route.
 	NetworkRouteFile string `mapstructure:"networkRouteFile"`
+	// NetworkRouteFamily is the address family of the default route, either ipv4 or ipv6. If empty, IPv4 is preferred
+	// and IPv6 is used if there is no IPv4 default route. Policy routing rules are followed across all the routing
+	// tables, unless routes are read from NetworkRouteFile, which only has the main one.
+	NetworkRouteFamily string `mapstructure:"networkRouteFamily"`
 	// Timeout controls the timeout for the requests to the kubelet.
 	Timeout time.Duration `mapstructure:"timeout"`
 	// Retries controls how many times the integration will attempt to connect to the kubelet before giving up.
@@ -170,6 +272,32 @@ type Kubelet struct {
 	// skipped (back-compat). When set, the bundle is loaded into the TLS RootCAs
 	// pool and InsecureSkipVerify is disabled.
 	CABundlePath string `mapstructure:"caBundlePath"`
+
+	// StartupLatencyWindow controls how far back pods are considered when rolling up their startup latencies per
+	// workload into K8sWorkloadStartupSample. If Aggregation is enabled, kubelet scrapers collect them in the partials
+	// of the aggregation stage, which rolls them up across nodes. Otherwise, each kubelet scraper rolls up the ones of
+	// the pods in its node, reported along with its name. Set to 0 to disable the rollup.
+	StartupLatencyWindow time.Duration `mapstructure:"startupLatencyWindow"`
+	// PodNetworkInterfaces enables reporting a K8sPodNetworkSample for each of the network interfaces of each pod,
+	// besides the metrics of the primary interface reported in K8sPodSample.
+	PodNetworkInterfaces bool `mapstructure:"podNetworkInterfaces"`
+	// PropagateNamespaceLabels are the labels of the namespace of pods reported as namespaceLabel.<key> in pod,
+	// container and volume samples.
+	PropagateNamespaceLabels []string `mapstructure:"propagateNamespaceLabels"`
+	// PropagateNodeLabels are the labels of the node reported as nodeLabel.<key> in pod, container and volume samples.
+	PropagateNodeLabels []string `mapstructure:"propagateNodeLabels"`
+	// UsageFromResourceMetrics takes the CPU and memory usage of the node, pods and containers from the lighter
+	// /metrics/resource endpoint instead of /stats/summary. It only exposes the working set and cumulative CPU time, so
+	// memoryUsedBytes, memoryUtilization, requestedMemoryUtilization, the node memoryAvailableBytes, memoryRssBytes and
+	// page faults, and network, filesystem, runtime and volume metrics are not reported when enabled. CPU usage is
+	// reported from the second scrape onwards.
+	UsageFromResourceMetrics bool `mapstructure:"usageFromResourceMetrics"`
+	// HealthMetrics reports the health metrics of the kubelet from its own /metrics endpoint in K8sNodeSample, like
+	// the PLEG relist and pod start durations, runtime operation errors and evictions.
+	HealthMetrics bool `mapstructure:"healthMetrics"`
+	// ProbeMetrics reports the number of successful and failed liveness, readiness and startup probes of containers in
+	// K8sContainerSample, from the /metrics/probes endpoint of the kubelet.
+	ProbeMetrics bool `mapstructure:"probeMetrics"`
 }
 
 // ControlPlane contains config options for the control plane scraper.
@@ -233,6 +361,9 @@ type Endpoint struct {
 	// InsecureSkipVerify allows to skip verification of TLS certificates.
 	// If URL scheme is not https, this field is ignored.
 	InsecureSkipVerify bool `mapstructure:"insecureSkipVerify"`
+	// CABundlePath is the path to a PEM-encoded CA bundle used to verify the certificate of the endpoint. A CA
+	// certificate found in the mTLS secret takes precedence over it.
+	CABundlePath string `mapstructure:"caBundlePath"`
 }
 
 // Auth specifies if authentication will be attempted against this endpoint.
@@ -254,6 +385,97 @@ type MTLS struct {
 	TLSSecretName string `mapstructure:"secretName"`
 	// TLSSecretNamespace is the namespace where the secret above is located.
 	TLSSecretNamespace string `mapstructure:"secretNamespace"`
+	// CertPath and KeyPath are paths to a PEM-encoded certificate and private key used to perform mutual TLS
+	// authentication with the endpoint. They are only used if TLSSecretName is empty.
+	CertPath string `mapstructure:"certPath"`
+	KeyPath  string `mapstructure:"keyPath"`
+}
+
+// AttributeFilter contains the rules deciding which label and annotation attributes, like label.app or
+// namespaceLabel.team, are reported.
+type AttributeFilter struct {
+	// AttributeRules are applied to the samples of all entity types.
+	AttributeRules `mapstructure:",squash"`
+	// EntityTypes holds rules applied to the samples of a single entity type, keyed by its group like pod or
+	// deployment.
+	EntityTypes map[string]AttributeRules `mapstructure:"entityTypes"`
+}
+
+// AttributeRules holds globs, or regular expressions if prefixed with "regex:", matching attribute names.
+type AttributeRules struct {
+	// Allow, if not empty, reports only the attributes matching any of its patterns.
+	Allow []string `mapstructure:"allow"`
+	// Deny drops the attributes matching any of its patterns.
+	Deny []string `mapstructure:"deny"`
+}
+
+// Aggregation contains the config of the rollup of the usage of containers per workload and namespace. The kubelet
+// scrapers store the usage of the containers in their node as partials in ConfigMaps, which the KSM scraper rolls up.
+type Aggregation struct {
+	// Enabled makes the integration report K8sWorkloadUsageSample and K8sNamespaceUsageSample, and roll up
+	// K8sWorkloadStartupSample across nodes instead of per node.
+	Enabled bool `mapstructure:"enabled"`
+	// Namespace is where the ConfigMaps holding partials are stored. It is required unless the kubelet and KSM
+	// scrapers run in the same integration, in which case only the usage of its own node is rolled up.
+	Namespace string `mapstructure:"namespace"`
+	// MaxPartialAge is how old partials can be to be rolled up. Kubelet scrapers update their ConfigMap every half of
+	// it, so lowering it increases the writes to the API server, one per node each time.
+	MaxPartialAge time.Duration `mapstructure:"maxPartialAge"`
+}
+
+// Cost contains the config of the cost allocation samples, reported by the kubelet scrapers for their node.
+type Cost struct {
+	// Enabled makes the integration report K8sCostSample.
+	Enabled bool `mapstructure:"enabled"`
+	// PriceTableFile is the path to a YAML file with the prices of nodes.
+	PriceTableFile string `mapstructure:"priceTableFile"`
+}
+
+// Rightsizing contains the config of the rightsizing recommendations. If aggregation is enabled, they are reported
+// by the scraper rolling up partials for the containers in all nodes. Otherwise, kubelet scrapers report them for the
+// containers of their node, so the replicas of a workload in other nodes are not part of them.
+type Rightsizing struct {
+	// Enabled makes the integration report K8sRightsizingSample.
+	Enabled bool `mapstructure:"enabled"`
+	// StoreFile is the path to a file where usage histograms are persisted, so they survive restarts. If empty,
+	// they are only kept in memory.
+	StoreFile string `mapstructure:"storeFile"`
+	// ReportInterval is how often recommendations are reported.
+	ReportInterval time.Duration `mapstructure:"reportInterval"`
+	// HalfLife is how long it takes for the weight of usage samples to halve.
+	HalfLife time.Duration `mapstructure:"halfLife"`
+	// RequestPercentile is the percentile of usage recommended as request, between 0 and 1.
+	RequestPercentile float64 `mapstructure:"requestPercentile"`
+	// LimitPercentile is the percentile of usage recommended as limit, between 0 and 1.
+	LimitPercentile float64 `mapstructure:"limitPercentile"`
+	// Margin is the fraction added to the percentiles of usage.
+	Margin float64 `mapstructure:"margin"`
+}
+
+// CustomAttribute is a static attribute added to all samples. It is a name and value pair instead of a map so the case
+// of names is kept.
+type CustomAttribute struct {
+	Name  string `mapstructure:"name"`
+	Value string `mapstructure:"value"`
+}
+
+// RelabelRule contains the config of a rule transforming or dropping samples, similar to a Prometheus relabel_config.
+type RelabelRule struct {
+	// Action is one of drop, keep, rename, copyFromNamespace or hash.
+	Action string `mapstructure:"action"`
+	// EventTypes limits the rule to the samples of these event types, like K8sPodSample. If empty, the rule applies
+	// to all samples.
+	EventTypes []string `mapstructure:"eventTypes"`
+	// Attribute is the attribute the rule matches, and the one renamed, copied or hashed. It is required by all actions.
+	Attribute string `mapstructure:"attribute"`
+	// Regex must match the whole value of Attribute for the rule to apply, missing attributes having an empty value.
+	// It matches any value if empty.
+	Regex string `mapstructure:"regex"`
+	// TargetAttribute is the new name of Attribute for rename, and where the result of copyFromNamespace and hash is
+	// set. It defaults to Attribute.
+	TargetAttribute string `mapstructure:"targetAttribute"`
+	// Modulus makes hash report the hash modulo this value, to bound the number of different values.
+	Modulus uint64 `mapstructure:"modulus"`
 }
 
 // NamespaceSelector contains config options for filtering namespaces.
@@ -304,6 +526,21 @@ func LoadConfig(filePath string, fileName string) (*Config, error) {
 	v.SetDefault("nodeName", "node")
 	v.SetDefault("nodeIP", "node")
 	v.SetDefault("testConnectionEndpoint", "/healthz")
+	v.SetDefault("ownerResolution|enabled", false)
+	v.SetDefault("topologyAttributes", false)
+	v.SetDefault("populateWorkers", 0)
+	v.SetDefault("disablePrometheusProtobuf", false)
+	v.SetDefault("aggregation|enabled", false)
+	v.SetDefault("aggregation|namespace", "")
+	v.SetDefault("aggregation|maxPartialAge", DefaultMaxPartialAge)
+	v.SetDefault("cost|enabled", false)
+	v.SetDefault("rightsizing|enabled", false)
+	v.SetDefault("rightsizing|storeFile", "")
+	v.SetDefault("rightsizing|reportInterval", DefaultRightsizingReportInterval)
+	v.SetDefault("rightsizing|halfLife", DefaultRightsizingHalfLife)
+	v.SetDefault("rightsizing|requestPercentile", DefaultRightsizingRequestPercentile)
+	v.SetDefault("rightsizing|limitPercentile", DefaultRightsizingLimitPercentile)
+	v.SetDefault("rightsizing|margin", DefaultRightsizingMargin)
 
 	// Sane connection defaults
 	v.SetDefault("sink|type", SinkTypeHTTP)
@@ -317,6 +554,11 @@ func LoadConfig(filePath string, fileName string) (*Config, error) {
 	v.SetDefault("kubelet|retries", DefaultRetries)
 	v.SetDefault("kubelet|scraperMaxReruns", DefaultScraperMaxReruns)
 	v.SetDefault("kubelet|fetchPodsFromKubeService", false)
+	v.SetDefault("kubelet|startupLatencyWindow", DefaultStartupLatencyWindow)
+	v.SetDefault("kubelet|podNetworkInterfaces", false)
+	v.SetDefault("kubelet|usageFromResourceMetrics", false)
+	v.SetDefault("kubelet|healthMetrics", false)
+	v.SetDefault("kubelet|probeMetrics", true)
 	// initTimeout and initBackoff intentionally have no defaults
 	// When missing from config, they default to 0s (legacy behavior: no retry)
 	// When present in config, their values are used (e.g., 180s enables retry)
@@ -330,6 +572,11 @@ func LoadConfig(filePath string, fileName string) (*Config, error) {
 	v.SetDefault("ksm|discovery|backoffDelay", 7*time.Second)
 	v.SetDefault("ksm|discovery|timeout", 60*time.Second)
 	v.SetDefault("ksm|enableResourceQuotaSamples", false)
+	v.SetDefault("ksm|stateSource", KSMStateSourceKSM)
+	v.SetDefault("ksm|sharding|enabled", false)
+	v.SetDefault("ksm|sharding|totalShards", 0)
+	v.SetDefault("ksm|sharding|indexLabel", "apps.kubernetes.io/pod-index")
+	v.SetDefault("ksm|nodeLocal|enabled", false)
 
 	v.SetEnvPrefix("NRI_KUBERNETES")
 	v.AutomaticEnv()
@@ -350,18 +597,143 @@ func LoadConfig(filePath string, fileName string) (*Config, error) {
 		return nil, err
 	}
 
+	// Distributed is kept as an alias of Sharding.Enabled for backwards compatibility.
+	if cfg.KSM.Distributed {
+		cfg.KSM.Sharding.Enabled = true
+	}
+
 	if err := checkNamespaceSelectorConfig(cfg); err != nil {
 		return &cfg, err
 	}
 
+	if err := checkKSMConfig(cfg); err != nil {
+		return &cfg, err
+	}
+
+	if err := checkCustomAttributes(cfg); err != nil {
+		return &cfg, err
+	}
+
+	if err := checkAggregationConfig(cfg); err != nil {
+		return &cfg, err
+	}
+
+	if cfg.Cost.Enabled && cfg.Cost.PriceTableFile == "" {
+		return &cfg, fmt.Errorf("%w: priceTableFile is required", ErrInvalidCost)
+	}
+
+	if err := checkRightsizingConfig(cfg); err != nil {
+		return &cfg, err
+	}
+
+	switch cfg.Kubelet.NetworkRouteFamily {
+	case "", "ipv4", "ipv6":
+	default:
+		return &cfg, fmt.Errorf("%w: %q", ErrInvalidNetworkRouteFamily, cfg.Kubelet.NetworkRouteFamily)
+	}
+
 	return &cfg, nil
 }
 
 var (
 	ErrInvalidMatchExpressionsValue = errors.New("invalid matchExpressions value")
 	ErrInvalidMatchLabelsValue      = errors.New("invalid matchLabels value")
+	ErrInvalidKSMStateSource        = errors.New("invalid ksm stateSource value")
+	ErrInvalidKSMTotalShards        = errors.New("invalid ksm sharding totalShards value")
+	ErrInvalidKSMNodeLocal          = errors.New("invalid ksm nodeLocal configuration")
+	ErrInvalidKSMAuth               = errors.New("invalid ksm auth configuration")
+	ErrInvalidCustomAttribute       = errors.New("invalid customAttributes entry")
+	ErrInvalidAggregation           = errors.New("invalid aggregation configuration")
+	ErrInvalidCost                  = errors.New("invalid cost configuration")
+	ErrInvalidRightsizing           = errors.New("invalid rightsizing configuration")
+	ErrInvalidNetworkRouteFamily    = errors.New("invalid kubelet networkRouteFamily value")
 )
 
+func checkKSMConfig(c Config) error {
+	switch c.KSM.StateSource {
+	case KSMStateSourceKSM, KSMStateSourceInformers:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidKSMStateSource, c.KSM.StateSource)
+	}
+
+	if c.KSM.Sharding.TotalShards < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidKSMTotalShards, c.KSM.Sharding.TotalShards)
+	}
+
+	// The secret is fetched by name, which requires knowing its namespace.
+	if auth := c.KSM.Auth; auth != nil && auth.MTLS != nil && auth.MTLS.TLSSecretName != "" && auth.MTLS.TLSSecretNamespace == "" {
+		return fmt.Errorf("%w: secretNamespace is required along with secretName", ErrInvalidKSMAuth)
+	}
+
+	if !c.KSM.NodeLocal.Enabled {
+		return nil
+	}
+
+	if c.KSM.ClusterScopedOnly {
+		return fmt.Errorf("%w: not supported together with clusterScopedOnly", ErrInvalidKSMNodeLocal)
+	}
+
+	switch {
+	case c.NodeName == "":
+		return fmt.Errorf("%w: nodeName is required", ErrInvalidKSMNodeLocal)
+	case c.KSM.StateSource != KSMStateSourceKSM:
+		return fmt.Errorf("%w: not supported with stateSource %q", ErrInvalidKSMNodeLocal, c.KSM.StateSource)
+	case c.KSM.Sharding.Enabled:
+		return fmt.Errorf("%w: not supported together with sharding", ErrInvalidKSMNodeLocal)
+	case c.KSM.StaticURL != "":
+		return fmt.Errorf("%w: not supported together with staticURL", ErrInvalidKSMNodeLocal)
+	}
+
+	return nil
+}
+
+func checkCustomAttributes(c Config) error {
+	for i, attr := range c.CustomAttributes {
+		if attr.Name == "" {
+			return fmt.Errorf("%w #%d: name is required", ErrInvalidCustomAttribute, i)
+		}
+	}
+
+	return nil
+}
+
+func checkAggregationConfig(c Config) error {
+	if !c.Aggregation.Enabled || c.Aggregation.Namespace != "" {
+		return nil
+	}
+
+	if !c.Kubelet.Enabled || !c.KSM.Enabled || c.KSM.NodeLocal.Enabled {
+		return fmt.Errorf("%w: namespace is required to share partials between kubelet and KSM scrapers", ErrInvalidAggregation)
+	}
+
+	return nil
+}
+
+func checkRightsizingConfig(c Config) error {
+	r := c.Rightsizing
+	if !r.Enabled {
+		return nil
+	}
+
+	if r.ReportInterval <= 0 || r.HalfLife <= 0 {
+		return fmt.Errorf("%w: reportInterval and halfLife must be positive", ErrInvalidRightsizing)
+	}
+
+	if r.RequestPercentile <= 0 || r.RequestPercentile > 1 || r.LimitPercentile <= 0 || r.LimitPercentile > 1 {
+		return fmt.Errorf("%w: percentiles must be between 0 and 1", ErrInvalidRightsizing)
+	}
+
+	if r.LimitPercentile < r.RequestPercentile {
+		return fmt.Errorf("%w: limitPercentile must not be lower than requestPercentile", ErrInvalidRightsizing)
+	}
+
+	if r.Margin < 0 {
+		return fmt.Errorf("%w: margin must not be negative", ErrInvalidRightsizing)
+	}
+
+	return nil
+}
+
 func checkNamespaceSelectorConfig(c Config) error {
 	if c.NamespaceSelector == nil {
 		return nil
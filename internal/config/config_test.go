@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -18,6 +19,26 @@ const wrongDataWithNamespaceFilterMatchLabels = "config_with_namespace_filter_wr
 const wrongDataWithNamespaceFiltersMatchExpressions = "config_with_namespace_filter_wrong_match_expressions"
 const unexpectedFields = "config_with_unexpected_fields"
 const configWithNewDefaults = "config_with_new_defaults"
+const configWithKSMInformers = "config_with_ksm_informers"
+const wrongKSMStateSource = "config_with_wrong_ksm_state_source"
+const configWithKSMSharding = "config_with_ksm_sharding"
+const configWithKSMNodeLocal = "config_with_ksm_node_local"
+const configWithKSMAuth = "config_with_ksm_auth"
+const wrongKSMAuthSecretWithoutNamespace = "config_with_ksm_auth_secret_without_namespace"
+const wrongKSMNodeLocalWithSharding = "config_with_ksm_node_local_and_sharding"
+const wrongKSMNodeLocalWithoutNodeName = "config_with_ksm_node_local_without_node_name"
+const wrongKSMNodeLocalClusterScopedOnly = "config_with_ksm_node_local_and_cluster_scoped_only"
+const configWithAttributeFilter = "config_with_attribute_filter"
+const configWithRelabel = "config_with_relabel"
+const configWithCustomAttributes = "config_with_custom_attributes"
+const wrongCustomAttributes = "config_with_wrong_custom_attributes"
+const configWithAggregation = "config_with_aggregation"
+const wrongAggregationWithoutNamespace = "config_with_aggregation_without_namespace"
+const configWithCost = "config_with_cost"
+const wrongCostWithoutPriceTable = "config_with_cost_without_price_table"
+const configWithRightsizing = "config_with_rightsizing"
+const wrongRightsizingPercentiles = "config_with_wrong_rightsizing_percentiles"
+const wrongNetworkRouteFamily = "config_with_wrong_network_route_family"
 
 func TestLoadConfig(t *testing.T) {
 
@@ -31,6 +52,8 @@ func TestLoadConfig(t *testing.T) {
 		t.Run("with_env_precedence", func(t *testing.T) {
 			_ = os.Setenv("NRI_KUBERNETES_CLUSTERNAME", "different_value")
 			_ = os.Setenv("NRI_KUBERNETES_NODENAME", "fake-node")
+			// Other tests rely on the nodeName from their config files.
+			t.Cleanup(func() { _ = os.Unsetenv("NRI_KUBERNETES_NODENAME") })
 
 			c, err := config.LoadConfig(fakeDataDir, workingData)
 			require.NoError(t, err)
@@ -143,3 +166,238 @@ func TestKubeletInitRetryFieldsOptional(t *testing.T) {
 			"initBackoff should be 5s when explicitly set in config")
 	})
 }
+
+func TestKSMStateSource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults_to_ksm", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.LoadConfig(fakeDataDir, workingData)
+		require.NoError(t, err)
+		require.Equal(t, config.KSMStateSourceKSM, cfg.KSM.StateSource)
+	})
+
+	t.Run("accepts_informers", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.LoadConfig(fakeDataDir, configWithKSMInformers)
+		require.NoError(t, err)
+		require.Equal(t, config.KSMStateSourceInformers, cfg.KSM.StateSource)
+	})
+
+	t.Run("fails_on_unknown_value", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.LoadConfig(fakeDataDir, wrongKSMStateSource)
+		require.ErrorIs(t, err, config.ErrInvalidKSMStateSource)
+	})
+}
+
+func TestKSMSharding(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.LoadConfig(fakeDataDir, configWithNewDefaults)
+		require.NoError(t, err)
+		require.False(t, cfg.KSM.Sharding.Enabled)
+		require.Equal(t, "apps.kubernetes.io/pod-index", cfg.KSM.Sharding.IndexLabel)
+	})
+
+	t.Run("is_loaded", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.LoadConfig(fakeDataDir, configWithKSMSharding)
+		require.NoError(t, err)
+		require.True(t, cfg.KSM.Sharding.Enabled)
+		require.Equal(t, 3, cfg.KSM.Sharding.TotalShards)
+	})
+
+	t.Run("is_enabled_by_distributed", func(t *testing.T) {
+		t.Parallel()
+
+		// Test config sets the deprecated ksm.distributed flag.
+		cfg, err := config.LoadConfig(fakeDataDir, workingData)
+		require.NoError(t, err)
+		require.True(t, cfg.KSM.Sharding.Enabled)
+	})
+}
+
+func TestKSMNodeLocal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("is_loaded", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := config.LoadConfig(fakeDataDir, configWithKSMNodeLocal)
+		require.NoError(t, err)
+		require.True(t, cfg.KSM.NodeLocal.Enabled)
+	})
+
+	t.Run("fails_with_sharding", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.LoadConfig(fakeDataDir, wrongKSMNodeLocalWithSharding)
+		require.ErrorIs(t, err, config.ErrInvalidKSMNodeLocal)
+	})
+
+	t.Run("fails_without_node_name", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.LoadConfig(fakeDataDir, wrongKSMNodeLocalWithoutNodeName)
+		require.ErrorIs(t, err, config.ErrInvalidKSMNodeLocal)
+	})
+
+	t.Run("fails_with_cluster_scoped_only", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.LoadConfig(fakeDataDir, wrongKSMNodeLocalClusterScopedOnly)
+		require.ErrorIs(t, err, config.ErrInvalidKSMNodeLocal)
+	})
+}
+
+func TestKSMAuth(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithKSMAuth)
+	require.NoError(t, err)
+
+	require.Equal(t, "/etc/ksm/ca.crt", cfg.KSM.CABundlePath)
+	require.False(t, cfg.KSM.InsecureSkipVerify)
+	require.Equal(t, &config.Auth{
+		Type: "mTLS",
+		MTLS: &config.MTLS{
+			CertPath: "/etc/ksm/tls.crt",
+			KeyPath:  "/etc/ksm/tls.key",
+		},
+	}, cfg.KSM.Auth)
+
+	t.Run("fails_with_secret_without_namespace", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.LoadConfig(fakeDataDir, wrongKSMAuthSecretWithoutNamespace)
+		require.ErrorIs(t, err, config.ErrInvalidKSMAuth)
+	})
+}
+
+func TestOwnerResolution(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithNewDefaults)
+	require.NoError(t, err)
+	require.False(t, cfg.OwnerResolution.Enabled)
+}
+
+func TestStartupLatencyWindow(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithNewDefaults)
+	require.NoError(t, err)
+	require.Equal(t, config.DefaultStartupLatencyWindow, cfg.Kubelet.StartupLatencyWindow)
+}
+
+func TestAttributeFilter(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithAttributeFilter)
+	require.NoError(t, err)
+	require.Equal(t, &config.AttributeFilter{
+		AttributeRules: config.AttributeRules{Deny: []string{"annotation.*"}},
+		EntityTypes: map[string]config.AttributeRules{
+			"pod": {Allow: []string{"label.app", `regex:label\.team-.*`}},
+		},
+	}, cfg.AttributeFilter)
+
+	cfg, err = config.LoadConfig(fakeDataDir, configWithNewDefaults)
+	require.NoError(t, err)
+	require.Nil(t, cfg.AttributeFilter)
+}
+
+func TestRelabel(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithRelabel)
+	require.NoError(t, err)
+	require.Equal(t, []config.RelabelRule{
+		{Action: "drop", EventTypes: []string{"K8sReplicasetSample"}, Attribute: "namespaceName", Regex: "kube-system"},
+		{Action: "hash", Attribute: "label.commitSHA", TargetAttribute: "commitBucket", Modulus: 16},
+	}, cfg.Relabel)
+}
+
+func TestCustomAttributes(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithCustomAttributes)
+	require.NoError(t, err)
+	require.Equal(t, []config.CustomAttribute{
+		{Name: "environment", Value: "production"},
+		{Name: "costCenter", Value: "1234"},
+	}, cfg.CustomAttributes)
+	require.True(t, cfg.TopologyAttributes)
+
+	cfg, err = config.LoadConfig(fakeDataDir, configWithNewDefaults)
+	require.NoError(t, err)
+	require.Empty(t, cfg.CustomAttributes)
+	require.False(t, cfg.TopologyAttributes)
+
+	_, err = config.LoadConfig(fakeDataDir, wrongCustomAttributes)
+	require.ErrorIs(t, err, config.ErrInvalidCustomAttribute)
+}
+
+func TestAggregation(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithAggregation)
+	require.NoError(t, err)
+	require.Equal(t, config.Aggregation{Enabled: true, Namespace: "newrelic", MaxPartialAge: 2 * time.Minute}, cfg.Aggregation)
+
+	cfg, err = config.LoadConfig(fakeDataDir, configWithNewDefaults)
+	require.NoError(t, err)
+	require.Equal(t, config.Aggregation{MaxPartialAge: config.DefaultMaxPartialAge}, cfg.Aggregation)
+
+	_, err = config.LoadConfig(fakeDataDir, wrongAggregationWithoutNamespace)
+	require.ErrorIs(t, err, config.ErrInvalidAggregation)
+}
+
+func TestCost(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithCost)
+	require.NoError(t, err)
+	require.Equal(t, config.Cost{Enabled: true, PriceTableFile: "/etc/newrelic/prices.yaml"}, cfg.Cost)
+
+	_, err = config.LoadConfig(fakeDataDir, wrongCostWithoutPriceTable)
+	require.ErrorIs(t, err, config.ErrInvalidCost)
+}
+
+func TestRightsizing(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithRightsizing)
+	require.NoError(t, err)
+	require.Equal(t, config.Rightsizing{
+		Enabled:           true,
+		StoreFile:         "/var/lib/newrelic/rightsizing.json",
+		ReportInterval:    time.Hour,
+		HalfLife:          config.DefaultRightsizingHalfLife,
+		RequestPercentile: 0.9,
+		LimitPercentile:   config.DefaultRightsizingLimitPercentile,
+		Margin:            config.DefaultRightsizingMargin,
+	}, cfg.Rightsizing)
+
+	_, err = config.LoadConfig(fakeDataDir, wrongRightsizingPercentiles)
+	require.ErrorIs(t, err, config.ErrInvalidRightsizing)
+}
+
+func TestNetworkRouteFamily(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.LoadConfig(fakeDataDir, configWithNewDefaults)
+	require.NoError(t, err)
+	require.Empty(t, cfg.Kubelet.NetworkRouteFamily)
+
+	_, err = config.LoadConfig(fakeDataDir, wrongNetworkRouteFamily)
+	require.ErrorIs(t, err, config.ErrInvalidNetworkRouteFamily)
+}
@@ -0,0 +1,314 @@
+package cost
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	v1 "k8s.io/api/core/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/sample"
+)
+
+// EventType is the event type of cost samples.
+const EventType = "K8sCostSample"
+
+// Scopes of cost samples, reported as costScope.
+const (
+	ScopeNode      = "node"
+	ScopeNamespace = "namespace"
+	ScopeWorkload  = "workload"
+)
+
+// Capacity types of nodes, reported as capacityType.
+const (
+	CapacityOnDemand = "onDemand"
+	CapacitySpot     = "spot"
+)
+
+const (
+	instanceTypeLabel           = "node.kubernetes.io/instance-type"
+	deprecatedInstanceTypeLabel = "beta.kubernetes.io/instance-type"
+
+	initContainerType = "init"
+	runningStatus     = "Running"
+
+	bytesPerGiB = 1 << 30
+)
+
+// spotLabels are the labels set by cloud providers and autoscalers to spot or preemptible nodes, with their value.
+var spotLabels = map[string]string{ //nolint: gochecknoglobals // read-only map.
+	"eks.amazonaws.com/capacityType":        "SPOT",
+	"karpenter.sh/capacity-type":            "spot",
+	"cloud.google.com/gke-spot":             "true",
+	"cloud.google.com/gke-preemptible":      "true",
+	"kubernetes.azure.com/scalesetpriority": "spot",
+}
+
+// Allocator adds cost samples for the containers in a node, allocating the price of the node to them by the maximum of
+// their requested and used CPU and memory. Costs are reported for the time between scrapes, so they can be summed
+// across nodes and over time:
+//   - allocatedCost is the cost of the maximum of the requested and used resources.
+//   - usedCost is the cost of the used resources.
+//   - idleCost is the cost of the resources requested but not used.
+//
+// Node samples also report the cost of the node and the part of it not allocated to any container as unallocatedCost.
+type Allocator struct {
+	table       *PriceTable
+	nodes       listersv1.NodeLister
+	nodeName    string
+	clusterName string
+	interval    time.Duration
+	attributes  []attribute.Attribute
+}
+
+// AllocatorOpt are options that can be used to configure the Allocator.
+type AllocatorOpt func(*Allocator)
+
+// WithCustomAttributes returns an OptionFunc to add static attributes to the cost samples.
+func WithCustomAttributes(attributes []config.CustomAttribute) AllocatorOpt {
+	return func(a *Allocator) {
+		a.attributes = sample.CustomAttributes(attributes)
+	}
+}
+
+// NewAllocator returns an Allocator for the given node, reporting the costs of the interval between scrapes.
+func NewAllocator(table *PriceTable, nodes listersv1.NodeLister, nodeName, clusterName string, interval time.Duration, opts ...AllocatorOpt) *Allocator {
+	a := &Allocator{
+		table:       table,
+		nodes:       nodes,
+		nodeName:    nodeName,
+		clusterName: clusterName,
+		interval:    interval,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// nodePrice is the price of a node and of its resources.
+type nodePrice struct {
+	instanceType string
+	capacityType string
+	UnitPrices
+	hourly float64
+}
+
+// price returns the price of a node from its instance type and capacity. Prices of instance types are split between
+// CPU and memory in proportion to the default price of its capacity.
+func (t *PriceTable) price(node *v1.Node) nodePrice {
+	p := nodePrice{
+		instanceType: node.Labels[instanceTypeLabel],
+		capacityType: CapacityOnDemand,
+		UnitPrices:   t.Default,
+	}
+	if p.instanceType == "" {
+		p.instanceType = node.Labels[deprecatedInstanceTypeLabel]
+	}
+
+	for label, value := range spotLabels {
+		if strings.EqualFold(node.Labels[label], value) {
+			p.capacityType = CapacitySpot
+			break
+		}
+	}
+
+	cores := node.Status.Capacity.Cpu().AsApproximateFloat64()
+	memoryGiB := node.Status.Capacity.Memory().AsApproximateFloat64() / bytesPerGiB
+	p.hourly = cores*t.Default.CPUCoreHour + memoryGiB*t.Default.MemoryGiBHour
+
+	instance, ok := t.InstanceTypes[p.instanceType]
+	if !ok || p.hourly == 0 {
+		return p
+	}
+
+	instanceHourly := instance.OnDemand
+	if p.capacityType == CapacitySpot && instance.Spot > 0 {
+		instanceHourly = instance.Spot
+	}
+
+	scale := instanceHourly / p.hourly
+	p.CPUCoreHour *= scale
+	p.MemoryGiBHour *= scale
+	p.hourly = instanceHourly
+
+	return p
+}
+
+// costs accumulates the costs of a namespace or workload.
+type costs struct {
+	attributes          []attribute.Attribute
+	allocatedCPUCost    float64
+	allocatedMemoryCost float64
+	usedCost            float64
+}
+
+func (c *costs) metrics() map[string]float64 {
+	allocated := c.allocatedCPUCost + c.allocatedMemoryCost
+	return map[string]float64{
+		"allocatedCost":       allocated,
+		"allocatedCpuCost":    c.allocatedCPUCost,
+		"allocatedMemoryCost": c.allocatedMemoryCost,
+		"usedCost":            c.usedCost,
+		"idleCost":            math.Max(allocated-c.usedCost, 0),
+	}
+}
+
+// Apply adds the cost samples of the node and of the namespaces and workloads of the container samples in i.
+func (a *Allocator) Apply(i *integration.Integration) error {
+	node, err := a.nodes.Get(a.nodeName)
+	if err != nil {
+		return fmt.Errorf("getting node %q: %w", a.nodeName, err)
+	}
+
+	price := a.table.price(node)
+	hours := a.interval.Hours()
+
+	namespaces := map[string]*costs{}
+	workloads := map[string]*costs{}
+	var nodeAllocated float64
+
+	for _, u := range containerUsages(i) {
+		usedGiB := u.memoryWorkingSetBytes / bytesPerGiB
+		allocatedCores := math.Max(u.cpuRequestedCores, u.cpuUsedCores)
+		allocatedGiB := math.Max(u.memoryRequestedBytes/bytesPerGiB, usedGiB)
+
+		c := costs{
+			allocatedCPUCost:    allocatedCores * price.CPUCoreHour * hours,
+			allocatedMemoryCost: allocatedGiB * price.MemoryGiBHour * hours,
+			usedCost:            (u.cpuUsedCores*price.CPUCoreHour + usedGiB*price.MemoryGiBHour) * hours,
+		}
+		nodeAllocated += c.allocatedCPUCost + c.allocatedMemoryCost
+
+		ns, ok := namespaces[u.namespace]
+		if !ok {
+			ns = &costs{attributes: []attribute.Attribute{attribute.Attr("namespaceName", u.namespace)}}
+			namespaces[u.namespace] = ns
+		}
+		ns.add(c)
+
+		if u.workloadKind == "" || u.workloadName == "" {
+			continue
+		}
+
+		key := u.namespace + "/" + u.workloadKind + "/" + u.workloadName
+		w, ok := workloads[key]
+		if !ok {
+			w = &costs{attributes: []attribute.Attribute{
+				attribute.Attr("namespaceName", u.namespace),
+				attribute.Attr("workloadKind", u.workloadKind),
+				attribute.Attr("workloadName", u.workloadName),
+			}}
+			workloads[key] = w
+		}
+		w.add(c)
+	}
+
+	for key, w := range workloads {
+		parts := strings.SplitN(key, "/", 3)
+		entityType := fmt.Sprintf("k8s:%s:%s:%s", a.clusterName, parts[0], strings.ToLower(parts[1]))
+		if err := a.addSample(i, parts[2], entityType, ScopeWorkload, price, w.attributes, w.metrics()); err != nil {
+			return err
+		}
+	}
+
+	for namespace, ns := range namespaces {
+		entityType := fmt.Sprintf("k8s:%s:namespace", a.clusterName)
+		if err := a.addSample(i, namespace, entityType, ScopeNamespace, price, ns.attributes, ns.metrics()); err != nil {
+			return err
+		}
+	}
+
+	nodeCost := price.hourly * hours
+	nodeMetrics := map[string]float64{
+		"nodeCost":           nodeCost,
+		"allocatedCost":      nodeAllocated,
+		"unallocatedCost":    math.Max(nodeCost-nodeAllocated, 0),
+		"cpuCoreHourPrice":   price.CPUCoreHour,
+		"memoryGiBHourPrice": price.MemoryGiBHour,
+	}
+
+	return a.addSample(i, a.nodeName, fmt.Sprintf("k8s:%s:node", a.clusterName), ScopeNode, price, nil, nodeMetrics)
+}
+
+func (c *costs) add(other costs) {
+	c.allocatedCPUCost += other.allocatedCPUCost
+	c.allocatedMemoryCost += other.allocatedMemoryCost
+	c.usedCost += other.usedCost
+}
+
+func (a *Allocator) addSample(
+	i *integration.Integration,
+	name, entityType, scope string,
+	price nodePrice,
+	attributes []attribute.Attribute,
+	metrics map[string]float64,
+) error {
+	attrs := []attribute.Attribute{
+		attribute.Attr("costScope", scope),
+		attribute.Attr("nodeName", a.nodeName),
+		attribute.Attr("instanceType", price.instanceType),
+		attribute.Attr("capacityType", price.capacityType),
+		attribute.Attr("currency", a.table.Currency),
+	}
+	attrs = append(attrs, attributes...)
+	attrs = append(attrs, a.attributes...)
+
+	metrics["intervalSeconds"] = a.interval.Seconds()
+
+	return sample.Add(i, name, entityType, EventType, a.clusterName, attrs, metrics)
+}
+
+// containerUsage holds the resources requested and used by a container.
+type containerUsage struct {
+	namespace             string
+	workloadKind          string
+	workloadName          string
+	cpuRequestedCores     float64
+	cpuUsedCores          float64
+	memoryRequestedBytes  float64
+	memoryWorkingSetBytes float64
+}
+
+// containerUsages returns the usage in the container samples of i, except for init containers and containers not
+// running. Samples of the same container, like the ones populated by both the KSM and kubelet scrapers, are merged.
+func containerUsages(i *integration.Integration) []containerUsage {
+	var usages []containerUsage
+
+	for _, ms := range sample.Containers(i) {
+		namespace := sample.StringAttribute(ms, "namespaceName")
+		if namespace == "" || sample.StringAttribute(ms, "containerType") == initContainerType {
+			continue
+		}
+		if status := sample.StringAttribute(ms, "status"); status != "" && status != runningStatus {
+			continue
+		}
+
+		usages = append(usages, containerUsage{
+			namespace:             namespace,
+			workloadKind:          sample.StringAttribute(ms, "workloadKind"),
+			workloadName:          sample.StringAttribute(ms, "workloadName"),
+			cpuRequestedCores:     floatMetric(ms, "cpuRequestedCores"),
+			cpuUsedCores:          floatMetric(ms, "cpuUsedCores"),
+			memoryRequestedBytes:  floatMetric(ms, "memoryRequestedBytes"),
+			memoryWorkingSetBytes: floatMetric(ms, "memoryWorkingSetBytes"),
+		})
+	}
+
+	return usages
+}
+
+// floatMetric returns the value of a numeric metric, or zero if it is missing.
+func floatMetric(ms *metric.Set, name string) float64 {
+	v, _ := ms.Metrics[name].(float64)
+	return v
+}
@@ -0,0 +1,173 @@
+package cost_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/cost"
+	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
+	"github.com/newrelic/nri-kubernetes/v3/internal/testutil"
+)
+
+const gib = 1 << 30
+
+func TestLoadPriceTable(t *testing.T) {
+	t.Parallel()
+
+	table, err := cost.LoadPriceTable("testdata/prices.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "USD", table.Currency)
+	assert.Equal(t, cost.UnitPrices{CPUCoreHour: 0.03, MemoryGiBHour: 0.005}, table.Default)
+	assert.Equal(t, map[string]cost.InstancePrices{
+		"m5.large":  {OnDemand: 0.096, Spot: 0.04},
+		"m5.xlarge": {OnDemand: 0.192},
+	}, table.InstanceTypes, "instance types in the table must take precedence over the ones in price files")
+}
+
+func TestLoadPriceTable_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := cost.LoadPriceTable("testdata/prices_without_default.yaml")
+	assert.ErrorIs(t, err, cost.ErrInvalidPriceTable)
+
+	_, err = cost.LoadPriceTable("testdata/missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestAllocator_Apply(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-a",
+			Labels: map[string]string{
+				"node.kubernetes.io/instance-type": "m5.large",
+				"karpenter.sh/capacity-type":       "spot",
+			},
+		},
+		Status: v1.NodeStatus{Capacity: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("2"),
+			v1.ResourceMemory: resource.MustParse("8Gi"),
+		}},
+	})
+	lister, closer := discovery.NewNodeLister(client)
+	t.Cleanup(func() { close(closer) })
+
+	table, err := cost.LoadPriceTable("testdata/prices.yaml")
+	require.NoError(t, err)
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	testutil.AddContainer(t, i, "api-1", "app", map[string]interface{}{
+		"workloadKind":          "Deployment",
+		"workloadName":          "api",
+		"cpuRequestedCores":     0.5,
+		"memoryRequestedBytes":  float64(gib),
+		"cpuUsedCores":          0.25,
+		"memoryWorkingSetBytes": float64(2 * gib),
+	})
+	// KSM also reports the requests of the same container.
+	testutil.AddContainer(t, i, "api-1", "app", map[string]interface{}{
+		"cpuRequestedCores":    0.5,
+		"memoryRequestedBytes": float64(gib),
+	})
+	testutil.AddContainer(t, i, "debug", "shell", map[string]interface{}{
+		"cpuUsedCores": float64(1),
+	})
+	// Requests of init containers and containers not running are not allocated.
+	testutil.AddContainer(t, i, "api-1", "migrate", map[string]interface{}{
+		"workloadKind":      "Deployment",
+		"workloadName":      "api",
+		"containerType":     "init",
+		"status":            "Terminated",
+		"cpuRequestedCores": float64(1),
+	})
+	testutil.AddContainer(t, i, "api-1", "migrate", map[string]interface{}{
+		"cpuRequestedCores": float64(1),
+	})
+	testutil.AddContainer(t, i, "api-2", "app", map[string]interface{}{
+		"workloadKind":      "Deployment",
+		"workloadName":      "api",
+		"status":            "Waiting",
+		"cpuRequestedCores": 0.5,
+	})
+
+	allocator := cost.NewAllocator(table, lister, "node-a", "cluster", time.Hour, cost.WithCustomAttributes([]config.CustomAttribute{
+		{Name: "environment", Value: "production"},
+	}))
+	require.NoError(t, allocator.Apply(i))
+
+	samples := testutil.Samples(i, cost.EventType)
+	require.Len(t, samples, 3)
+
+	// The spot price of the node is split between CPU and memory in proportion to the default prices of its
+	// capacity, 0.06 and 0.04 per hour, so a core costs 0.012 per hour and a GiB 0.002.
+	node := samples["node-a"]
+	require.NotNil(t, node)
+	assert.Equal(t, "m5.large", node["instanceType"])
+	assert.Equal(t, cost.CapacitySpot, node["capacityType"])
+	assert.Equal(t, "USD", node["currency"])
+	assert.Equal(t, "production", node["environment"])
+	assert.InDelta(t, 0.04, node["nodeCost"], 1e-9)
+	assert.InDelta(t, 0.012, node["cpuCoreHourPrice"], 1e-9)
+	assert.InDelta(t, 0.002, node["memoryGiBHourPrice"], 1e-9)
+	assert.InDelta(t, 0.022, node["allocatedCost"], 1e-9)
+	assert.InDelta(t, 0.018, node["unallocatedCost"], 1e-9)
+	assert.Equal(t, float64(3600), node["intervalSeconds"])
+
+	api := samples["api"]
+	require.NotNil(t, api)
+	assert.Equal(t, "Deployment", api["workloadKind"])
+	assert.Equal(t, "node-a", api["nodeName"])
+	assert.InDelta(t, 0.006, api["allocatedCpuCost"], 1e-9, "requested CPU is allocated when higher than the used one")
+	assert.InDelta(t, 0.004, api["allocatedMemoryCost"], 1e-9, "used memory is allocated when higher than the requested one")
+	assert.InDelta(t, 0.007, api["usedCost"], 1e-9)
+	assert.InDelta(t, 0.003, api["idleCost"], 1e-9)
+
+	namespace := samples["default"]
+	require.NotNil(t, namespace)
+	assert.InDelta(t, 0.022, namespace["allocatedCost"], 1e-9, "containers without workload must be accounted in the namespace")
+	assert.InDelta(t, 0.019, namespace["usedCost"], 1e-9)
+	assert.InDelta(t, 0.003, namespace["idleCost"], 1e-9)
+}
+
+func TestAllocator_Apply_UnknownInstanceType(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status: v1.NodeStatus{Capacity: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("4"),
+			v1.ResourceMemory: resource.MustParse("16Gi"),
+		}},
+	})
+	lister, closer := discovery.NewNodeLister(client)
+	t.Cleanup(func() { close(closer) })
+
+	table, err := cost.LoadPriceTable("testdata/prices.yaml")
+	require.NoError(t, err)
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	require.NoError(t, cost.NewAllocator(table, lister, "node-a", "cluster", time.Hour).Apply(i))
+
+	node := testutil.Samples(i, cost.EventType)["node-a"]
+	require.NotNil(t, node)
+	assert.Equal(t, cost.CapacityOnDemand, node["capacityType"])
+	assert.InDelta(t, 0.2, node["nodeCost"], 1e-9, "nodes must be priced by their capacity with the default prices")
+	assert.InDelta(t, 0.2, node["unallocatedCost"], 1e-9)
+
+	assert.Error(t, cost.NewAllocator(table, lister, "missing", "cluster", time.Hour).Apply(i))
+}
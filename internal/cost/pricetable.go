@@ -0,0 +1,115 @@
+// Package cost allocates the cost of nodes to the workloads and namespaces of the containers running in them, from a
+// user-supplied price table.
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// PriceTable holds the prices used to compute costs. For example, the following prices m5.large nodes by their
+// on-demand and spot price, and the rest of nodes by their capacity:
+//
+//	currency: USD
+//	default:
+//	  cpuCoreHour: 0.0316
+//	  memoryGiBHour: 0.0042
+//	instanceTypes:
+//	  m5.large:
+//	    onDemand: 0.096
+//	    spot: 0.037
+//	priceFiles:
+//	  - /etc/prices/aws-us-east-1.yaml
+type PriceTable struct {
+	// Currency is reported along with costs.
+	Currency string `json:"currency"`
+	// Default are the prices of nodes whose instance type is not in the table. They are also used to split the price
+	// of instances between CPU and memory, in proportion to the default price of their capacity.
+	Default UnitPrices `json:"default"`
+	// InstanceTypes maps instance types, as in the node.kubernetes.io/instance-type label of nodes, to their price.
+	InstanceTypes map[string]InstancePrices `json:"instanceTypes"`
+	// PriceFiles are paths to files with more instance types, like price lists exported from cloud providers, with the
+	// same format as instanceTypes. Instance types in the table take precedence. Relative paths are relative to the
+	// directory of the table.
+	PriceFiles []string `json:"priceFiles"`
+}
+
+// UnitPrices are prices per hour of a CPU core and a GiB of memory.
+type UnitPrices struct {
+	CPUCoreHour   float64 `json:"cpuCoreHour"`
+	MemoryGiBHour float64 `json:"memoryGiBHour"`
+}
+
+// InstancePrices are prices per hour of an instance type. Spot, if zero, defaults to OnDemand.
+type InstancePrices struct {
+	OnDemand float64 `json:"onDemand"`
+	Spot     float64 `json:"spot"`
+}
+
+// ErrInvalidPriceTable is returned when the price table cannot be used to compute costs.
+var ErrInvalidPriceTable = errors.New("invalid price table")
+
+// LoadPriceTable reads the price table from a YAML file, along with its price files.
+func LoadPriceTable(path string) (*PriceTable, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading price table: %w", err)
+	}
+
+	table := &PriceTable{}
+	if err := yaml.UnmarshalStrict(content, table); err != nil {
+		return nil, fmt.Errorf("parsing price table %s: %w", path, err)
+	}
+
+	if table.Default.CPUCoreHour <= 0 || table.Default.MemoryGiBHour <= 0 {
+		return nil, fmt.Errorf("%w: default cpuCoreHour and memoryGiBHour must be positive", ErrInvalidPriceTable)
+	}
+
+	if table.InstanceTypes == nil {
+		table.InstanceTypes = map[string]InstancePrices{}
+	}
+
+	for instanceType, prices := range table.InstanceTypes {
+		if prices.OnDemand <= 0 {
+			return nil, fmt.Errorf("%w: onDemand price of %q must be positive", ErrInvalidPriceTable, instanceType)
+		}
+	}
+
+	for _, file := range table.PriceFiles {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+
+		if err := table.loadPriceFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return table, nil
+}
+
+// loadPriceFile adds the instance types in file which are not in the table.
+func (t *PriceTable) loadPriceFile(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("reading price file: %w", err)
+	}
+
+	var instanceTypes map[string]InstancePrices
+	if err := yaml.UnmarshalStrict(content, &instanceTypes); err != nil {
+		return fmt.Errorf("parsing price file %s: %w", file, err)
+	}
+
+	for instanceType, prices := range instanceTypes {
+		if _, ok := t.InstanceTypes[instanceType]; ok || prices.OnDemand <= 0 {
+			continue
+		}
+		t.InstanceTypes[instanceType] = prices
+	}
+
+	return nil
+}
@@ -31,6 +31,21 @@ type EndpointsDiscoverer interface {
 	Discover() ([]string, error)
 }
 
+// Target is a discovered endpoint along with the pod backing it.
+type Target struct {
+	// Host is the address of the endpoint, in host:port form.
+	Host string
+	// PodNamespace and PodName identify the pod backing the endpoint. They are empty if the endpoint does not
+	// reference a pod.
+	PodNamespace string
+	PodName      string
+}
+
+// TargetsDiscoverer discovers endpoints along with the pods backing them.
+type TargetsDiscoverer interface {
+	DiscoverTargets() ([]Target, error)
+}
+
 var ErrDiscoveryTimeout = errors.New("timeout discovering endpoints")
 
 var ErrClientNotConfigured = errors.New("client must be configured")
@@ -45,18 +60,34 @@ type EndpointsDiscovererWithTimeout struct {
 // returns an error, or a non-empty list of endpoints.
 // If the max number of Retries is exceeded, it will return ErrDiscoveryTimeout.
 func (edt *EndpointsDiscovererWithTimeout) Discover() ([]string, error) {
+	return pollNonEmpty(edt.EndpointsDiscoverer.Discover, edt.BackoffDelay, edt.Timeout)
+}
+
+// TargetsDiscovererWithTimeout is the TargetsDiscoverer counterpart of EndpointsDiscovererWithTimeout.
+type TargetsDiscovererWithTimeout struct {
+	TargetsDiscoverer
+	BackoffDelay time.Duration
+	Timeout      time.Duration
+}
+
+// DiscoverTargets polls the inner TargetsDiscoverer the same way EndpointsDiscovererWithTimeout.Discover does.
+func (tdt *TargetsDiscovererWithTimeout) DiscoverTargets() ([]Target, error) {
+	return pollNonEmpty(tdt.TargetsDiscoverer.DiscoverTargets, tdt.BackoffDelay, tdt.Timeout)
+}
+
+func pollNonEmpty[T any](discover func() ([]T, error), backoffDelay, timeout time.Duration) ([]T, error) {
 	start := time.Now()
-	for time.Since(start) < edt.Timeout {
-		endpoints, err := edt.EndpointsDiscoverer.Discover()
+	for time.Since(start) < timeout {
+		found, err := discover()
 		if err != nil {
 			return nil, fmt.Errorf("discovering endpoints: %w", err)
 		}
 
-		if len(endpoints) > 0 {
-			return endpoints, nil
+		if len(found) > 0 {
+			return found, nil
 		}
 
-		time.Sleep(edt.BackoffDelay)
+		time.Sleep(backoffDelay)
 	}
 
 	return nil, ErrDiscoveryTimeout
@@ -69,6 +100,18 @@ type endpointSliceDiscoverer struct {
 
 //nolint:ireturn // Returning interface is correct design for abstraction.
 func NewEndpointSliceDiscoverer(config EndpointsDiscoveryConfig) (EndpointsDiscoverer, chan<- struct{}, error) {
+	return newEndpointSliceDiscoverer(config)
+}
+
+// NewEndpointSliceTargetsDiscoverer builds a TargetsDiscoverer that, on top of the endpoints returned by
+// NewEndpointSliceDiscoverer, reports the pods referenced by them.
+//
+//nolint:ireturn // Returning interface is correct design for abstraction.
+func NewEndpointSliceTargetsDiscoverer(config EndpointsDiscoveryConfig) (TargetsDiscoverer, chan<- struct{}, error) {
+	return newEndpointSliceDiscoverer(config)
+}
+
+func newEndpointSliceDiscoverer(config EndpointsDiscoveryConfig) (*endpointSliceDiscoverer, chan<- struct{}, error) {
 	if config.Client == nil {
 		return nil, nil, ErrClientNotConfigured
 	}
@@ -101,14 +144,28 @@ func NewEndpointSliceDiscoverer(config EndpointsDiscoveryConfig) (EndpointsDisco
 	return discoverer, stopCh, nil
 }
 
-//nolint:gocognit,gocyclo,cyclop // Nested loops match EndpointSlice structure (slices->endpoints->addresses->ports).
 func (d *endpointSliceDiscoverer) Discover() ([]string, error) {
+	targets, err := d.DiscoverTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+	for _, t := range targets {
+		hosts = append(hosts, t.Host)
+	}
+
+	return hosts, nil
+}
+
+//nolint:gocognit,gocyclo,cyclop // Nested loops match EndpointSlice structure (slices->endpoints->addresses->ports).
+func (d *endpointSliceDiscoverer) DiscoverTargets() ([]Target, error) {
 	slices, err := d.lister.List(labels.Everything())
 	if err != nil {
 		return nil, fmt.Errorf("listing endpointslices: %w", err)
 	}
 
-	var hosts []string
+	var targets []Target
 	seen := make(map[string]struct{}) // Deduplicate across multiple slices
 
 	for _, slice := range slices {
@@ -118,6 +175,11 @@ func (d *endpointSliceDiscoverer) Discover() ([]string, error) {
 				continue
 			}
 
+			var podNamespace, podName string
+			if ref := endpoint.TargetRef; ref != nil && ref.Kind == "Pod" {
+				podNamespace, podName = ref.Namespace, ref.Name
+			}
+
 			for _, address := range endpoint.Addresses {
 				for _, port := range slice.Ports {
 					if port.Port == nil {
@@ -131,7 +193,7 @@ func (d *endpointSliceDiscoverer) Discover() ([]string, error) {
 					host := net.JoinHostPort(address, strconv.Itoa(int(*port.Port)))
 
 					if _, exists := seen[host]; !exists {
-						hosts = append(hosts, host)
+						targets = append(targets, Target{Host: host, PodNamespace: podNamespace, PodName: podName})
 						seen[host] = struct{}{}
 					}
 				}
@@ -139,7 +201,9 @@ func (d *endpointSliceDiscoverer) Discover() ([]string, error) {
 		}
 	}
 
-	sort.Strings(hosts)
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Host < targets[j].Host
+	})
 
-	return hosts, nil
+	return targets, nil
 }
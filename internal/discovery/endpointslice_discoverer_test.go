@@ -633,3 +633,53 @@ func Test_endpointslice_discoverer_timeout_on_empty_results(t *testing.T) {
 	_, err = timeoutDiscoverer.Discover()
 	assert.ErrorIs(t, err, discovery.ErrDiscoveryTimeout)
 }
+
+func Test_endpointslice_targets_discoverer_reports_backing_pods(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN: An EndpointSlice with an endpoint backed by a pod and another one without target reference
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-state-metrics-abc123",
+			Namespace: "testNamespace",
+			Labels:    map[string]string{"app": "test"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses:  []string{"10.0.0.2"},
+				Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)},
+				TargetRef: &corev1.ObjectReference{
+					Kind:      "Pod",
+					Namespace: "testNamespace",
+					Name:      "kube-state-metrics-1",
+				},
+			},
+			{
+				Addresses:  []string{"10.0.0.1"},
+				Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)},
+			},
+		},
+		Ports: []discoveryv1.EndpointPort{
+			{Port: ptr.To(int32(8080)), Protocol: ptr.To(corev1.ProtocolTCP)},
+		},
+	}
+
+	client := testclient.NewSimpleClientset(slice) //nolint:staticcheck // Deprecated but no alternative.
+	discoverer, stopCh, err := discovery.NewEndpointSliceTargetsDiscoverer(discovery.EndpointsDiscoveryConfig{
+		Client:        client,
+		LabelSelector: "app=test",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { close(stopCh) })
+
+	// WHEN: Discovering targets
+	targets, err := discoverer.DiscoverTargets()
+
+	// THEN: Targets are sorted by host and carry the pod reference when present
+	require.NoError(t, err)
+	assert.Equal(t, []discovery.Target{
+		{Host: "10.0.0.1:8080"},
+		{Host: "10.0.0.2:8080", PodNamespace: "testNamespace", PodName: "kube-state-metrics-1"},
+	}, targets)
+}
@@ -125,6 +125,11 @@ func (nf *NamespaceFilter) parseToStringMap(matchLabels map[string]interface{})
 	return strMap
 }
 
+// Lister returns the lister the filter gets namespaces from, so other components can share its informer.
+func (nf *NamespaceFilter) Lister() listersv1.NamespaceLister {
+	return nf.lister
+}
+
 // Close closes the stop channel and implements the Closer interface.
 func (nf *NamespaceFilter) Close() error {
 	if nf.stopCh == nil {
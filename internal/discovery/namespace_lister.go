@@ -0,0 +1,21 @@
+package discovery
+
+import (
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+)
+
+// NewNamespaceLister returns a NamespaceLister to get namespaces with informers.
+func NewNamespaceLister(client kubernetes.Interface, options ...informers.SharedInformerOption) (listersv1.NamespaceLister, chan<- struct{}) {
+	stopCh := make(chan struct{})
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, defaultNamespaceResyncDuration, options...)
+
+	namespaceLister := factory.Core().V1().Namespaces().Lister()
+
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	return namespaceLister, stopCh
+}
@@ -57,3 +57,18 @@ func NewNamespacePodListerer(config PodListererConfig) (*MultiNamespacePodLister
 
 	return multiNamespacePodListerer, stopCh
 }
+
+// NewPodsLister returns a PodLister backed by an informer built with the given options, which can be used to restrict
+// the pods it caches.
+func NewPodsLister(client kubernetes.Interface, options ...informers.SharedInformerOption) (listersv1.PodLister, chan<- struct{}) {
+	stopCh := make(chan struct{})
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, defaultResyncDuration, options...)
+
+	lister := factory.Core().V1().Pods().Lister()
+
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	return lister, stopCh
+}
@@ -1,9 +1,13 @@
 package discovery
 
 import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 // SecretListerer return namespaced secret listers.
@@ -57,3 +61,24 @@ func NewNamespaceSecretListerer(config SecretListererConfig) (*MultiNamespaceSec
 
 	return multiNamespaceSecretListerer, stopCh
 }
+
+// NewStaticSecretListerer returns a MultiNamespaceSecretListerer with listers for the namespaces of the given secrets,
+// holding only them. It is meant for secrets fetched once by name, which only requires permissions to get them, as
+// opposed to list and watch all the secrets in their namespace.
+func NewStaticSecretListerer(secrets ...*corev1.Secret) (*MultiNamespaceSecretListerer, error) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	multiNamespaceSecretListerer := &MultiNamespaceSecretListerer{
+		listers: make(map[string]listersv1.SecretNamespaceLister),
+	}
+
+	lister := listersv1.NewSecretLister(indexer)
+	for _, secret := range secrets {
+		if err := indexer.Add(secret); err != nil {
+			return nil, fmt.Errorf("adding secret %q: %w", secret.Name, err)
+		}
+
+		multiNamespaceSecretListerer.listers[secret.Namespace] = lister.Secrets(secret.Namespace)
+	}
+
+	return multiNamespaceSecretListerer, nil
+}
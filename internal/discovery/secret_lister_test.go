@@ -224,3 +224,20 @@ func fakeSecret(namespace string) *corev1.Secret {
 		},
 	}
 }
+
+func Test_static_secrets(t *testing.T) {
+	t.Parallel()
+
+	listerer, err := discovery.NewStaticSecretListerer(fakeSecret(secretNamespace))
+	require.NoError(t, err)
+
+	d, ok := listerer.Lister(secretNamespace)
+	require.True(t, ok)
+
+	e, err := d.Get(secretName)
+	require.NoError(t, err)
+	assert.Equal(t, fakeSecret(secretNamespace), e)
+
+	_, ok = listerer.Lister(differentNamespace)
+	assert.False(t, ok)
+}
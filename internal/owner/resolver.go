@@ -0,0 +1,362 @@
+// Package owner resolves the workload an object belongs to by walking its ownerReferences up to the top-level
+// controller, using cached object metadata.
+package owner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+)
+
+const (
+	// defaultResyncDuration is an arbitrary value, same used in Prometheus and in the discovery package.
+	defaultResyncDuration = 10 * time.Minute
+	// defaultSyncTimeout is how long to wait for the cache of an owner kind to sync before giving up on it.
+	defaultSyncTimeout = 30 * time.Second
+	// maxDepth protects against cycles in ownerReferences.
+	maxDepth = 10
+)
+
+// Owner is an object owning another one.
+type Owner struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	// Unresolved is set on the last owner of a chain when it could not be looked up, so whether it has owners of its
+	// own is not known.
+	Unresolved bool
+}
+
+// Resolver resolves the chain of owners of an object.
+type Resolver interface {
+	// Resolve returns the owners of an object in namespace with the given ownerReferences, starting from its
+	// controller and ending in the top-level one. It returns nil if the object has no owners.
+	Resolve(namespace string, refs []metav1.OwnerReference) []Owner
+}
+
+// Workload returns the top-level owner of a chain returned by a Resolver. It returns false if the last owner of the
+// chain is unresolved, as it may be owned by the actual top-level one, like a ReplicaSet owned by a Deployment.
+func Workload(chain []Owner) (Owner, bool) {
+	if len(chain) == 0 || chain[len(chain)-1].Unresolved {
+		return Owner{}, false
+	}
+
+	return chain[len(chain)-1], true
+}
+
+// DeploymentName returns the name of the Deployment in a chain returned by a Resolver. If the chain ends in an
+// unresolved ReplicaSet, whose owners are not known, the name of the Deployment is guessed by removing the
+// pod-template-hash suffix Deployments add to the names of their ReplicaSets. ReplicaSets that were looked up and have
+// no owners are not owned by any Deployment.
+func DeploymentName(chain []Owner) (string, bool) {
+	for _, o := range chain {
+		if o.Kind == "Deployment" {
+			return o.Name, true
+		}
+	}
+
+	if len(chain) == 0 {
+		return "", false
+	}
+
+	workload := chain[len(chain)-1]
+	if workload.Kind != "ReplicaSet" || !workload.Unresolved {
+		return "", false
+	}
+
+	i := strings.LastIndex(workload.Name, "-")
+	if i <= 0 {
+		return "", false
+	}
+
+	return workload.Name[:i], true
+}
+
+// wellKnownKinds are the intermediate owners preloaded on startup, as most pods are owned by them.
+var wellKnownKinds = []schema.GroupVersionResource{ //nolint: gochecknoglobals // read-only list.
+	{Group: "apps", Version: "v1", Resource: "replicasets"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+}
+
+// wellKnownAPIVersions are the API versions of the built-in owner kinds, used when only the kind of an owner is known.
+var wellKnownAPIVersions = map[string]string{ //nolint: gochecknoglobals // read-only map.
+	"ReplicaSet":            "apps/v1",
+	"Deployment":            "apps/v1",
+	"StatefulSet":           "apps/v1",
+	"DaemonSet":             "apps/v1",
+	"Job":                   "batch/v1",
+	"CronJob":               "batch/v1",
+	"ReplicationController": "v1",
+	"Node":                  "v1",
+}
+
+// ControllerRef builds a controller reference for sources exposing only the kind and name of the owner of an object,
+// like KSM does. The API version is only set for the built-in kinds, so owners of other kinds are not looked up.
+func ControllerRef(kind, name string) metav1.OwnerReference {
+	controller := true
+	return metav1.OwnerReference{
+		APIVersion: wellKnownAPIVersions[kind],
+		Kind:       kind,
+		Name:       name,
+		Controller: &controller,
+	}
+}
+
+// DirectResolver is a Resolver returning only the controller of the object, without looking up its own owners, so it
+// is always unresolved.
+type DirectResolver struct{}
+
+// Resolve implements Resolver.
+func (DirectResolver) Resolve(_ string, refs []metav1.OwnerReference) []Owner {
+	ref, ok := controllerOf(refs)
+	if !ok {
+		return nil
+	}
+
+	return []Owner{{APIVersion: ref.APIVersion, Kind: ref.Kind, Name: ref.Name, Unresolved: true}}
+}
+
+// MetadataResolver is a Resolver backed by metadata informers. Informers for the kinds found in ownerReferences are
+// started in the background the first time they are found, so CRD-based workloads are resolved as well as long as the
+// integration has permissions to list and watch them. Until their informer syncs, owners of those kinds are returned
+// unresolved, so resolving never waits for the API server.
+type MetadataResolver struct {
+	logger      *log.Logger
+	client      metadata.Interface
+	mapper      meta.RESTMapper
+	syncTimeout time.Duration
+
+	stopCh chan struct{}
+
+	lock    sync.Mutex
+	listers map[schema.GroupVersionKind]*kindLister
+}
+
+// kindLister holds the lister for a kind, which is nil if the kind cannot be looked up.
+type kindLister struct {
+	// ready is closed once the informer for the kind has synced or failed to, and the fields below are set.
+	ready  chan struct{}
+	lister cache.GenericLister
+	// clusterScoped kinds are not looked up, as namespaced workloads cannot be owned by them.
+	clusterScoped bool
+}
+
+// OptionFunc are options that can be used to configure the MetadataResolver.
+type OptionFunc func(r *MetadataResolver) error
+
+// WithLogger returns an OptionFunc to change the logger from the default noop logger.
+func WithLogger(logger *log.Logger) OptionFunc {
+	return func(r *MetadataResolver) error {
+		r.logger = logger
+		return nil
+	}
+}
+
+// WithSyncTimeout returns an OptionFunc to change how long to wait for informer caches to sync.
+func WithSyncTimeout(timeout time.Duration) OptionFunc {
+	return func(r *MetadataResolver) error {
+		r.syncTimeout = timeout
+		return nil
+	}
+}
+
+// NewMetadataResolver builds a MetadataResolver using client to watch object metadata and mapper to find the
+// resources of the owner kinds. Informers for ReplicaSets and Jobs are started right away. The returned channel should
+// be closed to stop all the informers.
+func NewMetadataResolver(client metadata.Interface, mapper meta.RESTMapper, opts ...OptionFunc) (*MetadataResolver, chan<- struct{}, error) {
+	r := &MetadataResolver{
+		logger:      logutil.Discard,
+		client:      client,
+		mapper:      mapper,
+		syncTimeout: defaultSyncTimeout,
+		stopCh:      make(chan struct{}),
+		listers:     map[schema.GroupVersionKind]*kindLister{},
+	}
+
+	for i, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, nil, fmt.Errorf("applying option #%d: %w", i, err)
+		}
+	}
+
+	for _, gvr := range wellKnownKinds {
+		gvk, err := mapper.KindFor(gvr)
+		if err != nil {
+			r.logger.Warnf("Cannot find kind for %s, owners of this kind will not be resolved: %v", gvr, err)
+			continue
+		}
+
+		kl := &kindLister{ready: make(chan struct{}), lister: r.startInformer(gvr)}
+		close(kl.ready)
+		r.listers[gvk] = kl
+	}
+
+	return r, r.stopCh, nil
+}
+
+// Resolve implements Resolver. The walk stops at the first owner that cannot be looked up, which is then considered
+// the top-level one and marked as unresolved.
+func (r *MetadataResolver) Resolve(namespace string, refs []metav1.OwnerReference) []Owner {
+	var chain []Owner
+
+	for depth := 0; depth < maxDepth; depth++ {
+		ref, ok := controllerOf(refs)
+		if !ok {
+			break
+		}
+
+		o := Owner{APIVersion: ref.APIVersion, Kind: ref.Kind, Name: ref.Name}
+		next, resolved := r.lookup(namespace, ref)
+		o.Unresolved = !resolved
+		chain = append(chain, o)
+
+		if !resolved {
+			break
+		}
+		refs = next
+	}
+
+	return chain
+}
+
+// lookup returns the ownerReferences of the owner referenced by ref, and whether it could be looked up.
+func (r *MetadataResolver) lookup(namespace string, ref metav1.OwnerReference) ([]metav1.OwnerReference, bool) {
+	kl := r.listerFor(ref)
+	if kl == nil {
+		return nil, false
+	}
+
+	if kl.clusterScoped {
+		return nil, true
+	}
+
+	if kl.lister == nil {
+		return nil, false
+	}
+
+	obj, err := kl.lister.ByNamespace(namespace).Get(ref.Name)
+	if err != nil {
+		r.logger.Debugf("Owner %s %s/%s not found in cache: %v", ref.Kind, namespace, ref.Name, err)
+		return nil, false
+	}
+
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, false
+	}
+
+	return objMeta.GetOwnerReferences(), true
+}
+
+// listerFor returns the lister for the kind of ref, or nil if it is not known yet. The first time a kind is found, an
+// informer is started for it in the background, so lookups never wait for it to sync.
+func (r *MetadataResolver) listerFor(ref metav1.OwnerReference) *kindLister {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil || gv.Version == "" {
+		return nil
+	}
+	gvk := gv.WithKind(ref.Kind)
+
+	r.lock.Lock()
+	kl, found := r.listers[gvk]
+	if !found {
+		kl = &kindLister{ready: make(chan struct{})}
+		r.listers[gvk] = kl
+		go r.startKind(gvk, kl)
+	}
+	r.lock.Unlock()
+
+	select {
+	case <-kl.ready:
+		return kl
+	default:
+		return nil
+	}
+}
+
+// startKind sets the lister of kl for gvk, starting an informer and waiting for it to sync, and closes kl.ready.
+func (r *MetadataResolver) startKind(gvk schema.GroupVersionKind, kl *kindLister) {
+	defer close(kl.ready)
+
+	mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		r.logger.Debugf("Cannot find resource for kind %s, owners of this kind will not be resolved: %v", gvk, err)
+		return
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		kl.clusterScoped = true
+		return
+	}
+
+	r.logger.Debugf("Starting metadata informer for owners of kind %s", gvk)
+	kl.lister = r.startInformer(mapping.Resource)
+}
+
+// startInformer starts a metadata informer for gvr and waits for it to sync. If the cache does not sync in time,
+// which typically means the integration lacks permissions to list the resource, the informer is stopped and nil is
+// returned. Callers cache the result, so the resource is not listed again.
+func (r *MetadataResolver) startInformer(gvr schema.GroupVersionResource) cache.GenericLister {
+	informer := metadatainformer.NewFilteredMetadataInformer(r.client, gvr, metav1.NamespaceAll, defaultResyncDuration, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	}, nil)
+
+	informerStopCh := make(chan struct{})
+	go informer.Informer().Run(mergeChannels(r.stopCh, informerStopCh))
+
+	timeoutCh := make(chan struct{})
+	timer := time.AfterFunc(r.syncTimeout, func() { close(timeoutCh) })
+	defer timer.Stop()
+
+	// Closed once done waiting, so the goroutines merging the channels return even if neither of the others is closed.
+	waitDoneCh := make(chan struct{})
+	defer close(waitDoneCh)
+
+	if !cache.WaitForCacheSync(mergeChannels(r.stopCh, mergeChannels(timeoutCh, waitDoneCh)), informer.Informer().HasSynced) {
+		r.logger.Warnf("Metadata cache for %s did not sync, owners of this kind will not be resolved", gvr)
+		close(informerStopCh)
+		return nil
+	}
+
+	return informer.Lister()
+}
+
+// controllerOf returns the controller reference in refs, or the first one if none is marked as controller.
+func controllerOf(refs []metav1.OwnerReference) (metav1.OwnerReference, bool) {
+	if len(refs) == 0 {
+		return metav1.OwnerReference{}, false
+	}
+
+	for _, ref := range refs {
+		if ref.Controller != nil && *ref.Controller {
+			return ref, true
+		}
+	}
+
+	return refs[0], true
+}
+
+// mergeChannels returns a channel that is closed when any of a or b is closed.
+func mergeChannels(a, b <-chan struct{}) <-chan struct{} {
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		select {
+		case <-a:
+		case <-b:
+		}
+	}()
+
+	return merged
+}
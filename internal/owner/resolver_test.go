@@ -0,0 +1,335 @@
+package owner_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/owner"
+)
+
+var (
+	replicaSetGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}
+	deploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	jobGVK        = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}
+	cronJobGVK    = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}
+	rolloutGVK    = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+	nodeGVK       = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Node"}
+)
+
+func controllerRef(gvk schema.GroupVersionKind, name string) metav1.OwnerReference {
+	controller := true
+	return metav1.OwnerReference{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind, Name: name, Controller: &controller}
+}
+
+func object(gvk schema.GroupVersionKind, name string, refs ...metav1.OwnerReference) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, OwnerReferences: refs},
+	}
+}
+
+func testMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{replicaSetGVK, deploymentGVK, jobGVK, cronJobGVK, rolloutGVK} {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	mapper.Add(nodeGVK, meta.RESTScopeRoot)
+
+	return mapper
+}
+
+func testScheme() *runtime.Scheme {
+	scheme := fake.NewTestScheme()
+	metav1.AddMetaToScheme(scheme)
+
+	return scheme
+}
+
+func newResolver(t *testing.T, objects ...runtime.Object) *owner.MetadataResolver {
+	t.Helper()
+
+	client := fake.NewSimpleMetadataClient(testScheme(), objects...)
+	resolver, closer, err := owner.NewMetadataResolver(client, testMapper(), owner.WithSyncTimeout(5*time.Second))
+	require.NoError(t, err)
+	t.Cleanup(func() { close(closer) })
+
+	return resolver
+}
+
+func TestMetadataResolver(t *testing.T) {
+	t.Parallel()
+
+	resolver := newResolver(t,
+		// Deployment whose ReplicaSet name does not follow the <deployment>-<hash> convention.
+		object(replicaSetGVK, "unusual", controllerRef(deploymentGVK, "web")),
+		object(deploymentGVK, "web"),
+		object(jobGVK, "backup-28000000", controllerRef(cronJobGVK, "backup")),
+		object(cronJobGVK, "backup"),
+		object(replicaSetGVK, "canary-7d9f", controllerRef(rolloutGVK, "canary")),
+		object(rolloutGVK, "canary"),
+		object(replicaSetGVK, "foo-bar"),
+	)
+
+	tests := []struct {
+		name     string
+		refs     []metav1.OwnerReference
+		expected []owner.Owner
+	}{
+		{
+			name: "deployment",
+			refs: []metav1.OwnerReference{controllerRef(replicaSetGVK, "unusual")},
+			expected: []owner.Owner{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "unusual"},
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			},
+		},
+		{
+			name: "cronjob",
+			refs: []metav1.OwnerReference{controllerRef(jobGVK, "backup-28000000")},
+			expected: []owner.Owner{
+				{APIVersion: "batch/v1", Kind: "Job", Name: "backup-28000000"},
+				{APIVersion: "batch/v1", Kind: "CronJob", Name: "backup"},
+			},
+		},
+		{
+			name: "custom_resource",
+			refs: []metav1.OwnerReference{controllerRef(replicaSetGVK, "canary-7d9f")},
+			expected: []owner.Owner{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "canary-7d9f"},
+				{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "canary"},
+			},
+		},
+		{
+			name: "controller_is_preferred",
+			refs: []metav1.OwnerReference{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "not-a-controller"},
+				controllerRef(deploymentGVK, "web"),
+			},
+			expected: []owner.Owner{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+		},
+		{
+			name:     "bare_replicaset",
+			refs:     []metav1.OwnerReference{controllerRef(replicaSetGVK, "foo-bar")},
+			expected: []owner.Owner{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "foo-bar"}},
+		},
+		{
+			name:     "cluster_scoped_owner",
+			refs:     []metav1.OwnerReference{controllerRef(nodeGVK, "node-1")},
+			expected: []owner.Owner{{APIVersion: "v1", Kind: "Node", Name: "node-1"}},
+		},
+		{
+			name:     "unknown_kind",
+			refs:     []metav1.OwnerReference{controllerRef(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}, "foo")},
+			expected: []owner.Owner{{APIVersion: "example.com/v1", Kind: "Unknown", Name: "foo", Unresolved: true}},
+		},
+		{
+			name:     "owner_not_found",
+			refs:     []metav1.OwnerReference{controllerRef(replicaSetGVK, "missing")},
+			expected: []owner.Owner{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "missing", Unresolved: true}},
+		},
+		{
+			name: "kind_only",
+			refs: []metav1.OwnerReference{owner.ControllerRef("ReplicaSet", "unusual")},
+			expected: []owner.Owner{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "unusual"},
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			},
+		},
+		{
+			name:     "kind_only_custom_resource",
+			refs:     []metav1.OwnerReference{owner.ControllerRef("Rollout", "canary")},
+			expected: []owner.Owner{{Kind: "Rollout", Name: "canary", Unresolved: true}},
+		},
+		{
+			name: "no_owners",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Informers for kinds other than ReplicaSets and Jobs are started by the first lookup.
+			assert.EventuallyWithT(t, func(c *assert.CollectT) {
+				assert.Equal(c, tc.expected, resolver.Resolve("default", tc.refs))
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestMetadataResolver_StopsOnCycles(t *testing.T) {
+	t.Parallel()
+
+	resolver := newResolver(t,
+		object(replicaSetGVK, "a", controllerRef(replicaSetGVK, "b")),
+		object(replicaSetGVK, "b", controllerRef(replicaSetGVK, "a")),
+	)
+
+	chain := resolver.Resolve("default", []metav1.OwnerReference{controllerRef(replicaSetGVK, "a")})
+	assert.Len(t, chain, 10)
+}
+
+func TestMetadataResolver_ForbiddenKind(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleMetadataClient(testScheme(),
+		object(replicaSetGVK, "canary-7d9f", controllerRef(rolloutGVK, "canary")),
+		object(replicaSetGVK, "web-abc", controllerRef(deploymentGVK, "web")),
+		object(deploymentGVK, "web"),
+	)
+
+	var rolloutLists atomic.Int32
+	client.PrependReactor("list", "rollouts", func(clienttesting.Action) (bool, runtime.Object, error) {
+		rolloutLists.Add(1)
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "argoproj.io", Resource: "rollouts"}, "", nil)
+	})
+
+	syncTimeout := 2 * time.Second
+	resolver, closer, err := owner.NewMetadataResolver(client, testMapper(), owner.WithSyncTimeout(syncTimeout))
+	require.NoError(t, err)
+	t.Cleanup(func() { close(closer) })
+
+	// Lookups do not wait for the informer for Rollouts to sync, so the Rollout is returned unresolved.
+	canary := []metav1.OwnerReference{controllerRef(replicaSetGVK, "canary-7d9f")}
+	expected := []owner.Owner{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "canary-7d9f"},
+		{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "canary", Unresolved: true},
+	}
+	start := time.Now()
+	assert.Equal(t, expected, resolver.Resolve("default", canary))
+	assert.Less(t, time.Since(start), syncTimeout/2)
+	require.Eventually(t, func() bool { return rolloutLists.Load() > 0 }, syncTimeout, 10*time.Millisecond)
+
+	// Other kinds are resolved while the informer for Rollouts is waiting to sync.
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []owner.Owner{
+			{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc"},
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+		}, resolver.Resolve("default", []metav1.OwnerReference{controllerRef(replicaSetGVK, "web-abc")}))
+	}, syncTimeout/2, 10*time.Millisecond)
+
+	// Once the informer gives up, lookups keep returning the Rollout unresolved.
+	time.Sleep(syncTimeout)
+	start = time.Now()
+	assert.Equal(t, expected, resolver.Resolve("default", canary))
+	assert.Less(t, time.Since(start), syncTimeout/2)
+}
+
+func TestMetadataResolver_SlowKind(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleMetadataClient(testScheme(),
+		object(replicaSetGVK, "canary-7d9f", controllerRef(rolloutGVK, "canary")),
+		object(rolloutGVK, "canary"),
+	)
+
+	release := make(chan struct{})
+	client.PrependReactor("list", "rollouts", func(clienttesting.Action) (bool, runtime.Object, error) {
+		<-release
+		return false, nil, nil
+	})
+
+	resolver, closer, err := owner.NewMetadataResolver(client, testMapper(), owner.WithSyncTimeout(5*time.Second))
+	require.NoError(t, err)
+	t.Cleanup(func() { close(closer) })
+
+	canary := []metav1.OwnerReference{controllerRef(replicaSetGVK, "canary-7d9f")}
+	assert.Equal(t, []owner.Owner{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "canary-7d9f"},
+		{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "canary", Unresolved: true},
+	}, resolver.Resolve("default", canary))
+
+	close(release)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []owner.Owner{
+			{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "canary-7d9f"},
+			{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "canary"},
+		}, resolver.Resolve("default", canary))
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDeploymentName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		chain    []owner.Owner
+		expected string
+	}{
+		{
+			name:     "resolved_deployment",
+			chain:    []owner.Owner{{Kind: "ReplicaSet", Name: "unusual"}, {Kind: "Deployment", Name: "web"}},
+			expected: "web",
+		},
+		{
+			name:     "unresolved_replicaset",
+			chain:    []owner.Owner{{Kind: "ReplicaSet", Name: "web-7d9f8c", Unresolved: true}},
+			expected: "web",
+		},
+		{
+			name:  "bare_replicaset",
+			chain: []owner.Owner{{Kind: "ReplicaSet", Name: "foo-bar"}},
+		},
+		{
+			name:  "replicaset_owned_by_custom_resource",
+			chain: []owner.Owner{{Kind: "ReplicaSet", Name: "canary-7d9f"}, {Kind: "Rollout", Name: "canary"}},
+		},
+		{
+			name:  "replicaset_without_hash",
+			chain: []owner.Owner{{Kind: "ReplicaSet", Name: "standalone", Unresolved: true}},
+		},
+		{
+			name:  "statefulset",
+			chain: []owner.Owner{{Kind: "StatefulSet", Name: "db"}},
+		},
+		{
+			name: "no_owners",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			name, ok := owner.DeploymentName(tc.chain)
+			assert.Equal(t, tc.expected != "", ok)
+			assert.Equal(t, tc.expected, name)
+		})
+	}
+}
+
+func TestDirectResolver(t *testing.T) {
+	t.Parallel()
+
+	chain := owner.DirectResolver{}.Resolve("default", []metav1.OwnerReference{controllerRef(replicaSetGVK, "web-abc")})
+	assert.Equal(t, []owner.Owner{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Unresolved: true}}, chain)
+
+	_, ok := owner.Workload(chain)
+	assert.False(t, ok, "unresolved owners are not reported as the workload")
+
+	_, ok = owner.Workload(nil)
+	assert.False(t, ok)
+}
+
+func TestWorkload(t *testing.T) {
+	t.Parallel()
+
+	workload, ok := owner.Workload([]owner.Owner{
+		{APIVersion: "batch/v1", Kind: "Job", Name: "report-28000000"},
+		{APIVersion: "batch/v1", Kind: "CronJob", Name: "report"},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "report", workload.Name)
+
+	_, ok = owner.Workload([]owner.Owner{
+		{APIVersion: "batch/v1", Kind: "Job", Name: "report-28000000", Unresolved: true},
+	})
+	assert.False(t, ok)
+}
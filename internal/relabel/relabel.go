@@ -0,0 +1,266 @@
+// Package relabel transforms and drops the samples of an integration before they are published, with rules similar to
+// Prometheus relabel_configs.
+package relabel
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/sample"
+)
+
+// Supported actions.
+const (
+	// ActionDrop drops the samples whose attribute matches the regex.
+	ActionDrop = "drop"
+	// ActionKeep drops the samples whose attribute does not match the regex.
+	ActionKeep = "keep"
+	// ActionRename renames the attribute to the target one, if its value matches the regex.
+	ActionRename = "rename"
+	// ActionCopyFromNamespace copies the attribute of the namespace sample to the samples in that namespace, if its
+	// value matches the regex.
+	ActionCopyFromNamespace = "copyFromNamespace"
+	// ActionHash replaces the attribute by a hash of its value, if it matches the regex.
+	ActionHash = "hash"
+)
+
+const (
+	namespaceEventType     = "K8sNamespaceSample"
+	namespaceNameAttribute = "namespaceName"
+	namespaceAttribute     = "namespace"
+)
+
+var (
+	ErrInvalidAction = errors.New("invalid relabel action")
+	ErrMissingField  = errors.New("missing relabel rule field")
+)
+
+// Relabeler applies a list of rules to the samples of an integration.
+type Relabeler struct {
+	rules []rule
+}
+
+type rule struct {
+	config.RelabelRule
+	regex      *regexp.Regexp
+	eventTypes map[string]bool
+}
+
+// Result holds the number of samples each rule changed or dropped, in the order of the rules.
+type Result struct {
+	Rules []RuleResult
+}
+
+// RuleResult is the effect of a rule.
+type RuleResult struct {
+	config.RelabelRule
+	// Samples is the number of samples the rule changed or dropped.
+	Samples int
+}
+
+// New validates and compiles rules.
+func New(rules []config.RelabelRule) (*Relabeler, error) {
+	r := &Relabeler{rules: make([]rule, 0, len(rules))}
+
+	for i, c := range rules {
+		compiled, err := compileRule(c)
+		if err != nil {
+			return nil, fmt.Errorf("compiling relabel rule #%d: %w", i, err)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+
+	return r, nil
+}
+
+func compileRule(c config.RelabelRule) (rule, error) {
+	switch c.Action {
+	case ActionRename:
+		if c.TargetAttribute == "" {
+			return rule{}, fmt.Errorf("%w: targetAttribute is required by %s", ErrMissingField, c.Action)
+		}
+		fallthrough
+	case ActionDrop, ActionKeep, ActionCopyFromNamespace, ActionHash:
+		if c.Attribute == "" {
+			return rule{}, fmt.Errorf("%w: attribute is required by %s", ErrMissingField, c.Action)
+		}
+	default:
+		return rule{}, fmt.Errorf("%w %q", ErrInvalidAction, c.Action)
+	}
+
+	if c.TargetAttribute == "" {
+		c.TargetAttribute = c.Attribute
+	}
+
+	expr := c.Regex
+	if expr == "" {
+		expr = ".*"
+	}
+
+	regex, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return rule{}, fmt.Errorf("compiling regex %q: %w", c.Regex, err)
+	}
+
+	var eventTypes map[string]bool
+	if len(c.EventTypes) > 0 {
+		eventTypes = make(map[string]bool, len(c.EventTypes))
+		for _, t := range c.EventTypes {
+			eventTypes[t] = true
+		}
+	}
+
+	return rule{RelabelRule: c, regex: regex, eventTypes: eventTypes}, nil
+}
+
+// Apply runs the rules over the samples of i, in order. Entities are visited sorted by type and name so the result does
+// not depend on the order scrapers populated them. Entities left without samples are removed.
+func (r *Relabeler) Apply(i *integration.Integration) Result {
+	result := Result{Rules: make([]RuleResult, len(r.rules))}
+	for n, rl := range r.rules {
+		result.Rules[n].RelabelRule = rl.RelabelRule
+	}
+
+	if len(r.rules) == 0 {
+		return result
+	}
+
+	entities := make([]*integration.Entity, len(i.Entities))
+	copy(entities, i.Entities)
+	sort.SliceStable(entities, func(a, b int) bool {
+		ma, mb := entities[a].Metadata, entities[b].Metadata
+		if ma == nil || mb == nil {
+			return ma == nil && mb != nil
+		}
+		if ma.Namespace != mb.Namespace {
+			return ma.Namespace < mb.Namespace
+		}
+		return ma.Name < mb.Name
+	})
+
+	for n, rl := range r.rules {
+		var namespaces map[string]string
+		if rl.Action == ActionCopyFromNamespace {
+			namespaces = namespaceValues(entities, rl.Attribute)
+		}
+
+		for _, e := range entities {
+			kept := e.Metrics[:0]
+			for _, ms := range e.Metrics {
+				changed, keep := rl.apply(ms, namespaces)
+				if changed {
+					result.Rules[n].Samples++
+				}
+				if keep {
+					kept = append(kept, ms)
+				}
+			}
+			e.Metrics = kept
+		}
+	}
+
+	removeEmptyEntities(i)
+
+	return result
+}
+
+// apply runs the rule over a sample, returning whether it was changed or dropped and whether it must be kept.
+func (rl rule) apply(ms *metric.Set, namespaces map[string]string) (bool, bool) {
+	if rl.eventTypes != nil && !rl.eventTypes[sample.StringAttribute(ms, sample.EventTypeAttribute)] {
+		return false, true
+	}
+
+	switch rl.Action {
+	case ActionDrop:
+		drop := rl.regex.MatchString(sample.StringAttribute(ms, rl.Attribute))
+		return drop, !drop
+	case ActionKeep:
+		keep := rl.regex.MatchString(sample.StringAttribute(ms, rl.Attribute))
+		return !keep, keep
+
+	case ActionRename:
+		value, ok := ms.Metrics[rl.Attribute]
+		if !ok || !rl.regex.MatchString(fmt.Sprint(value)) {
+			return false, true
+		}
+		delete(ms.Metrics, rl.Attribute)
+		ms.Metrics[rl.TargetAttribute] = value
+
+	case ActionCopyFromNamespace:
+		if sample.StringAttribute(ms, sample.EventTypeAttribute) == namespaceEventType {
+			return false, true
+		}
+		value, ok := namespaces[sampleNamespace(ms)]
+		if !ok || !rl.regex.MatchString(value) {
+			return false, true
+		}
+		ms.Metrics[rl.TargetAttribute] = value
+
+	case ActionHash:
+		value, ok := ms.Metrics[rl.Attribute]
+		if !ok || !rl.regex.MatchString(fmt.Sprint(value)) {
+			return false, true
+		}
+		ms.Metrics[rl.TargetAttribute] = rl.hash(fmt.Sprint(value))
+	}
+
+	return true, true
+}
+
+// hash returns the hexadecimal FNV-1a hash of value, or the hash modulo Modulus if set.
+func (rl rule) hash(value string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+	sum := h.Sum64()
+
+	if rl.Modulus > 0 {
+		return strconv.FormatUint(sum%rl.Modulus, 10)
+	}
+
+	return strconv.FormatUint(sum, 16)
+}
+
+// namespaceValues returns the value of an attribute in the namespace samples, by namespace name.
+func namespaceValues(entities []*integration.Entity, attribute string) map[string]string {
+	values := map[string]string{}
+	for _, e := range entities {
+		for _, ms := range e.Metrics {
+			if sample.StringAttribute(ms, sample.EventTypeAttribute) != namespaceEventType {
+				continue
+			}
+			if value, ok := ms.Metrics[attribute]; ok {
+				values[sampleNamespace(ms)] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	return values
+}
+
+// sampleNamespace returns the namespace a sample belongs to, or an empty string for cluster-scoped ones.
+func sampleNamespace(ms *metric.Set) string {
+	if ns := sample.StringAttribute(ms, namespaceNameAttribute); ns != "" {
+		return ns
+	}
+
+	return sample.StringAttribute(ms, namespaceAttribute)
+}
+
+// removeEmptyEntities removes the entities left without samples, events and inventory.
+func removeEmptyEntities(i *integration.Integration) {
+	kept := i.Entities[:0]
+	for _, e := range i.Entities {
+		if len(e.Metrics) > 0 || len(e.Events) > 0 || (e.Inventory != nil && len(e.Inventory.Items()) > 0) {
+			kept = append(kept, e)
+		}
+	}
+	i.Entities = kept
+}
@@ -0,0 +1,135 @@
+package relabel_test
+
+import (
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/relabel"
+	"github.com/newrelic/nri-kubernetes/v3/internal/testutil"
+)
+
+func testIntegration(t *testing.T) *integration.Integration {
+	t.Helper()
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	testutil.AddSample(t, i, "k8s:cluster:namespace", "default", "K8sNamespaceSample", map[string]interface{}{
+		"namespaceName": "default",
+		"label.team":    "payments",
+	})
+	testutil.AddSample(t, i, "k8s:cluster:default:pod", "api", "K8sPodSample", map[string]interface{}{
+		"namespaceName": "default",
+		"podName":       "api",
+		"label.commit":  "3f2a1b",
+	})
+	testutil.AddSample(t, i, "k8s:cluster:default:pod", "worker", "K8sPodSample", map[string]interface{}{
+		"namespaceName": "default",
+		"podName":       "worker",
+	})
+	testutil.AddSample(t, i, "k8s:cluster:kube-system:replicaset", "coredns", "K8sReplicasetSample", map[string]interface{}{
+		"namespaceName":  "kube-system",
+		"replicasetName": "coredns",
+	})
+
+	return i
+}
+
+func TestRelabeler_Apply(t *testing.T) {
+	t.Parallel()
+
+	r, err := relabel.New([]config.RelabelRule{
+		{Action: relabel.ActionDrop, EventTypes: []string{"K8sReplicasetSample"}, Attribute: "event_type"},
+		{Action: relabel.ActionKeep, EventTypes: []string{"K8sPodSample"}, Attribute: "podName", Regex: "api|web"},
+		{Action: relabel.ActionRename, Attribute: "label.commit", TargetAttribute: "commit"},
+		{Action: relabel.ActionCopyFromNamespace, Attribute: "label.team", TargetAttribute: "team"},
+		{Action: relabel.ActionHash, Attribute: "commit", Modulus: 10},
+	})
+	require.NoError(t, err)
+
+	i := testIntegration(t)
+	result := r.Apply(i)
+
+	samplesPerRule := make([]int, 0, len(result.Rules))
+	for _, rr := range result.Rules {
+		samplesPerRule = append(samplesPerRule, rr.Samples)
+	}
+	assert.Equal(t, []int{1, 1, 1, 1, 1}, samplesPerRule)
+
+	assert.Empty(t, testutil.Samples(i, "K8sReplicasetSample"))
+	assert.Len(t, i.Entities, 2, "entities without samples must be removed")
+
+	pods := testutil.Samples(i, "K8sPodSample")
+	require.Len(t, pods, 1)
+	assert.Equal(t, "api", pods["api"]["podName"])
+	assert.NotContains(t, pods["api"], "label.commit")
+	assert.Equal(t, "payments", pods["api"]["team"])
+	assert.Regexp(t, "^[0-9]$", pods["api"]["commit"])
+
+	namespaces := testutil.Samples(i, "K8sNamespaceSample")
+	require.Len(t, namespaces, 1)
+	assert.NotContains(t, namespaces["default"], "team")
+}
+
+func TestRelabeler_Apply_Hash(t *testing.T) {
+	t.Parallel()
+
+	r, err := relabel.New([]config.RelabelRule{
+		{Action: relabel.ActionHash, Attribute: "label.commit", Regex: "[0-9a-f]+"},
+	})
+	require.NoError(t, err)
+
+	first, second := testIntegration(t), testIntegration(t)
+	r.Apply(first)
+	r.Apply(second)
+
+	hashed := testutil.Samples(first, "K8sPodSample")
+	assert.NotEqual(t, "3f2a1b", hashed["api"]["label.commit"])
+	assert.Equal(t, hashed["api"]["label.commit"], testutil.Samples(second, "K8sPodSample")["api"]["label.commit"], "hashes must be stable")
+}
+
+func TestRelabeler_Apply_NoRules(t *testing.T) {
+	t.Parallel()
+
+	r, err := relabel.New(nil)
+	require.NoError(t, err)
+
+	i := testIntegration(t)
+	result := r.Apply(i)
+
+	assert.Empty(t, result.Rules)
+	assert.Len(t, i.Entities, 4)
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule config.RelabelRule
+		err  error
+	}{
+		{name: "unknown_action", rule: config.RelabelRule{Action: "replace", Attribute: "foo"}, err: relabel.ErrInvalidAction},
+		{name: "rename_without_target", rule: config.RelabelRule{Action: relabel.ActionRename, Attribute: "foo"}, err: relabel.ErrMissingField},
+		{name: "drop_without_attribute", rule: config.RelabelRule{Action: relabel.ActionDrop, Regex: "kube-system"}, err: relabel.ErrMissingField},
+		{name: "keep_without_attribute", rule: config.RelabelRule{Action: relabel.ActionKeep, EventTypes: []string{"K8sPodSample"}}, err: relabel.ErrMissingField},
+		{name: "hash_without_attribute", rule: config.RelabelRule{Action: relabel.ActionHash}, err: relabel.ErrMissingField},
+		{name: "invalid_regex", rule: config.RelabelRule{Action: relabel.ActionDrop, Attribute: "foo", Regex: "(["}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := relabel.New([]config.RelabelRule{tc.rule})
+			require.Error(t, err)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
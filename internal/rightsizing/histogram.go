@@ -0,0 +1,110 @@
+package rightsizing
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// maxDecayExponent bounds the weight of new samples relative to the reference time of a histogram. Past it, weights
+// are rescaled to the time of the new sample so they do not overflow.
+const maxDecayExponent = 64
+
+// minWeight is the weight under which buckets are dropped when weights are rescaled.
+const minWeight = 1e-6
+
+// buckets defines exponentially growing buckets: the first one holds values up to min, and each of the next ones
+// values up to growth times the previous limit, up to the one holding max.
+type buckets struct {
+	min    float64
+	max    float64
+	growth float64
+}
+
+var (
+	// cpuBuckets hold usage from 1 millicore up to 1000 cores with a 5% error.
+	cpuBuckets = buckets{min: 0.001, max: 1000, growth: 1.05} //nolint: gochecknoglobals // read-only.
+	// memoryBuckets hold usage from 1 MiB up to 1 TiB with a 5% error.
+	memoryBuckets = buckets{min: 1 << 20, max: 1 << 40, growth: 1.05} //nolint: gochecknoglobals // read-only.
+)
+
+func (b buckets) index(value float64) int {
+	if value <= b.min {
+		return 0
+	}
+
+	value = math.Min(value, b.max)
+	return int(math.Ceil(math.Log(value/b.min) / math.Log(b.growth)))
+}
+
+func (b buckets) upperBound(index int) float64 {
+	return b.min * math.Pow(b.growth, float64(index))
+}
+
+// histogram is a decaying histogram of usage, where the weight of samples halves every half-life. Instead of decaying
+// the existing weights, new samples weigh exponentially more than the ones at the reference time, which is equivalent
+// for computing percentiles.
+type histogram struct {
+	Weights   map[int]float64 `json:"weights"`
+	Total     float64         `json:"total"`
+	Reference time.Time       `json:"reference"`
+}
+
+func (h *histogram) add(b buckets, value float64, at time.Time, halfLife time.Duration) {
+	if h.Weights == nil {
+		h.Weights = map[int]float64{}
+		h.Reference = at
+	}
+
+	exponent := at.Sub(h.Reference).Seconds() / halfLife.Seconds()
+	if exponent > maxDecayExponent {
+		h.rescale(math.Exp2(-exponent))
+		h.Reference = at
+		exponent = 0
+	}
+
+	weight := math.Exp2(exponent)
+	h.Weights[b.index(value)] += weight
+	h.Total += weight
+}
+
+func (h *histogram) rescale(factor float64) {
+	h.Total = 0
+	for index, weight := range h.Weights {
+		weight *= factor
+		if weight < minWeight {
+			delete(h.Weights, index)
+			continue
+		}
+		h.Weights[index] = weight
+		h.Total += weight
+	}
+}
+
+func (h *histogram) empty() bool {
+	return h.Total == 0
+}
+
+// percentile returns the upper bound of the bucket holding the given percentile, between 0 and 1.
+func (h *histogram) percentile(b buckets, p float64) float64 {
+	if h.empty() {
+		return 0
+	}
+
+	indexes := make([]int, 0, len(h.Weights))
+	for index := range h.Weights {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	threshold := p * h.Total
+	var accumulated float64
+	for _, index := range indexes {
+		accumulated += h.Weights[index]
+		if accumulated >= threshold {
+			return b.upperBound(index)
+		}
+	}
+
+	return b.upperBound(indexes[len(indexes)-1])
+}
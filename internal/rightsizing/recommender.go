@@ -0,0 +1,366 @@
+// Package rightsizing recommends requests and limits for containers from the history of their usage, kept across
+// scrapes as decaying histograms in a storer.
+//
+// A Recommender can take the usage from the container samples of a kubelet scraper, in which case its histories only
+// hold the replicas in that node, or from the partials of all nodes rolled up by the aggregation stage, in which case
+// there is a single recommendation per container of each workload in the cluster.
+package rightsizing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/newrelic/infra-integrations-sdk/persist"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/aggregate"
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/internal/sample"
+	"github.com/newrelic/nri-kubernetes/v3/internal/storer"
+)
+
+// EventType is the event type of rightsizing samples.
+const EventType = "K8sRightsizingSample"
+
+// HistoryRetention is how long the history of a container is kept after its last sample.
+const HistoryRetention = 7 * 24 * time.Hour
+
+const stateKey = "rightsizing"
+
+// history is the usage of the containers with the same name in the pods of a workload.
+type history struct {
+	Namespace     string `json:"namespace"`
+	WorkloadKind  string `json:"workloadKind"`
+	WorkloadName  string `json:"workloadName"`
+	ContainerName string `json:"containerName"`
+
+	CPU    histogram `json:"cpu"`
+	Memory histogram `json:"memory"`
+
+	SampleCount int       `json:"sampleCount"`
+	FirstSample time.Time `json:"firstSample"`
+	LastSample  time.Time `json:"lastSample"`
+
+	// Requests and limits are the last ones seen.
+	CPURequestedCores    float64 `json:"cpuRequestedCores"`
+	CPULimitCores        float64 `json:"cpuLimitCores"`
+	MemoryRequestedBytes float64 `json:"memoryRequestedBytes"`
+	MemoryLimitBytes     float64 `json:"memoryLimitBytes"`
+}
+
+// state is what the Recommender keeps in the storer.
+type state struct {
+	Histories  map[string]*history `json:"histories"`
+	LastReport time.Time           `json:"lastReport"`
+	// Partials holds the timestamp of the last partial recorded for each node, so partials which have not been
+	// updated since the previous scrape are not recorded twice.
+	Partials map[string]time.Time `json:"partials,omitempty"`
+}
+
+// containerUsage is the usage of a container along with its requests and limits.
+type containerUsage struct {
+	namespace string
+	kind      string
+	workload  string
+	container string
+	metrics   map[string]float64
+}
+
+// Recommender adds the usage of containers to their history on every scrape, and periodically reports the recommended
+// requests and limits for them. Recommendations are a percentile of usage plus a margin, like the ones of the Vertical
+// Pod Autoscaler, and are reported along with how much the current requests exceed them as overProvisionedCores and
+// overProvisionedBytes, which are negative for under-provisioned containers.
+//
+// Containers are identified by their workload and name, so the usage of all the replicas of a workload is added to the
+// same history. When fed by Apply, only the replicas in the node are seen, and samples carry the nodeName. When fed by
+// ConsumePartials, replicas in all nodes are. Containers not owned by a workload are not recommended for.
+type Recommender struct {
+	logger      *log.Logger
+	store       storer.Storer
+	clusterName string
+	nodeName    string
+	config      config.Rightsizing
+	attributes  []attribute.Attribute
+	now         func() time.Time
+}
+
+// RecommenderOpt are options that can be used to configure the Recommender.
+type RecommenderOpt func(*Recommender)
+
+// WithLogger returns an OptionFunc to change the logger from the default noop logger.
+func WithLogger(logger *log.Logger) RecommenderOpt {
+	return func(r *Recommender) {
+		r.logger = logger
+	}
+}
+
+// WithCustomAttributes returns an OptionFunc to add static attributes to the rightsizing samples.
+func WithCustomAttributes(attributes []config.CustomAttribute) RecommenderOpt {
+	return func(r *Recommender) {
+		r.attributes = sample.CustomAttributes(attributes)
+	}
+}
+
+// WithNow returns an OptionFunc to change the clock of the Recommender, which is useful for tests.
+func WithNow(now func() time.Time) RecommenderOpt {
+	return func(r *Recommender) {
+		r.now = now
+	}
+}
+
+// NewRecommender returns a Recommender for the containers of the given node, or of the whole cluster if nodeName is
+// empty, keeping their history in store. If store implements Save, like the file storer of the sdk, it is called after
+// every update so the history survives restarts.
+func NewRecommender(store storer.Storer, clusterName, nodeName string, c config.Rightsizing, opts ...RecommenderOpt) *Recommender {
+	r := &Recommender{
+		logger:      logutil.Discard,
+		store:       store,
+		clusterName: clusterName,
+		nodeName:    nodeName,
+		config:      c,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Apply adds the usage of the container samples in i to their history, and adds rightsizing samples to i every report
+// interval. The first report happens one interval after the history starts. Init and ephemeral containers are left out,
+// as their usage is not representative of the workload.
+func (r *Recommender) Apply(i *integration.Integration) error {
+	return r.apply(i, func(s *state, now time.Time) {
+		for _, ms := range sample.Containers(i) {
+			if !sample.AppContainer(ms) {
+				continue
+			}
+			r.record(s, sampleUsage(ms), now)
+		}
+	})
+}
+
+// ConsumePartials implements aggregate.Consumer, adding the usage of the containers in partials to their history, at
+// the time each partial was collected, and adding rightsizing samples to i like Apply does.
+func (r *Recommender) ConsumePartials(i *integration.Integration, partials []aggregate.Partial) error {
+	return r.apply(i, func(s *state, now time.Time) {
+		if s.Partials == nil {
+			s.Partials = map[string]time.Time{}
+		}
+
+		for _, p := range partials {
+			if !p.Timestamp.After(s.Partials[p.NodeName]) {
+				continue
+			}
+			s.Partials[p.NodeName] = p.Timestamp
+
+			for _, u := range p.Containers {
+				r.record(s, partialUsage(u), p.Timestamp)
+			}
+		}
+
+		for nodeName, last := range s.Partials {
+			if now.Sub(last) > HistoryRetention {
+				delete(s.Partials, nodeName)
+			}
+		}
+	})
+}
+
+// apply runs record over the state in the store, removes the expired histories, and reports them if it is time to.
+func (r *Recommender) apply(i *integration.Integration, record func(s *state, now time.Time)) error {
+	now := r.now()
+	s := r.load()
+
+	record(s, now)
+
+	for key, h := range s.Histories {
+		if now.Sub(h.LastSample) > HistoryRetention {
+			delete(s.Histories, key)
+		}
+	}
+
+	var err error
+	switch {
+	case s.LastReport.IsZero():
+		s.LastReport = now
+	case now.Sub(s.LastReport) >= r.config.ReportInterval:
+		r.logger.Debugf("Reporting rightsizing recommendations for %d containers", len(s.Histories))
+		err = r.report(i, s)
+		s.LastReport = now
+	}
+
+	r.store.Set(stateKey, *s)
+	if saver, ok := r.store.(interface{ Save() error }); ok {
+		if saveErr := saver.Save(); saveErr != nil {
+			return fmt.Errorf("saving usage histories: %w", saveErr)
+		}
+	}
+
+	return err
+}
+
+// load returns the state in the store, or an empty one if it is missing or cannot be read.
+func (r *Recommender) load() *state {
+	s := state{}
+	if _, err := r.store.Get(stateKey, &s); err != nil && !errors.Is(err, persist.ErrNotFound) {
+		r.logger.Warnf("Discarding usage histories which could not be read: %v", err)
+		s = state{}
+	}
+
+	if s.Histories == nil {
+		s.Histories = map[string]*history{}
+	}
+
+	return &s
+}
+
+// sampleUsage returns the usage in a container sample.
+func sampleUsage(ms *metric.Set) containerUsage {
+	u := containerUsage{
+		namespace: sample.StringAttribute(ms, "namespaceName"),
+		kind:      sample.StringAttribute(ms, "workloadKind"),
+		workload:  sample.StringAttribute(ms, "workloadName"),
+		container: sample.StringAttribute(ms, "containerName"),
+		metrics:   map[string]float64{},
+	}
+
+	for name, value := range ms.Metrics {
+		if v, ok := value.(float64); ok {
+			u.metrics[name] = v
+		}
+	}
+
+	return u
+}
+
+// partialUsage returns the usage of a container in a partial.
+func partialUsage(pu aggregate.Usage) containerUsage {
+	u := containerUsage{
+		namespace: pu.Namespace,
+		kind:      pu.WorkloadKind,
+		workload:  pu.WorkloadName,
+		container: pu.ContainerName,
+		metrics:   make(map[string]float64, len(pu.Values)+len(pu.Resources)),
+	}
+
+	for name, v := range pu.Values {
+		u.metrics[name] = v
+	}
+	for name, v := range pu.Resources {
+		u.metrics[name] = v
+	}
+
+	return u
+}
+
+// record adds the usage of a container to its history.
+func (r *Recommender) record(s *state, u containerUsage, now time.Time) {
+	if u.namespace == "" || u.kind == "" || u.workload == "" || u.container == "" {
+		return
+	}
+
+	cpu, hasCPU := u.metrics["cpuUsedCores"]
+	memory, hasMemory := u.metrics["memoryWorkingSetBytes"]
+	if !hasCPU && !hasMemory {
+		return
+	}
+
+	key := strings.Join([]string{u.namespace, u.kind, u.workload, u.container}, "/")
+	h, ok := s.Histories[key]
+	if !ok {
+		h = &history{
+			Namespace:     u.namespace,
+			WorkloadKind:  u.kind,
+			WorkloadName:  u.workload,
+			ContainerName: u.container,
+			FirstSample:   now,
+		}
+		s.Histories[key] = h
+	}
+
+	if hasCPU {
+		h.CPU.add(cpuBuckets, cpu, now, r.config.HalfLife)
+	}
+	if hasMemory {
+		h.Memory.add(memoryBuckets, memory, now, r.config.HalfLife)
+	}
+
+	h.SampleCount++
+	if now.After(h.LastSample) {
+		h.LastSample = now
+	}
+	h.CPURequestedCores = u.metrics["cpuRequestedCores"]
+	h.CPULimitCores = u.metrics["cpuLimitCores"]
+	h.MemoryRequestedBytes = u.metrics["memoryRequestedBytes"]
+	h.MemoryLimitBytes = u.metrics["memoryLimitBytes"]
+}
+
+func (r *Recommender) report(i *integration.Integration, s *state) error {
+	margin := 1 + r.config.Margin
+
+	for _, h := range s.Histories {
+		metrics := map[string]float64{
+			"sampleCount":    float64(h.SampleCount),
+			"historySeconds": h.LastSample.Sub(h.FirstSample).Seconds(),
+		}
+
+		if !h.CPU.empty() {
+			request := h.CPU.percentile(cpuBuckets, r.config.RequestPercentile) * margin
+			metrics["recommendedCpuRequestCores"] = request
+			metrics["recommendedCpuLimitCores"] = h.CPU.percentile(cpuBuckets, r.config.LimitPercentile) * margin
+			setIfPositive(metrics, "cpuRequestedCores", h.CPURequestedCores)
+			setIfPositive(metrics, "cpuLimitCores", h.CPULimitCores)
+			if h.CPURequestedCores > 0 {
+				metrics["overProvisionedCores"] = h.CPURequestedCores - request
+			}
+		}
+
+		if !h.Memory.empty() {
+			request := h.Memory.percentile(memoryBuckets, r.config.RequestPercentile) * margin
+			metrics["recommendedMemoryRequestBytes"] = request
+			metrics["recommendedMemoryLimitBytes"] = h.Memory.percentile(memoryBuckets, r.config.LimitPercentile) * margin
+			setIfPositive(metrics, "memoryRequestedBytes", h.MemoryRequestedBytes)
+			setIfPositive(metrics, "memoryLimitBytes", h.MemoryLimitBytes)
+			if h.MemoryRequestedBytes > 0 {
+				metrics["overProvisionedBytes"] = h.MemoryRequestedBytes - request
+			}
+		}
+
+		if err := r.addSample(i, h, metrics); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Recommender) addSample(i *integration.Integration, h *history, metrics map[string]float64) error {
+	entityType := fmt.Sprintf("k8s:%s:%s:%s", r.clusterName, h.Namespace, strings.ToLower(h.WorkloadKind))
+	attrs := []attribute.Attribute{
+		attribute.Attr("namespaceName", h.Namespace),
+		attribute.Attr("workloadKind", h.WorkloadKind),
+		attribute.Attr("workloadName", h.WorkloadName),
+		attribute.Attr("containerName", h.ContainerName),
+	}
+	if r.nodeName != "" {
+		attrs = append(attrs, attribute.Attr("nodeName", r.nodeName))
+	}
+	attrs = append(attrs, r.attributes...)
+
+	return sample.Add(i, h.WorkloadName, entityType, EventType, r.clusterName, attrs, metrics)
+}
+
+func setIfPositive(metrics map[string]float64, name string, value float64) {
+	if value > 0 {
+		metrics[name] = value
+	}
+}
@@ -0,0 +1,241 @@
+package rightsizing_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	sdklog "github.com/newrelic/infra-integrations-sdk/log"
+	"github.com/newrelic/infra-integrations-sdk/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/aggregate"
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/internal/rightsizing"
+	"github.com/newrelic/nri-kubernetes/v3/internal/storer"
+	"github.com/newrelic/nri-kubernetes/v3/internal/testutil"
+)
+
+const mib = 1 << 20
+
+func testConfig() config.Rightsizing {
+	return config.Rightsizing{
+		Enabled:           true,
+		ReportInterval:    10 * time.Minute,
+		HalfLife:          time.Hour,
+		RequestPercentile: 0.95,
+		LimitPercentile:   0.99,
+		Margin:            0.15,
+	}
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+// scrape returns an integration with the containers of two replicas of the api deployment, using cpu and memory, and
+// of a standalone pod. The first replica has an init and an ephemeral container too.
+func scrape(t *testing.T, cpu []float64, memory []float64) *integration.Integration {
+	t.Helper()
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	for n, pod := range []string{"api-1", "api-2"} {
+		testutil.AddContainer(t, i, pod, "app", map[string]interface{}{
+			"workloadKind":          "Deployment",
+			"workloadName":          "api",
+			"cpuUsedCores":          cpu[n],
+			"memoryWorkingSetBytes": memory[n],
+			"cpuRequestedCores":     float64(1),
+			"memoryRequestedBytes":  float64(512 * mib),
+		})
+	}
+	// KSM also reports the requests of the same container, without usage.
+	testutil.AddContainer(t, i, "api-1", "app", map[string]interface{}{
+		"cpuRequestedCores":    float64(1),
+		"memoryRequestedBytes": float64(512 * mib),
+	})
+	testutil.AddContainer(t, i, "debug", "shell", map[string]interface{}{
+		"cpuUsedCores": float64(2),
+	})
+	for container, containerType := range map[string]string{"migrate": "init", "debugger": "ephemeral"} {
+		testutil.AddContainer(t, i, "api-1", container, map[string]interface{}{
+			"workloadKind":          "Deployment",
+			"workloadName":          "api",
+			"containerType":         containerType,
+			"cpuUsedCores":          float64(2),
+			"memoryWorkingSetBytes": float64(1024 * mib),
+		})
+	}
+
+	return i
+}
+
+func TestRecommender_Apply(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Now()}
+	store := storer.NewInMemoryStore(time.Hour, time.Hour, logutil.Discard)
+	t.Cleanup(store.StopVacuum)
+
+	r := rightsizing.NewRecommender(store, "cluster", "node-a", testConfig(), rightsizing.WithNow(c.Now), rightsizing.WithCustomAttributes([]config.CustomAttribute{
+		{Name: "environment", Value: "production"},
+	}))
+
+	for minute := 0; minute < 10; minute++ {
+		i := scrape(t, []float64{0.2, 0.4}, []float64{100 * mib, 200 * mib})
+		require.NoError(t, r.Apply(i))
+		assert.Empty(t, testutil.Samples(i, rightsizing.EventType), "recommendations must be reported once the report interval elapses")
+		c.now = c.now.Add(time.Minute)
+	}
+
+	i := scrape(t, []float64{0.2, 0.4}, []float64{100 * mib, 200 * mib})
+	require.NoError(t, r.Apply(i))
+
+	found := testutil.Samples(i, rightsizing.EventType)
+	require.Len(t, found, 1, "containers without workload, init and ephemeral containers must not be recommended for")
+
+	s := found["api"]
+	assert.Equal(t, "cluster", s["clusterName"])
+	assert.Equal(t, "node-a", s["nodeName"])
+	assert.Equal(t, "default", s["namespaceName"])
+	assert.Equal(t, "Deployment", s["workloadKind"])
+	assert.Equal(t, "api", s["workloadName"])
+	assert.Equal(t, "app", s["containerName"])
+	assert.Equal(t, "production", s["environment"])
+	assert.Equal(t, float64(22), s["sampleCount"], "usage of all replicas must be added to the history")
+	assert.Equal(t, float64(600), s["historySeconds"])
+
+	// Buckets are 5% wide, so percentiles are within 5% of the usage.
+	assert.InEpsilon(t, 0.4*1.15, s["recommendedCpuRequestCores"], 0.05)
+	assert.InEpsilon(t, 0.4*1.15, s["recommendedCpuLimitCores"], 0.05)
+	assert.InEpsilon(t, 200*mib*1.15, s["recommendedMemoryRequestBytes"], 0.05)
+	assert.Equal(t, float64(1), s["cpuRequestedCores"])
+	assert.InEpsilon(t, 1-0.4*1.15, s["overProvisionedCores"], 0.05)
+	assert.InEpsilon(t, 512*mib-200*mib*1.15, s["overProvisionedBytes"], 0.05)
+	assert.NotContains(t, s, "cpuLimitCores")
+
+	// The next report happens after another interval.
+	c.now = c.now.Add(time.Minute)
+	i = scrape(t, []float64{0.2, 0.4}, []float64{100 * mib, 200 * mib})
+	require.NoError(t, r.Apply(i))
+	assert.Empty(t, testutil.Samples(i, rightsizing.EventType))
+}
+
+func TestRecommender_Apply_Decay(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Now()}
+	store := storer.NewInMemoryStore(time.Hour, time.Hour, logutil.Discard)
+	t.Cleanup(store.StopVacuum)
+
+	cfg := testConfig()
+	cfg.HalfLife = 10 * time.Minute
+	r := rightsizing.NewRecommender(store, "cluster", "node-a", cfg, rightsizing.WithNow(c.Now))
+
+	for n := 0; n < 10; n++ {
+		require.NoError(t, r.Apply(scrape(t, []float64{2, 2}, []float64{mib, mib})))
+		c.now = c.now.Add(time.Minute)
+	}
+
+	// Usage two days later outweighs the old one, which was far more than 64 half-lives ago.
+	c.now = c.now.Add(48 * time.Hour)
+	i := scrape(t, []float64{0.1, 0.1}, []float64{mib, mib})
+	require.NoError(t, r.Apply(i))
+
+	found := testutil.Samples(i, rightsizing.EventType)
+	require.Len(t, found, 1)
+	assert.InEpsilon(t, 0.1*1.15, found["api"]["recommendedCpuLimitCores"], 0.05)
+	assert.Equal(t, float64(22), found["api"]["sampleCount"])
+}
+
+func TestRecommender_Apply_Persisted(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Now()}
+	path := filepath.Join(t.TempDir(), "rightsizing.json")
+
+	for n := 0; n < 2; n++ {
+		// A new store is loaded from the file every time, as if the integration restarted.
+		store, err := persist.NewFileStore(path, sdklog.Discard, rightsizing.HistoryRetention)
+		require.NoError(t, err)
+
+		r := rightsizing.NewRecommender(store, "cluster", "node-a", testConfig(), rightsizing.WithNow(c.Now))
+		require.NoError(t, r.Apply(scrape(t, []float64{0.2, 0.4}, []float64{100 * mib, 200 * mib})))
+		c.now = c.now.Add(10 * time.Minute)
+	}
+
+	store, err := persist.NewFileStore(path, sdklog.Discard, rightsizing.HistoryRetention)
+	require.NoError(t, err)
+
+	i := scrape(t, []float64{0.2, 0.4}, []float64{100 * mib, 200 * mib})
+	require.NoError(t, rightsizing.NewRecommender(store, "cluster", "node-a", testConfig(), rightsizing.WithNow(c.Now)).Apply(i))
+
+	found := testutil.Samples(i, rightsizing.EventType)
+	require.Len(t, found, 1)
+	assert.Equal(t, float64(6), found["api"]["sampleCount"], "history must survive restarts")
+	assert.InEpsilon(t, 0.4*1.15, found["api"]["recommendedCpuRequestCores"], 0.05)
+}
+
+// partial returns the partial of a node with a replica of the api deployment using cpu and memory.
+func partial(nodeName, pod string, timestamp time.Time, cpu, memory float64) aggregate.Partial {
+	return aggregate.Partial{
+		NodeName:  nodeName,
+		Timestamp: timestamp,
+		Containers: []aggregate.Usage{{
+			Namespace:     "default",
+			PodName:       pod,
+			ContainerName: "app",
+			WorkloadKind:  "Deployment",
+			WorkloadName:  "api",
+			Values:        map[string]float64{"cpuUsedCores": cpu, "memoryWorkingSetBytes": memory},
+			Resources:     map[string]float64{"cpuRequestedCores": 1},
+		}},
+	}
+}
+
+func TestRecommender_ConsumePartials(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Now()}
+	store := storer.NewInMemoryStore(time.Hour, time.Hour, logutil.Discard)
+	t.Cleanup(store.StopVacuum)
+
+	r := rightsizing.NewRecommender(store, "cluster", "", testConfig(), rightsizing.WithNow(c.Now))
+
+	stale := partial("node-b", "api-2", c.now, 0.4, 200*mib)
+	for minute := 0; minute <= 10; minute++ {
+		partials := []aggregate.Partial{partial("node-a", "api-1", c.now, 0.2, 100*mib)}
+		// The partial of node-b is not updated after the first minute, and must be recorded only once.
+		partials = append(partials, stale)
+
+		i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+		require.NoError(t, err)
+		require.NoError(t, r.ConsumePartials(i, partials))
+
+		if minute < 10 {
+			assert.Empty(t, testutil.Samples(i, rightsizing.EventType))
+			c.now = c.now.Add(time.Minute)
+			continue
+		}
+
+		found := testutil.Samples(i, rightsizing.EventType)
+		require.Len(t, found, 1, "usage of all nodes must be recommended for once per workload container")
+
+		s := found["api"]
+		assert.NotContains(t, s, "nodeName")
+		assert.Equal(t, "api", s["workloadName"])
+		assert.Equal(t, "app", s["containerName"])
+		assert.Equal(t, float64(12), s["sampleCount"])
+		assert.Equal(t, float64(1), s["cpuRequestedCores"])
+		assert.InEpsilon(t, 0.4*1.15, s["recommendedCpuLimitCores"], 0.05)
+	}
+}
@@ -0,0 +1,170 @@
+// Package sample looks up the samples populated by the scrapers and adds new ones, for the stages that run over them
+// before they are published, like aggregation, cost, rightsizing and relabel.
+package sample
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+)
+
+// Attributes and event types of the samples populated by the scrapers.
+const (
+	EventTypeAttribute = "event_type"
+	ContainerEventType = "K8sContainerSample"
+	PodEventType       = "K8sPodSample"
+)
+
+// Values of the containerType attribute of container samples of containers not running for the lifetime of their pod.
+const (
+	initContainerType      = "init"
+	ephemeralContainerType = "ephemeral"
+)
+
+// ownerNameAttributes are the attributes with the names of the owners of pods and containers that are workloads, along
+// with their kinds.
+var ownerNameAttributes = []struct { //nolint: gochecknoglobals // read-only list.
+	attribute string
+	kind      string
+}{
+	{attribute: "deploymentName", kind: "Deployment"},
+	{attribute: "statefulsetName", kind: "StatefulSet"},
+	{attribute: "daemonsetName", kind: "DaemonSet"},
+	{attribute: "jobName", kind: "Job"},
+}
+
+// Workload returns the kind and name of the workload of the pod or container sample ms. Samples whose top-level owner
+// is not resolved, which have no workloadKind, are accounted to the Deployment, StatefulSet, DaemonSet or Job owning
+// them, if any. An empty kind is returned otherwise.
+func Workload(ms *metric.Set) (string, string) {
+	if kind := StringAttribute(ms, "workloadKind"); kind != "" {
+		return kind, StringAttribute(ms, "workloadName")
+	}
+
+	for _, a := range ownerNameAttributes {
+		if name := StringAttribute(ms, a.attribute); name != "" {
+			return a.kind, name
+		}
+	}
+
+	return "", ""
+}
+
+// AppContainer returns whether the container sample ms is of an app container or a sidecar, which run for the lifetime
+// of their pod, rather than of an init or ephemeral container.
+func AppContainer(ms *metric.Set) bool {
+	switch StringAttribute(ms, "containerType") {
+	case initContainerType, ephemeralContainerType:
+		return false
+	default:
+		return true
+	}
+}
+
+// StringAttribute returns the value of an attribute as a string, or an empty one if it is missing.
+func StringAttribute(ms *metric.Set, name string) string {
+	value, ok := ms.Metrics[name]
+	if !ok || value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// ContainerKey returns the namespace, pod and container names of a container sample, joined by slashes.
+func ContainerKey(ms *metric.Set) string {
+	return StringAttribute(ms, "namespaceName") + "/" + StringAttribute(ms, "podName") + "/" + StringAttribute(ms, "containerName")
+}
+
+// PodKey returns the namespace and pod names of a pod sample, joined by a slash.
+func PodKey(ms *metric.Set) string {
+	return StringAttribute(ms, "namespaceName") + "/" + StringAttribute(ms, "podName")
+}
+
+// Containers returns the container samples in i by ContainerKey, merged as in Merge.
+func Containers(i *integration.Integration) map[string]*metric.Set {
+	return Merge(i, ContainerEventType, ContainerKey)
+}
+
+// Pods returns the pod samples in i by PodKey, merged as in Merge.
+func Pods(i *integration.Integration) map[string]*metric.Set {
+	return Merge(i, PodEventType, PodKey)
+}
+
+// Merge returns the samples of eventType in i by the given key, merging the ones with the same key, like the ones
+// populated by both the KSM and kubelet scrapers. The maximum of numeric values is kept, and the last non-empty value
+// of the other ones.
+func Merge(i *integration.Integration, eventType string, key func(*metric.Set) string) map[string]*metric.Set {
+	samples := map[string]*metric.Set{}
+
+	for _, e := range i.Entities {
+		for _, ms := range e.Metrics {
+			if StringAttribute(ms, EventTypeAttribute) != eventType {
+				continue
+			}
+
+			k := key(ms)
+			merged, ok := samples[k]
+			if !ok {
+				merged = &metric.Set{Metrics: map[string]interface{}{}}
+				samples[k] = merged
+			}
+
+			for name, value := range ms.Metrics {
+				current, isFloat := merged.Metrics[name].(float64)
+				v, ok := value.(float64)
+				if ok && isFloat {
+					merged.Metrics[name] = math.Max(current, v)
+					continue
+				}
+				if _, exists := merged.Metrics[name]; !exists || value != "" {
+					merged.Metrics[name] = value
+				}
+			}
+		}
+	}
+
+	return samples
+}
+
+// CustomAttributes returns the custom attributes in the config as sample attributes.
+func CustomAttributes(attributes []config.CustomAttribute) []attribute.Attribute {
+	attrs := make([]attribute.Attribute, 0, len(attributes))
+	for _, attr := range attributes {
+		attrs = append(attrs, attribute.Attr(attr.Name, attr.Value))
+	}
+
+	return attrs
+}
+
+// Add adds a sample of eventType to the entity with the given name and type, with the clusterName and displayName
+// attributes followed by the given ones, and metrics as gauges.
+func Add(
+	i *integration.Integration,
+	name, entityType, eventType, clusterName string,
+	attributes []attribute.Attribute,
+	metrics map[string]float64,
+) error {
+	e, err := i.Entity(name, entityType)
+	if err != nil {
+		return fmt.Errorf("creating entity %q of type %q: %w", name, entityType, err)
+	}
+
+	attrs := make([]attribute.Attribute, 0, len(attributes)+2)
+	attrs = append(attrs, attribute.Attr("clusterName", clusterName), attribute.Attr("displayName", name))
+	attrs = append(attrs, attributes...)
+
+	ms := e.NewMetricSet(eventType, attrs...)
+	for metricName, v := range metrics {
+		if err := ms.SetMetric(metricName, v, metric.GAUGE); err != nil {
+			return fmt.Errorf("setting %q in %s: %w", metricName, eventType, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package sample_test
+
+import (
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/sample"
+	"github.com/newrelic/nri-kubernetes/v3/internal/testutil"
+)
+
+func TestContainers(t *testing.T) {
+	t.Parallel()
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	testutil.AddContainer(t, i, "api-1", "app", map[string]interface{}{
+		"workloadKind": "Deployment",
+		"cpuUsedCores": 0.25,
+	})
+	// KSM also reports the same container, without workload nor usage.
+	testutil.AddContainer(t, i, "api-1", "app", map[string]interface{}{
+		"workloadKind":      "",
+		"cpuUsedCores":      float64(0),
+		"cpuRequestedCores": 0.5,
+	})
+	testutil.AddContainer(t, i, "api-2", "app", nil)
+	testutil.AddSample(t, i, "k8s:cluster:default:pod", "api-1", "K8sPodSample", map[string]interface{}{
+		"namespaceName": "default",
+		"podName":       "api-1",
+	})
+
+	containers := sample.Containers(i)
+	require.Len(t, containers, 2)
+
+	app := containers["default/api-1/app"]
+	require.NotNil(t, app)
+	assert.Equal(t, "Deployment", sample.StringAttribute(app, "workloadKind"), "empty values must not replace other ones")
+	assert.Equal(t, 0.25, app.Metrics["cpuUsedCores"], "the maximum of numeric values must be kept")
+	assert.Equal(t, 0.5, app.Metrics["cpuRequestedCores"])
+	assert.Empty(t, sample.StringAttribute(app, "missing"))
+
+	assert.Contains(t, sample.Pods(i), "default/api-1")
+}
+
+func TestWorkload(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		metrics  map[string]interface{}
+		kind     string
+		workload string
+	}{
+		{
+			name:     "resolved",
+			metrics:  map[string]interface{}{"workloadKind": "CronJob", "workloadName": "backup", "jobName": "backup-28"},
+			kind:     "CronJob",
+			workload: "backup",
+		},
+		{
+			name:     "unresolved_replicaset",
+			metrics:  map[string]interface{}{"replicasetName": "web-7d9f", "deploymentName": "web"},
+			kind:     "Deployment",
+			workload: "web",
+		},
+		{
+			name:     "unresolved_statefulset",
+			metrics:  map[string]interface{}{"statefulsetName": "db"},
+			kind:     "StatefulSet",
+			workload: "db",
+		},
+		{
+			name:     "unresolved_job",
+			metrics:  map[string]interface{}{"jobName": "backup-28"},
+			kind:     "Job",
+			workload: "backup-28",
+		},
+		{
+			name:    "no_workload",
+			metrics: map[string]interface{}{"replicasetName": "bare"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			kind, name := sample.Workload(&metric.Set{Metrics: tc.metrics})
+			assert.Equal(t, tc.kind, kind)
+			assert.Equal(t, tc.workload, name)
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	t.Parallel()
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	attrs := sample.CustomAttributes([]config.CustomAttribute{{Name: "environment", Value: "production"}})
+	require.NoError(t, sample.Add(i, "api", "k8s:cluster:default:deployment", "K8sTestSample", "cluster", attrs, map[string]float64{
+		"podCount": 2,
+	}))
+
+	s := testutil.Samples(i, "K8sTestSample")["api"]
+	require.NotNil(t, s)
+	assert.Equal(t, "cluster", s["clusterName"])
+	assert.Equal(t, "api", s["displayName"])
+	assert.Equal(t, "production", s["environment"])
+	assert.Equal(t, float64(2), s["podCount"])
+}
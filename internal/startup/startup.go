@@ -0,0 +1,187 @@
+// Package startup rolls up the startup latencies of pods per workload into K8sWorkloadStartupSample samples.
+//
+// Kubelet scrapers report the latencies of each pod in its K8sPodSample. The ones of the pods created in a window are
+// collected, and their p50 and p95 are reported per Deployment, StatefulSet and DaemonSet, either across nodes by the
+// aggregation stage, or per node by a Reporter when aggregation is disabled.
+package startup
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/sample"
+)
+
+// EventType is the event type of the rolled up samples.
+const EventType = "K8sWorkloadStartupSample"
+
+// latencies are the metrics of pod samples whose p50 and p95 are reported per workload.
+var latencies = []string{ //nolint: gochecknoglobals // read-only list.
+	"schedulingLatencySeconds",
+	"initLatencySeconds",
+	"startupLatencySeconds",
+}
+
+// workloadKinds are the kinds of the workloads startup latencies are rolled up for.
+var workloadKinds = map[string]bool{ //nolint: gochecknoglobals // read-only map.
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+}
+
+// Pod holds the startup latencies of a pod of a workload.
+type Pod struct {
+	Namespace    string             `json:"namespace"`
+	PodName      string             `json:"podName"`
+	WorkloadKind string             `json:"workloadKind"`
+	WorkloadName string             `json:"workloadName"`
+	Latencies    map[string]float64 `json:"latencies,omitempty"`
+}
+
+// Collect returns the startup latencies in the pod samples of i of the pods of Deployments, StatefulSets and
+// DaemonSets created in the window before now. Nothing is collected if window is zero.
+func Collect(i *integration.Integration, now time.Time, window time.Duration) []Pod {
+	if window <= 0 {
+		return nil
+	}
+
+	samples := sample.Pods(i)
+	keys := make([]string, 0, len(samples))
+	for key := range samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pods []Pod
+	for _, key := range keys {
+		ms := samples[key]
+
+		createdAt, ok := ms.Metrics["createdAt"].(float64)
+		if !ok || time.Unix(int64(createdAt), 0).Before(now.Add(-window)) {
+			continue
+		}
+
+		kind, name := sample.Workload(ms)
+		if !workloadKinds[kind] || name == "" {
+			continue
+		}
+
+		pod := Pod{
+			Namespace:    sample.StringAttribute(ms, "namespaceName"),
+			PodName:      sample.StringAttribute(ms, "podName"),
+			WorkloadKind: kind,
+			WorkloadName: name,
+			Latencies:    map[string]float64{},
+		}
+		for _, l := range latencies {
+			if v, ok := ms.Metrics[l].(float64); ok {
+				pod.Latencies[l] = v
+			}
+		}
+		pods = append(pods, pod)
+	}
+
+	return pods
+}
+
+// workload accumulates the startup latencies of the pods of a workload.
+type workload struct {
+	pods      map[string]bool
+	latencies map[string][]float64
+}
+
+// Rollup adds to i a K8sWorkloadStartupSample per workload of pods, with the number of pods and the p50 and p95 of
+// their latencies, followed by the given attributes. Pods with the same name, like the ones collected from different
+// nodes, are counted once.
+func Rollup(i *integration.Integration, clusterName string, pods []Pod, attributes []attribute.Attribute) error {
+	workloads := map[string]*workload{}
+
+	for _, pod := range pods {
+		key := pod.Namespace + "/" + pod.WorkloadKind + "/" + pod.WorkloadName
+		w, ok := workloads[key]
+		if !ok {
+			w = &workload{pods: map[string]bool{}, latencies: map[string][]float64{}}
+			workloads[key] = w
+		}
+
+		w.pods[pod.PodName] = true
+		for name, v := range pod.Latencies {
+			w.latencies[name] = append(w.latencies[name], v)
+		}
+	}
+
+	for key, w := range workloads {
+		parts := strings.SplitN(key, "/", 3)
+		namespace, kind, name := parts[0], parts[1], parts[2]
+
+		metrics := map[string]float64{
+			"podCount": float64(len(w.pods)),
+		}
+		for l, values := range w.latencies {
+			sort.Float64s(values)
+			metrics[l+"P50"] = percentile(values, 50)
+			metrics[l+"P95"] = percentile(values, 95)
+		}
+
+		attrs := make([]attribute.Attribute, 0, len(attributes)+3)
+		attrs = append(attrs,
+			attribute.Attr("namespaceName", namespace),
+			attribute.Attr("workloadKind", kind),
+			attribute.Attr("workloadName", name),
+		)
+		attrs = append(attrs, attributes...)
+
+		entityType := fmt.Sprintf("k8s:%s:%s:%s", clusterName, namespace, strings.ToLower(kind))
+		if err := sample.Add(i, name, entityType, EventType, clusterName, attrs, metrics); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// percentile returns the nearest-rank percentile p of the sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// Reporter rolls up the startup latencies of the pods in the node a kubelet scraper runs in, reported along with its
+// name. It is used when aggregation is disabled, as otherwise they are rolled up across nodes by the aggregation stage.
+type Reporter struct {
+	clusterName string
+	window      time.Duration
+	attributes  []attribute.Attribute
+	now         func() time.Time
+}
+
+// NewReporter returns a Reporter for the pods created in window in the given node, adding the custom attributes to the
+// samples.
+func NewReporter(clusterName, nodeName string, window time.Duration, customAttributes []config.CustomAttribute) *Reporter {
+	return &Reporter{
+		clusterName: clusterName,
+		window:      window,
+		attributes:  append([]attribute.Attribute{attribute.Attr("nodeName", nodeName)}, sample.CustomAttributes(customAttributes)...),
+		now:         time.Now,
+	}
+}
+
+// Apply rolls up the startup latencies in the pod samples of i into i.
+func (r *Reporter) Apply(i *integration.Integration) error {
+	if err := Rollup(i, r.clusterName, Collect(i, r.now(), r.window), r.attributes); err != nil {
+		return fmt.Errorf("rolling up startup latencies: %w", err)
+	}
+
+	return nil
+}
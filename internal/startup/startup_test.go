@@ -0,0 +1,117 @@
+package startup_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/startup"
+	"github.com/newrelic/nri-kubernetes/v3/internal/testutil"
+)
+
+type startupPod struct {
+	kind, workload string
+	// deployment is the deploymentName of pods whose top-level owner is not resolved, which have no workloadKind.
+	deployment string
+	age        time.Duration
+	startup    float64
+}
+
+// startupIntegration returns an integration with the pod samples populated by the kubelet scraper of a node, with
+// their startup latencies.
+func startupIntegration(t *testing.T, now time.Time, pods map[string]startupPod) *integration.Integration {
+	t.Helper()
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	for name, pod := range pods {
+		metrics := map[string]interface{}{
+			"namespaceName":            "default",
+			"podName":                  name,
+			"createdAt":                float64(now.Add(-pod.age).Unix()),
+			"schedulingLatencySeconds": pod.startup / 10,
+			"startupLatencySeconds":    pod.startup,
+		}
+		if pod.kind != "" {
+			metrics["workloadKind"] = pod.kind
+			metrics["workloadName"] = pod.workload
+		}
+		if pod.deployment != "" {
+			metrics["deploymentName"] = pod.deployment
+		}
+		testutil.AddSample(t, i, "k8s:cluster:default:pod", name, "K8sPodSample", metrics)
+	}
+
+	return i
+}
+
+func TestRollup(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	var pods []startup.Pod
+	pods = append(pods, startup.Collect(startupIntegration(t, now, map[string]startupPod{
+		"web-1": {kind: "Deployment", workload: "web", age: time.Minute, startup: 10},
+		"web-2": {kind: "Deployment", workload: "web", age: time.Minute, startup: 20},
+		"web-0": {kind: "Deployment", workload: "web", age: 2 * time.Hour, startup: 1000},
+		"job-1": {kind: "Job", workload: "job", age: time.Minute, startup: 5},
+	}), now, time.Hour)...)
+	pods = append(pods, startup.Collect(startupIntegration(t, now, map[string]startupPod{
+		"web-3": {kind: "Deployment", workload: "web", age: time.Minute, startup: 100},
+		"web-4": {deployment: "web", age: time.Minute, startup: 30},
+		"bare":  {age: time.Minute, startup: 30},
+		"db-0":  {kind: "StatefulSet", workload: "db", age: time.Minute, startup: 50},
+	}), now, time.Hour)...)
+
+	i, err := integration.New("test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+	require.NoError(t, startup.Rollup(i, "cluster", pods, nil))
+
+	startups := testutil.Samples(i, startup.EventType)
+	require.Len(t, startups, 2, "only pods of Deployments, StatefulSets and DaemonSets are rolled up")
+
+	// Latencies of the pods of a workload are rolled up across nodes, excluding the ones created before the window.
+	// Pods whose top-level owner is not resolved are accounted to their Deployment.
+	web := startups["web"]
+	assert.Equal(t, "Deployment", web["workloadKind"])
+	assert.Equal(t, "default", web["namespaceName"])
+	assert.NotContains(t, web, "nodeName")
+	assert.Equal(t, float64(4), web["podCount"])
+	assert.Equal(t, 20.0, web["startupLatencySecondsP50"])
+	assert.Equal(t, 100.0, web["startupLatencySecondsP95"])
+	assert.Equal(t, 2.0, web["schedulingLatencySecondsP50"])
+	assert.NotContains(t, web, "initLatencySecondsP50")
+
+	db := startups["db"]
+	assert.Equal(t, "StatefulSet", db["workloadKind"])
+	assert.Equal(t, float64(1), db["podCount"])
+	assert.Equal(t, 50.0, db["startupLatencySecondsP95"])
+
+	// Startup latencies are not collected if the window is zero.
+	assert.Empty(t, startup.Collect(startupIntegration(t, now, map[string]startupPod{
+		"web-1": {kind: "Deployment", workload: "web", age: time.Minute, startup: 10},
+	}), now, 0))
+}
+
+func TestReporter(t *testing.T) {
+	t.Parallel()
+
+	i := startupIntegration(t, time.Now(), map[string]startupPod{
+		"web-1": {deployment: "web", age: time.Minute, startup: 10},
+	})
+
+	reporter := startup.NewReporter("cluster", "node-a", time.Hour, []config.CustomAttribute{{Name: "environment", Value: "production"}})
+	require.NoError(t, reporter.Apply(i))
+
+	web := testutil.Samples(i, startup.EventType)["web"]
+	require.NotNil(t, web)
+	assert.Equal(t, "Deployment", web["workloadKind"])
+	assert.Equal(t, "node-a", web["nodeName"])
+	assert.Equal(t, "production", web["environment"])
+	assert.Equal(t, float64(1), web["podCount"])
+}
@@ -0,0 +1,54 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+)
+
+// AddSample adds a sample of eventType with the given metrics to the entity with the given type and name, as the
+// scrapers populate them. It fails the test if the entity cannot be created.
+func AddSample(t *testing.T, i *integration.Integration, entityType, name, eventType string, metrics map[string]interface{}) {
+	t.Helper()
+
+	e, err := i.Entity(name, entityType)
+	if err != nil {
+		t.Fatalf("creating entity %q of type %q: %v", name, entityType, err)
+	}
+
+	ms := e.NewMetricSet(eventType, attribute.Attr("displayName", name))
+	for k, v := range metrics {
+		ms.Metrics[k] = v
+	}
+}
+
+// AddContainer adds a K8sContainerSample with the given metrics for a container of a pod in the default namespace.
+func AddContainer(t *testing.T, i *integration.Integration, pod, container string, metrics map[string]interface{}) {
+	t.Helper()
+
+	all := map[string]interface{}{
+		"namespaceName": "default",
+		"podName":       pod,
+		"containerName": container,
+	}
+	for k, v := range metrics {
+		all[k] = v
+	}
+
+	AddSample(t, i, "k8s:cluster:default:"+pod+":container", container, "K8sContainerSample", all)
+}
+
+// Samples returns the metrics of the samples of eventType in i, by the name of their entity.
+func Samples(i *integration.Integration, eventType string) map[string]map[string]interface{} {
+	found := map[string]map[string]interface{}{}
+	for _, e := range i.Entities {
+		for _, ms := range e.Metrics {
+			if ms.Metrics["event_type"] == eventType {
+				found[e.Metadata.Name] = ms.Metrics
+			}
+		}
+	}
+
+	return found
+}
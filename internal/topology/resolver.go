@@ -0,0 +1,70 @@
+// Package topology resolves the zone and region samples are in, from the labels of the node they belong to.
+package topology
+
+import (
+	listersv1 "k8s.io/client-go/listers/core/v1"
+)
+
+// Well-known labels of nodes holding their topology. The deprecated failure-domain ones are used as fallback for old
+// clusters.
+const (
+	ZoneLabel             = "topology.kubernetes.io/zone"
+	RegionLabel           = "topology.kubernetes.io/region"
+	deprecatedZoneLabel   = "failure-domain.beta.kubernetes.io/zone"
+	deprecatedRegionLabel = "failure-domain.beta.kubernetes.io/region"
+)
+
+// Attributes reported in samples.
+const (
+	ZoneAttribute   = "zone"
+	RegionAttribute = "region"
+)
+
+// Resolver returns the topology attributes of the samples of a node.
+type Resolver struct {
+	nodes       listersv1.NodeLister
+	defaultNode string
+}
+
+// NewResolver returns a Resolver getting nodes from lister. Samples not belonging to any node, like the ones of volumes,
+// are considered to be in defaultNode, if set.
+func NewResolver(lister listersv1.NodeLister, defaultNode string) *Resolver {
+	return &Resolver{
+		nodes:       lister,
+		defaultNode: defaultNode,
+	}
+}
+
+// NodeAttributes returns the zone and region of the given node, if it exists and has the topology labels.
+func (r *Resolver) NodeAttributes(nodeName string) map[string]string {
+	if nodeName == "" {
+		nodeName = r.defaultNode
+	}
+
+	if nodeName == "" {
+		return nil
+	}
+
+	node, err := r.nodes.Get(nodeName)
+	if err != nil {
+		return nil
+	}
+
+	attributes := map[string]string{}
+	if zone := labelWithFallback(node.Labels, ZoneLabel, deprecatedZoneLabel); zone != "" {
+		attributes[ZoneAttribute] = zone
+	}
+	if region := labelWithFallback(node.Labels, RegionLabel, deprecatedRegionLabel); region != "" {
+		attributes[RegionAttribute] = region
+	}
+
+	return attributes
+}
+
+func labelWithFallback(labels map[string]string, label, fallback string) string {
+	if value, ok := labels[label]; ok {
+		return value
+	}
+
+	return labels[fallback]
+}
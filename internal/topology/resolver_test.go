@@ -0,0 +1,54 @@
+package topology_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
+	"github.com/newrelic/nri-kubernetes/v3/internal/topology"
+)
+
+func TestResolver_NodeAttributes(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   "current",
+			Labels: map[string]string{topology.ZoneLabel: "us-east-1a", topology.RegionLabel: "us-east-1"},
+		}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   "legacy",
+			Labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": "europe-west1-b"},
+		}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+	)
+	lister, closer := discovery.NewNodeLister(client)
+	t.Cleanup(func() { close(closer) })
+
+	tests := []struct {
+		name        string
+		defaultNode string
+		nodeName    string
+		expected    map[string]string
+	}{
+		{name: "topology_labels", nodeName: "current", expected: map[string]string{"zone": "us-east-1a", "region": "us-east-1"}},
+		{name: "deprecated_labels", nodeName: "legacy", expected: map[string]string{"zone": "europe-west1-b"}},
+		{name: "no_labels", nodeName: "unlabeled", expected: map[string]string{}},
+		{name: "unknown_node", nodeName: "unknown", expected: nil},
+		{name: "no_node", expected: nil},
+		{name: "default_node", defaultNode: "current", expected: map[string]string{"zone": "us-east-1a", "region": "us-east-1"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := topology.NewResolver(lister, tc.defaultNode)
+			assert.Equal(t, tc.expected, r.NodeAttributes(tc.nodeName))
+		})
+	}
+}
@@ -70,6 +70,7 @@ func (a K8sClientAuthenticator) AuthenticatedTransport(endpoint config.Endpoint)
 	transportConfig := &transport.Config{
 		TLS: transport.TLSConfig{
 			Insecure: endpoint.InsecureSkipVerify,
+			CAFile:   endpoint.CABundlePath,
 		},
 	}
 
@@ -82,6 +83,13 @@ func (a K8sClientAuthenticator) AuthenticatedTransport(endpoint config.Endpoint)
 
 		transportConfig.BearerTokenFile = a.InClusterConfig.BearerTokenFile
 
+	case strings.EqualFold(endpoint.Auth.Type, mTLSAuth) && endpoint.Auth.MTLS != nil &&
+		endpoint.Auth.MTLS.TLSSecretName == "" && endpoint.Auth.MTLS.CertPath != "":
+		a.logger.Debugf("Using mTLS with certificates from %q to authenticate request to %q", endpoint.Auth.MTLS.CertPath, endpoint.URL)
+
+		transportConfig.TLS.CertFile = endpoint.Auth.MTLS.CertPath
+		transportConfig.TLS.KeyFile = endpoint.Auth.MTLS.KeyPath
+
 	case strings.EqualFold(endpoint.Auth.Type, mTLSAuth) && endpoint.Auth.MTLS != nil:
 		a.logger.Debugf("Using mTLS to authenticate request to %q", endpoint.URL)
 
@@ -90,7 +98,7 @@ func (a K8sClientAuthenticator) AuthenticatedTransport(endpoint config.Endpoint)
 			return nil, fmt.Errorf("could not load TLS configuration for endpoint %q: %w", endpoint.URL, err)
 		}
 
-		if certs.ca == nil && !endpoint.InsecureSkipVerify {
+		if certs.ca == nil && !endpoint.InsecureSkipVerify && endpoint.CABundlePath == "" {
 			return nil, fmt.Errorf("insecureSkipVerify is false and CA cert is missing from secret %q", endpoint.URL)
 		}
 
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -420,3 +422,41 @@ zi8BOyoQSU/5M+6It/Ch0MgdOM94SGmXGTjjq/q9gCHgkCco1Qk00+54Trc1UZsR
 tYY1BVEEikAx4nKW/G8YLMSuzPZuTknCvafC+N552AWqevnShNJkI1d5
 -----END CERTIFICATE-----`
 )
+
+func Test_Authenticator_with_mTLS_from_files(t *testing.T) {
+	t.Parallel()
+
+	endpoint := startMTLSServer()
+
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	authenticator, err := authenticator.New(authenticator.Config{})
+	require.NoError(t, err)
+
+	e := config.Endpoint{
+		Auth: &config.Auth{
+			Type: "mtls",
+			MTLS: &config.MTLS{
+				CertPath: writeFile("tls.crt", clientCert),
+				KeyPath:  writeFile("tls.key", clientKey),
+			},
+		},
+		CABundlePath: writeFile("ca.crt", serverCACert),
+	}
+
+	rt, err := authenticator.AuthenticatedTransport(e)
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: rt}).Get(fmt.Sprintf("https://%s/test", endpoint))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, testString, string(bodyBytes))
+}
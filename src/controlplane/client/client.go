@@ -16,10 +16,11 @@ import (
 
 // Client implements a client for ControlPlane component.
 type Client struct {
-	logger   *log.Logger
-	doer     client.HTTPDoer
-	endpoint url.URL
-	retries  int
+	logger       *log.Logger
+	doer         client.HTTPDoer
+	endpoint     url.URL
+	retries      int
+	acceptHeader string
 }
 
 type OptionFunc func(c *Client) error
@@ -45,10 +46,20 @@ func WithMaxRetries(retries int) OptionFunc {
 	}
 }
 
+// WithTextFormat returns an OptionFunc making the client only accept the plain text exposition format, instead of
+// preferring the protobuf one.
+func WithTextFormat() OptionFunc {
+	return func(c *Client) error {
+		c.acceptHeader = prometheus.TextAcceptHeader
+		return nil
+	}
+}
+
 // New builds a Client using the given options.
 func New(connector connector.Connector, opts ...OptionFunc) (*Client, error) {
 	c := &Client{
-		logger: logutil.Discard,
+		logger:       logutil.Discard,
+		acceptHeader: prometheus.AcceptHeader,
 	}
 
 	for i, opt := range opts {
@@ -84,7 +95,7 @@ func New(connector connector.Connector, opts ...OptionFunc) (*Client, error) {
 // Notice that it does not satisfy prometheus.MetricFamiliesGetFunc, since the url path is injected by the connector
 func (c *Client) MetricFamiliesGetFunc() prometheus.FetchAndFilterMetricsFamilies {
 	return func(queries []prometheus.Query) ([]prometheus.MetricFamily, error) {
-		mFamily, err := prometheus.GetFilteredMetricFamilies(c.doer, c.endpoint.String(), queries, c.logger)
+		mFamily, err := prometheus.GetFilteredMetricFamilies(c.doer, c.endpoint.String(), c.acceptHeader, queries, c.logger)
 		if err != nil {
 			return nil, fmt.Errorf("getting filtered metric families %q: %w", c.endpoint.String(), err)
 		}
@@ -192,6 +192,19 @@ func (s *Scraper) Run(i *integration.Integration) error {
 	return nil
 }
 
+// clientOptions returns the options of the clients of the components.
+func (s *Scraper) clientOptions() []controlplaneClient.OptionFunc {
+	opts := []controlplaneClient.OptionFunc{
+		controlplaneClient.WithLogger(s.logger),
+		controlplaneClient.WithMaxRetries(s.config.ControlPlane.Retries),
+	}
+	if s.config.DisablePrometheusProtobuf {
+		opts = append(opts, controlplaneClient.WithTextFormat())
+	}
+
+	return opts
+}
+
 // externalEndpoint builds the client based on the StaticEndpointConfig and fails if
 // the client probe cannot reach the endpoint.
 func (s *Scraper) externalEndpoint(c component) (*scrape.Job, error) {
@@ -207,11 +220,7 @@ func (s *Scraper) externalEndpoint(c component) (*scrape.Job, error) {
 		return nil, fmt.Errorf("creating connector for %q failed: %w", c.Name, err)
 	}
 
-	client, err := controlplaneClient.New(
-		connector,
-		controlplaneClient.WithLogger(s.logger),
-		controlplaneClient.WithMaxRetries(s.config.ControlPlane.Retries),
-	)
+	client, err := controlplaneClient.New(connector, s.clientOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("creating client for %q failed: %w", c.Name, err)
 	}
@@ -230,7 +239,7 @@ func (s *Scraper) externalEndpoint(c component) (*scrape.Job, error) {
 		u.Host,
 	)
 
-	return scrape.NewScrapeJob(string(c.Name), grouper, c.Specs), nil
+	return scrape.NewScrapeJob(string(c.Name), grouper, c.Specs, scrape.JobWithCustomAttributes(s.config.CustomAttributes)), nil
 }
 
 // autodiscover will iterate over the Autodiscovery configs from a component and for each:
@@ -265,11 +274,7 @@ func (s *Scraper) autodiscover(c component) (*scrape.Job, error) {
 			return nil, fmt.Errorf("creating connector for %q failed: %w", c.Name, err)
 		}
 
-		client, err := controlplaneClient.New(
-			connector,
-			controlplaneClient.WithLogger(s.logger),
-			controlplaneClient.WithMaxRetries(s.config.ControlPlane.Retries),
-		)
+		client, err := controlplaneClient.New(connector, s.clientOptions()...)
 		if err != nil {
 			s.logger.Debugf("Failed creating %q client: %v", c.Name, err)
 			continue
@@ -282,7 +287,7 @@ func (s *Scraper) autodiscover(c component) (*scrape.Job, error) {
 			pod.Name,
 		)
 
-		return scrape.NewScrapeJob(string(c.Name), grouper, c.Specs), nil
+		return scrape.NewScrapeJob(string(c.Name), grouper, c.Specs, scrape.JobWithCustomAttributes(s.config.CustomAttributes)), nil
 	}
 
 	s.logger.Debugf("No %q pod has been discovered", c.Name)
@@ -1,9 +1,13 @@
 package definition
 
 import (
+	"errors"
 	"fmt"
 )
 
+// ErrMetricNotFound is returned by FromRaw, wrapped along with the metric key, when an entity does not have the metric.
+var ErrMetricNotFound = errors.New("metric not found")
+
 // RawValue is just any value from a raw metric.
 type RawValue interface{}
 
@@ -36,6 +40,9 @@ type TransformFunc func(FetchedValue) (FetchedValue, error)
 
 // FromRaw fetches metrics from raw metrics. Is the most simple use case.
 func FromRaw(metricKey string) FetchFunc {
+	// Metrics missing in entities are common, so the error is only built once.
+	errNotFound := fmt.Errorf("%w: %s", ErrMetricNotFound, metricKey)
+
 	return func(groupLabel, entityID string, groups RawGroups) (FetchedValue, error) {
 		group, ok := groups[groupLabel]
 		if !ok {
@@ -49,7 +56,7 @@ func FromRaw(metricKey string) FetchFunc {
 
 		value, ok := entity[metricKey]
 		if !ok {
-			return nil, fmt.Errorf("metric %q not found", metricKey)
+			return nil, errNotFound
 		}
 
 		return value, nil
@@ -52,7 +52,8 @@ func TestFromRawErrorsOnNotFound(t *testing.T) {
 	assert.Nil(t, v)
 
 	v, err = FromRaw("non_existing_metric")("group1", "entity2", raw)
-	assert.EqualError(t, err, "metric \"non_existing_metric\" not found")
+	assert.EqualError(t, err, "metric not found: non_existing_metric")
+	assert.ErrorIs(t, err, ErrMetricNotFound)
 	assert.Nil(t, v)
 }
 
@@ -107,7 +108,7 @@ func TestTransformBypassesError(t *testing.T) {
 	assert.Nil(t, v)
 
 	v, err = Transform(FromRaw("non_existing_metric"), transformFunc)("group1", "entity2", raw)
-	assert.EqualError(t, err, "metric \"non_existing_metric\" not found")
+	assert.EqualError(t, err, "metric not found: non_existing_metric")
 	assert.Nil(t, v)
 }
 
@@ -153,7 +154,7 @@ func TestTransformAndFilter(t *testing.T) { //nolint: funlen
 				},
 			},
 			want:    nil,
-			wantErr: "metric \"dummy_metric\" not found",
+			wantErr: "metric not found: dummy_metric",
 		},
 		{
 			name: "FilterFuncError",
@@ -3,15 +3,26 @@ package definition
 import (
 	"fmt"
 
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
 	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/newrelic/nri-kubernetes/v3/internal/attributefilter"
 	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
 )
 
 const (
 	NamespaceGroup         = "namespace"
 	NamespaceFilteredLabel = "nrFiltered"
+	// DroppedAttributesMetric is the number of label and annotation attributes dropped from a sample by the
+	// AttributeFilter.
+	DroppedAttributesMetric = "droppedAttributes"
 )
 
+// NodeAttributesGetter returns the attributes to add to the samples belonging to a node. Samples not belonging to any
+// node are passed an empty nodeName.
+type NodeAttributesGetter interface {
+	NodeAttributes(nodeName string) map[string]string
+}
+
 // GuessFunc guesses from data.
 type GuessFunc func(groupLabel string) (string, error)
 
@@ -24,4 +35,12 @@ type IntegrationPopulateConfig struct {
 	Groups         RawGroups
 	Specs          SpecGroups
 	Filterer       discovery.NamespaceFilterer
+	// AttributeFilter, if set, decides which label and annotation attributes are reported.
+	AttributeFilter attributefilter.Filterer
+	// CustomAttributes are added to all samples, including the cluster one.
+	CustomAttributes []attribute.Attribute
+	// NodeAttributes, if set, returns attributes added to samples from the node in their nodeName attribute.
+	NodeAttributes NodeAttributesGetter
+	// Workers is the number of entities populated in parallel. If zero, it is the number of CPUs usable.
+	Workers int
 }
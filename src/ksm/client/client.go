@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/sethgrid/pester"
@@ -15,10 +16,12 @@ import (
 // Client implements a client for KSM, capable of retrieving prometheus metrics from a given endpoint.
 type Client struct {
 	// http is an HttpDoer that the KSM client will use to make requests.
-	http    client.HTTPDoer
-	logger  *log.Logger
-	retries int
-	timeout time.Duration
+	http         client.HTTPDoer
+	logger       *log.Logger
+	retries      int
+	timeout      time.Duration
+	transport    http.RoundTripper
+	acceptHeader string
 }
 
 type OptionFunc func(kc *Client) error
@@ -47,10 +50,29 @@ func WithMaxRetries(retries int) OptionFunc {
 	}
 }
 
+// WithTransport returns an OptionFunc to change the RoundTripper used to connect to KSM, which can be used to configure
+// TLS and authentication.
+func WithTransport(transport http.RoundTripper) OptionFunc {
+	return func(kc *Client) error {
+		kc.transport = transport
+		return nil
+	}
+}
+
+// WithTextFormat returns an OptionFunc making the client only accept the plain text exposition format, instead of
+// preferring the protobuf one.
+func WithTextFormat() OptionFunc {
+	return func(kc *Client) error {
+		kc.acceptHeader = prometheus.TextAcceptHeader
+		return nil
+	}
+}
+
 // New builds a Client using the given options. By default, it will use pester as an HTTP Doer and a noop logger.
 func New(opts ...OptionFunc) (*Client, error) {
 	k := &Client{
-		logger: logutil.Discard,
+		logger:       logutil.Discard,
+		acceptHeader: prometheus.AcceptHeader,
 	}
 
 	for i, opt := range opts {
@@ -63,6 +85,7 @@ func New(opts ...OptionFunc) (*Client, error) {
 	httpPester.Backoff = pester.LinearBackoff
 	httpPester.MaxRetries = k.retries
 	httpPester.Timeout = k.timeout
+	httpPester.Transport = k.transport
 	httpPester.LogHook = func(e pester.ErrEntry) {
 		k.logger.Debugf("getting data from ksm: %v", e)
 	}
@@ -74,7 +97,7 @@ func New(opts ...OptionFunc) (*Client, error) {
 // MetricFamiliesGetFunc returns a function that obtains metric families from a list of prometheus queries.
 func (c *Client) MetricFamiliesGetFunc(url string) prometheus.FetchAndFilterMetricsFamilies {
 	return func(queries []prometheus.Query) ([]prometheus.MetricFamily, error) {
-		mFamily, err := prometheus.GetFilteredMetricFamilies(c.http, url, queries, c.logger)
+		mFamily, err := prometheus.GetFilteredMetricFamilies(c.http, url, c.acceptHeader, queries, c.logger)
 		if err != nil {
 			return nil, fmt.Errorf("getting filtered metric families: %w", err)
 		}
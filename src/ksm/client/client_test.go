@@ -66,6 +66,72 @@ func Test_Client_Read(t *testing.T) {
 	require.Equal(t, len(families), 1)
 }
 
+func Test_Client_WithTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	// Without the server CA the TLS handshake fails.
+	cpClient, err := client.New(client.WithMaxRetries(0))
+	require.NoError(t, err)
+
+	_, err = cpClient.MetricFamiliesGetFunc(server.URL)(nil)
+	require.Error(t, err)
+
+	cpClient, err = client.New(client.WithMaxRetries(0), client.WithTransport(bearerTransport{
+		token: "secret",
+		rt:    server.Client().Transport,
+	}))
+	require.NoError(t, err)
+
+	_, err = cpClient.MetricFamiliesGetFunc(server.URL)(nil)
+	require.NoError(t, err)
+}
+
+func Test_Client_WithTextFormat(t *testing.T) {
+	t.Parallel()
+
+	accepted := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted <- r.Header.Get("Accept")
+	}))
+	t.Cleanup(server.Close)
+
+	cpClient, err := client.New(client.WithMaxRetries(0))
+	require.NoError(t, err)
+
+	_, err = cpClient.MetricFamiliesGetFunc(server.URL)(nil)
+	require.NoError(t, err)
+	assert.Equal(t, prometheus.AcceptHeader, <-accepted)
+
+	cpClient, err = client.New(client.WithMaxRetries(0), client.WithTextFormat())
+	require.NoError(t, err)
+
+	_, err = cpClient.MetricFamiliesGetFunc(server.URL)(nil)
+	require.NoError(t, err)
+	assert.Equal(t, prometheus.TextAcceptHeader, <-accepted)
+}
+
+type bearerTransport struct {
+	token string
+	rt    http.RoundTripper
+}
+
+func (b bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+b.token)
+
+	return b.rt.RoundTrip(r)
+}
+
 func testHTTPServer(t *testing.T, requestsReceived *int, timeout time.Duration) *httptest.Server {
 	t.Helper()
 
@@ -4,15 +4,22 @@ import (
 	"fmt"
 
 	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/internal/owner"
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	listersv1 "k8s.io/client-go/listers/core/v1"
 
 	"github.com/newrelic/nri-kubernetes/v3/src/data"
 	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	ksmMetric "github.com/newrelic/nri-kubernetes/v3/src/ksm/metric"
 	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
 )
 
+// noneLabelValue is the value KSM gives to labels of pods without owner.
+const noneLabelValue = "<none>"
+
 type grouper struct {
 	Config
 	logger *log.Logger
@@ -23,6 +30,11 @@ type Config struct {
 	MetricFamiliesGetter       prometheus.FetchAndFilterMetricsFamilies
 	ServicesLister             listersv1.ServiceLister
 	EnableResourceQuotaSamples bool
+	// OwnerResolver resolves the workloads owning pods. If nil, only the direct owner of each pod is known.
+	OwnerResolver owner.Resolver
+	// PendingPodsLister lists the pending pods, whose PodScheduled condition holds the reasons why the scheduler could
+	// not place them. Pods in other phases, if listed, are skipped. If nil, those reasons are not reported.
+	PendingPodsLister listersv1.PodLister
 }
 
 type OptionFunc func(kc *grouper) error
@@ -76,6 +88,14 @@ func (g *grouper) Group(specGroups definition.SpecGroups) (definition.RawGroups,
 		}
 	}
 
+	if podGroup, ok := groups["pod"]; ok {
+		g.addPodWorkloadToGroup(podGroup)
+
+		if err := g.addPodSchedulingFailureToGroup(podGroup); err != nil {
+			errs = append(errs, fmt.Errorf("adding pod scheduling failures to group: %w", err))
+		}
+	}
+
 	if !g.EnableResourceQuotaSamples {
 		if _, ok := groups["resourcequota"]; ok {
 			delete(groups, "resourcequota")
@@ -120,3 +140,89 @@ func (g *grouper) addServiceSpecSelectorToGroup(serviceGroup map[string]definiti
 	}
 	return nil
 }
+
+// addPodWorkloadToGroup adds a new metric to the pod group with the workload
+// owning each pod, resolved from the owner reported by KSM.
+func (g *grouper) addPodWorkloadToGroup(podGroup map[string]definition.RawMetrics) {
+	resolver := g.OwnerResolver
+	if resolver == nil {
+		resolver = owner.DirectResolver{}
+	}
+
+	for podID, podRawMetrics := range podGroup {
+		info, ok := podRawMetrics["kube_pod_info"].(prometheus.Metric)
+		if !ok {
+			continue
+		}
+
+		kind, name := info.Labels["created_by_kind"], info.Labels["created_by_name"]
+		if kind == "" || kind == noneLabelValue || name == "" || name == noneLabelValue {
+			continue
+		}
+
+		chain := resolver.Resolve(info.Labels["namespace"], []metav1.OwnerReference{owner.ControllerRef(kind, name)})
+		promLabels := prometheus.Labels{}
+
+		if workload, ok := owner.Workload(chain); ok {
+			g.logger.Tracef("Pod %s is owned by %s %s", podID, workload.Kind, workload.Name)
+			promLabels["workload_kind"] = workload.Kind
+			promLabels["workload_name"] = workload.Name
+		}
+
+		if deployment, ok := owner.DeploymentName(chain); ok {
+			promLabels["deployment"] = deployment
+		}
+
+		if len(promLabels) == 0 {
+			continue
+		}
+
+		podRawMetrics["apiserver_kube_pod_workload"] = prometheus.Metric{
+			Labels: promLabels,
+			Value:  nil,
+		}
+	}
+}
+
+// addPodSchedulingFailureToGroup adds the reasons why the scheduler could not place each unschedulable pod, parsed
+// from the message of its PodScheduled condition, which KSM does not expose.
+func (g *grouper) addPodSchedulingFailureToGroup(podGroup map[string]definition.RawMetrics) error {
+	if g.PendingPodsLister == nil {
+		return nil
+	}
+
+	pods, err := g.PendingPodsLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("listing pending pods: %w", err)
+	}
+
+	for _, p := range pods {
+		if p.Status.Phase != corev1.PodPending {
+			continue
+		}
+
+		podRawMetrics, ok := podGroup[p.Namespace+"_"+p.Name]
+		if !ok {
+			continue
+		}
+
+		for _, c := range p.Status.Conditions {
+			if c.Type != corev1.PodScheduled || c.Status != corev1.ConditionFalse || c.Reason != corev1.PodReasonUnschedulable {
+				continue
+			}
+
+			failure, ok := ksmMetric.ParseSchedulingFailure(c.Message)
+			if !ok {
+				g.logger.Debugf("Unexpected scheduling message for pod %s/%s: %q", p.Namespace, p.Name, c.Message)
+				continue
+			}
+
+			podRawMetrics["apiserver_kube_pod_scheduling_failure_summary"] = failure.Summary()
+			podRawMetrics["apiserver_kube_pod_scheduling_available_nodes"] = failure.AvailableNodes
+			podRawMetrics["apiserver_kube_pod_scheduling_total_nodes"] = failure.TotalNodes
+			podRawMetrics["apiserver_kube_pod_scheduling_failures"] = failure.Reasons
+		}
+	}
+
+	return nil
+}
@@ -6,9 +6,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/internal/owner"
 	"github.com/newrelic/nri-kubernetes/v3/src/definition"
 	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
 )
@@ -69,3 +72,118 @@ func TestResourceQuotaGroupRemovedWhenDisabled(t *testing.T) {
 	assert.False(t, exists, `"resourcequota" group should be removed when EnableResourceQuotaSamples is false`)
 	assert.Contains(t, groups, "other")
 }
+
+// staticResolver resolves any owner to itself followed by the top-level owner.
+type staticResolver owner.Owner
+
+func (r staticResolver) Resolve(_ string, refs []metav1.OwnerReference) []owner.Owner {
+	return []owner.Owner{
+		{APIVersion: refs[0].APIVersion, Kind: refs[0].Kind, Name: refs[0].Name},
+		owner.Owner(r),
+	}
+}
+
+func TestAddPodWorkloadToGroup(t *testing.T) {
+	podInfo := func(kind, name string) definition.RawMetrics {
+		return definition.RawMetrics{
+			"kube_pod_info": prometheus.Metric{Labels: prometheus.Labels{
+				"namespace":       "default",
+				"created_by_kind": kind,
+				"created_by_name": name,
+			}},
+		}
+	}
+
+	t.Run("direct_owner", func(t *testing.T) {
+		g := &grouper{logger: logutil.Discard}
+
+		podGroup := map[string]definition.RawMetrics{
+			"default_web":     podInfo("StatefulSet", "web"),
+			"default_orphan":  podInfo("<none>", "<none>"),
+			"default_no_info": {},
+		}
+		g.addPodWorkloadToGroup(podGroup)
+
+		assert.NotContains(t, podGroup["default_web"], "apiserver_kube_pod_workload", "unresolved owners are not workloads")
+		assert.NotContains(t, podGroup["default_orphan"], "apiserver_kube_pod_workload")
+		assert.NotContains(t, podGroup["default_no_info"], "apiserver_kube_pod_workload")
+	})
+
+	t.Run("unresolved_replicaset", func(t *testing.T) {
+		g := &grouper{logger: logutil.Discard}
+
+		podGroup := map[string]definition.RawMetrics{
+			"default_web-7d9f8c-abcde": podInfo("ReplicaSet", "web-7d9f8c"),
+		}
+		g.addPodWorkloadToGroup(podGroup)
+
+		assert.Equal(t, prometheus.Labels{"deployment": "web"},
+			podGroup["default_web-7d9f8c-abcde"]["apiserver_kube_pod_workload"].(prometheus.Metric).Labels)
+	})
+
+	t.Run("resolved_deployment", func(t *testing.T) {
+		g := &grouper{logger: logutil.Discard, Config: Config{
+			OwnerResolver: staticResolver{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+		}}
+
+		podGroup := map[string]definition.RawMetrics{
+			"default_web-unusual-abcde": podInfo("ReplicaSet", "web-unusual"),
+		}
+		g.addPodWorkloadToGroup(podGroup)
+
+		assert.Equal(t, prometheus.Labels{"workload_kind": "Deployment", "workload_name": "web", "deployment": "web"},
+			podGroup["default_web-unusual-abcde"]["apiserver_kube_pod_workload"].(prometheus.Metric).Labels)
+	})
+
+	t.Run("resolved_custom_resource", func(t *testing.T) {
+		g := &grouper{logger: logutil.Discard, Config: Config{
+			OwnerResolver: staticResolver{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "canary"},
+		}}
+
+		podGroup := map[string]definition.RawMetrics{
+			"default_canary-7d9f-abcde": podInfo("ReplicaSet", "canary-7d9f"),
+		}
+		g.addPodWorkloadToGroup(podGroup)
+
+		assert.Equal(t, prometheus.Labels{"workload_kind": "Rollout", "workload_name": "canary"},
+			podGroup["default_canary-7d9f-abcde"]["apiserver_kube_pod_workload"].(prometheus.Metric).Labels)
+	})
+}
+
+func TestAddPodSchedulingFailureToGroup(t *testing.T) {
+	unschedulable := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"},
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{{
+				Type:    v1.PodScheduled,
+				Status:  v1.ConditionFalse,
+				Reason:  v1.PodReasonUnschedulable,
+				Message: "0/10 nodes are available: 3 Insufficient cpu, 7 node(s) had untolerated taint {dedicated: gpu}.",
+			}},
+		},
+	}
+	scheduling := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "scheduling", Namespace: "default"},
+		Status:     v1.PodStatus{Phase: v1.PodPending},
+	}
+
+	podsLister, closer := discovery.NewPodsLister(fake.NewSimpleClientset(unschedulable, scheduling))
+	defer close(closer)
+
+	g := &grouper{logger: logutil.Discard, Config: Config{PendingPodsLister: podsLister}}
+
+	podGroup := map[string]definition.RawMetrics{
+		"default_pending":    {},
+		"default_scheduling": {},
+	}
+	require.NoError(t, g.addPodSchedulingFailureToGroup(podGroup))
+
+	assert.Equal(t, definition.RawMetrics{
+		"apiserver_kube_pod_scheduling_failure_summary": "0/10 nodes: 3 insufficient cpu, 7 taint",
+		"apiserver_kube_pod_scheduling_available_nodes": 0,
+		"apiserver_kube_pod_scheduling_total_nodes":     10,
+		"apiserver_kube_pod_scheduling_failures":        map[string]int{"insufficient_cpu": 3, "taint": 7},
+	}, podGroup["default_pending"])
+	assert.Empty(t, podGroup["default_scheduling"])
+}
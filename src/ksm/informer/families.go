@@ -0,0 +1,699 @@
+package informer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+// noneValue is the value KSM uses for labels that cannot be filled, e.g. owner labels of objects without owners. KSM v1
+// used `<none>`, while v2 leaves them empty.
+const noneValue = ""
+
+var invalidLabelCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`) //nolint: gochecknoglobals // compiled once.
+
+// sanitizeLabelName mimics how KSM turns Kubernetes label and annotation keys into Prometheus label names.
+func sanitizeLabelName(s string) string {
+	return invalidLabelCharRE.ReplaceAllString(s, "_")
+}
+
+// with returns a copy of base with the given key-value pairs added.
+func with(base prometheus.Labels, kv ...string) prometheus.Labels {
+	l := make(prometheus.Labels, len(base)+len(kv)/2)
+	for k, v := range base {
+		l[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		l[kv[i]] = kv[i+1]
+	}
+
+	return l
+}
+
+// withPrefixed returns a copy of base with every entry of m added as a `<prefix>_<sanitized key>` label, which is how
+// KSM renders `*_labels` and `*_annotations` metrics.
+func withPrefixed(base prometheus.Labels, prefix string, m map[string]string) prometheus.Labels {
+	l := with(base)
+	for k, v := range m {
+		l[prefix+"_"+sanitizeLabelName(k)] = v
+	}
+
+	return l
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func timestamp(t metav1.Time) float64 {
+	return float64(t.Unix())
+}
+
+// quantityValue converts a quantity to a float in the same way KSM does, keeping sub-unit precision for CPU-like
+// quantities.
+func quantityValue(q resource.Quantity) float64 {
+	return float64(q.MilliValue()) / 1000 //nolint: gomnd // milli to unit.
+}
+
+// conditionStatus emits one sample per possible condition status, set to 1 for the current one, as KSM does for
+// condition metrics.
+func (fs familySet) conditionStatus(name string, base prometheus.Labels, labelName string, status corev1.ConditionStatus) {
+	for _, s := range []corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown} {
+		fs.gauge(name, boolValue(s == status), with(base, labelName, strings.ToLower(string(s))))
+	}
+}
+
+// owner emits the `*_owner` family for an object, using KSM placeholders if the object has no owners.
+func (fs familySet) owner(name string, base prometheus.Labels, owners []metav1.OwnerReference) {
+	if len(owners) == 0 {
+		fs.gauge(name, 1, with(base, "owner_kind", noneValue, "owner_name", noneValue, "owner_is_controller", noneValue))
+		return
+	}
+
+	for _, o := range owners {
+		isController := "false"
+		if o.Controller != nil && *o.Controller {
+			isController = "true"
+		}
+		fs.gauge(name, 1, with(base, "owner_kind", o.Kind, "owner_name", o.Name, "owner_is_controller", isController))
+	}
+}
+
+func (s *Source) namespaceFamilies(fs familySet) error {
+	namespaces, err := s.namespaces.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		base := prometheus.Labels{"namespace": ns.Name}
+		fs.gauge("kube_namespace_created", timestamp(ns.CreationTimestamp), base)
+		fs.gauge("kube_namespace_labels", 1, withPrefixed(base, "label", ns.Labels))
+		fs.gauge("kube_namespace_annotations", 1, withPrefixed(base, "annotation", ns.Annotations))
+		for _, phase := range []corev1.NamespacePhase{corev1.NamespaceActive, corev1.NamespaceTerminating} {
+			fs.gauge("kube_namespace_status_phase", boolValue(ns.Status.Phase == phase), with(base, "phase", string(phase)))
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) deploymentFamilies(fs familySet) error {
+	deployments, err := s.deployments.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deployments {
+		fs.deployment(d)
+	}
+
+	return nil
+}
+
+func (fs familySet) deployment(d *appsv1.Deployment) {
+	base := prometheus.Labels{"namespace": d.Namespace, "deployment": d.Name}
+
+	fs.gauge("kube_deployment_created", timestamp(d.CreationTimestamp), base)
+	fs.gauge("kube_deployment_labels", 1, withPrefixed(base, "label", d.Labels))
+	fs.gauge("kube_deployment_annotations", 1, withPrefixed(base, "annotation", d.Annotations))
+	fs.gauge("kube_deployment_metadata_generation", float64(d.Generation), base)
+	fs.gauge("kube_deployment_spec_paused", boolValue(d.Spec.Paused), base)
+	fs.gauge("kube_deployment_status_replicas", float64(d.Status.Replicas), base)
+	fs.gauge("kube_deployment_status_replicas_ready", float64(d.Status.ReadyReplicas), base)
+	fs.gauge("kube_deployment_status_replicas_available", float64(d.Status.AvailableReplicas), base)
+	fs.gauge("kube_deployment_status_replicas_unavailable", float64(d.Status.UnavailableReplicas), base)
+	fs.gauge("kube_deployment_status_replicas_updated", float64(d.Status.UpdatedReplicas), base)
+	fs.gauge("kube_deployment_status_observed_generation", float64(d.Status.ObservedGeneration), base)
+
+	for _, c := range d.Status.Conditions {
+		fs.conditionStatus("kube_deployment_status_condition", with(base, "condition", string(c.Type)), "status", c.Status)
+	}
+
+	if d.Spec.Replicas == nil {
+		return
+	}
+	replicas := int(*d.Spec.Replicas)
+	fs.gauge("kube_deployment_spec_replicas", float64(replicas), base)
+
+	if ru := d.Spec.Strategy.RollingUpdate; ru != nil {
+		if maxSurge, err := intstr.GetScaledValueFromIntOrPercent(ru.MaxSurge, replicas, true); err == nil {
+			fs.gauge("kube_deployment_spec_strategy_rollingupdate_max_surge", float64(maxSurge), base)
+		}
+		if maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(ru.MaxUnavailable, replicas, false); err == nil {
+			fs.gauge("kube_deployment_spec_strategy_rollingupdate_max_unavailable", float64(maxUnavailable), base)
+		}
+	}
+}
+
+func (s *Source) replicaSetFamilies(fs familySet) error {
+	replicaSets, err := s.replicaSets.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, rs := range replicaSets {
+		base := prometheus.Labels{"namespace": rs.Namespace, "replicaset": rs.Name}
+
+		fs.gauge("kube_replicaset_created", timestamp(rs.CreationTimestamp), base)
+		fs.gauge("kube_replicaset_labels", 1, withPrefixed(base, "label", rs.Labels))
+		fs.gauge("kube_replicaset_metadata_generation", float64(rs.Generation), base)
+		fs.gauge("kube_replicaset_status_replicas", float64(rs.Status.Replicas), base)
+		fs.gauge("kube_replicaset_status_ready_replicas", float64(rs.Status.ReadyReplicas), base)
+		fs.gauge("kube_replicaset_status_fully_labeled_replicas", float64(rs.Status.FullyLabeledReplicas), base)
+		fs.gauge("kube_replicaset_status_observed_generation", float64(rs.Status.ObservedGeneration), base)
+		if rs.Spec.Replicas != nil {
+			fs.gauge("kube_replicaset_spec_replicas", float64(*rs.Spec.Replicas), base)
+		}
+		fs.owner("kube_replicaset_owner", base, rs.OwnerReferences)
+	}
+
+	return nil
+}
+
+func (s *Source) statefulSetFamilies(fs familySet) error {
+	statefulSets, err := s.statefulSets.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, ss := range statefulSets {
+		base := prometheus.Labels{"namespace": ss.Namespace, "statefulset": ss.Name}
+
+		fs.gauge("kube_statefulset_created", timestamp(ss.CreationTimestamp), base)
+		fs.gauge("kube_statefulset_labels", 1, withPrefixed(base, "label", ss.Labels))
+		fs.gauge("kube_statefulset_metadata_generation", float64(ss.Generation), base)
+		fs.gauge("kube_statefulset_status_replicas", float64(ss.Status.Replicas), base)
+		fs.gauge("kube_statefulset_status_replicas_ready", float64(ss.Status.ReadyReplicas), base)
+		fs.gauge("kube_statefulset_status_replicas_current", float64(ss.Status.CurrentReplicas), base)
+		fs.gauge("kube_statefulset_status_replicas_updated", float64(ss.Status.UpdatedReplicas), base)
+		fs.gauge("kube_statefulset_status_observed_generation", float64(ss.Status.ObservedGeneration), base)
+		fs.gauge("kube_statefulset_status_current_revision", 1, with(base, "revision", ss.Status.CurrentRevision))
+		fs.gauge("kube_statefulset_status_update_revision", 1, with(base, "revision", ss.Status.UpdateRevision))
+		if ss.Spec.Replicas != nil {
+			fs.gauge("kube_statefulset_replicas", float64(*ss.Spec.Replicas), base)
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) daemonSetFamilies(fs familySet) error {
+	daemonSets, err := s.daemonSets.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, ds := range daemonSets {
+		base := prometheus.Labels{"namespace": ds.Namespace, "daemonset": ds.Name}
+
+		fs.gauge("kube_daemonset_created", timestamp(ds.CreationTimestamp), base)
+		fs.gauge("kube_daemonset_labels", 1, withPrefixed(base, "label", ds.Labels))
+		fs.gauge("kube_daemonset_metadata_generation", float64(ds.Generation), base)
+		fs.gauge("kube_daemonset_status_desired_number_scheduled", float64(ds.Status.DesiredNumberScheduled), base)
+		fs.gauge("kube_daemonset_status_current_number_scheduled", float64(ds.Status.CurrentNumberScheduled), base)
+		fs.gauge("kube_daemonset_status_number_ready", float64(ds.Status.NumberReady), base)
+		fs.gauge("kube_daemonset_status_number_available", float64(ds.Status.NumberAvailable), base)
+		fs.gauge("kube_daemonset_status_number_unavailable", float64(ds.Status.NumberUnavailable), base)
+		fs.gauge("kube_daemonset_status_number_misscheduled", float64(ds.Status.NumberMisscheduled), base)
+		fs.gauge("kube_daemonset_status_updated_number_scheduled", float64(ds.Status.UpdatedNumberScheduled), base)
+		fs.gauge("kube_daemonset_status_observed_generation", float64(ds.Status.ObservedGeneration), base)
+	}
+
+	return nil
+}
+
+// jobFailureReasons are the reasons KSM reports on kube_job_status_failed when the job has a Failed condition.
+var jobFailureReasons = []string{"BackoffLimitExceeded", "DeadlineExceeded", "Evicted"} //nolint: gochecknoglobals
+
+func (s *Source) jobFamilies(fs familySet) error {
+	jobs, err := s.jobs.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, j := range jobs {
+		fs.job(j)
+	}
+
+	return nil
+}
+
+func (fs familySet) job(j *batchv1.Job) {
+	base := prometheus.Labels{"namespace": j.Namespace, "job_name": j.Name}
+
+	fs.gauge("kube_job_created", timestamp(j.CreationTimestamp), base)
+	fs.gauge("kube_job_info", 1, base)
+	fs.gauge("kube_job_labels", 1, withPrefixed(base, "label", j.Labels))
+	fs.owner("kube_job_owner", base, j.OwnerReferences)
+	fs.gauge("kube_job_status_active", float64(j.Status.Active), base)
+	fs.gauge("kube_job_status_succeeded", float64(j.Status.Succeeded), base)
+
+	if j.Spec.Parallelism != nil {
+		fs.gauge("kube_job_spec_parallelism", float64(*j.Spec.Parallelism), base)
+	}
+	if j.Spec.Completions != nil {
+		fs.gauge("kube_job_spec_completions", float64(*j.Spec.Completions), base)
+	}
+	if j.Spec.ActiveDeadlineSeconds != nil {
+		fs.gauge("kube_job_spec_active_deadline_seconds", float64(*j.Spec.ActiveDeadlineSeconds), base)
+	}
+	if j.Status.StartTime != nil {
+		fs.gauge("kube_job_status_start_time", timestamp(*j.Status.StartTime), base)
+	}
+	if j.Status.CompletionTime != nil {
+		fs.gauge("kube_job_status_completion_time", timestamp(*j.Status.CompletionTime), base)
+	}
+
+	var failedReason string
+	for _, c := range j.Status.Conditions {
+		switch c.Type {
+		case batchv1.JobComplete:
+			fs.conditionStatus("kube_job_complete", base, "condition", c.Status)
+		case batchv1.JobFailed:
+			fs.conditionStatus("kube_job_failed", base, "condition", c.Status)
+			if c.Status == corev1.ConditionTrue {
+				failedReason = c.Reason
+			}
+		}
+	}
+
+	if failedReason == "" {
+		fs.gauge("kube_job_status_failed", float64(j.Status.Failed), base)
+		return
+	}
+	for _, reason := range jobFailureReasons {
+		fs.gauge("kube_job_status_failed", boolValue(reason == failedReason), with(base, "reason", reason))
+	}
+}
+
+// cronJobFamilies renders CronJobs as KSM would. Note that kube_cronjob_next_schedule_time is not rendered, as it
+// requires evaluating the cron expression.
+func (s *Source) cronJobFamilies(fs familySet) error {
+	cronJobs, err := s.cronJobs.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, cj := range cronJobs {
+		base := prometheus.Labels{"namespace": cj.Namespace, "cronjob": cj.Name}
+
+		fs.gauge("kube_cronjob_created", timestamp(cj.CreationTimestamp), base)
+		fs.gauge("kube_cronjob_info", 1, with(base, "schedule", cj.Spec.Schedule, "concurrency_policy", string(cj.Spec.ConcurrencyPolicy)))
+		fs.gauge("kube_cronjob_labels", 1, withPrefixed(base, "label", cj.Labels))
+		fs.gauge("kube_cronjob_status_active", float64(len(cj.Status.Active)), base)
+		fs.gauge("kube_cronjob_spec_suspend", boolValue(cj.Spec.Suspend != nil && *cj.Spec.Suspend), base)
+
+		if cj.Status.LastScheduleTime != nil {
+			fs.gauge("kube_cronjob_status_last_schedule_time", timestamp(*cj.Status.LastScheduleTime), base)
+		}
+		if cj.Spec.StartingDeadlineSeconds != nil {
+			fs.gauge("kube_cronjob_spec_starting_deadline_seconds", float64(*cj.Spec.StartingDeadlineSeconds), base)
+		}
+		if rv, err := strconv.ParseFloat(cj.ResourceVersion, 64); err == nil {
+			fs.gauge("kube_cronjob_metadata_resource_version", rv, base)
+		}
+	}
+
+	return nil
+}
+
+// podPhases are the phases KSM reports on kube_pod_status_phase.
+var podPhases = []corev1.PodPhase{ //nolint: gochecknoglobals
+	corev1.PodPending, corev1.PodSucceeded, corev1.PodFailed, corev1.PodUnknown, corev1.PodRunning,
+}
+
+func (s *Source) podFamilies(fs familySet) error {
+	pods, err := s.pods.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pods {
+		fs.pod(p)
+	}
+
+	return nil
+}
+
+func (fs familySet) pod(p *corev1.Pod) {
+	base := prometheus.Labels{"namespace": p.Namespace, "pod": p.Name, "uid": string(p.UID)}
+
+	createdByKind, createdByName := noneValue, noneValue
+	if len(p.OwnerReferences) > 0 {
+		createdByKind = p.OwnerReferences[0].Kind
+		createdByName = p.OwnerReferences[0].Name
+	}
+
+	fs.gauge("kube_pod_created", timestamp(p.CreationTimestamp), base)
+	fs.gauge("kube_pod_info", 1, with(base,
+		"host_ip", p.Status.HostIP,
+		"pod_ip", p.Status.PodIP,
+		"node", p.Spec.NodeName,
+		"created_by_kind", createdByKind,
+		"created_by_name", createdByName,
+		"priority_class", p.Spec.PriorityClassName,
+		"host_network", strconv.FormatBool(p.Spec.HostNetwork),
+	))
+	fs.gauge("kube_pod_labels", 1, withPrefixed(base, "label", p.Labels))
+	fs.gauge("kube_pod_annotations", 1, withPrefixed(base, "annotation", p.Annotations))
+
+	for _, phase := range podPhases {
+		fs.gauge("kube_pod_status_phase", boolValue(p.Status.Phase == phase), with(base, "phase", string(phase)))
+	}
+
+	if p.Status.StartTime != nil {
+		fs.gauge("kube_pod_start_time", timestamp(*p.Status.StartTime), base)
+	}
+
+	for _, c := range p.Status.Conditions {
+		switch c.Type {
+		case corev1.PodScheduled:
+			fs.conditionStatus("kube_pod_status_scheduled", base, "condition", c.Status)
+		case corev1.PodReady:
+			fs.conditionStatus("kube_pod_status_ready", base, "condition", c.Status)
+		}
+	}
+}
+
+func (s *Source) serviceFamilies(fs familySet) error {
+	services, err := s.services.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range services {
+		base := prometheus.Labels{"namespace": svc.Namespace, "service": svc.Name}
+
+		fs.gauge("kube_service_created", timestamp(svc.CreationTimestamp), base)
+		fs.gauge("kube_service_labels", 1, withPrefixed(base, "label", svc.Labels))
+		fs.gauge("kube_service_spec_type", 1, with(base, "type", string(svc.Spec.Type)))
+		fs.gauge("kube_service_info", 1, with(base,
+			"cluster_ip", svc.Spec.ClusterIP,
+			"external_name", svc.Spec.ExternalName,
+			"load_balancer_ip", svc.Spec.LoadBalancerIP, //nolint: staticcheck // Still reported by KSM.
+		))
+
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			fs.gauge("kube_service_status_load_balancer_ingress", 1, with(base, "ip", ingress.IP, "hostname", ingress.Hostname))
+		}
+	}
+
+	return nil
+}
+
+// endpointFamilies renders KSM endpoint families out of EndpointSlices, which replace the deprecated Endpoints API.
+// Slices are aggregated by the service they belong to, which is the name KSM uses for the matching Endpoints object.
+func (s *Source) endpointFamilies(fs familySet) error {
+	slices, err := s.endpointSlices.List(everything)
+	if err != nil {
+		return err
+	}
+
+	type endpoint struct {
+		base    prometheus.Labels
+		created metav1.Time
+		labels  map[string]string
+		slices  []*discoveryv1.EndpointSlice
+	}
+
+	endpoints := map[string]*endpoint{}
+	for _, slice := range slices {
+		name := slice.Labels[discoveryv1.LabelServiceName]
+		if name == "" {
+			continue
+		}
+
+		key := slice.Namespace + "/" + name
+		e, ok := endpoints[key]
+		if !ok {
+			e = &endpoint{
+				base:    prometheus.Labels{"namespace": slice.Namespace, "endpoint": name},
+				created: slice.CreationTimestamp,
+				labels:  map[string]string{},
+			}
+			endpoints[key] = e
+		}
+
+		if slice.CreationTimestamp.Before(&e.created) {
+			e.created = slice.CreationTimestamp
+		}
+		// Slices inherit the labels of their service, plus some we filter out as they do not exist in Endpoints.
+		for k, v := range slice.Labels {
+			if k == discoveryv1.LabelServiceName || k == discoveryv1.LabelManagedBy {
+				continue
+			}
+			e.labels[k] = v
+		}
+		e.slices = append(e.slices, slice)
+	}
+
+	for _, e := range endpoints {
+		base := e.base
+
+		fs.gauge("kube_endpoint_created", timestamp(e.created), base)
+		fs.gauge("kube_endpoint_labels", 1, withPrefixed(base, "label", e.labels))
+
+		for _, slice := range e.slices {
+			for _, ep := range slice.Endpoints {
+				ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+				for _, address := range ep.Addresses {
+					fs.gauge("kube_endpoint_address", 1, with(base, "ip", address, "ready", strconv.FormatBool(ready)))
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) persistentVolumeFamilies(fs familySet) error {
+	pvs, err := s.pvs.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, pv := range pvs {
+		fs.persistentVolume(pv)
+	}
+
+	return nil
+}
+
+// pvPhases are the phases KSM reports on kube_persistentvolume_status_phase.
+var pvPhases = []corev1.PersistentVolumePhase{ //nolint: gochecknoglobals
+	corev1.VolumePending, corev1.VolumeAvailable, corev1.VolumeBound, corev1.VolumeReleased, corev1.VolumeFailed,
+}
+
+func (fs familySet) persistentVolume(pv *corev1.PersistentVolume) {
+	base := prometheus.Labels{"persistentvolume": pv.Name}
+
+	fs.gauge("kube_persistentvolume_created", timestamp(pv.CreationTimestamp), base)
+	fs.gauge("kube_persistentvolume_labels", 1, withPrefixed(base, "label", pv.Labels))
+
+	if storage, ok := pv.Spec.Capacity[corev1.ResourceStorage]; ok {
+		fs.gauge("kube_persistentvolume_capacity_bytes", float64(storage.Value()), base)
+	}
+
+	for _, phase := range pvPhases {
+		fs.gauge("kube_persistentvolume_status_phase", boolValue(pv.Status.Phase == phase), with(base, "phase", string(phase)))
+	}
+
+	if ref := pv.Spec.ClaimRef; ref != nil {
+		fs.gauge("kube_persistentvolume_claim_ref", 1, with(base, "name", ref.Name, "claim_namespace", ref.Namespace))
+	}
+
+	info := with(base, "storageclass", pv.Spec.StorageClassName)
+	src := pv.Spec.PersistentVolumeSource
+	switch {
+	case src.GCEPersistentDisk != nil:
+		info["gce_persistent_disk_name"] = src.GCEPersistentDisk.PDName
+	case src.AWSElasticBlockStore != nil:
+		info["ebs_volume_id"] = src.AWSElasticBlockStore.VolumeID
+	case src.AzureDisk != nil:
+		info["azure_disk_name"] = src.AzureDisk.DiskName
+	case src.FC != nil:
+		info["fc_wwids"] = strings.Join(src.FC.WWIDs, ",")
+		info["fc_target_wwns"] = strings.Join(src.FC.TargetWWNs, ",")
+		if src.FC.Lun != nil {
+			info["fc_lun"] = strconv.Itoa(int(*src.FC.Lun))
+		}
+	case src.ISCSI != nil:
+		info["iscsi_target_portal"] = src.ISCSI.TargetPortal
+		info["iscsi_iqn"] = src.ISCSI.IQN
+		info["iscsi_lun"] = strconv.Itoa(int(src.ISCSI.Lun))
+		if src.ISCSI.InitiatorName != nil {
+			info["iscsi_initiator_name"] = *src.ISCSI.InitiatorName
+		}
+	case src.NFS != nil:
+		info["nfs_server"] = src.NFS.Server
+		info["nfs_path"] = src.NFS.Path
+	case src.CSI != nil:
+		info["csi_driver"] = src.CSI.Driver
+		info["csi_volume_handle"] = src.CSI.VolumeHandle
+	case src.Local != nil:
+		info["local_path"] = src.Local.Path
+		if src.Local.FSType != nil {
+			info["local_fs"] = *src.Local.FSType
+		}
+	case src.HostPath != nil:
+		info["host_path"] = src.HostPath.Path
+		if src.HostPath.Type != nil {
+			info["host_path_type"] = string(*src.HostPath.Type)
+		}
+	}
+	fs.gauge("kube_persistentvolume_info", 1, info)
+}
+
+// pvcPhases are the phases KSM reports on kube_persistentvolumeclaim_status_phase.
+var pvcPhases = []corev1.PersistentVolumeClaimPhase{corev1.ClaimLost, corev1.ClaimBound, corev1.ClaimPending} //nolint: gochecknoglobals
+
+func (s *Source) persistentVolumeClaimFamilies(fs familySet) error {
+	pvcs, err := s.pvcs.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, pvc := range pvcs {
+		base := prometheus.Labels{"namespace": pvc.Namespace, "persistentvolumeclaim": pvc.Name}
+
+		storageClass := ""
+		if pvc.Spec.StorageClassName != nil {
+			storageClass = *pvc.Spec.StorageClassName
+		}
+
+		fs.gauge("kube_persistentvolumeclaim_created", timestamp(pvc.CreationTimestamp), base)
+		fs.gauge("kube_persistentvolumeclaim_labels", 1, withPrefixed(base, "label", pvc.Labels))
+		fs.gauge("kube_persistentvolumeclaim_info", 1, with(base, "storageclass", storageClass, "volumename", pvc.Spec.VolumeName))
+
+		for _, mode := range pvc.Spec.AccessModes {
+			fs.gauge("kube_persistentvolumeclaim_access_mode", 1, with(base, "access_mode", string(mode)))
+		}
+
+		for _, phase := range pvcPhases {
+			fs.gauge("kube_persistentvolumeclaim_status_phase", boolValue(pvc.Status.Phase == phase), with(base, "phase", string(phase)))
+		}
+
+		if storage, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			fs.gauge("kube_persistentvolumeclaim_resource_requests_storage_bytes", float64(storage.Value()), base)
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) hpaFamilies(fs familySet) error {
+	hpas, err := s.hpas.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, hpa := range hpas {
+		fs.hpa(hpa)
+	}
+
+	return nil
+}
+
+func (fs familySet) hpa(hpa *autoscalingv2.HorizontalPodAutoscaler) {
+	base := prometheus.Labels{"namespace": hpa.Namespace, "horizontalpodautoscaler": hpa.Name}
+
+	fs.gauge("kube_horizontalpodautoscaler_info", 1, with(base,
+		"scaletargetref_kind", hpa.Spec.ScaleTargetRef.Kind,
+		"scaletargetref_name", hpa.Spec.ScaleTargetRef.Name,
+	))
+	fs.gauge("kube_horizontalpodautoscaler_labels", 1, withPrefixed(base, "label", hpa.Labels))
+	fs.gauge("kube_horizontalpodautoscaler_metadata_generation", float64(hpa.Generation), base)
+	fs.gauge("kube_horizontalpodautoscaler_spec_max_replicas", float64(hpa.Spec.MaxReplicas), base)
+	fs.gauge("kube_horizontalpodautoscaler_status_current_replicas", float64(hpa.Status.CurrentReplicas), base)
+	fs.gauge("kube_horizontalpodautoscaler_status_desired_replicas", float64(hpa.Status.DesiredReplicas), base)
+	if hpa.Spec.MinReplicas != nil {
+		fs.gauge("kube_horizontalpodautoscaler_spec_min_replicas", float64(*hpa.Spec.MinReplicas), base)
+	}
+
+	for _, c := range hpa.Status.Conditions {
+		fs.conditionStatus("kube_horizontalpodautoscaler_status_condition", with(base, "condition", string(c.Type)), "status", c.Status)
+	}
+
+	for _, m := range hpa.Spec.Metrics {
+		name, target := hpaMetricTarget(m)
+		if target == nil {
+			continue
+		}
+		if target.Value != nil {
+			fs.gauge("kube_horizontalpodautoscaler_spec_target_metric", quantityValue(*target.Value), with(base, "metric_name", name, "metric_target_type", "value"))
+		}
+		if target.AverageValue != nil {
+			fs.gauge("kube_horizontalpodautoscaler_spec_target_metric", quantityValue(*target.AverageValue), with(base, "metric_name", name, "metric_target_type", "average"))
+		}
+		if target.AverageUtilization != nil {
+			fs.gauge("kube_horizontalpodautoscaler_spec_target_metric", float64(*target.AverageUtilization), with(base, "metric_name", name, "metric_target_type", "utilization"))
+		}
+	}
+}
+
+// hpaMetricTarget returns the name and target of an HPA metric spec, regardless of its source type.
+func hpaMetricTarget(m autoscalingv2.MetricSpec) (string, *autoscalingv2.MetricTarget) {
+	switch m.Type {
+	case autoscalingv2.ResourceMetricSourceType:
+		if m.Resource != nil {
+			return string(m.Resource.Name), &m.Resource.Target
+		}
+	case autoscalingv2.ContainerResourceMetricSourceType:
+		if m.ContainerResource != nil {
+			return string(m.ContainerResource.Name), &m.ContainerResource.Target
+		}
+	case autoscalingv2.PodsMetricSourceType:
+		if m.Pods != nil {
+			return m.Pods.Metric.Name, &m.Pods.Target
+		}
+	case autoscalingv2.ObjectMetricSourceType:
+		if m.Object != nil {
+			return m.Object.Metric.Name, &m.Object.Target
+		}
+	case autoscalingv2.ExternalMetricSourceType:
+		if m.External != nil {
+			return m.External.Metric.Name, &m.External.Target
+		}
+	}
+
+	return "", nil
+}
+
+func (s *Source) resourceQuotaFamilies(fs familySet) error {
+	quotas, err := s.resourceQuotas.List(everything)
+	if err != nil {
+		return err
+	}
+
+	for _, rq := range quotas {
+		base := prometheus.Labels{"namespace": rq.Namespace, "resourcequota": rq.Name}
+
+		fs.gauge("kube_resourcequota_created", timestamp(rq.CreationTimestamp), base)
+		fs.gauge("kube_resourcequota_labels", 1, withPrefixed(base, "label", rq.Labels))
+		fs.gauge("kube_resourcequota_annotations", 1, withPrefixed(base, "annotation", rq.Annotations))
+
+		for res, q := range rq.Status.Hard {
+			fs.gauge("kube_resourcequota", quantityValue(q), with(base, "resource", string(res), "type", "hard"))
+		}
+		for res, q := range rq.Status.Used {
+			fs.gauge("kube_resourcequota", quantityValue(q), with(base, "resource", string(res), "type", "used"))
+		}
+	}
+
+	return nil
+}
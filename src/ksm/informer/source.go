@@ -0,0 +1,200 @@
+// Package informer implements a KSM-less state source: it builds the same metric families kube-state-metrics would
+// expose, directly from shared informers on the API server, so they can be consumed by the existing KSM grouper and
+// metric.KSMSpecs without changes.
+package informer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	model "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appsv1 "k8s.io/client-go/listers/apps/v1"
+	autoscalingv2 "k8s.io/client-go/listers/autoscaling/v2"
+	batchv1 "k8s.io/client-go/listers/batch/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	discoveryv1 "k8s.io/client-go/listers/discovery/v1"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+// defaultResyncDuration is an arbitrary value, same used in Prometheus and in the discovery package.
+const defaultResyncDuration = 10 * time.Minute
+
+// Source builds KSM-like metric families out of objects cached by shared informers.
+type Source struct {
+	logger *log.Logger
+
+	deployments    appsv1.DeploymentLister
+	replicaSets    appsv1.ReplicaSetLister
+	statefulSets   appsv1.StatefulSetLister
+	daemonSets     appsv1.DaemonSetLister
+	jobs           batchv1.JobLister
+	cronJobs       batchv1.CronJobLister
+	pods           listersv1.PodLister
+	namespaces     listersv1.NamespaceLister
+	services       listersv1.ServiceLister
+	pvs            listersv1.PersistentVolumeLister
+	pvcs           listersv1.PersistentVolumeClaimLister
+	resourceQuotas listersv1.ResourceQuotaLister
+	endpointSlices discoveryv1.EndpointSliceLister
+	hpas           autoscalingv2.HorizontalPodAutoscalerLister
+}
+
+// OptionFunc are options that can be used to configure the Source.
+type OptionFunc func(s *Source) error
+
+// WithLogger returns an OptionFunc to change the logger from the default noop logger.
+func WithLogger(logger *log.Logger) OptionFunc {
+	return func(s *Source) error {
+		s.logger = logger
+		return nil
+	}
+}
+
+// NewSource builds a Source, starting the informers for all the object kinds KSMSpecs are built from and waiting for
+// their caches to sync. The returned channel should be closed to stop the informers.
+func NewSource(client kubernetes.Interface, opts ...OptionFunc) (*Source, chan<- struct{}, error) {
+	s := &Source{
+		logger: logutil.Discard,
+	}
+
+	for i, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, nil, fmt.Errorf("applying option #%d: %w", i, err)
+		}
+	}
+
+	stopCh := make(chan struct{})
+	factory := informers.NewSharedInformerFactory(client, defaultResyncDuration)
+
+	s.deployments = factory.Apps().V1().Deployments().Lister()
+	s.replicaSets = factory.Apps().V1().ReplicaSets().Lister()
+	s.statefulSets = factory.Apps().V1().StatefulSets().Lister()
+	s.daemonSets = factory.Apps().V1().DaemonSets().Lister()
+	s.jobs = factory.Batch().V1().Jobs().Lister()
+	s.cronJobs = factory.Batch().V1().CronJobs().Lister()
+	s.pods = factory.Core().V1().Pods().Lister()
+	s.namespaces = factory.Core().V1().Namespaces().Lister()
+	s.services = factory.Core().V1().Services().Lister()
+	s.pvs = factory.Core().V1().PersistentVolumes().Lister()
+	s.pvcs = factory.Core().V1().PersistentVolumeClaims().Lister()
+	s.resourceQuotas = factory.Core().V1().ResourceQuotas().Lister()
+	s.endpointSlices = factory.Discovery().V1().EndpointSlices().Lister()
+	s.hpas = factory.Autoscaling().V2().HorizontalPodAutoscalers().Lister()
+
+	factory.Start(stopCh)
+	for informerType, synced := range factory.WaitForCacheSync(stopCh) {
+		if !synced {
+			close(stopCh)
+			return nil, nil, fmt.Errorf("waiting for %v informer cache to sync", informerType)
+		}
+	}
+
+	return s, stopCh, nil
+}
+
+// Pods returns the lister of the pods cached by the Source, so other consumers of pods can share its informer.
+//
+//nolint:ireturn // Returning interface is correct design for abstraction.
+func (s *Source) Pods() listersv1.PodLister {
+	return s.pods
+}
+
+// MetricFamilies satisfies prometheus.FetchAndFilterMetricsFamilies. It renders the cached objects as KSM metric
+// families and returns the ones matching the given queries, so it can be used as a drop-in replacement of a KSM
+// endpoint.
+func (s *Source) MetricFamilies(queries []prometheus.Query) ([]prometheus.MetricFamily, error) {
+	fs := familySet{}
+
+	builders := []struct {
+		kind  string
+		build func(familySet) error
+	}{
+		{kind: "namespaces", build: s.namespaceFamilies},
+		{kind: "deployments", build: s.deploymentFamilies},
+		{kind: "replicasets", build: s.replicaSetFamilies},
+		{kind: "statefulsets", build: s.statefulSetFamilies},
+		{kind: "daemonsets", build: s.daemonSetFamilies},
+		{kind: "jobs", build: s.jobFamilies},
+		{kind: "cronjobs", build: s.cronJobFamilies},
+		{kind: "pods", build: s.podFamilies},
+		{kind: "services", build: s.serviceFamilies},
+		{kind: "endpointslices", build: s.endpointFamilies},
+		{kind: "persistentvolumes", build: s.persistentVolumeFamilies},
+		{kind: "persistentvolumeclaims", build: s.persistentVolumeClaimFamilies},
+		{kind: "horizontalpodautoscalers", build: s.hpaFamilies},
+		{kind: "resourcequotas", build: s.resourceQuotaFamilies},
+	}
+
+	for _, b := range builders {
+		if err := b.build(fs); err != nil {
+			return nil, fmt.Errorf("building metric families for %s: %w", b.kind, err)
+		}
+	}
+
+	families := fs.families()
+	s.logger.Debugf("Built %d metric families from informers", len(families))
+
+	return prometheus.FilterMetricFamilies(families, queries), nil
+}
+
+// everything is the selector used to list all cached objects.
+var everything = labels.Everything() //nolint: gochecknoglobals // read-only selector.
+
+// familySet accumulates synthetic gauge metric families indexed by name.
+type familySet map[string]*model.MetricFamily
+
+// gauge appends a gauge sample with the given value and labels to the family with the given name.
+func (fs familySet) gauge(name string, value float64, l prometheus.Labels) {
+	f, ok := fs[name]
+	if !ok {
+		f = &model.MetricFamily{
+			Name: proto.String(name),
+			Type: model.MetricType_GAUGE.Enum(),
+		}
+		fs[name] = f
+	}
+
+	f.Metric = append(f.Metric, &model.Metric{
+		Label: labelPairs(l),
+		Gauge: &model.Gauge{Value: proto.Float64(value)},
+	})
+}
+
+// families returns the accumulated families sorted by name, so results are deterministic.
+func (fs familySet) families() []*model.MetricFamily {
+	names := make([]string, 0, len(fs))
+	for name := range fs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := make([]*model.MetricFamily, 0, len(fs))
+	for _, name := range names {
+		families = append(families, fs[name])
+	}
+
+	return families
+}
+
+func labelPairs(l prometheus.Labels) []*model.LabelPair {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]*model.LabelPair, 0, len(l))
+	for _, k := range keys {
+		pairs = append(pairs, &model.LabelPair{Name: proto.String(k), Value: proto.String(l[k])})
+	}
+
+	return pairs
+}
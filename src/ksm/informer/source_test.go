@@ -0,0 +1,103 @@
+package informer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/ksm/informer"
+	"github.com/newrelic/nri-kubernetes/v3/src/metric"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+func fakeObjects() []runtime.Object {
+	replicas := int32(2)
+
+	return []runtime.Object{
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"team": "infra"}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{Replicas: 2, AvailableReplicas: 1},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nginx-abc",
+				Namespace: "default",
+				UID:       "1234",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "ReplicaSet", Name: "nginx-6d4cf56db6"},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionFalse},
+				},
+			},
+		},
+	}
+}
+
+func newSource(t *testing.T) *informer.Source {
+	t.Helper()
+
+	client := fake.NewSimpleClientset(fakeObjects()...)
+
+	source, closer, err := informer.NewSource(client)
+	require.NoError(t, err)
+	t.Cleanup(func() { close(closer) })
+
+	return source
+}
+
+func TestSource_MetricFamilies_FiltersByQuery(t *testing.T) {
+	t.Parallel()
+
+	source := newSource(t)
+
+	families, err := source.MetricFamilies([]prometheus.Query{
+		{MetricName: "kube_deployment_spec_replicas"},
+	})
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+
+	f := families[0]
+	assert.Equal(t, "kube_deployment_spec_replicas", f.Name)
+	require.Len(t, f.Metrics, 1)
+	assert.Equal(t, prometheus.GaugeValue(2), f.Metrics[0].Value)
+	assert.Equal(t, prometheus.Labels{"namespace": "default", "deployment": "nginx"}, f.Metrics[0].Labels)
+}
+
+func TestSource_MetricFamilies_GroupsWithKSMSpecs(t *testing.T) {
+	t.Parallel()
+
+	source := newSource(t)
+
+	families, err := source.MetricFamilies(metric.KSMQueries)
+	require.NoError(t, err)
+
+	// Errors are expected for the kinds with no objects in the fake cluster.
+	groups, _ := prometheus.GroupMetricsBySpec(metric.KSMSpecs, families)
+
+	require.Contains(t, groups, "namespace")
+	assert.Contains(t, groups["namespace"], "default")
+
+	require.Contains(t, groups, "deployment")
+	assert.Contains(t, groups["deployment"], "default_nginx")
+
+	require.Contains(t, groups, "pod")
+	pod, ok := groups["pod"]["default_nginx-abc"]
+	require.True(t, ok)
+	assert.Contains(t, pod, "kube_pod_info")
+	assert.Contains(t, pod, "kube_pod_status_phase")
+}
@@ -4,12 +4,14 @@ package ksm_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/newrelic/infra-integrations-sdk/integration"
 	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 
 	"github.com/newrelic/nri-kubernetes/v3/internal/config"
 	"github.com/newrelic/nri-kubernetes/v3/internal/testutil"
@@ -173,3 +175,165 @@ func TestScraper_FilterNamespace(t *testing.T) {
 		assert.Equal(t, 34, len(i.Entities))
 	})
 }
+
+// TestScraper_InformerStateSource checks that the informer state source populates the same samples as KSM for the
+// objects of the testdata, which are the ones KSM reported in its metrics. KSM only reports the labels and annotations
+// it is allowed to, while informers report all of them, so only the ones reported by KSM are compared.
+func TestScraper_InformerStateSource(t *testing.T) {
+	// Kinds of objects in the testdata, whose samples are populated by both sources. Endpoints are listed from
+	// EndpointSlices by informers, which the testdata does not have.
+	comparedEventTypes := []string{"K8sNamespaceSample", "K8sPodSample", "K8sServiceSample"}
+
+	for _, v := range testutil.AllVersions() {
+		version := v
+		t.Run(fmt.Sprintf("for_version_%s", version), func(t *testing.T) {
+			t.Parallel()
+
+			testServer, err := version.Server()
+			require.NoError(t, err)
+
+			ksmCli, err := ksmClient.New()
+			require.NoError(t, err)
+
+			k8sData, err := version.K8s()
+			require.NoError(t, err)
+
+			run := func(ksmConfig config.KSM) *integration.Integration {
+				t.Helper()
+
+				scraper, err := ksm.NewScraper(&config.Config{KSM: ksmConfig, ClusterName: t.Name()}, ksm.Providers{
+					K8s: fake.NewSimpleClientset(k8sData.Everything()...),
+					KSM: ksmCli,
+				})
+				require.NoError(t, err)
+				t.Cleanup(scraper.Close)
+
+				i := testutil.NewIntegration(t)
+				require.NoError(t, scraper.Run(i))
+
+				return i
+			}
+
+			fromKSM := run(config.KSM{StaticURL: testServer.KSMEndpoint(), StateSource: config.KSMStateSourceKSM})
+			fromInformers := run(config.KSM{StateSource: config.KSMStateSourceInformers})
+
+			for _, eventType := range comparedEventTypes {
+				expected := testutil.Samples(fromKSM, eventType)
+				actual := testutil.Samples(fromInformers, eventType)
+				require.NotEmpty(t, expected, eventType)
+
+				for name := range actual {
+					assert.Contains(t, expected, name, "%s reported by informers only", eventType)
+				}
+
+				for name, expectedSample := range expected {
+					actualSample, ok := actual[name]
+					if !assert.True(t, ok, "%s of %q not reported by informers", eventType, name) {
+						continue
+					}
+
+					for metricName := range actualSample {
+						if strings.HasPrefix(metricName, "label.") || strings.HasPrefix(metricName, "annotation.") {
+							continue
+						}
+						assert.Contains(t, expectedSample, metricName, "%s of %q", eventType, name)
+					}
+
+					for metricName, value := range expectedSample {
+						assert.Equal(t, value, actualSample[metricName], "%s of %q: %s", eventType, name, metricName)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestScraper_ClusterScopedOnly checks that the central scraper leaves pod samples to the node-local scrapers.
+func TestScraper_ClusterScopedOnly(t *testing.T) {
+	version := testutil.LatestVersion()
+
+	testServer, err := version.Server()
+	require.NoError(t, err)
+
+	ksmCli, err := ksmClient.New()
+	require.NoError(t, err)
+
+	k8sData, err := version.K8s()
+	require.NoError(t, err)
+
+	scraper, err := ksm.NewScraper(&config.Config{
+		KSM: config.KSM{
+			StaticURL:         testServer.KSMEndpoint(),
+			ClusterScopedOnly: true,
+		},
+		ClusterName: t.Name(),
+	}, ksm.Providers{
+		K8s: fake.NewSimpleClientset(k8sData.Everything()...),
+		KSM: ksmCli,
+	})
+	require.NoError(t, err)
+	t.Cleanup(scraper.Close)
+
+	i := testutil.NewIntegration(t)
+	require.NoError(t, scraper.Run(i))
+
+	assert.Empty(t, testutil.Samples(i, "K8sPodSample"))
+	assert.NotEmpty(t, testutil.Samples(i, "K8sNamespaceSample"))
+	assert.NotEmpty(t, testutil.Samples(i, "K8sDeploymentSample"))
+}
+
+// TestScraper_PendingPodsWatches checks that pending pods are only watched on their own when the scraper reports pod
+// samples and the pods informer of the state source cannot be reused.
+func TestScraper_PendingPodsWatches(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name             string
+		ksm              config.KSM
+		expectedPodLists []string
+	}{
+		{
+			name:             "ksm",
+			ksm:              config.KSM{StaticURL: "http://ksm:8080/metrics"},
+			expectedPodLists: []string{"status.phase=Pending"},
+		},
+		{
+			name:             "informers",
+			ksm:              config.KSM{StateSource: config.KSMStateSourceInformers},
+			expectedPodLists: []string{"all pods"},
+		},
+		{
+			name:             "cluster_scoped_only",
+			ksm:              config.KSM{StaticURL: "http://ksm:8080/metrics", ClusterScopedOnly: true},
+			expectedPodLists: []string{"app.kubernetes.io/name=kube-state-metrics"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset()
+			scraper, err := ksm.NewScraper(&config.Config{KSM: tc.ksm}, ksm.Providers{K8s: client})
+			require.NoError(t, err)
+			t.Cleanup(scraper.Close)
+
+			var podLists []string
+			for _, action := range client.Actions() {
+				list, ok := action.(clienttesting.ListAction)
+				if !ok || action.GetResource().Resource != "pods" {
+					continue
+				}
+
+				restrictions := list.GetListRestrictions()
+				switch {
+				case !restrictions.Fields.Empty():
+					podLists = append(podLists, restrictions.Fields.String())
+				case !restrictions.Labels.Empty():
+					podLists = append(podLists, restrictions.Labels.String())
+				default:
+					podLists = append(podLists, "all pods")
+				}
+			}
+			assert.Equal(t, tc.expectedPodLists, podLists)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package metric
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Categories of the reasons the scheduler gives for not placing a pod in a node.
+const (
+	schedulingFailureInsufficient    = "insufficient"
+	schedulingFailureTaint           = "taint"
+	schedulingFailureNodeAffinity    = "node_affinity"
+	schedulingFailurePodAffinity     = "pod_affinity"
+	schedulingFailurePodAntiAffinity = "pod_anti_affinity"
+	schedulingFailureVolume          = "volume"
+	schedulingFailurePorts           = "ports"
+	schedulingFailureUnschedulable   = "unschedulable"
+	schedulingFailureTooManyPods     = "too_many_pods"
+	schedulingFailureTopologySpread  = "topology_spread"
+	schedulingFailureOther           = "other"
+)
+
+// schedulingMessageRegex matches the message of the PodScheduled condition of unschedulable pods, like:
+// 0/10 nodes are available: 3 Insufficient cpu, 7 node(s) had untolerated taint {key: value}. preemption: ...
+var schedulingMessageRegex = regexp.MustCompile(`^(\d+)/(\d+) nodes are available: (.*)$`)
+
+// schedulingReasonRegex matches each of the reasons in the message, prefixed by the number of nodes it applies to.
+var schedulingReasonRegex = regexp.MustCompile(`^(\d+) (.+)$`)
+
+// SchedulingFailure is the summary of the reasons why the scheduler could not place a pod.
+type SchedulingFailure struct {
+	AvailableNodes int
+	TotalNodes     int
+	// Reasons maps each failure category to the number of nodes rejected because of it. Insufficient resources are
+	// reported per resource, e.g. insufficient_cpu.
+	Reasons map[string]int
+}
+
+// Summary returns a short description of the failure, like "0/10 nodes: 3 insufficient cpu, 7 taint".
+func (f SchedulingFailure) Summary() string {
+	categories := make([]string, 0, len(f.Reasons))
+	for category := range f.Reasons {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	reasons := make([]string, 0, len(categories))
+	for _, category := range categories {
+		reasons = append(reasons, fmt.Sprintf("%d %s", f.Reasons[category], strings.ReplaceAll(category, "_", " ")))
+	}
+
+	return fmt.Sprintf("%d/%d nodes: %s", f.AvailableNodes, f.TotalNodes, strings.Join(reasons, ", "))
+}
+
+// ParseSchedulingFailure parses the message the scheduler sets in the PodScheduled condition of unschedulable pods.
+// It returns false if the message does not have the expected format.
+func ParseSchedulingFailure(message string) (SchedulingFailure, bool) {
+	matches := schedulingMessageRegex.FindStringSubmatch(strings.TrimSpace(message))
+	if matches == nil {
+		return SchedulingFailure{}, false
+	}
+
+	available, _ := strconv.Atoi(matches[1])
+	total, _ := strconv.Atoi(matches[2])
+	failure := SchedulingFailure{
+		AvailableNodes: available,
+		TotalNodes:     total,
+		Reasons:        map[string]int{},
+	}
+
+	// Preemption results, if any, come after the first sentence.
+	reasons := matches[3]
+	if i := strings.Index(reasons, ". "); i >= 0 {
+		reasons = reasons[:i]
+	}
+	reasons = strings.TrimSuffix(reasons, ".")
+
+	// Reasons are separated by commas, which can also be part of a reason (e.g. in the taints), so parts not starting
+	// with a number of nodes are joined to the previous one.
+	var parts []string
+	for _, part := range strings.Split(reasons, ", ") {
+		if schedulingReasonRegex.MatchString(part) || len(parts) == 0 {
+			parts = append(parts, part)
+			continue
+		}
+		parts[len(parts)-1] += ", " + part
+	}
+
+	for _, part := range parts {
+		reasonMatches := schedulingReasonRegex.FindStringSubmatch(part)
+		if reasonMatches == nil {
+			continue
+		}
+
+		nodes, _ := strconv.Atoi(reasonMatches[1])
+		failure.Reasons[schedulingFailureCategory(reasonMatches[2])] += nodes
+	}
+
+	return failure, true
+}
+
+// schedulingFailureCategory maps a reason given by the scheduler to one of the known categories.
+func schedulingFailureCategory(reason string) string {
+	lower := strings.ToLower(reason)
+
+	switch {
+	case strings.HasPrefix(lower, "insufficient "):
+		return schedulingFailureInsufficient + "_" + strings.TrimSpace(reason[len("insufficient "):])
+	case strings.Contains(lower, "taint"):
+		return schedulingFailureTaint
+	case strings.Contains(lower, "pod anti-affinity"):
+		return schedulingFailurePodAntiAffinity
+	case strings.Contains(lower, "pod affinity"):
+		return schedulingFailurePodAffinity
+	case strings.Contains(lower, "node affinity/selector"):
+		return schedulingFailureNodeAffinity
+	case strings.Contains(lower, "volume"), strings.Contains(lower, "persistentvolumeclaim"):
+		return schedulingFailureVolume
+	case strings.Contains(lower, "free ports"):
+		return schedulingFailurePorts
+	case strings.Contains(lower, "were unschedulable"):
+		return schedulingFailureUnschedulable
+	case strings.Contains(lower, "too many pods"):
+		return schedulingFailureTooManyPods
+	case strings.Contains(lower, "topology spread"):
+		return schedulingFailureTopologySpread
+	default:
+		return schedulingFailureOther
+	}
+}
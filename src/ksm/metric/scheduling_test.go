@@ -0,0 +1,81 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedulingFailure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		message  string
+		expected SchedulingFailure
+		summary  string
+	}{
+		{
+			name:    "resources_and_taints",
+			message: "0/10 nodes are available: 3 Insufficient cpu, 7 node(s) had untolerated taint {node-role.kubernetes.io/control-plane: }. preemption: 0/10 nodes are available: 10 Preemption is not helpful for scheduling.",
+			expected: SchedulingFailure{
+				AvailableNodes: 0,
+				TotalNodes:     10,
+				Reasons:        map[string]int{"insufficient_cpu": 3, "taint": 7},
+			},
+			summary: "0/10 nodes: 3 insufficient cpu, 7 taint",
+		},
+		{
+			name:    "multiple_taints_with_commas",
+			message: "0/3 nodes are available: 1 node(s) had untolerated taint {a: b}, 2 node(s) had untolerated taint {c: d}.",
+			expected: SchedulingFailure{
+				TotalNodes: 3,
+				Reasons:    map[string]int{"taint": 3},
+			},
+			summary: "0/3 nodes: 3 taint",
+		},
+		{
+			name:    "affinity_volumes_and_extended_resources",
+			message: "0/6 nodes are available: 1 node(s) didn't match Pod's node affinity/selector, 1 node(s) didn't match pod anti-affinity rules, 1 node(s) had volume node affinity conflict, 1 Insufficient nvidia.com/gpu, 1 node(s) were unschedulable, 1 node(s) didn't have free ports for the requested pod ports.",
+			expected: SchedulingFailure{
+				TotalNodes: 6,
+				Reasons: map[string]int{
+					"node_affinity":               1,
+					"pod_anti_affinity":           1,
+					"volume":                      1,
+					"insufficient_nvidia.com/gpu": 1,
+					"unschedulable":               1,
+					"ports":                       1,
+				},
+			},
+			summary: "0/6 nodes: 1 insufficient nvidia.com/gpu, 1 node affinity, 1 pod anti affinity, 1 ports, 1 unschedulable, 1 volume",
+		},
+		{
+			name:    "unknown_reason",
+			message: "0/1 nodes are available: 1 something new.",
+			expected: SchedulingFailure{
+				TotalNodes: 1,
+				Reasons:    map[string]int{"other": 1},
+			},
+			summary: "0/1 nodes: 1 other",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			failure, ok := ParseSchedulingFailure(tc.message)
+			assert.True(t, ok)
+			assert.Equal(t, tc.expected, failure)
+			assert.Equal(t, tc.summary, failure.Summary())
+		})
+	}
+}
+
+func TestParseSchedulingFailure_UnknownFormat(t *testing.T) {
+	t.Parallel()
+
+	_, ok := ParseSchedulingFailure("no nodes available to schedule pods")
+	assert.False(t, ok)
+}
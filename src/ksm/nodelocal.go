@@ -0,0 +1,232 @@
+package ksm
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+const (
+	// nodeArg is the KSM flag used to shard pods per node.
+	nodeArg = "--node"
+	// podScopedPrefix is the prefix of the KSM metric families describing pods, which are the only ones exposed by a
+	// KSM sharded per node.
+	podScopedPrefix = "kube_pod_"
+	// defaultKSMPortName is the name given to the metrics port in the upstream KSM manifests.
+	defaultKSMPortName = "http-metrics"
+	defaultKSMPort     = 8080
+)
+
+// podScopedGroups are the KSMSpecs groups built exclusively from pod-scoped metric families.
+var podScopedGroups = []string{"pod"} //nolint: gochecknoglobals // read-only list.
+
+// podScopedSpecs returns the subset of specs that can be populated from a KSM sharded per node.
+func podScopedSpecs(specs definition.SpecGroups) definition.SpecGroups {
+	scoped := definition.SpecGroups{}
+	for _, group := range podScopedGroups {
+		if sg, ok := specs[group]; ok {
+			scoped[group] = sg
+		}
+	}
+
+	return scoped
+}
+
+// podScopedQueries returns the subset of queries requesting pod-scoped metric families.
+func podScopedQueries(queries []prometheus.Query) []prometheus.Query {
+	var scoped []prometheus.Query
+	for _, q := range queries {
+		if strings.HasPrefix(q.MetricName, podScopedPrefix) {
+			scoped = append(scoped, q)
+		}
+	}
+
+	return scoped
+}
+
+// clusterScopedSpecs returns the subset of specs not populated from pod-scoped metric families, which are left to the
+// node-local scrapers.
+func clusterScopedSpecs(specs definition.SpecGroups) definition.SpecGroups {
+	scoped := definition.SpecGroups{}
+	for group, sg := range specs {
+		if !slices.Contains(podScopedGroups, group) {
+			scoped[group] = sg
+		}
+	}
+
+	return scoped
+}
+
+// clusterScopedQueries returns the subset of queries not requesting pod-scoped metric families.
+func clusterScopedQueries(queries []prometheus.Query) []prometheus.Query {
+	var scoped []prometheus.Query
+	for _, q := range queries {
+		if !strings.HasPrefix(q.MetricName, podScopedPrefix) {
+			scoped = append(scoped, q)
+		}
+	}
+
+	return scoped
+}
+
+// clusterScopedURLs returns the URLs of the discovered KSM endpoints, leaving out the ones backed by KSM pods sharded
+// per node, as they only expose the pods of their node and are scraped by the node-local scrapers.
+func (s *Scraper) clusterScopedURLs() ([]string, error) {
+	targets, err := s.targetsDiscoverer.DiscoverTargets()
+	if err != nil {
+		return nil, fmt.Errorf("discovering KSM endpoints: %w", err)
+	}
+
+	var urls []string
+	for _, t := range targets {
+		if t.PodName != "" {
+			pod, err := s.ksmPodsLister.Pods(t.PodNamespace).Get(t.PodName)
+			if err != nil {
+				s.logger.Debugf("Getting KSM pod %s/%s to check whether it is sharded per node: %v", t.PodNamespace, t.PodName, err)
+			} else if hasArg(pod, nodeArg) {
+				s.logger.Debugf("Ignoring KSM pod %s/%s as it is sharded per node", t.PodNamespace, t.PodName)
+				continue
+			}
+		}
+
+		urls = append(urls, s.ksmURL(t.Host))
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("discovering KSM endpoints: only KSM pods sharded per node were found")
+	}
+
+	return urls, nil
+}
+
+// buildNodePodsLister returns a lister caching only the pods running in the node the integration runs on that match
+// the KSM selector and namespace.
+func (s *Scraper) buildNodePodsLister() (listersv1.PodLister, chan<- struct{}) {
+	selector := defaultLabelSelector
+	if s.config.KSM.Selector != "" {
+		selector = s.config.KSM.Selector
+	}
+
+	options := []informers.SharedInformerOption{
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = selector
+			options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", s.config.NodeName).String()
+		}),
+	}
+
+	if s.config.KSM.Namespace != "" {
+		options = append(options, informers.WithNamespace(s.config.KSM.Namespace))
+	}
+
+	return discovery.NewPodsLister(s.K8s, options...)
+}
+
+// nodeLocalURLs returns the URLs of the running KSM pods sharded per node found in the node of the integration.
+func (s *Scraper) nodeLocalURLs() ([]string, error) {
+	pods, err := s.nodePodsLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing KSM pods in node %q: %w", s.config.NodeName, err)
+	}
+
+	var urls []string
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+
+		// KSM instances not sharded per node may be scheduled in this node too, and must be left to the
+		// cluster-wide scraper.
+		if !hasArg(pod, nodeArg) {
+			s.logger.Debugf("Ignoring KSM pod %s/%s as it is not sharded per node", pod.Namespace, pod.Name)
+			continue
+		}
+
+		port := ksmPodPort(pod, s.config.KSM.Port)
+		urls = append(urls, s.ksmURL(net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(port))))
+	}
+
+	return urls, nil
+}
+
+// runNodeLocal scrapes the KSM pods found in the node of the integration. Failures are only logged, as the node-local
+// scraper runs alongside the kubelet one, which should not be prevented from running.
+func (s *Scraper) runNodeLocal(i *integration.Integration) error {
+	endpoints, err := s.nodeLocalURLs()
+	if err != nil {
+		s.logger.Warnf("Discovering node-local KSM: %v", err)
+		return nil
+	}
+
+	if len(endpoints) == 0 {
+		s.logger.Warnf("No KSM pod sharded per node found in node %q", s.config.NodeName)
+		return nil
+	}
+
+	for _, endpoint := range endpoints {
+		s.logger.Debugf("Fetching node-local KSM data from %q", endpoint)
+		populated, err := s.populate(i, s.KSM.MetricFamiliesGetFunc(endpoint))
+		if err != nil {
+			s.logger.Warnf("Populating node-local KSM data from %q: %v", endpoint, err)
+			continue
+		}
+
+		if !populated {
+			s.logger.Debugf("No pod-scoped metrics were populated from %q", endpoint)
+		}
+	}
+
+	return nil
+}
+
+// hasArg returns whether any container of the pod is run with the given flag.
+func hasArg(pod *corev1.Pod, flag string) bool {
+	for _, c := range pod.Spec.Containers {
+		for _, arg := range append(append([]string{}, c.Command...), c.Args...) {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ksmPodPort returns the configured port if any, or the metrics port declared by the KSM pod.
+func ksmPodPort(pod *corev1.Pod, configured int) int {
+	if configured != 0 {
+		return configured
+	}
+
+	var first int
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == defaultKSMPortName {
+				return int(p.ContainerPort)
+			}
+
+			if first == 0 {
+				first = int(p.ContainerPort)
+			}
+		}
+	}
+
+	if first != 0 {
+		return first
+	}
+
+	return defaultKSMPort
+}
@@ -0,0 +1,137 @@
+package ksm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/src/metric"
+)
+
+func ksmPod(name string, args []string, ports ...corev1.ContainerPort) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kube-system",
+			Labels:    map[string]string{"app.kubernetes.io/name": "kube-state-metrics"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{{Name: "kube-state-metrics", Args: args, Ports: ports}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0." + name[len(name)-1:]},
+	}
+}
+
+func TestNodeLocal_DiscoversOnlyPodsShardedPerNode(t *testing.T) {
+	t.Parallel()
+
+	objects := []runtime.Object{
+		ksmPod("ksm-node-1", []string{"--node=$(NODE_NAME)"}, corev1.ContainerPort{Name: "http-metrics", ContainerPort: 8081}),
+		ksmPod("ksm-central-2", []string{"--resources=deployments"}),
+		ksmPod("ksm-node-3", []string{"--node", "$(NODE_NAME)"}),
+	}
+
+	cfg := &config.Config{NodeName: "node-1"}
+	cfg.KSM.NodeLocal.Enabled = true
+
+	s, err := NewScraper(cfg, Providers{K8s: fake.NewSimpleClientset(objects...)})
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	urls, err := s.nodeLocalURLs()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"http://10.0.0.1:8081/metrics", "http://10.0.0.3:8080/metrics"}, urls)
+}
+
+func TestNodeLocal_ListsOnlyPodsInItsNode(t *testing.T) {
+	t.Parallel()
+
+	// The fake clientset ignores field selectors, so the ones requested are recorded instead.
+	client := fake.NewSimpleClientset()
+	selectors := make(chan string, 10)
+	client.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		selectors <- action.(clienttesting.ListAction).GetListRestrictions().Fields.String()
+		return false, nil, nil
+	})
+
+	cfg := &config.Config{NodeName: "node-1"}
+	cfg.KSM.NodeLocal.Enabled = true
+
+	s, err := NewScraper(cfg, Providers{K8s: client})
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	select {
+	case selector := <-selectors:
+		assert.Equal(t, "spec.nodeName=node-1", selector)
+	case <-time.After(5 * time.Second):
+		t.Fatal("KSM pods were not listed")
+	}
+}
+
+func TestNodeLocal_RestrictsToPodScopedData(t *testing.T) {
+	t.Parallel()
+
+	specs := podScopedSpecs(metric.KSMSpecs)
+	assert.Len(t, specs, 1)
+	assert.Contains(t, specs, "pod")
+
+	queries := podScopedQueries(metric.KSMQueries)
+	require.NotEmpty(t, queries)
+	for _, q := range queries {
+		assert.True(t, strings.HasPrefix(q.MetricName, "kube_pod_"), q.MetricName)
+	}
+}
+
+func TestClusterScoped_LeavesOutPodsShardedPerNode(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(
+		ksmPod("ksm-node-1", []string{"--node=$(NODE_NAME)"}),
+		ksmPod("ksm-central-2", []string{"--resources=deployments"}),
+	)
+
+	c := &config.Config{}
+	c.KSM.ClusterScopedOnly = true
+
+	s := &Scraper{
+		Providers: Providers{K8s: client},
+		logger:    logutil.Discard,
+		config:    c,
+		targetsDiscoverer: fakeTargetsDiscoverer{
+			{Host: "10.0.0.1:8080", PodNamespace: "kube-system", PodName: "ksm-node-1"},
+			{Host: "10.0.0.2:8080", PodNamespace: "kube-system", PodName: "ksm-central-2"},
+		},
+	}
+
+	lister, closer := s.buildKSMPodsLister()
+	t.Cleanup(func() { close(closer) })
+	s.ksmPodsLister = lister
+
+	urls, err := s.ksmURLs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://10.0.0.2:8080/metrics"}, urls)
+}
+
+func TestClusterScoped_LeavesOutPodScopedData(t *testing.T) {
+	t.Parallel()
+
+	specs := clusterScopedSpecs(metric.KSMSpecs)
+	assert.NotContains(t, specs, "pod")
+	assert.Len(t, specs, len(metric.KSMSpecs)-1)
+
+	for _, q := range clusterScopedQueries(metric.KSMQueries) {
+		assert.False(t, strings.HasPrefix(q.MetricName, "kube_pod_"), q.MetricName)
+	}
+}
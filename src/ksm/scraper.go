@@ -3,17 +3,28 @@ package ksm
 import (
 	"fmt"
 	"net/url"
+	"sort"
 
 	"github.com/newrelic/infra-integrations-sdk/integration"
 	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	listersv1 "k8s.io/client-go/listers/core/v1"
 
+	"github.com/newrelic/nri-kubernetes/v3/internal/attributefilter"
 	"github.com/newrelic/nri-kubernetes/v3/internal/config"
 	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
+	"github.com/newrelic/nri-kubernetes/v3/internal/owner"
+	"github.com/newrelic/nri-kubernetes/v3/internal/topology"
+	"github.com/newrelic/nri-kubernetes/v3/src/data"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
 	ksmGrouper "github.com/newrelic/nri-kubernetes/v3/src/ksm/grouper"
+	"github.com/newrelic/nri-kubernetes/v3/src/ksm/informer"
 	"github.com/newrelic/nri-kubernetes/v3/src/metric"
 	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
 	"github.com/newrelic/nri-kubernetes/v3/src/scrape"
@@ -40,9 +51,19 @@ type Scraper struct {
 	cloudClusterID      string
 	k8sVersion          *version.Info
 	endpointsDiscoverer discovery.EndpointsDiscoverer
+	targetsDiscoverer   discovery.TargetsDiscoverer
 	servicesLister      listersv1.ServiceLister
+	nodePodsLister      listersv1.PodLister
+	pendingPodsLister   listersv1.PodLister
+	ksmPodsLister       listersv1.PodLister
+	specs               definition.SpecGroups
+	queries             []prometheus.Query
+	stateSource         *informer.Source
+	ownerResolver       owner.Resolver
 	informerClosers     []chan<- struct{}
 	Filterer            discovery.NamespaceFilterer
+	attributeFilter     attributefilter.Filterer
+	topology            *topology.Resolver
 }
 
 // ScraperOpt are options that can be used to configure the Scraper.
@@ -64,6 +85,22 @@ func WithFilterer(filterer discovery.NamespaceFilterer) ScraperOpt {
 	}
 }
 
+// WithOwnerResolver returns an OptionFunc to resolve the workloads owning pods.
+func WithOwnerResolver(resolver owner.Resolver) ScraperOpt {
+	return func(s *Scraper) error {
+		s.ownerResolver = resolver
+		return nil
+	}
+}
+
+// WithAttributeFilter returns an OptionFunc to filter the label and annotation attributes reported.
+func WithAttributeFilter(filter attributefilter.Filterer) ScraperOpt {
+	return func(s *Scraper) error {
+		s.attributeFilter = filter
+		return nil
+	}
+}
+
 // WithCloudClusterID returns an OptionFunc to set the cloud-detected cluster id.
 func WithCloudClusterID(id string) ScraperOpt {
 	return func(s *Scraper) error {
@@ -72,13 +109,29 @@ func WithCloudClusterID(id string) ScraperOpt {
 	}
 }
 
+// WithExtraSpecs returns an OptionFunc to add extra specs and queries to the built-in ones.
+func WithExtraSpecs(extra metric.ExtraSource) ScraperOpt {
+	return func(s *Scraper) error {
+		specs, queries, err := extra.Merge(s.specs, s.queries)
+		if err != nil {
+			return fmt.Errorf("merging extra specs: %w", err)
+		}
+
+		s.specs = specs
+		s.queries = queries
+		return nil
+	}
+}
+
 // NewScraper builds a new Scraper, initializing its internal informers. After use, informers should be closed by calling
 // Close() to prevent resource leakage.
-func NewScraper(config *config.Config, providers Providers, options ...ScraperOpt) (*Scraper, error) {
+func NewScraper(cfg *config.Config, providers Providers, options ...ScraperOpt) (*Scraper, error) {
 	s := &Scraper{
-		config:    config,
+		config:    cfg,
 		Providers: providers,
 		logger:    logutil.Discard,
+		specs:     metric.KSMSpecs,
+		queries:   metric.KSMQueries,
 	}
 
 	// TODO: Sanity check config
@@ -101,14 +154,67 @@ func NewScraper(config *config.Config, providers Providers, options ...ScraperOp
 	// Assume Kubernetes version will not change during the lifetime of the integration, and store it
 	s.k8sVersion = k8sVersion
 
-	s.logger.Debugf("Building KSM discoverer")
-	endpointsDiscoverer, endpointsCloser, err := s.buildDiscoverer()
-	if err != nil {
-		return nil, fmt.Errorf("building endpoints disoverer: %w", err)
+	if cfg.KSM.StateSource == config.KSMStateSourceInformers {
+		s.logger.Debugf("Building informer-based KSM state source")
+		source, sourceCloser, err := informer.NewSource(providers.K8s, informer.WithLogger(s.logger))
+		if err != nil {
+			return nil, fmt.Errorf("building informer state source: %w", err)
+		}
+
+		s.stateSource = source
+		s.informerClosers = append(s.informerClosers, sourceCloser)
+	} else if cfg.KSM.NodeLocal.Enabled {
+		s.logger.Debugf("Building node-local KSM pods lister for node %q", cfg.NodeName)
+		nodePodsLister, nodePodsCloser := s.buildNodePodsLister()
+		s.nodePodsLister = nodePodsLister
+		s.informerClosers = append(s.informerClosers, nodePodsCloser)
+
+		s.specs = podScopedSpecs(s.specs)
+		s.queries = podScopedQueries(s.queries)
+	} else {
+		s.logger.Debugf("Building KSM discoverer")
+		endpointsDiscoverer, targetsDiscoverer, endpointsCloser, err := s.buildDiscoverer()
+		if err != nil {
+			return nil, fmt.Errorf("building endpoints disoverer: %w", err)
+		}
+
+		s.endpointsDiscoverer = endpointsDiscoverer
+		s.targetsDiscoverer = targetsDiscoverer
+		s.informerClosers = append(s.informerClosers, endpointsCloser)
+
+		if targetsDiscoverer != nil {
+			s.logger.Debugf("Building KSM pods lister to find their shards")
+			ksmPodsLister, ksmPodsCloser := s.buildKSMPodsLister()
+			s.ksmPodsLister = ksmPodsLister
+			s.informerClosers = append(s.informerClosers, ksmPodsCloser)
+		}
+
+		if cfg.KSM.ClusterScopedOnly {
+			s.specs = clusterScopedSpecs(s.specs)
+			s.queries = clusterScopedQueries(s.queries)
+		}
+	}
+
+	if cfg.TopologyAttributes {
+		s.logger.Debugf("Building nodes lister for topology attributes")
+		nodeLister, nodeCloser := discovery.NewNodeLister(providers.K8s)
+		s.topology = topology.NewResolver(nodeLister, "")
+		s.informerClosers = append(s.informerClosers, nodeCloser)
 	}
 
-	s.endpointsDiscoverer = endpointsDiscoverer
-	s.informerClosers = append(s.informerClosers, endpointsCloser)
+	// Scheduling failures are reported in pod samples, which node-local KSM instances only have for the pods in their
+	// node, so pending pods are not among them.
+	_, reportsPods := s.specs["pod"]
+	switch {
+	case !reportsPods || s.nodePodsLister != nil:
+	case s.stateSource != nil:
+		s.pendingPodsLister = s.stateSource.Pods()
+	default:
+		s.logger.Debugf("Building pending pods lister for scheduling failures")
+		pendingPodsLister, pendingPodsCloser := s.buildPendingPodsLister()
+		s.pendingPodsLister = pendingPodsLister
+		s.informerClosers = append(s.informerClosers, pendingPodsCloser)
+	}
 
 	servicesLister, servicesCloser := discovery.NewServicesLister(providers.K8s)
 	s.servicesLister = servicesLister
@@ -120,6 +226,28 @@ func NewScraper(config *config.Config, providers Providers, options ...ScraperOp
 // Run runs the scraper, adding all the KSM-related metrics and entities into the integration i.
 // Run must not be called after Close().
 func (s *Scraper) Run(i *integration.Integration) error {
+	if s.stateSource != nil {
+		s.logger.Debugf("Building KSM data from informers")
+		populated, err := s.populate(i, s.stateSource.MetricFamilies)
+		if err != nil {
+			return err
+		}
+
+		if !populated {
+			return fmt.Errorf("KSM data was not populated from informers")
+		}
+
+		return nil
+	}
+
+	if s.nodePodsLister != nil {
+		return s.runNodeLocal(i)
+	}
+
+	if s.config.KSM.Sharding.Enabled && s.config.KSM.StaticURL == "" {
+		return s.runSharded(i)
+	}
+
 	populated := false
 
 	endpoints, err := s.ksmURLs()
@@ -131,39 +259,18 @@ func (s *Scraper) Run(i *integration.Integration) error {
 
 	for _, endpoint := range endpoints {
 		s.logger.Debugf("Fetching KSM data from %q", endpoint)
-		grouper, err := ksmGrouper.New(ksmGrouper.Config{
-			MetricFamiliesGetter:       s.KSM.MetricFamiliesGetFunc(endpoint),
-			Queries:                    metric.KSMQueries,
-			ServicesLister:             s.servicesLister,
-			EnableResourceQuotaSamples: s.config.EnableResourceQuotaSamples,
-		}, ksmGrouper.WithLogger(s.logger))
+		endpointPopulated, err := s.populate(i, s.KSM.MetricFamiliesGetFunc(endpoint))
 		if err != nil {
-			return fmt.Errorf("creating KSM grouper: %w", err)
+			return err
 		}
 
-		// TODO: Check if the concept of job still makes sense with the new architecture.
-		job := scrape.NewScrapeJob("kube-state-metrics", grouper, metric.KSMSpecs, scrape.JobWithFilterer(s.Filterer))
-
-		s.logger.Debugf("Running KSM job")
-		r := job.Populate(i, s.config.ClusterName, s.cloudClusterID, s.logger, s.k8sVersion)
-		if r.Errors != nil {
-			if r.Populated {
-				s.logger.Tracef("Error populating KSM metrics: %v", r.Error())
-			} else {
-				s.logger.Warnf("Error populating KSM metrics: %v", r.Error())
-			}
-		}
-
-		if !r.Populated {
+		if !endpointPopulated {
 			log.Debug("No metrics were populated, trying next endpoint")
 			continue
 		}
 
-		populated = r.Populated
-
-		if !s.config.KSM.Distributed {
-			break
-		}
+		populated = true
+		break
 	}
 
 	if !populated {
@@ -173,6 +280,90 @@ func (s *Scraper) Run(i *integration.Integration) error {
 	return nil
 }
 
+// runSharded scrapes all the discovered KSM shards concurrently and populates their merged data at once.
+func (s *Scraper) runSharded(i *integration.Integration) error {
+	shards, err := s.discoverShards()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Debugf("Discovered KSM shards: %v", shards)
+
+	for _, err := range checkShards(shards, s.config.KSM.Sharding.TotalShards).errors() {
+		s.logger.Warnf("Inconsistent KSM sharding: %v", err)
+	}
+
+	sharded := &shardedGrouper{logger: s.logger, shards: shards}
+	for _, sh := range shards {
+		grouper, err := s.grouper(s.KSM.MetricFamiliesGetFunc(sh.url))
+		if err != nil {
+			return err
+		}
+
+		sharded.groupers = append(sharded.groupers, grouper)
+	}
+
+	if !s.populateFrom(i, sharded) {
+		return fmt.Errorf("KSM data was not populated from any of the %d shards", len(shards))
+	}
+
+	return nil
+}
+
+// populate runs a KSM job fetching metric families from getter and returns whether any metric was populated.
+func (s *Scraper) populate(i *integration.Integration, getter prometheus.FetchAndFilterMetricsFamilies) (bool, error) {
+	grouper, err := s.grouper(getter)
+	if err != nil {
+		return false, err
+	}
+
+	return s.populateFrom(i, grouper), nil
+}
+
+func (s *Scraper) populateFrom(i *integration.Integration, grouper data.Grouper) bool {
+	// TODO: Check if the concept of job still makes sense with the new architecture.
+	jobOpts := []scrape.JobOpt{
+		scrape.JobWithFilterer(s.Filterer),
+		scrape.JobWithAttributeFilter(s.attributeFilter),
+		scrape.JobWithCustomAttributes(s.config.CustomAttributes),
+		scrape.JobWithPopulateWorkers(s.config.PopulateWorkers),
+	}
+	if s.topology != nil {
+		jobOpts = append(jobOpts, scrape.JobWithNodeAttributes(s.topology))
+	}
+
+	job := scrape.NewScrapeJob("kube-state-metrics", grouper, s.specs, jobOpts...)
+
+	s.logger.Debugf("Running KSM job")
+	r := job.Populate(i, s.config.ClusterName, s.cloudClusterID, s.logger, s.k8sVersion)
+	if r.Errors != nil {
+		if r.Populated {
+			s.logger.Tracef("Error populating KSM metrics: %v", r.Error())
+		} else {
+			s.logger.Warnf("Error populating KSM metrics: %v", r.Error())
+		}
+	}
+
+	return r.Populated
+}
+
+//nolint:ireturn // Returning interface is correct design for abstraction.
+func (s *Scraper) grouper(getter prometheus.FetchAndFilterMetricsFamilies) (data.Grouper, error) {
+	grouper, err := ksmGrouper.New(ksmGrouper.Config{
+		MetricFamiliesGetter:       getter,
+		Queries:                    s.queries,
+		ServicesLister:             s.servicesLister,
+		EnableResourceQuotaSamples: s.config.EnableResourceQuotaSamples,
+		OwnerResolver:              s.ownerResolver,
+		PendingPodsLister:          s.pendingPodsLister,
+	}, ksmGrouper.WithLogger(s.logger))
+	if err != nil {
+		return nil, fmt.Errorf("creating KSM grouper: %w", err)
+	}
+
+	return grouper, nil
+}
+
 // Close will signal internal informers to stop running.
 func (s *Scraper) Close() {
 	for _, ch := range s.informerClosers {
@@ -180,8 +371,11 @@ func (s *Scraper) Close() {
 	}
 }
 
+// buildDiscoverer returns the discoverer used to find KSM endpoints, and the one used to find them along with their
+// pods, which is only built if sharding is enabled or KSM pods sharded per node must be left out.
+//
 //nolint:ireturn // Returning interface is correct design for abstraction.
-func (s *Scraper) buildDiscoverer() (discovery.EndpointsDiscoverer, chan<- struct{}, error) {
+func (s *Scraper) buildDiscoverer() (discovery.EndpointsDiscoverer, discovery.TargetsDiscoverer, chan<- struct{}, error) {
 	dc := discovery.EndpointsDiscoveryConfig{
 		LabelSelector: defaultLabelSelector,
 		Client:        s.K8s,
@@ -204,7 +398,19 @@ func (s *Scraper) buildDiscoverer() (discovery.EndpointsDiscoverer, chan<- struc
 
 	discoverer, stopCh, err := discovery.NewEndpointSliceDiscoverer(dc)
 	if err != nil {
-		return nil, nil, fmt.Errorf("creating EndpointSlice discoverer: %w", err)
+		return nil, nil, nil, fmt.Errorf("creating EndpointSlice discoverer: %w", err)
+	}
+
+	var targetsDiscoverer discovery.TargetsDiscoverer
+	if s.config.KSM.Sharding.Enabled || s.config.KSM.ClusterScopedOnly {
+		// NewEndpointSliceDiscoverer returns a TargetsDiscoverer as well, reuse it instead of starting more informers.
+		if td, ok := discoverer.(discovery.TargetsDiscoverer); ok {
+			targetsDiscoverer = &discovery.TargetsDiscovererWithTimeout{
+				TargetsDiscoverer: td,
+				BackoffDelay:      s.config.KSM.Discovery.BackoffDelay,
+				Timeout:           s.config.KSM.Discovery.Timeout,
+			}
+		}
 	}
 
 	return &discovery.EndpointsDiscovererWithTimeout{
@@ -212,7 +418,35 @@ func (s *Scraper) buildDiscoverer() (discovery.EndpointsDiscoverer, chan<- struc
 
 		BackoffDelay: s.config.KSM.Discovery.BackoffDelay,
 		Timeout:      s.config.KSM.Discovery.Timeout,
-	}, stopCh, nil
+	}, targetsDiscoverer, stopCh, nil
+}
+
+// buildPendingPodsLister returns a lister caching only the pending pods of the cluster, to get the reasons why they
+// could not be scheduled.
+func (s *Scraper) buildPendingPodsLister() (listersv1.PodLister, chan<- struct{}) {
+	return discovery.NewPodsLister(s.K8s, informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("status.phase", string(corev1.PodPending)).String()
+	}))
+}
+
+// buildKSMPodsLister returns a lister caching the KSM pods matching the discovery namespace and selector, to read
+// the shard each one serves and whether it is sharded per node.
+func (s *Scraper) buildKSMPodsLister() (listersv1.PodLister, chan<- struct{}) {
+	selector := defaultLabelSelector
+	if s.config.KSM.Selector != "" {
+		selector = s.config.KSM.Selector
+	}
+
+	options := []informers.SharedInformerOption{
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = selector
+		}),
+	}
+	if s.config.KSM.Namespace != "" {
+		options = append(options, informers.WithNamespace(s.config.KSM.Namespace))
+	}
+
+	return discovery.NewPodsLister(s.K8s, options...)
 }
 
 func (s *Scraper) ksmURLs() ([]string, error) {
@@ -221,24 +455,84 @@ func (s *Scraper) ksmURLs() ([]string, error) {
 		return []string{u}, nil
 	}
 
+	if s.config.KSM.ClusterScopedOnly && s.targetsDiscoverer != nil {
+		return s.clusterScopedURLs()
+	}
+
 	endpoints, err := s.endpointsDiscoverer.Discover()
 	if err != nil {
 		return nil, fmt.Errorf("discovering KSM endpoints: %w", err)
 	}
 
-	scheme := s.config.KSM.Scheme
-	if scheme == "" {
-		scheme = defaultScheme
-	}
-
 	urls := make([]string, 0, len(endpoints))
 	for _, endpoint := range endpoints {
-		urls = append(urls, (&url.URL{
-			Scheme: scheme,
-			Host:   endpoint,
-			Path:   ksmMetricsPath,
-		}).String())
+		urls = append(urls, s.ksmURL(endpoint))
 	}
 
 	return urls, nil
 }
+
+// discoverShards returns the discovered KSM endpoints along with the shard each one serves, sorted by shard index.
+func (s *Scraper) discoverShards() ([]shard, error) {
+	if s.targetsDiscoverer == nil {
+		return nil, fmt.Errorf("KSM sharding requires endpoint discovery")
+	}
+
+	targets, err := s.targetsDiscoverer.DiscoverTargets()
+	if err != nil {
+		return nil, fmt.Errorf("discovering KSM endpoints: %w", err)
+	}
+
+	shards := make([]shard, 0, len(targets))
+	seen := map[string]struct{}{}
+	for _, t := range targets {
+		sh := shard{url: s.ksmURL(t.Host), index: unknownShard, total: unknownShard}
+
+		if t.PodName != "" {
+			sh.pod = t.PodNamespace + "/" + t.PodName
+
+			// Pods exposing more than one port are discovered once per port but serve a single shard, so only the first
+			// port is scraped unless the KSM port is configured.
+			if _, ok := seen[sh.pod]; ok {
+				continue
+			}
+			seen[sh.pod] = struct{}{}
+
+			pod, err := s.ksmPodsLister.Pods(t.PodNamespace).Get(t.PodName)
+			if err != nil {
+				s.logger.Warnf("Getting KSM pod %q to find its shard: %v", sh.pod, err)
+			} else if s.config.KSM.ClusterScopedOnly && hasArg(pod, nodeArg) {
+				s.logger.Debugf("Ignoring KSM pod %q as it is sharded per node", sh.pod)
+				continue
+			} else {
+				sh.index, sh.total = podShard(pod, s.config.KSM.Sharding.IndexLabel)
+			}
+		}
+
+		shards = append(shards, sh)
+	}
+
+	// Endpoints with an unknown shard go last so known shards take precedence when merging.
+	sort.SliceStable(shards, func(i, j int) bool {
+		if shards[i].index == unknownShard || shards[j].index == unknownShard {
+			return shards[j].index == unknownShard && shards[i].index != unknownShard
+		}
+
+		return shards[i].index < shards[j].index
+	})
+
+	return shards, nil
+}
+
+func (s *Scraper) ksmURL(host string) string {
+	scheme := s.config.KSM.Scheme
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+
+	return (&url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   ksmMetricsPath,
+	}).String()
+}
@@ -0,0 +1,252 @@
+package ksm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/data"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+)
+
+const (
+	shardArg       = "--shard"
+	totalShardsArg = "--total-shards"
+	unknownShard   = -1
+)
+
+// shard is a discovered KSM endpoint along with the shard it serves.
+type shard struct {
+	url string
+	// pod is the namespace/name of the pod backing the endpoint, if known.
+	pod string
+	// index and total are unknownShard if they could not be determined from the pod.
+	index int
+	total int
+}
+
+func (s shard) String() string {
+	if s.pod != "" {
+		return fmt.Sprintf("%s (%s)", s.url, s.pod)
+	}
+
+	return s.url
+}
+
+// podShard returns the shard index and total number of shards of a KSM pod. They are read from the `--shard` and
+// `--total-shards` arguments of its containers, falling back to indexLabel for the index, which is what automatically
+// sharded StatefulSets rely on.
+func podShard(pod *corev1.Pod, indexLabel string) (index int, total int) {
+	index, total = unknownShard, unknownShard
+
+	for _, c := range pod.Spec.Containers {
+		args := append(append([]string{}, c.Command...), c.Args...)
+		if v, ok := argValue(args, shardArg); ok {
+			index = v
+		}
+		if v, ok := argValue(args, totalShardsArg); ok {
+			total = v
+		}
+	}
+
+	if index == unknownShard && indexLabel != "" {
+		if v, err := strconv.Atoi(pod.Labels[indexLabel]); err == nil && v >= 0 {
+			index = v
+		}
+	}
+
+	return index, total
+}
+
+// argValue looks for a non-negative integer flag in args, supporting both `--flag=value` and `--flag value` forms.
+func argValue(args []string, flag string) (int, bool) {
+	for i, arg := range args {
+		var raw string
+		switch {
+		case strings.HasPrefix(arg, flag+"="):
+			raw = strings.TrimPrefix(arg, flag+"=")
+		case arg == flag && i+1 < len(args):
+			raw = args[i+1]
+		default:
+			continue
+		}
+
+		if v, err := strconv.Atoi(raw); err == nil && v >= 0 {
+			return v, true
+		}
+	}
+
+	return 0, false
+}
+
+// shardReport holds the inconsistencies found in a set of discovered shards.
+type shardReport struct {
+	total      int
+	missing    []int
+	duplicates map[int][]string
+	unknown    []string
+}
+
+// checkShards compares the discovered shards against the expected total, which is taken from the `--total-shards`
+// argument of the pods, from configuredTotal, or from the number of distinct shards found, in that order.
+func checkShards(shards []shard, configuredTotal int) shardReport {
+	report := shardReport{duplicates: map[int][]string{}}
+
+	byIndex := map[int][]string{}
+	for _, s := range shards {
+		if s.index == unknownShard {
+			report.unknown = append(report.unknown, s.String())
+			continue
+		}
+
+		byIndex[s.index] = append(byIndex[s.index], s.String())
+		if s.total > report.total {
+			report.total = s.total
+		}
+	}
+
+	if report.total == 0 {
+		report.total = configuredTotal
+	}
+	if report.total == 0 {
+		report.total = len(byIndex)
+	}
+
+	for i := 0; i < report.total; i++ {
+		if _, ok := byIndex[i]; !ok {
+			report.missing = append(report.missing, i)
+		}
+	}
+
+	for index, endpoints := range byIndex {
+		if len(endpoints) > 1 {
+			report.duplicates[index] = endpoints
+		}
+	}
+
+	return report
+}
+
+// errors returns the inconsistencies in the report as a list of errors, sorted by shard index.
+func (r shardReport) errors() []error {
+	var errs []error
+
+	if len(r.missing) > 0 {
+		errs = append(errs, fmt.Errorf("missing KSM shards %v out of %d", r.missing, r.total))
+	}
+
+	indexes := make([]int, 0, len(r.duplicates))
+	for index := range r.duplicates {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		errs = append(errs, fmt.Errorf("KSM shard %d is served by more than one endpoint: %v", index, r.duplicates[index]))
+	}
+
+	if len(r.unknown) > 0 {
+		errs = append(errs, fmt.Errorf("could not determine the shard of KSM endpoints %v", r.unknown))
+	}
+
+	return errs
+}
+
+// shardedGrouper is a data.Grouper that groups the data of several KSM shards concurrently and merges it, so entities
+// reported by more than one shard are populated only once.
+type shardedGrouper struct {
+	logger   *log.Logger
+	shards   []shard
+	groupers []data.Grouper
+}
+
+type shardResult struct {
+	groups definition.RawGroups
+	errs   *data.ErrorGroup
+}
+
+// Group implements data.Grouper. Shards are merged in order, so when an entity is reported by several shards the
+// metrics of the first one take precedence. Failing shards are reported as recoverable errors as long as at least one
+// shard succeeds.
+func (g *shardedGrouper) Group(specGroups definition.SpecGroups) (definition.RawGroups, *data.ErrorGroup) {
+	results := make([]shardResult, len(g.groupers))
+
+	var wg sync.WaitGroup
+	for i, grouper := range g.groupers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			groups, errs := grouper.Group(specGroups)
+			results[i] = shardResult{groups: groups, errs: errs}
+		}()
+	}
+	wg.Wait()
+
+	merged := definition.RawGroups{}
+	errGroup := &data.ErrorGroup{Recoverable: true}
+	succeeded, duplicates := 0, 0
+
+	for i, r := range results {
+		if r.errs != nil {
+			for _, err := range r.errs.Errors {
+				errGroup.Append(fmt.Errorf("shard %s: %w", g.shards[i], err))
+			}
+
+			if !r.errs.Recoverable {
+				continue
+			}
+		}
+
+		succeeded++
+		duplicates += mergeRawGroups(merged, r.groups)
+	}
+
+	if duplicates > 0 {
+		g.logger.Debugf("Dropped %d entities reported by more than one KSM shard", duplicates)
+	}
+
+	if succeeded == 0 {
+		errGroup.Recoverable = false
+	}
+
+	if len(errGroup.Errors) > 0 {
+		return merged, errGroup
+	}
+
+	return merged, nil
+}
+
+// mergeRawGroups adds the entities in src to dst. Metrics of entities already present in dst are only added if dst
+// does not have them already. It returns the number of entities that were already present.
+func mergeRawGroups(dst, src definition.RawGroups) int {
+	duplicates := 0
+
+	for groupLabel, entities := range src {
+		dstEntities, ok := dst[groupLabel]
+		if !ok {
+			dstEntities = make(map[string]definition.RawMetrics, len(entities))
+			dst[groupLabel] = dstEntities
+		}
+
+		for entityID, metrics := range entities {
+			dstMetrics, ok := dstEntities[entityID]
+			if !ok {
+				dstEntities[entityID] = metrics
+				continue
+			}
+
+			duplicates++
+			for name, value := range metrics {
+				if _, ok := dstMetrics[name]; !ok {
+					dstMetrics[name] = value
+				}
+			}
+		}
+	}
+
+	return duplicates
+}
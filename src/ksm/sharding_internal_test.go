@@ -0,0 +1,251 @@
+package ksm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/src/data"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+)
+
+func TestPodShard(t *testing.T) {
+	t.Parallel()
+
+	const indexLabel = "apps.kubernetes.io/pod-index"
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		expectedIndex int
+		expectedTotal int
+	}{
+		{
+			name: "from_args_with_equals",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Args: []string{"--port=8080", "--shard=1", "--total-shards=3"}},
+			}}},
+			expectedIndex: 1,
+			expectedTotal: 3,
+		},
+		{
+			name: "from_separate_args",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Command: []string{"/kube-state-metrics", "--shard", "2"}, Args: []string{"--total-shards", "4"}},
+			}}},
+			expectedIndex: 2,
+			expectedTotal: 4,
+		},
+		{
+			name: "from_label",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{indexLabel: "0"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Args: []string{"--pod=$(POD_NAME)"}}}},
+			},
+			expectedIndex: 0,
+			expectedTotal: unknownShard,
+		},
+		{
+			name: "args_take_precedence_over_label",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{indexLabel: "0"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Args: []string{"--shard=1"}}}},
+			},
+			expectedIndex: 1,
+			expectedTotal: unknownShard,
+		},
+		{
+			name: "unsharded",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Args: []string{"--shard=invalid"}},
+			}}},
+			expectedIndex: unknownShard,
+			expectedTotal: unknownShard,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			index, total := podShard(tc.pod, indexLabel)
+			assert.Equal(t, tc.expectedIndex, index)
+			assert.Equal(t, tc.expectedTotal, total)
+		})
+	}
+}
+
+func TestCheckShards(t *testing.T) {
+	t.Parallel()
+
+	t.Run("consistent", func(t *testing.T) {
+		t.Parallel()
+
+		report := checkShards([]shard{
+			{url: "a", index: 0, total: 2},
+			{url: "b", index: 1, total: 2},
+		}, 0)
+		assert.Empty(t, report.errors())
+	})
+
+	t.Run("missing_and_duplicated", func(t *testing.T) {
+		t.Parallel()
+
+		report := checkShards([]shard{
+			{url: "a", index: 0, total: 3},
+			{url: "b", index: 0, total: 3},
+			{url: "c", index: 2, total: 3},
+			{url: "d", index: unknownShard, total: unknownShard},
+		}, 0)
+
+		assert.Equal(t, 3, report.total)
+		assert.Equal(t, []int{1}, report.missing)
+		assert.Equal(t, map[int][]string{0: {"a", "b"}}, report.duplicates)
+		assert.Equal(t, []string{"d"}, report.unknown)
+		assert.Len(t, report.errors(), 3)
+	})
+
+	t.Run("uses_configured_total", func(t *testing.T) {
+		t.Parallel()
+
+		report := checkShards([]shard{
+			{url: "a", index: 0, total: unknownShard},
+		}, 2)
+		assert.Equal(t, []int{1}, report.missing)
+	})
+}
+
+type fakeTargetsDiscoverer []discovery.Target
+
+func (f fakeTargetsDiscoverer) DiscoverTargets() ([]discovery.Target, error) {
+	return f, nil
+}
+
+func TestScraper_DiscoverShards(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(
+		ksmPod("ksm-0", []string{"--shard=0", "--total-shards=2"}),
+		ksmPod("ksm-1", []string{"--shard=1", "--total-shards=2"}),
+	)
+
+	c := &config.Config{}
+	c.KSM.Sharding.Enabled = true
+
+	s := &Scraper{
+		Providers: Providers{K8s: client},
+		logger:    logutil.Discard,
+		config:    c,
+		// Both pods expose the metrics and telemetry ports, and there is an endpoint whose pod is gone.
+		targetsDiscoverer: fakeTargetsDiscoverer{
+			{Host: "10.0.0.1:8080", PodNamespace: "kube-system", PodName: "ksm-1"},
+			{Host: "10.0.0.1:8081", PodNamespace: "kube-system", PodName: "ksm-1"},
+			{Host: "10.0.0.2:8080", PodNamespace: "kube-system", PodName: "ksm-0"},
+			{Host: "10.0.0.2:8081", PodNamespace: "kube-system", PodName: "ksm-0"},
+			{Host: "10.0.0.3:8080", PodNamespace: "kube-system", PodName: "ksm-2"},
+		},
+	}
+
+	lister, closer := s.buildKSMPodsLister()
+	t.Cleanup(func() { close(closer) })
+	s.ksmPodsLister = lister
+
+	actions := len(client.Actions())
+
+	shards, err := s.discoverShards()
+	require.NoError(t, err)
+	assert.Equal(t, []shard{
+		{url: "http://10.0.0.2:8080/metrics", pod: "kube-system/ksm-0", index: 0, total: 2},
+		{url: "http://10.0.0.1:8080/metrics", pod: "kube-system/ksm-1", index: 1, total: 2},
+		{url: "http://10.0.0.3:8080/metrics", pod: "kube-system/ksm-2", index: unknownShard, total: unknownShard},
+	}, shards)
+	assert.Empty(t, checkShards(shards, 0).duplicates)
+	assert.Len(t, client.Actions(), actions, "pods must be read from the cache")
+}
+
+type fakeGrouper struct {
+	groups definition.RawGroups
+	errs   *data.ErrorGroup
+}
+
+func (f fakeGrouper) Group(definition.SpecGroups) (definition.RawGroups, *data.ErrorGroup) {
+	return f.groups, f.errs
+}
+
+func TestShardedGrouper(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merges_and_dedupes_entities", func(t *testing.T) {
+		t.Parallel()
+
+		g := &shardedGrouper{
+			logger: logutil.Discard,
+			shards: []shard{{url: "a", index: 0}, {url: "b", index: 1}},
+			groupers: []data.Grouper{
+				fakeGrouper{groups: definition.RawGroups{
+					"pod": {"default_a": {"kube_pod_info": 1}},
+				}},
+				fakeGrouper{groups: definition.RawGroups{
+					"pod": {
+						"default_a": {"kube_pod_info": 2, "kube_pod_created": 3},
+						"default_b": {"kube_pod_info": 4},
+					},
+					"deployment": {"default_d": {"kube_deployment_created": 5}},
+				}},
+			},
+		}
+
+		groups, errs := g.Group(nil)
+		require.Nil(t, errs)
+		assert.Equal(t, definition.RawGroups{
+			"pod": {
+				"default_a": {"kube_pod_info": 1, "kube_pod_created": 3},
+				"default_b": {"kube_pod_info": 4},
+			},
+			"deployment": {"default_d": {"kube_deployment_created": 5}},
+		}, groups)
+	})
+
+	t.Run("failing_shard_is_recoverable", func(t *testing.T) {
+		t.Parallel()
+
+		g := &shardedGrouper{
+			logger: logutil.Discard,
+			shards: []shard{{url: "a", index: 0}, {url: "b", index: 1}},
+			groupers: []data.Grouper{
+				fakeGrouper{groups: definition.RawGroups{"pod": {"default_a": {"kube_pod_info": 1}}}},
+				fakeGrouper{errs: &data.ErrorGroup{Errors: []error{errors.New("connection refused")}}},
+			},
+		}
+
+		groups, errs := g.Group(nil)
+		require.NotNil(t, errs)
+		assert.True(t, errs.Recoverable)
+		assert.Len(t, errs.Errors, 1)
+		assert.Contains(t, groups, "pod")
+	})
+
+	t.Run("all_shards_failing_is_not_recoverable", func(t *testing.T) {
+		t.Parallel()
+
+		g := &shardedGrouper{
+			logger: logutil.Discard,
+			shards: []shard{{url: "a", index: 0}},
+			groupers: []data.Grouper{
+				fakeGrouper{errs: &data.ErrorGroup{Errors: []error{errors.New("connection refused")}}},
+			},
+		}
+
+		_, errs := g.Group(nil)
+		require.NotNil(t, errs)
+		assert.False(t, errs.Recoverable)
+	})
+}
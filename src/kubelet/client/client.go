@@ -23,10 +23,11 @@ const (
 
 // Client implements a client for Kubelet, capable of retrieving prometheus metrics from a given endpoint.
 type Client struct {
-	logger   *log.Logger
-	doer     client.HTTPDoer
-	endpoint url.URL
-	retries  int
+	logger       *log.Logger
+	doer         client.HTTPDoer
+	endpoint     url.URL
+	retries      int
+	acceptHeader string
 }
 
 type OptionFunc func(kc *Client) error
@@ -47,10 +48,20 @@ func WithMaxRetries(retries int) OptionFunc {
 	}
 }
 
+// WithTextFormat returns an OptionFunc making the client only accept the plain text exposition format, instead of
+// preferring the protobuf one.
+func WithTextFormat() OptionFunc {
+	return func(kubeletClient *Client) error {
+		kubeletClient.acceptHeader = prometheus.TextAcceptHeader
+		return nil
+	}
+}
+
 // New builds a Client using the given options.
 func New(connector Connector, opts ...OptionFunc) (*Client, error) {
 	c := &Client{
-		logger: logutil.Discard,
+		logger:       logutil.Discard,
+		acceptHeader: prometheus.AcceptHeader,
 	}
 
 	for i, opt := range opts {
@@ -121,7 +132,7 @@ func (client *Client) MetricFamiliesGetFunc(url string) prometheus.FetchAndFilte
 		e := client.endpoint
 		e.Path = path.Join(client.endpoint.Path, url)
 
-		mFamily, err := prometheus.GetFilteredMetricFamilies(client.doer, e.String(), queries, client.logger)
+		mFamily, err := prometheus.GetFilteredMetricFamilies(client.doer, e.String(), client.acceptHeader, queries, client.logger)
 		if err != nil {
 			return nil, fmt.Errorf("getting filtered metric families %q: %w", e.String(), err)
 		}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/newrelic/nri-kubernetes/v3/internal/config"
 	"github.com/newrelic/nri-kubernetes/v3/src/kubelet/client"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
 )
 
 const (
@@ -74,7 +75,7 @@ func TestClientCalls(t *testing.T) {
 
 		r, found := requests[prometheusMetric]
 		assert.True(t, found)
-		assert.Equal(t, "text/plain", r.Header["Accept"][0])
+		assert.Equal(t, prometheus.AcceptHeader, r.Header["Accept"][0])
 	})
 }
 
@@ -169,7 +170,7 @@ func TestClientCallsViaAPIProxy(t *testing.T) {
 		r, found := requests[path.Join(apiProxy, prometheusMetric)]
 		assert.True(t, found)
 
-		assert.Equal(t, "text/plain", r.Header["Accept"][0])
+		assert.Equal(t, prometheus.AcceptHeader, r.Header["Accept"][0])
 	})
 
 	t.Run("do_not_hit_prometheus_endpoint", func(t *testing.T) {
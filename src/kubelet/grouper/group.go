@@ -24,6 +24,23 @@ type Config struct {
 	Client                  client.HTTPGetter
 	Fetchers                []data.FetchFunc
 	DefaultNetworkInterface string
+	// DefaultNetworkFamily is the address family of the default route, reported in the node when known.
+	DefaultNetworkFamily string
+	// PodNetworkInterfaces enables grouping the metrics of each network interface of pods on their own.
+	PodNetworkInterfaces bool
+	// UsageFetcher, if set, is used instead of the /stats/summary endpoint to get the usage of the node, pods and
+	// containers. It must return a single entity in the node group, keyed by the name of the node.
+	UsageFetcher data.FetchFunc
+	// KubeletMetricsFetcher, if set, is used to get the health metrics of the kubelet, which are added to the node. It
+	// handles its own errors, as nodes are reported without these metrics if they cannot be fetched.
+	KubeletMetricsFetcher func() definition.RawMetrics
+	// NamespaceLister is used to get the labels of namespaces in NamespaceLabels. It must be set if NamespaceLabels
+	// is not empty.
+	NamespaceLister listersv1.NamespaceLister
+	// NamespaceLabels are the labels of namespaces added to the pods, containers and volumes in them.
+	NamespaceLabels []string
+	// NodeLabels are the labels of the node added to its pods, containers and volumes.
+	NodeLabels []string
 }
 
 type OptionFunc func(kc *grouper) error
@@ -42,6 +59,10 @@ func New(config Config, opts ...OptionFunc) (data.Grouper, error) {
 		return nil, fmt.Errorf("NodeGetter must be set")
 	}
 
+	if len(config.NamespaceLabels) > 0 && config.NamespaceLister == nil {
+		return nil, fmt.Errorf("NamespaceLister must be set to propagate namespace labels")
+	}
+
 	g := &grouper{
 		Config: config,
 		logger: logutil.Discard,
@@ -79,25 +100,14 @@ func (r *grouper) Group(definition.SpecGroups) (definition.RawGroups, *data.Erro
 		fillGroupsAndMergeNonExistent(rawGroups, g)
 	}
 
-	// TODO wrap this process in a new fetchFunc
-	response, err := metric.GetMetricsData(r.Client)
-	if err != nil {
-		return nil, &data.ErrorGroup{
-			Errors: []error{fmt.Errorf("error querying Kubelet. %s", err)},
-		}
-	}
-
-	resources, errs := metric.GroupStatsSummary(response)
-	if len(errs) > 0 {
-		return nil, &data.ErrorGroup{
-			Recoverable: true,
-			Errors:      errs,
-		}
+	resources, nodeName, errGroup := r.usage()
+	if errGroup != nil {
+		return nil, errGroup
 	}
 
 	fillGroupsAndMergeNonExistent(rawGroups, resources)
 
-	node, err := r.NodeGetter.Get(response.Node.NodeName)
+	node, err := r.NodeGetter.Get(nodeName)
 	if err != nil {
 		return nil, &data.ErrorGroup{
 			Errors: []error{fmt.Errorf("error querying ApiServer: %v", err)},
@@ -108,6 +118,11 @@ func (r *grouper) Group(definition.SpecGroups) (definition.RawGroups, *data.Erro
 
 	if _, ok := rawGroups["container"]; ok {
 		for _, container := range rawGroups["container"] {
+			// Init containers run to completion before app containers start, so their requests are not added up.
+			if container["containerType"] == metric.ContainerTypeInit {
+				continue
+			}
+
 			if containerMemoryRequestedBytes, ok := container["memoryRequestedBytes"]; ok {
 				// if this map key exist, it's Quantity.MilliValue() (int64)
 				requestedMemoryBytes += containerMemoryRequestedBytes.(int64)
@@ -150,7 +165,7 @@ func (r *grouper) Group(definition.SpecGroups) (definition.RawGroups, *data.Erro
 
 	g := definition.RawGroups{
 		"node": {
-			response.Node.NodeName: definition.RawMetrics{
+			nodeName: definition.RawMetrics{
 				"labels":               node.Labels,
 				"allocatable":          node.Status.Allocatable,
 				"capacity":             node.Status.Capacity,
@@ -163,11 +178,132 @@ func (r *grouper) Group(definition.SpecGroups) (definition.RawGroups, *data.Erro
 			},
 		},
 	}
+	if r.KubeletMetricsFetcher != nil {
+		for k, v := range r.KubeletMetricsFetcher() {
+			g["node"][nodeName][k] = v
+		}
+	}
+
+	if r.DefaultNetworkFamily != "" {
+		g["node"][nodeName]["defaultNetworkInterfaceFamily"] = r.DefaultNetworkFamily
+	}
 	fillGroupsAndMergeNonExistent(rawGroups, g)
 
+	r.propagateLabels(rawGroups, node)
+
+	if r.PodNetworkInterfaces {
+		if network := metric.GroupPodNetwork(rawGroups["pod"]); len(network) > 0 {
+			rawGroups[metric.PodNetworkGroup] = network
+		}
+	}
+
 	return rawGroups, nil
 }
 
+// propagateLabels adds the selected labels of the node, and of the namespace of each entity, to the pods, containers
+// and volumes, so they can be reported along their own labels.
+func (r *grouper) propagateLabels(rawGroups definition.RawGroups, node *v1.Node) {
+	if len(r.NamespaceLabels) == 0 && len(r.NodeLabels) == 0 {
+		return
+	}
+
+	nodeLabels := selectLabels(node.Labels, r.NodeLabels)
+	namespaceLabels := map[string]map[string]string{}
+
+	for _, group := range []string{"pod", "container", "volume"} {
+		for _, entity := range rawGroups[group] {
+			if len(nodeLabels) > 0 {
+				entity["nodeLabels"] = nodeLabels
+			}
+
+			namespace, _ := entity["namespace"].(string)
+			if len(r.NamespaceLabels) == 0 || namespace == "" {
+				continue
+			}
+
+			labels, ok := namespaceLabels[namespace]
+			if !ok {
+				labels = r.namespaceLabels(namespace)
+				namespaceLabels[namespace] = labels
+			}
+
+			if len(labels) > 0 {
+				entity["namespaceLabels"] = labels
+			}
+		}
+	}
+}
+
+// namespaceLabels returns the selected labels of a namespace, or nil if it cannot be found.
+func (r *grouper) namespaceLabels(name string) map[string]string {
+	namespace, err := r.NamespaceLister.Get(name)
+	if err != nil {
+		r.logger.Debugf("Getting namespace %q to propagate its labels: %v", name, err)
+		return nil
+	}
+
+	return selectLabels(namespace.Labels, r.NamespaceLabels)
+}
+
+// selectLabels returns the labels with the given keys.
+func selectLabels(labels map[string]string, keys []string) map[string]string {
+	selected := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := labels[key]; ok {
+			selected[key] = value
+		}
+	}
+
+	return selected
+}
+
+// usage returns the node, pod and container groups with their usage, and the name of the node, taken either from the
+// UsageFetcher or from the /stats/summary endpoint.
+func (r *grouper) usage() (definition.RawGroups, string, *data.ErrorGroup) {
+	if r.UsageFetcher != nil {
+		resources, err := r.UsageFetcher()
+		if err != nil {
+			if _, ok := err.(data.ErrorGroup); !ok {
+				return nil, "", &data.ErrorGroup{
+					Errors: []error{fmt.Errorf("error querying Kubelet. %s", err)},
+				}
+			}
+			r.logger.Debugf("Errors fetching usage from Kubelet: %v", err)
+		}
+
+		if len(resources["node"]) != 1 {
+			return nil, "", &data.ErrorGroup{
+				Errors: []error{fmt.Errorf("expected usage for a single node, got %d", len(resources["node"]))},
+			}
+		}
+
+		var nodeName string
+		for name := range resources["node"] {
+			nodeName = name
+		}
+
+		return resources, nodeName, nil
+	}
+
+	// TODO wrap this process in a new fetchFunc
+	response, err := metric.GetMetricsData(r.Client)
+	if err != nil {
+		return nil, "", &data.ErrorGroup{
+			Errors: []error{fmt.Errorf("error querying Kubelet. %s", err)},
+		}
+	}
+
+	resources, errs := metric.GroupStatsSummary(response)
+	if len(errs) > 0 {
+		return nil, "", &data.ErrorGroup{
+			Recoverable: true,
+			Errors:      errs,
+		}
+	}
+
+	return resources, response.Node.NodeName, nil
+}
+
 // Count the number of pods in a 'Running' state for the current node.
 func (r *grouper) countRunningPods(rawGroups definition.RawGroups) int {
 	runningPodsCount := 0
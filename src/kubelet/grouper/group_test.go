@@ -102,6 +102,7 @@ func TestGroup(t *testing.T) {
 	podsFetcher := metric.NewBasicPodsFetcher(
 		log.StandardLogger(),
 		&c,
+		metric.WithOwnerResolver(testdata.OwnerResolver),
 	)
 
 	kubeletClient, err := client.New(client.StaticConnector(&c, url.URL{}), client.WithMaxRetries(3))
@@ -116,6 +117,7 @@ func TestGroup(t *testing.T) {
 				metric.CadvisorFetchFunc(kubeletClient.MetricFamiliesGetFunc(metric.KubeletCAdvisorMetricsPath), queries),
 			},
 			DefaultNetworkInterface: "eth0",
+			DefaultNetworkFamily:    "ipv4",
 		},
 	)
 	assert.Nil(t, err)
@@ -202,3 +204,56 @@ func getNode() *v1.Node {
 		},
 	}
 }
+
+func TestPropagateLabels(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "payments",
+			Labels: map[string]string{"cost-center": "cc-42", "team": "checkout", "other": "ignored"},
+		},
+	})
+	namespaceLister, closer := discovery.NewNamespaceLister(k8sClient)
+	defer close(closer)
+
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"topology.kubernetes.io/zone": "us-east-1a", "kubernetes.io/os": "linux"},
+		},
+	}
+
+	g := &grouper{
+		Config: Config{
+			NamespaceLister: namespaceLister,
+			NamespaceLabels: []string{"cost-center", "team", "missing"},
+			NodeLabels:      []string{"topology.kubernetes.io/zone"},
+		},
+		logger: log.StandardLogger(),
+	}
+
+	rawGroups := definition.RawGroups{
+		"pod":       {"payments_api": {"namespace": "payments"}},
+		"container": {"payments_api_app": {"namespace": "payments"}},
+		"volume":    {"payments_api_data": {"namespace": "unknown"}},
+		"node":      {"node": {}},
+	}
+
+	g.propagateLabels(rawGroups, node)
+
+	expectedNamespaceLabels := map[string]string{"cost-center": "cc-42", "team": "checkout"}
+	expectedNodeLabels := map[string]string{"topology.kubernetes.io/zone": "us-east-1a"}
+
+	assert.Equal(t, expectedNamespaceLabels, rawGroups["pod"]["payments_api"]["namespaceLabels"])
+	assert.Equal(t, expectedNodeLabels, rawGroups["pod"]["payments_api"]["nodeLabels"])
+	assert.Equal(t, expectedNamespaceLabels, rawGroups["container"]["payments_api_app"]["namespaceLabels"])
+	assert.Equal(t, expectedNodeLabels, rawGroups["volume"]["payments_api_data"]["nodeLabels"])
+	assert.NotContains(t, rawGroups["volume"]["payments_api_data"], "namespaceLabels")
+	assert.NotContains(t, rawGroups["node"]["node"], "nodeLabels")
+}
+
+func TestNew_NamespaceLabelsRequireLister(t *testing.T) {
+	nodeGetter, closer := discovery.NewNodeLister(fake.NewSimpleClientset())
+	defer close(closer)
+
+	_, err := New(Config{NodeGetter: nodeGetter, NamespaceLabels: []string{"team"}})
+	assert.Error(t, err)
+}
@@ -5,13 +5,17 @@ package kubelet_test
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/newrelic/infra-integrations-sdk/integration"
 	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 
 	"github.com/newrelic/nri-kubernetes/v3/internal/testutil/asserter"
 	"github.com/newrelic/nri-kubernetes/v3/internal/testutil/asserter/exclude"
@@ -70,6 +74,9 @@ func TestScraper(t *testing.T) {
 
 			scraper, err := kubelet.NewScraper(&config.Config{
 				ClusterName: t.Name(),
+				Kubelet: config.Kubelet{
+					PodNetworkInterfaces: true,
+				},
 			}, kubelet.Providers{
 				K8s:      fakeK8s,
 				Kubelet:  kubeletClient,
@@ -131,6 +138,196 @@ func TestScraper_FilterNamespace(t *testing.T) {
 	})
 }
 
+func TestScraper_SaturationMetrics(t *testing.T) {
+	for _, version := range testutil.AllVersions() {
+		// PSI is only reported by default since Kubernetes 1.34.
+		if testutil.IsBelow(version, testutil.Testdata134) {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("for_version_%s", version), func(t *testing.T) {
+			t.Parallel()
+
+			testServer, err := version.Server()
+			require.NoError(t, err)
+
+			u, _ := url.Parse(testServer.KubeletEndpoint())
+
+			kubeletClient, err := kubeletClient.New(
+				kubeletClient.StaticConnector(&http.Client{}, *u),
+				kubeletClient.WithMaxRetries(3),
+			)
+			require.NoError(t, err)
+
+			k8sData, err := version.K8s()
+			require.NoError(t, err)
+
+			scraper, err := kubelet.NewScraper(&config.Config{
+				ClusterName: t.Name(),
+			}, kubelet.Providers{
+				K8s:      fake.NewSimpleClientset(k8sData.Everything()...),
+				Kubelet:  kubeletClient,
+				CAdvisor: kubeletClient,
+			})
+			require.NoError(t, err)
+
+			i := testutil.NewIntegration(t)
+			require.NoError(t, scraper.Run(i))
+
+			found := map[string][]string{}
+			for _, e := range i.Entities {
+				for _, ms := range e.Metrics {
+					eventType, _ := ms.Metrics["event_type"].(string)
+					for _, name := range []string{"cpuPressureSomeAvg10", "memoryPressureFullAvg60", "ioPressureSomeAvg300", "swapUsedBytes"} {
+						if _, ok := ms.Metrics[name]; ok {
+							found[eventType] = append(found[eventType], name)
+						}
+					}
+				}
+			}
+
+			for _, eventType := range []string{"K8sNodeSample", "K8sPodSample", "K8sContainerSample"} {
+				assert.Subset(t, found[eventType], []string{"cpuPressureSomeAvg10", "memoryPressureFullAvg60", "ioPressureSomeAvg300", "swapUsedBytes"}, eventType)
+			}
+		})
+	}
+}
+
+func TestScraper_HealthMetricsFailure(t *testing.T) {
+	t.Parallel()
+
+	version := testutil.LatestVersion()
+	testServer, err := version.Server()
+	require.NoError(t, err)
+
+	// The kubelet metrics endpoint is forbidden, like when the service account lacks the nodes/metrics permission.
+	forbidden := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/kubelet/metrics" {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		testServer.Config.Handler.ServeHTTP(rw, r)
+	}))
+	t.Cleanup(forbidden.Close)
+
+	u, _ := url.Parse(forbidden.URL + "/kubelet")
+
+	kubeletClient, err := kubeletClient.New(
+		kubeletClient.StaticConnector(&http.Client{}, *u),
+		kubeletClient.WithMaxRetries(3),
+	)
+	require.NoError(t, err)
+
+	k8sData, err := version.K8s()
+	require.NoError(t, err)
+
+	logger, hook := logtest.NewNullLogger()
+	logger.SetLevel(log.DebugLevel)
+
+	scraper, err := kubelet.NewScraper(&config.Config{
+		ClusterName: t.Name(),
+		Kubelet:     config.Kubelet{HealthMetrics: true},
+	}, kubelet.Providers{
+		K8s:      fake.NewSimpleClientset(k8sData.Everything()...),
+		Kubelet:  kubeletClient,
+		CAdvisor: kubeletClient,
+	}, kubelet.WithLogger(logger))
+	require.NoError(t, err)
+
+	levels := map[log.Level]int{}
+	for n := 0; n < 3; n++ {
+		i := testutil.NewIntegration(t)
+		require.NoError(t, scraper.Run(i), "nodes must be reported without health metrics")
+	}
+	for _, entry := range hook.AllEntries() {
+		if strings.Contains(entry.Message, "kubelet health metrics") {
+			levels[entry.Level]++
+		}
+	}
+
+	assert.Equal(t, map[log.Level]int{log.WarnLevel: 1, log.DebugLevel: 2}, levels, "only the first failure must be logged as a warning")
+}
+
+func TestScraper_ProbeMetrics(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name         string
+		probeMetrics bool
+		requests     int
+		levels       map[log.Level]int
+	}{
+		{
+			name:         "enabled",
+			probeMetrics: true,
+			requests:     3,
+			levels:       map[log.Level]int{log.WarnLevel: 1, log.DebugLevel: 2},
+		},
+		{
+			name:         "disabled",
+			probeMetrics: false,
+			requests:     0,
+			levels:       map[log.Level]int{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			version := testutil.LatestVersion()
+			testServer, err := version.Server()
+			require.NoError(t, err)
+
+			// The kubelet probes endpoint is forbidden, like when the service account lacks the nodes/metrics permission.
+			var requests atomic.Int32
+			forbidden := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/kubelet/metrics/probes" {
+					requests.Add(1)
+					rw.WriteHeader(http.StatusForbidden)
+					return
+				}
+				testServer.Config.Handler.ServeHTTP(rw, r)
+			}))
+			t.Cleanup(forbidden.Close)
+
+			u, _ := url.Parse(forbidden.URL + "/kubelet")
+
+			kubeletClient, err := kubeletClient.New(kubeletClient.StaticConnector(&http.Client{}, *u))
+			require.NoError(t, err)
+
+			k8sData, err := version.K8s()
+			require.NoError(t, err)
+
+			logger, hook := logtest.NewNullLogger()
+			logger.SetLevel(log.DebugLevel)
+
+			scraper, err := kubelet.NewScraper(&config.Config{
+				ClusterName: t.Name(),
+				Kubelet:     config.Kubelet{ProbeMetrics: tc.probeMetrics},
+			}, kubelet.Providers{
+				K8s:      fake.NewSimpleClientset(k8sData.Everything()...),
+				Kubelet:  kubeletClient,
+				CAdvisor: kubeletClient,
+			}, kubelet.WithLogger(logger))
+			require.NoError(t, err)
+
+			for n := 0; n < 3; n++ {
+				i := testutil.NewIntegration(t)
+				require.NoError(t, scraper.Run(i), "nodes must be reported without probe metrics")
+			}
+
+			levels := map[log.Level]int{}
+			for _, entry := range hook.AllEntries() {
+				if strings.Contains(entry.Message, "kubelet probe metrics") {
+					levels[entry.Level]++
+				}
+			}
+
+			assert.Equal(t, tc.levels, levels, "only the first failure must be logged as a warning")
+			assert.Equal(t, int32(tc.requests), requests.Load(), "probes must only be requested once per run when enabled")
+		})
+	}
+}
+
 // kubeletExclusions is a helper that returns all the exclusions needed to assert the kubelet metrics without getting
 // false negatives.
 func kubeletExclusions() []exclude.Func {
@@ -0,0 +1,197 @@
+package metric
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	model "github.com/prometheus/client_model/go"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/data"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+const (
+	// KubeletMetricsPath is the path where kubelet serves its own metrics.
+	KubeletMetricsPath = "/metrics"
+
+	plegRelistDurationSeconds = "kubelet_pleg_relist_duration_seconds"
+	plegLastSeenSeconds       = "kubelet_pleg_last_seen_seconds"
+	podStartDurationSeconds   = "kubelet_pod_start_duration_seconds"
+	runtimeOperationsErrors   = "kubelet_runtime_operations_errors_total"
+	evictions                 = "kubelet_evictions"
+	volumeManagerTotalVolumes = "volume_manager_total_volumes"
+)
+
+// KubeletQueries are the queries done to the kubelet metrics endpoint.
+var KubeletQueries = []prometheus.Query{ //nolint: gochecknoglobals // read-only list.
+	{MetricName: plegRelistDurationSeconds, Histogram: true},
+	{MetricName: plegLastSeenSeconds},
+	{MetricName: podStartDurationSeconds, Histogram: true},
+	{MetricName: runtimeOperationsErrors},
+	{MetricName: evictions},
+	{MetricName: volumeManagerTotalVolumes},
+}
+
+// kubeletHistograms maps the histograms reported by the fetcher to the prefix of their raw metrics.
+var kubeletHistograms = map[string]string{ //nolint: gochecknoglobals // read-only map.
+	plegRelistDurationSeconds: "kubeletPlegRelistDurationSeconds",
+	podStartDurationSeconds:   "kubeletPodStartDurationSeconds",
+}
+
+// kubeletQuantiles are the quantiles reported for each histogram, by the suffix of their raw metric.
+var kubeletQuantiles = map[string]float64{ //nolint: gochecknoglobals // read-only map.
+	"P50": 0.5,
+	"P99": 0.99,
+}
+
+// volumeManagerStates maps the states of the volume manager to the raw metric holding the number of volumes in it.
+var volumeManagerStates = map[string]string{ //nolint: gochecknoglobals // read-only map.
+	"desired_state_of_world": "kubeletVolumeManagerDesiredVolumes",
+	"actual_state_of_world":  "kubeletVolumeManagerActualVolumes",
+}
+
+// KubeletMetricsFetcher fetches the health metrics of the kubelet itself, to be reported in the node it runs in.
+// Quantiles are computed over the observations since the previous fetch, so they reflect the recent behavior of the
+// kubelet and not the one since it started.
+type KubeletMetricsFetcher struct {
+	fetch   prometheus.FetchAndFilterMetricsFamilies
+	queries []prometheus.Query
+	now     func() time.Time
+
+	lock     sync.Mutex
+	previous map[string][]prometheus.Bucket
+}
+
+// NewKubeletMetricsFetcher returns a KubeletMetricsFetcher getting metric families with fetch.
+func NewKubeletMetricsFetcher(fetch prometheus.FetchAndFilterMetricsFamilies, queries []prometheus.Query) *KubeletMetricsFetcher {
+	return &KubeletMetricsFetcher{
+		fetch:    fetch,
+		queries:  queries,
+		now:      time.Now,
+		previous: map[string][]prometheus.Bucket{},
+	}
+}
+
+// DoKubeletMetricsFetch returns the raw metrics of the kubelet.
+func (f *KubeletMetricsFetcher) DoKubeletMetricsFetch() (definition.RawMetrics, error) {
+	families, err := f.fetch(f.queries)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting kubelet metrics endpoint: %w", err)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	r := definition.RawMetrics{}
+	var errs []error
+
+	for _, family := range families {
+		if prefix, ok := kubeletHistograms[family.Name]; ok {
+			f.addQuantiles(r, family, prefix)
+			continue
+		}
+
+		for _, m := range family.Metrics {
+			value, err := floatValue(m.Value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("parsing %s value: %w", family.Name, err))
+				continue
+			}
+
+			switch family.Name {
+			case plegLastSeenSeconds:
+				r["kubeletPlegLastSeenAgeSeconds"] = f.now().Sub(time.Unix(0, int64(value*nanosecondsPerSecond))).Seconds()
+			case runtimeOperationsErrors:
+				addFloat(r, "kubeletRuntimeOperationsErrors", value)
+			case evictions:
+				addFloat(r, "kubeletEvictions", value)
+			case volumeManagerTotalVolumes:
+				if key, ok := volumeManagerStates[m.Labels["state"]]; ok {
+					addFloat(r, key, value)
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return r, data.ErrorGroup{
+			Errors:      errs,
+			Recoverable: true,
+		}
+	}
+
+	return r, nil
+}
+
+// addQuantiles adds the quantiles of the observations of a histogram since the previous fetch, or since the kubelet
+// started on the first fetch and after the kubelet restarts. Series with different labels are merged.
+func (f *KubeletMetricsFetcher) addQuantiles(r definition.RawMetrics, family prometheus.MetricFamily, prefix string) {
+	var buckets []prometheus.Bucket
+	for _, m := range family.Metrics {
+		h, ok := m.Value.(*model.Histogram)
+		if !ok {
+			continue
+		}
+		buckets = mergeBuckets(buckets, prometheus.HistogramBuckets(h))
+	}
+
+	if buckets == nil {
+		return
+	}
+
+	observations := buckets
+	if delta, ok := prometheus.SubtractBuckets(buckets, f.previous[family.Name]); ok {
+		observations = delta
+	}
+	f.previous[family.Name] = buckets
+
+	for suffix, q := range kubeletQuantiles {
+		if v, ok := prometheus.HistogramQuantile(q, observations); ok {
+			r[prefix+suffix] = v
+		}
+	}
+}
+
+// mergeBuckets adds up the counts of two lists of buckets with the same upper bounds. If the upper bounds differ, the
+// one with more observations is kept.
+func mergeBuckets(a, b []prometheus.Bucket) []prometheus.Bucket {
+	if a == nil {
+		return b
+	}
+
+	merged, ok := addBuckets(a, b)
+	if ok {
+		return merged
+	}
+
+	if b[len(b)-1].CumulativeCount > a[len(a)-1].CumulativeCount {
+		return b
+	}
+
+	return a
+}
+
+// addBuckets returns the sum of the counts of a and b, if both have the same upper bounds.
+func addBuckets(a, b []prometheus.Bucket) ([]prometheus.Bucket, bool) {
+	if len(a) != len(b) {
+		return nil, false
+	}
+
+	sum := make([]prometheus.Bucket, len(a))
+	for i := range a {
+		if a[i].UpperBound != b[i].UpperBound {
+			return nil, false
+		}
+		sum[i] = prometheus.Bucket{UpperBound: a[i].UpperBound, CumulativeCount: a[i].CumulativeCount + b[i].CumulativeCount}
+	}
+
+	return sum, true
+}
+
+// addFloat adds value to the float64 raw metric with the given key.
+func addFloat(r definition.RawMetrics, key string, value float64) {
+	current, _ := r[key].(float64)
+	r[key] = current + value
+}
@@ -0,0 +1,100 @@
+package metric
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/kubelet/client"
+)
+
+const kubeletMetricsTemplate = `# HELP kubelet_pleg_relist_duration_seconds [ALPHA] Duration in seconds for relisting pods in PLEG.
+# TYPE kubelet_pleg_relist_duration_seconds histogram
+kubelet_pleg_relist_duration_seconds_bucket{le="0.01"} %d
+kubelet_pleg_relist_duration_seconds_bucket{le="0.1"} %d
+kubelet_pleg_relist_duration_seconds_bucket{le="1"} %d
+kubelet_pleg_relist_duration_seconds_bucket{le="+Inf"} %d
+kubelet_pleg_relist_duration_seconds_sum 10
+kubelet_pleg_relist_duration_seconds_count %d
+# HELP kubelet_pleg_last_seen_seconds [ALPHA] Timestamp in seconds when PLEG was last seen active.
+# TYPE kubelet_pleg_last_seen_seconds gauge
+kubelet_pleg_last_seen_seconds 1.7673624e+09
+# HELP kubelet_runtime_operations_errors_total [ALPHA] Cumulative number of runtime operation errors by operation type.
+# TYPE kubelet_runtime_operations_errors_total counter
+kubelet_runtime_operations_errors_total{operation_type="container_status"} 3
+kubelet_runtime_operations_errors_total{operation_type="exec_sync"} 2
+# HELP kubelet_evictions [ALPHA] Cumulative number of pod evictions by eviction signal
+# TYPE kubelet_evictions counter
+kubelet_evictions{eviction_signal="memory.available"} 4
+# HELP volume_manager_total_volumes [ALPHA] Number of volumes in Volume Manager
+# TYPE volume_manager_total_volumes gauge
+volume_manager_total_volumes{plugin_name="kubernetes.io/configmap",state="actual_state_of_world"} 5
+volume_manager_total_volumes{plugin_name="kubernetes.io/configmap",state="desired_state_of_world"} 6
+volume_manager_total_volumes{plugin_name="kubernetes.io/projected",state="actual_state_of_world"} 7
+volume_manager_total_volumes{plugin_name="kubernetes.io/projected",state="desired_state_of_world"} 7
+`
+
+func TestKubeletMetricsFetcher(t *testing.T) {
+	t.Parallel()
+
+	// Buckets of the PLEG relist histogram for each scrape.
+	scrapes := [][4]int{
+		{50, 90, 100, 100},
+		{50, 90, 200, 200},
+	}
+	scrape := 0
+
+	c := &testClient{
+		handler: func(w http.ResponseWriter, _ *http.Request) {
+			b := scrapes[scrape]
+			fmt.Fprintf(w, kubeletMetricsTemplate, b[0], b[1], b[2], b[3], b[3]) // nolint: errcheck
+			scrape++
+		},
+	}
+
+	kubeletClient, err := client.New(client.StaticConnector(c, url.URL{}))
+	require.NoError(t, err)
+
+	fetcher := NewKubeletMetricsFetcher(kubeletClient.MetricFamiliesGetFunc(KubeletMetricsPath), KubeletQueries)
+	fetcher.now = func() time.Time { return time.Unix(1767362430, 0) }
+
+	r, err := fetcher.DoKubeletMetricsFetch()
+	require.NoError(t, err)
+
+	assert.InDelta(t, 0.01, r["kubeletPlegRelistDurationSecondsP50"], 1e-9)
+	assert.InDelta(t, 0.91, r["kubeletPlegRelistDurationSecondsP99"], 1e-9)
+	assert.Equal(t, 30.0, r["kubeletPlegLastSeenAgeSeconds"])
+	assert.Equal(t, 5.0, r["kubeletRuntimeOperationsErrors"])
+	assert.Equal(t, 4.0, r["kubeletEvictions"])
+	assert.Equal(t, 13.0, r["kubeletVolumeManagerDesiredVolumes"])
+	assert.Equal(t, 12.0, r["kubeletVolumeManagerActualVolumes"])
+	assert.NotContains(t, r, "kubeletPodStartDurationSecondsP50")
+
+	// Quantiles of the second scrape only account for the 100 relists between 0.1s and 1s since the first one.
+	r, err = fetcher.DoKubeletMetricsFetch()
+	require.NoError(t, err)
+
+	assert.InDelta(t, 0.55, r["kubeletPlegRelistDurationSecondsP50"], 1e-9)
+	assert.InDelta(t, 0.991, r["kubeletPlegRelistDurationSecondsP99"], 1e-9)
+}
+
+func TestKubeletMetricsFetcher_EndpointError(t *testing.T) {
+	t.Parallel()
+
+	c := &testClient{
+		handler: func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		},
+	}
+
+	kubeletClient, err := client.New(client.StaticConnector(c, url.URL{}))
+	require.NoError(t, err)
+
+	_, err = NewKubeletMetricsFetcher(kubeletClient.MetricFamiliesGetFunc(KubeletMetricsPath), KubeletQueries).DoKubeletMetricsFetch()
+	assert.ErrorContains(t, err, "kubelet metrics endpoint")
+}
@@ -62,6 +62,7 @@ func fetchNodeStats(n v1.NodeStats) (definition.RawMetrics, string, error) {
 	if n.CPU != nil {
 		AddUint64RawMetric(r, "usageNanoCores", n.CPU.UsageNanoCores)
 		AddUint64RawMetric(r, "usageCoreNanoSeconds", n.CPU.UsageCoreNanoSeconds)
+		addPSIRawMetrics(r, "cpu", n.CPU.PSI)
 	}
 
 	if n.Memory != nil {
@@ -71,8 +72,15 @@ func fetchNodeStats(n v1.NodeStats) (definition.RawMetrics, string, error) {
 		AddUint64RawMetric(r, "memoryRssBytes", n.Memory.RSSBytes)
 		AddUint64RawMetric(r, "memoryPageFaults", n.Memory.PageFaults)
 		AddUint64RawMetric(r, "memoryMajorPageFaults", n.Memory.MajorPageFaults)
+		addPSIRawMetrics(r, "memory", n.Memory.PSI)
 	}
 
+	if n.IO != nil {
+		addPSIRawMetrics(r, "io", n.IO.PSI)
+	}
+
+	addSwapRawMetrics(r, n.Swap)
+
 	if n.Network != nil {
 		AddUint64RawMetric(r, "rxBytes", n.Network.RxBytes)
 		AddUint64RawMetric(r, "txBytes", n.Network.TxBytes)
@@ -144,10 +152,25 @@ func fetchPodStats(pod v1.PodStats) (definition.RawMetrics, string, error) {
 
 	if pod.CPU != nil {
 		AddUint64RawMetric(r, "usageNanoCores", pod.CPU.UsageNanoCores)
+		addPSIRawMetrics(r, "cpu", pod.CPU.PSI)
 	}
 	if pod.Memory != nil {
 		AddUint64RawMetric(r, "usageBytes", pod.Memory.UsageBytes)
 		AddUint64RawMetric(r, "workingSetBytes", pod.Memory.WorkingSetBytes)
+		addPSIRawMetrics(r, "memory", pod.Memory.PSI)
+	}
+	if pod.IO != nil {
+		addPSIRawMetrics(r, "io", pod.IO.PSI)
+	}
+
+	addSwapRawMetrics(r, pod.Swap)
+
+	if pod.EphemeralStorage != nil {
+		AddUint64RawMetric(r, "ephemeralStorageUsedBytes", pod.EphemeralStorage.UsedBytes)
+		AddUint64RawMetric(r, "ephemeralStorageInodesUsed", pod.EphemeralStorage.InodesUsed)
+	}
+	if pod.ProcessStats != nil {
+		AddUint64RawMetric(r, "processCount", pod.ProcessStats.ProcessCount)
 	}
 
 	rawEntityID := fmt.Sprintf("%s_%s", r["namespace"], r["podName"])
@@ -165,11 +188,18 @@ func fetchContainerStats(c v1.ContainerStats) (definition.RawMetrics, error) {
 
 	if c.CPU != nil {
 		AddUint64RawMetric(r, "usageNanoCores", c.CPU.UsageNanoCores)
+		addPSIRawMetrics(r, "cpu", c.CPU.PSI)
 	}
 	if c.Memory != nil {
 		AddUint64RawMetric(r, "usageBytes", c.Memory.UsageBytes)
 		AddUint64RawMetric(r, "workingSetBytes", c.Memory.WorkingSetBytes)
+		addPSIRawMetrics(r, "memory", c.Memory.PSI)
 	}
+	if c.IO != nil {
+		addPSIRawMetrics(r, "io", c.IO.PSI)
+	}
+
+	addSwapRawMetrics(r, c.Swap)
 	if c.Rootfs != nil {
 		AddUint64RawMetric(r, "fsAvailableBytes", c.Rootfs.AvailableBytes)
 		AddUint64RawMetric(r, "fsCapacityBytes", c.Rootfs.CapacityBytes)
@@ -178,6 +208,10 @@ func fetchContainerStats(c v1.ContainerStats) (definition.RawMetrics, error) {
 		AddUint64RawMetric(r, "fsInodes", c.Rootfs.Inodes)
 		AddUint64RawMetric(r, "fsInodesUsed", c.Rootfs.InodesUsed)
 	}
+	if c.Logs != nil {
+		AddUint64RawMetric(r, "logsUsedBytes", c.Logs.UsedBytes)
+		AddUint64RawMetric(r, "logsInodesUsed", c.Logs.InodesUsed)
+	}
 
 	return r, nil
 }
@@ -376,6 +410,33 @@ func getKeys(groupLabel, rawEntityID string, groups definition.RawGroups, keys .
 	return s, nil
 }
 
+// addSwapRawMetrics adds the swap usage of a node, pod or container. Available bytes are only reported when a swap
+// limit applies.
+func addSwapRawMetrics(r definition.RawMetrics, swap *v1.SwapStats) {
+	if swap == nil {
+		return
+	}
+
+	AddUint64RawMetric(r, "swapUsedBytes", swap.SwapUsageBytes)
+	AddUint64RawMetric(r, "swapAvailableBytes", swap.SwapAvailableBytes)
+}
+
+// addPSIRawMetrics adds the Pressure Stall Information of a resource, reported by kubelets running on cgroup v2 nodes
+// with the KubeletPSI feature enabled. Metrics are named after the resource, e.g. cpuPressureSomeAvg10.
+func addPSIRawMetrics(r definition.RawMetrics, resource string, psi *v1.PSIStats) {
+	if psi == nil {
+		return
+	}
+
+	for kind, data := range map[string]v1.PSIData{"Some": psi.Some, "Full": psi.Full} {
+		prefix := resource + "Pressure" + kind
+		r[prefix+"Avg10"] = data.Avg10
+		r[prefix+"Avg60"] = data.Avg60
+		r[prefix+"Avg300"] = data.Avg300
+		r[prefix+"Total"] = data.Total
+	}
+}
+
 // AddUint64RawMetric adds a new metric to a RawMetrics if it exists
 func AddUint64RawMetric(r definition.RawMetrics, name string, valuePtr *uint64) {
 	if valuePtr != nil {
@@ -148,6 +148,8 @@ func TestGroupStatsSummary_CorrectValue(t *testing.T) {
 				"fsInodesFree":     uint64(9574871),
 				"fsInodesUsed":     uint64(24),
 				"fsUsedBytes":      uint64(35000320),
+				"logsUsedBytes":    uint64(20480),
+				"logsInodesUsed":   uint64(157225),
 			},
 			"kube-system_newrelic-infra-monitoring-pjp0v_newrelic-infra": definition.RawMetrics{
 				"containerName":    "newrelic-infra",
@@ -162,6 +164,8 @@ func TestGroupStatsSummary_CorrectValue(t *testing.T) {
 				"fsInodesFree":     uint64(9574871),
 				"fsInodesUsed":     uint64(52),
 				"fsUsedBytes":      uint64(1305837568),
+				"logsUsedBytes":    uint64(657747968),
+				"logsInodesUsed":   uint64(157225),
 			},
 			"kube-system_kube-dns-910330662-pflkj_dnsmasq": definition.RawMetrics{
 				"containerName":    "dnsmasq",
@@ -176,6 +180,8 @@ func TestGroupStatsSummary_CorrectValue(t *testing.T) {
 				"fsInodesFree":     uint64(9574871),
 				"fsInodesUsed":     uint64(20),
 				"fsUsedBytes":      uint64(42041344),
+				"logsUsedBytes":    uint64(20480),
+				"logsInodesUsed":   uint64(157225),
 			},
 		},
 	}
@@ -223,6 +229,8 @@ func TestGroupStatsSummary_MissingNodeData_ContainerWithTheSameName(t *testing.T
 				"fsInodesFree":     uint64(9574871),
 				"fsInodesUsed":     uint64(24),
 				"fsUsedBytes":      uint64(35000320),
+				"logsUsedBytes":    uint64(20480),
+				"logsInodesUsed":   uint64(157225),
 			},
 			"kube-system_kube-dns-910330662-pflkj_kube-state-metrics": definition.RawMetrics{
 				"containerName":    "kube-state-metrics",
@@ -237,6 +245,8 @@ func TestGroupStatsSummary_MissingNodeData_ContainerWithTheSameName(t *testing.T
 				"fsInodesFree":     uint64(9574871),
 				"fsInodesUsed":     uint64(24),
 				"fsUsedBytes":      uint64(35000320),
+				"logsUsedBytes":    uint64(20480),
+				"logsInodesUsed":   uint64(157225),
 			},
 			"kube-system_newrelic-infra-monitoring-pjp0v_newrelic-infra": definition.RawMetrics{
 				"containerName":    "newrelic-infra",
@@ -251,6 +261,8 @@ func TestGroupStatsSummary_MissingNodeData_ContainerWithTheSameName(t *testing.T
 				"fsInodesFree":     uint64(9574871),
 				"fsInodesUsed":     uint64(52),
 				"fsUsedBytes":      uint64(1305837568),
+				"logsUsedBytes":    uint64(657747968),
+				"logsInodesUsed":   uint64(157225),
 			},
 			"kube-system_kube-dns-910330662-pflkj_dnsmasq": definition.RawMetrics{
 				"containerName":    "dnsmasq",
@@ -265,6 +277,8 @@ func TestGroupStatsSummary_MissingNodeData_ContainerWithTheSameName(t *testing.T
 				"fsInodesFree":     uint64(9574871),
 				"fsInodesUsed":     uint64(20),
 				"fsUsedBytes":      uint64(42041344),
+				"logsUsedBytes":    uint64(20480),
+				"logsInodesUsed":   uint64(157225),
 			},
 		},
 		"volume": {
@@ -748,3 +762,101 @@ func TestPrefixFromMapInt(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "not-a-map", val)
 }
+
+func TestGroupStatsSummary_SwapAndPSI(t *testing.T) {
+	psi := `"psi": {
+		"full": {"total": 1500000000, "avg10": 1.5, "avg60": 0.5, "avg300": 0.1},
+		"some": {"total": 3000000000, "avg10": 3.5, "avg60": 1.5, "avg300": 0.3}
+	}`
+
+	summary := toSummary(t, `{
+		"node": {
+			"nodeName": "fooNode",
+			"cpu": {"usageNanoCores": 1, `+psi+`},
+			"memory": {"usageBytes": 1, `+psi+`},
+			"io": {`+psi+`},
+			"swap": {"swapAvailableBytes": 1024, "swapUsageBytes": 512}
+		},
+		"pods": [{
+			"podRef": {"name": "foo", "namespace": "bar"},
+			"cpu": {"usageNanoCores": 1, `+psi+`},
+			"io": {`+psi+`},
+			"swap": {"swapUsageBytes": 256},
+			"containers": [{
+				"name": "baz",
+				"memory": {"usageBytes": 1, `+psi+`},
+				"swap": {"swapAvailableBytes": 0, "swapUsageBytes": 0}
+			}]
+		}]
+	}`)
+
+	rawData, errs := GroupStatsSummary(summary)
+	require.Empty(t, errs)
+
+	expectedPSI := func(resource string) definition.RawMetrics {
+		return definition.RawMetrics{
+			resource + "PressureSomeAvg10":  3.5,
+			resource + "PressureSomeAvg60":  1.5,
+			resource + "PressureSomeAvg300": 0.3,
+			resource + "PressureSomeTotal":  uint64(3000000000),
+			resource + "PressureFullAvg10":  1.5,
+			resource + "PressureFullAvg60":  0.5,
+			resource + "PressureFullAvg300": 0.1,
+			resource + "PressureFullTotal":  uint64(1500000000),
+		}
+	}
+
+	assertContains := func(t *testing.T, actual definition.RawMetrics, expected definition.RawMetrics) {
+		t.Helper()
+
+		for k, v := range expected {
+			assert.Equal(t, v, actual[k], k)
+		}
+	}
+
+	node := rawData["node"]["fooNode"]
+	assertContains(t, node, expectedPSI("cpu"))
+	assertContains(t, node, expectedPSI("memory"))
+	assertContains(t, node, expectedPSI("io"))
+	assertContains(t, node, definition.RawMetrics{"swapAvailableBytes": uint64(1024), "swapUsedBytes": uint64(512)})
+
+	pod := rawData["pod"]["bar_foo"]
+	assertContains(t, pod, expectedPSI("cpu"))
+	assertContains(t, pod, expectedPSI("io"))
+	assert.NotContains(t, pod, "memoryPressureSomeAvg10")
+	assert.Equal(t, uint64(256), pod["swapUsedBytes"])
+	assert.NotContains(t, pod, "swapAvailableBytes")
+
+	container := rawData["container"]["bar_foo_baz"]
+	assertContains(t, container, expectedPSI("memory"))
+	assert.NotContains(t, container, "cpuPressureSomeAvg10")
+	assertContains(t, container, definition.RawMetrics{"swapAvailableBytes": uint64(0), "swapUsedBytes": uint64(0)})
+}
+
+func TestGroupStatsSummary_EphemeralStorage(t *testing.T) {
+	summary := toSummary(t, `{
+		"node": {"nodeName": "fooNode"},
+		"pods": [{
+			"podRef": {"name": "foo", "namespace": "bar"},
+			"ephemeral-storage": {"usedBytes": 4096, "inodesUsed": 3},
+			"process_stats": {"process_count": 7},
+			"containers": [{
+				"name": "baz",
+				"rootfs": {"usedBytes": 1024},
+				"logs": {"usedBytes": 2048, "inodesUsed": 2}
+			}]
+		}]
+	}`)
+
+	rawData, errs := GroupStatsSummary(summary)
+	require.Empty(t, errs)
+
+	pod := rawData["pod"]["bar_foo"]
+	assert.Equal(t, uint64(4096), pod["ephemeralStorageUsedBytes"])
+	assert.Equal(t, uint64(3), pod["ephemeralStorageInodesUsed"])
+	assert.Equal(t, uint64(7), pod["processCount"])
+
+	container := rawData["container"]["bar_foo_baz"]
+	assert.Equal(t, uint64(2048), container["logsUsedBytes"])
+	assert.Equal(t, uint64(2), container["logsInodesUsed"])
+}
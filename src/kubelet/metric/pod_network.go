@@ -0,0 +1,96 @@
+package metric
+
+import (
+	"fmt"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+)
+
+// PodNetworkGroup is the group holding the network metrics of each of the interfaces of a pod.
+const PodNetworkGroup = "pod-network"
+
+// GroupPodNetwork splits the interfaces reported for each pod into entities of their own, so pods attached to several
+// networks, e.g. with Multus or SR-IOV, report metrics for all of them and not only for the primary one.
+func GroupPodNetwork(pods map[string]definition.RawMetrics) map[string]definition.RawMetrics {
+	group := map[string]definition.RawMetrics{}
+
+	for podID, pod := range pods {
+		interfaces, ok := pod["interfaces"].(map[string]definition.RawMetrics)
+		if !ok {
+			continue
+		}
+
+		for name, i := range interfaces {
+			r := definition.RawMetrics{
+				"podEntityID":   podID,
+				"interfaceName": name,
+			}
+			for _, key := range []string{"podName", "namespace", "nodeName"} {
+				if v, ok := pod[key]; ok {
+					r[key] = v
+				}
+			}
+			for k, v := range i {
+				r[k] = v
+			}
+
+			group[fmt.Sprintf("%s_%s", podID, name)] = r
+		}
+	}
+
+	return group
+}
+
+// IsPrimaryPodInterface returns a FetchFunc telling whether an entity of the PodNetworkGroup is the interface
+// FromRawWithFallbackToDefaultInterface reports the metrics of the pod for. The interface resolved for the pod is
+// taken from cache if present, and stored in it otherwise.
+func IsPrimaryPodInterface(cache *InterfaceCache) definition.FetchFunc {
+	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
+		e, err := getEntityMetrics(groupLabel, entityID, groups)
+		if err != nil {
+			return nil, err
+		}
+
+		podID, ok := e["podEntityID"].(string)
+		if !ok {
+			return nil, fmt.Errorf("pod entity ID not found for %q", entityID) //nolint:err113
+		}
+
+		pod, err := getEntityMetrics("pod", podID, groups)
+		if err != nil {
+			return nil, fmt.Errorf("getting pod %q: %w", podID, err)
+		}
+
+		primary, err := primaryPodInterface(cache, podID, pod)
+		if err != nil {
+			return nil, err
+		}
+
+		if e["interfaceName"] == primary {
+			return "true", nil
+		}
+		return "false", nil
+	}
+}
+
+// primaryPodInterface resolves the primary interface of a pod, using the cache if possible.
+func primaryPodInterface(cache *InterfaceCache, podID string, pod definition.RawMetrics) (string, error) {
+	if cache != nil {
+		if iface, found := cache.Get(podID); found {
+			if _, err := getMetricFromInterface(iface, "rxBytes", pod); err == nil {
+				return iface, nil
+			}
+		}
+	}
+
+	iface, err := resolveInterfaceHeuristic(podID, "rxBytes", pod)
+	if err != nil {
+		return "", err
+	}
+
+	if cache != nil {
+		cache.Put(podID, iface)
+	}
+
+	return iface, nil
+}
@@ -0,0 +1,105 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+)
+
+func multiNICPods() map[string]definition.RawMetrics {
+	return map[string]definition.RawMetrics{
+		"default_dpdk-app": {
+			"podName":   "dpdk-app",
+			"namespace": "default",
+			"nodeName":  "node-1",
+			"rxBytes":   uint64(100),
+			"interfaces": map[string]definition.RawMetrics{
+				"eth0": {"rxBytes": uint64(100), "txBytes": uint64(200), "errors": uint64(0)},
+				"net1": {"rxBytes": uint64(5000), "txBytes": uint64(7000), "errors": uint64(3)},
+			},
+		},
+		"default_no-network": {
+			"podName":   "no-network",
+			"namespace": "default",
+		},
+	}
+}
+
+func TestGroupPodNetwork(t *testing.T) {
+	t.Parallel()
+
+	group := GroupPodNetwork(multiNICPods())
+
+	assert.Equal(t, map[string]definition.RawMetrics{
+		"default_dpdk-app_eth0": {
+			"podEntityID":   "default_dpdk-app",
+			"podName":       "dpdk-app",
+			"namespace":     "default",
+			"nodeName":      "node-1",
+			"interfaceName": "eth0",
+			"rxBytes":       uint64(100),
+			"txBytes":       uint64(200),
+			"errors":        uint64(0),
+		},
+		"default_dpdk-app_net1": {
+			"podEntityID":   "default_dpdk-app",
+			"podName":       "dpdk-app",
+			"namespace":     "default",
+			"nodeName":      "node-1",
+			"interfaceName": "net1",
+			"rxBytes":       uint64(5000),
+			"txBytes":       uint64(7000),
+			"errors":        uint64(3),
+		},
+	}, group)
+}
+
+func TestIsPrimaryPodInterface(t *testing.T) {
+	t.Parallel()
+
+	pods := multiNICPods()
+	groups := definition.RawGroups{
+		"pod":           pods,
+		PodNetworkGroup: GroupPodNetwork(pods),
+	}
+	cache := NewInterfaceCache()
+	isPrimary := IsPrimaryPodInterface(cache)
+
+	value, err := isPrimary(PodNetworkGroup, "default_dpdk-app_eth0", groups)
+	require.NoError(t, err)
+	assert.Equal(t, "true", value)
+
+	value, err = isPrimary(PodNetworkGroup, "default_dpdk-app_net1", groups)
+	require.NoError(t, err)
+	assert.Equal(t, "false", value)
+
+	iface, found := cache.Get("default_dpdk-app")
+	assert.True(t, found)
+	assert.Equal(t, "eth0", iface)
+}
+
+func TestIsPrimaryPodInterface_UsesCache(t *testing.T) {
+	t.Parallel()
+
+	pods := multiNICPods()
+	groups := definition.RawGroups{
+		"pod":           pods,
+		PodNetworkGroup: GroupPodNetwork(pods),
+	}
+	cache := NewInterfaceCache()
+	cache.Put("default_dpdk-app", "net1")
+
+	value, err := IsPrimaryPodInterface(cache)(PodNetworkGroup, "default_dpdk-app_net1", groups)
+	require.NoError(t, err)
+	assert.Equal(t, "true", value)
+
+	// Interfaces no longer present in the pod are resolved again.
+	cache.Put("default_dpdk-app", "net2")
+
+	value, err = IsPrimaryPodInterface(cache)(PodNetworkGroup, "default_dpdk-app_net1", groups)
+	require.NoError(t, err)
+	assert.Equal(t, "false", value)
+}
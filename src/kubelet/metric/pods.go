@@ -9,16 +9,29 @@ import (
 	"net/url"
 	"os"
 	"path"
-	"strings"
 	"time"
 
 	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/owner"
 	"github.com/newrelic/nri-kubernetes/v3/src/client"
 	"github.com/newrelic/nri-kubernetes/v3/src/definition"
 	"k8s.io/client-go/rest"
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Values of the containerType attribute of container samples.
+const (
+	// ContainerTypeRegular is an app container, listed in the containers of the pod spec.
+	ContainerTypeRegular = "regular"
+	// ContainerTypeInit is an init container, which runs to completion before app containers start.
+	ContainerTypeInit = "init"
+	// ContainerTypeSidecar is a native sidecar, an init container with RestartPolicy Always.
+	ContainerTypeSidecar = "sidecar"
+	// ContainerTypeEphemeral is an ephemeral container, usually added by `kubectl debug`.
+	ContainerTypeEphemeral = "ephemeral"
 )
 
 // KubeletPodsPath is the path where kubelet serves information about pods.
@@ -41,6 +54,19 @@ type PodsFetcher struct {
 	client         client.HTTPGetter
 	useKubeService bool
 	uri            url.URL
+	ownerResolver  owner.Resolver
+	now            func() time.Time
+}
+
+// PodsFetcherOpt are options that can be used to configure the PodsFetcher.
+type PodsFetcherOpt func(podsFetcher *PodsFetcher)
+
+// WithOwnerResolver returns a PodsFetcherOpt to resolve the owners of pods up to their top-level workload. By default
+// only the direct owner of each pod is known.
+func WithOwnerResolver(resolver owner.Resolver) PodsFetcherOpt {
+	return func(podsFetcher *PodsFetcher) {
+		podsFetcher.ownerResolver = resolver
+	}
 }
 
 // DoPodsFetch used to have a cache that was invalidated each execution of the integration
@@ -140,46 +166,43 @@ func (podsFetcher *PodsFetcher) Fetch() (*http.Response, error) {
 	return podsFetcher.client.Get(KubeletPodsPath) //nolint:wrapcheck
 }
 
-// NewPodsFetcher returns a new PodsFetcher.
-func NewBasicPodsFetcher(l *log.Logger, c client.HTTPGetter) *PodsFetcher {
-	return &PodsFetcher{
+// NewBasicPodsFetcher returns a new PodsFetcher.
+func NewBasicPodsFetcher(l *log.Logger, c client.HTTPGetter, opts ...PodsFetcherOpt) *PodsFetcher {
+	podsFetcher := &PodsFetcher{
 		logger:         l,
 		client:         c,
 		useKubeService: false,
+		now:            time.Now,
 	}
+
+	for _, opt := range opts {
+		opt(podsFetcher)
+	}
+
+	return podsFetcher
 }
 
 // NewPodsFetcher returns a new PodsFetcher.
-func NewPodsFetcher(log *log.Logger, c client.HTTPGetter, config *config.Config) *PodsFetcher {
+func NewPodsFetcher(log *log.Logger, c client.HTTPGetter, config *config.Config, opts ...PodsFetcherOpt) *PodsFetcher {
+	podsFetcher := NewBasicPodsFetcher(log, c, opts...)
+
 	if config.FetchPodsFromKubeService {
 		log.Info("Using Kubernetes service to fetch pods.")
 
 		uri, err := url.Parse(getKubeServiceHost())
 		if err != nil || uri == nil {
 			log.Warnf("Failed to parse kube service host URL: %v", err)
-			return &PodsFetcher{
-				logger:         log,
-				client:         c,
-				useKubeService: false,
-			}
+			return podsFetcher
 		}
 
 		uri.Path = path.Join(uri.Path, KubeServiceKubeletPodsPath)
 		uri.RawQuery = fmt.Sprintf(nodeSelectorQuery, config.NodeName)
 
-		return &PodsFetcher{
-			logger:         log,
-			client:         c,
-			uri:            *uri,
-			useKubeService: true,
-		}
+		podsFetcher.uri = *uri
+		podsFetcher.useKubeService = true
 	}
 
-	return &PodsFetcher{
-		logger:         log,
-		client:         c,
-		useKubeService: false,
-	}
+	return podsFetcher
 }
 
 func getKubeServiceHost() string {
@@ -198,20 +221,13 @@ func (podsFetcher *PodsFetcher) fetchContainersData(pod *v1.Pod) map[string]defi
 	fillContainerStatuses(pod, containerStatusByName, statuses)
 
 	metrics := make(map[string]definition.RawMetrics)
-	containers := pod.Spec.Containers
 
-	// Add sidecar containers (init containers with RestartPolicy Always)
-	for _, initContainer := range pod.Spec.InitContainers {
-		if initContainer.RestartPolicy != nil && *initContainer.RestartPolicy == v1.ContainerRestartPolicyAlways {
-			containers = append(containers, initContainer)
-		}
-	}
-
-	for _, c := range containers {
+	for _, c := range podContainers(pod) {
 		id := containerID(pod, c.Name)
 		metrics[id] = definition.RawMetrics{
 			"containerName":  c.Name,
 			"containerImage": c.Image,
+			"containerType":  c.containerType,
 			"namespace":      pod.GetObjectMeta().GetNamespace(),
 			"podName":        pod.GetObjectMeta().GetName(),
 			"nodeName":       pod.Spec.NodeName,
@@ -247,12 +263,38 @@ func (podsFetcher *PodsFetcher) fetchContainersData(pod *v1.Pod) map[string]defi
 			metrics[id]["memoryLimitBytes"] = v.Value()
 		}
 
-		if ref := pod.GetOwnerReferences(); len(ref) > 0 {
-			creatorKind := ref[0].Kind
-			creatorName := ref[0].Name
-			addWorkloadNameBasedOnCreator(creatorKind, creatorName, metrics[id])
+		// Desired resources are reported apart from the applied ones, so pending or infeasible resizes are visible.
+		if v, ok := c.Resources.Requests[v1.ResourceCPU]; ok {
+			metrics[id]["desiredCpuRequestedCores"] = v.MilliValue()
+		}
+
+		if v, ok := c.Resources.Limits[v1.ResourceCPU]; ok {
+			metrics[id]["desiredCpuLimitCores"] = v.MilliValue()
+		}
+
+		if v, ok := c.Resources.Requests[v1.ResourceMemory]; ok {
+			metrics[id]["desiredMemoryRequestedBytes"] = v.Value()
+		}
+
+		if v, ok := c.Resources.Limits[v1.ResourceMemory]; ok {
+			metrics[id]["desiredMemoryLimitBytes"] = v.Value()
+		}
+
+		if cs, ok := containerStatusByName[c.Name]; ok {
+			addAllocatedResources(metrics[id], cs.AllocatedResources)
+		}
+
+		// Ephemeral storage cannot be resized in place, so the spec is the source of truth.
+		if v, ok := c.Resources.Requests[v1.ResourceEphemeralStorage]; ok {
+			metrics[id]["ephemeralStorageRequestedBytes"] = v.Value()
+		}
+
+		if v, ok := c.Resources.Limits[v1.ResourceEphemeralStorage]; ok {
+			metrics[id]["ephemeralStorageLimitBytes"] = v.Value()
 		}
 
+		podsFetcher.addWorkload(pod, metrics[id])
+
 		// merging status data
 		for k, v := range statuses[id] {
 			metrics[id][k] = v
@@ -267,22 +309,51 @@ func (podsFetcher *PodsFetcher) fetchContainersData(pod *v1.Pod) map[string]defi
 	return metrics
 }
 
+// typedContainer is a container of a pod along with its ContainerType.
+type typedContainer struct {
+	v1.Container
+	containerType string
+}
+
+// podContainers returns all the containers of a pod: app containers, sidecars, init containers and ephemeral
+// containers. Container names are unique across all of them.
+func podContainers(pod *v1.Pod) []typedContainer {
+	containers := make([]typedContainer, 0, len(pod.Spec.Containers)+len(pod.Spec.InitContainers)+len(pod.Spec.EphemeralContainers))
+
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, typedContainer{Container: c, containerType: ContainerTypeRegular})
+	}
+
+	for _, c := range pod.Spec.InitContainers {
+		containerType := ContainerTypeInit
+		if isSidecar(c) {
+			containerType = ContainerTypeSidecar
+		}
+		containers = append(containers, typedContainer{Container: c, containerType: containerType})
+	}
+
+	for _, c := range pod.Spec.EphemeralContainers {
+		containers = append(containers, typedContainer{Container: v1.Container(c.EphemeralContainerCommon), containerType: ContainerTypeEphemeral})
+	}
+
+	return containers
+}
+
+// isSidecar returns true for native sidecar containers, which are init containers with RestartPolicy Always.
+func isSidecar(c v1.Container) bool {
+	return c.RestartPolicy != nil && *c.RestartPolicy == v1.ContainerRestartPolicyAlways
+}
+
 func getContainerStatusesByName(pod *v1.Pod) map[string]*v1.ContainerStatus {
 	containerStatusByName := make(map[string]*v1.ContainerStatus)
-	for i := range pod.Status.ContainerStatuses {
-		containerStatus := &pod.Status.ContainerStatuses[i]
-		containerStatusByName[containerStatus.Name] = containerStatus
-	}
-
-	// Add sidecar containers (init containers with RestartPolicy Always)
-	for _, initContainer := range pod.Spec.InitContainers {
-		if initContainer.RestartPolicy != nil && *initContainer.RestartPolicy == v1.ContainerRestartPolicyAlways {
-			for _, initContainerStatus := range pod.Status.InitContainerStatuses {
-				if initContainerStatus.Name == initContainer.Name {
-					containerStatusByName[initContainerStatus.Name] = &initContainerStatus
-					break
-				}
-			}
+	for _, statuses := range [][]v1.ContainerStatus{
+		pod.Status.ContainerStatuses,
+		pod.Status.InitContainerStatuses,
+		pod.Status.EphemeralContainerStatuses,
+	} {
+		for i := range statuses {
+			containerStatus := &statuses[i]
+			containerStatusByName[containerStatus.Name] = containerStatus
 		}
 	}
 
@@ -326,6 +397,7 @@ func fillContainerStatuses(pod *v1.Pod, containerStatuses map[string]*v1.Contain
 			dest[id]["status"] = "Terminated"
 			dest[id]["isReady"] = false
 			dest[id]["reason"] = c.State.Terminated.Reason
+			dest[id]["exitCode"] = c.State.Terminated.ExitCode
 			dest[id]["restartCount"] = c.RestartCount
 			dest[id]["lastTerminatedExitCode"] = lastTerminatedExitCode
 			dest[id]["lastTerminatedExitReason"] = lastTerminatedExitReason
@@ -380,9 +452,18 @@ func (podsFetcher *PodsFetcher) fetchPodData(pod *v1.Pod) definition.RawMetrics
 		creatorName := ref[0].Name
 		metrics["createdKind"] = creatorKind
 		metrics["createdBy"] = creatorName
-		addWorkloadNameBasedOnCreator(creatorKind, creatorName, metrics)
 	}
 
+	podsFetcher.addWorkload(pod, metrics)
+
+	addStartupLatencies(metrics)
+
+	if qosClass := pod.Status.QOSClass; qosClass != "" {
+		metrics["qosClass"] = string(qosClass)
+	}
+
+	addPodConditions(pod, metrics)
+
 	if pod.Status.Reason != "" {
 		metrics["reason"] = pod.Status.Reason
 	}
@@ -418,6 +499,9 @@ func (podsFetcher *PodsFetcher) fetchPodData(pod *v1.Pod) definition.RawMetrics
 		}
 	}
 
+	addAllocatedResources(metrics, pod.Status.AllocatedResources)
+	addPodEphemeralStorage(pod, metrics)
+
 	labels := podLabels(pod)
 	if len(labels) > 0 {
 		metrics["labels"] = labels
@@ -426,6 +510,61 @@ func (podsFetcher *PodsFetcher) fetchPodData(pod *v1.Pod) definition.RawMetrics
 	return metrics
 }
 
+// addAllocatedResources adds the CPU and memory the kubelet has admitted for a pod or container, which during a resize
+// may differ from both the desired and the applied resources.
+func addAllocatedResources(metrics definition.RawMetrics, allocated v1.ResourceList) {
+	if v, ok := allocated[v1.ResourceCPU]; ok {
+		metrics["allocatedCpuCores"] = v.MilliValue()
+	}
+
+	if v, ok := allocated[v1.ResourceMemory]; ok {
+		metrics["allocatedMemoryBytes"] = v.Value()
+	}
+}
+
+// addPodEphemeralStorage adds the ephemeral-storage requests and limits of the pod, computed as the sum of the ones of
+// its app and sidecar containers. This matches the limit the kubelet evicts the pod against, which only accounts for
+// the containers declaring one.
+func addPodEphemeralStorage(pod *v1.Pod, metrics definition.RawMetrics) {
+	var requests, limits *resource.Quantity
+
+	for _, c := range pod.Spec.Containers {
+		requests = addQuantity(requests, c.Resources.Requests, v1.ResourceEphemeralStorage)
+		limits = addQuantity(limits, c.Resources.Limits, v1.ResourceEphemeralStorage)
+	}
+
+	for _, c := range pod.Spec.InitContainers {
+		if !isSidecar(c) {
+			continue
+		}
+		requests = addQuantity(requests, c.Resources.Requests, v1.ResourceEphemeralStorage)
+		limits = addQuantity(limits, c.Resources.Limits, v1.ResourceEphemeralStorage)
+	}
+
+	if requests != nil {
+		metrics["ephemeralStorageRequestedBytes"] = requests.Value()
+	}
+
+	if limits != nil {
+		metrics["ephemeralStorageLimitBytes"] = limits.Value()
+	}
+}
+
+// addQuantity adds the quantity of name in list to total, if present. A nil total means no quantity was added yet.
+func addQuantity(total *resource.Quantity, list v1.ResourceList, name v1.ResourceName) *resource.Quantity {
+	v, ok := list[name]
+	if !ok {
+		return total
+	}
+
+	if total == nil {
+		total = &resource.Quantity{}
+	}
+	total.Add(v)
+
+	return total
+}
+
 func (podsFetcher *PodsFetcher) fillPodStatus(r definition.RawMetrics, pod *v1.Pod) { //nolint:gocognit,gocyclo,cyclop
 	// TODO Review if those Fake Pending Pods are still an issue
 	if isFakePendingPod(pod.Status) {
@@ -466,12 +605,69 @@ func (podsFetcher *PodsFetcher) fillPodStatus(r definition.RawMetrics, pod *v1.P
 					r["scheduledAt"] = c.LastTransitionTime.In(time.UTC)
 				}
 			}
+		case v1.PodResizePending:
+			addResizeCondition(r, "isResizePending", "resizePending", c, podsFetcher.now())
+		case v1.PodResizeInProgress:
+			addResizeCondition(r, "isResizeInProgress", "resizeInProgress", c, podsFetcher.now())
 		}
 	}
 
 	r["status"] = string(pod.Status.Phase)
 }
 
+// addResizeCondition adds the state of an in-place resize condition, which is only present while the resize is
+// pending or in progress, and the seconds elapsed since it started until now. Reason is Deferred or Infeasible for
+// pending resizes, and Error for failed ones.
+func addResizeCondition(r definition.RawMetrics, statusKey, prefix string, c v1.PodCondition, now time.Time) {
+	if c.Status != v1.ConditionTrue {
+		return
+	}
+
+	r[statusKey] = string(c.Status)
+
+	if c.Reason != "" {
+		r[prefix+"Reason"] = c.Reason
+	}
+
+	if c.Message != "" {
+		r[prefix+"Message"] = c.Message
+	}
+
+	if !c.LastTransitionTime.IsZero() {
+		r[prefix+"Since"] = c.LastTransitionTime.In(time.UTC)
+		r[prefix+"Seconds"] = now.Sub(c.LastTransitionTime.Time).Seconds()
+	}
+}
+
+// addPodConditions adds the status of every condition of the pod, as done for nodes, along with the time of their
+// last transition.
+func addPodConditions(pod *v1.Pod, r definition.RawMetrics) {
+	if len(pod.Status.Conditions) == 0 {
+		return
+	}
+
+	conditions := make(map[string]int, len(pod.Status.Conditions))
+	transitions := make(map[string]int, len(pod.Status.Conditions))
+
+	for _, c := range pod.Status.Conditions {
+		switch c.Status {
+		case v1.ConditionTrue:
+			conditions[string(c.Type)] = 1
+		case v1.ConditionFalse:
+			conditions[string(c.Type)] = 0
+		default:
+			conditions[string(c.Type)] = -1
+		}
+
+		if !c.LastTransitionTime.IsZero() {
+			transitions[string(c.Type)] = int(c.LastTransitionTime.Unix())
+		}
+	}
+
+	r["conditions"] = conditions
+	r["conditionTransitions"] = transitions
+}
+
 func podLabels(p *v1.Pod) map[string]string {
 	labels := make(map[string]string, len(p.GetObjectMeta().GetLabels()))
 	for k, v := range p.GetObjectMeta().GetLabels() {
@@ -481,37 +677,44 @@ func podLabels(p *v1.Pod) map[string]string {
 	return labels
 }
 
-func addWorkloadNameBasedOnCreator(creatorKind string, creatorName string, metrics definition.RawMetrics) {
-	switch creatorKind {
+// addWorkload adds the names of the owners of the pod, from its controller up to the top-level one, and the kind and
+// name of the latter as the workload of the pod, unless it is unresolved and thus may not be the top-level one.
+func (podsFetcher *PodsFetcher) addWorkload(pod *v1.Pod, metrics definition.RawMetrics) {
+	resolver := podsFetcher.ownerResolver
+	if resolver == nil {
+		resolver = owner.DirectResolver{}
+	}
+
+	chain := resolver.Resolve(pod.GetNamespace(), pod.GetOwnerReferences())
+	for _, o := range chain {
+		addWorkloadNameBasedOnOwner(o.Kind, o.Name, metrics)
+	}
+
+	if workload, ok := owner.Workload(chain); ok {
+		metrics["workloadKind"] = workload.Kind
+		metrics["workloadName"] = workload.Name
+	}
+
+	if deployment, ok := owner.DeploymentName(chain); ok {
+		metrics["deploymentName"] = deployment
+	}
+}
+
+func addWorkloadNameBasedOnOwner(ownerKind string, ownerName string, metrics definition.RawMetrics) {
+	switch ownerKind {
 	case "DaemonSet":
-		metrics["daemonsetName"] = creatorName
+		metrics["daemonsetName"] = ownerName
 	case "Deployment":
-		metrics["deploymentName"] = creatorName
+		metrics["deploymentName"] = ownerName
 	case "Job":
-		metrics["jobName"] = creatorName
+		metrics["jobName"] = ownerName
 	case "ReplicaSet":
-		metrics["replicasetName"] = creatorName
-		if d := deploymentNameBasedOnCreator(creatorKind, creatorName); d != "" {
-			metrics["deploymentName"] = d
-		}
+		metrics["replicasetName"] = ownerName
 	case "StatefulSet":
-		metrics["statefulsetName"] = creatorName
+		metrics["statefulsetName"] = ownerName
 	}
 }
 
-func deploymentNameBasedOnCreator(creatorKind, creatorName string) string {
-	var deploymentName string
-	if creatorKind == "ReplicaSet" {
-		deploymentName = replicasetNameToDeploymentName(creatorName)
-	}
-	return deploymentName
-}
-
-func replicasetNameToDeploymentName(rsName string) string {
-	s := strings.Split(rsName, "-")
-	return strings.Join(s[:len(s)-1], "-")
-}
-
 func podID(pod *v1.Pod) string {
 	return fmt.Sprintf("%v_%v", pod.GetObjectMeta().GetNamespace(), pod.GetObjectMeta().GetName())
 }
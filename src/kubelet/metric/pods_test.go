@@ -13,6 +13,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -64,7 +65,7 @@ func TestFetchFunc(t *testing.T) {
 		handler: servePayload,
 	}
 
-	f := NewBasicPodsFetcher(logutil.Debug, &c)
+	f := NewBasicPodsFetcher(logutil.Debug, &c, WithOwnerResolver(testdata.OwnerResolver))
 	g, err := f.DoPodsFetch()
 
 	assert.NoError(t, err)
@@ -88,7 +89,7 @@ func TestFetchFunFromKubeService(test *testing.T) {
 		Kubelet: config.Kubelet{
 			FetchPodsFromKubeService: true,
 		},
-	})
+	}, WithOwnerResolver(testdata.OwnerResolver))
 	podFetchResult, err := podFetch.DoPodsFetch()
 
 	assert.NoError(test, err)
@@ -269,7 +270,7 @@ func assertError(t *testing.T, errorMessage string, handler http.HandlerFunc) {
 		handler: handler,
 	}
 
-	f := NewBasicPodsFetcher(logutil.Debug, &c)
+	f := NewBasicPodsFetcher(logutil.Debug, &c, WithOwnerResolver(testdata.OwnerResolver))
 	g, err := f.DoPodsFetch()
 
 	assert.EqualError(t, err, errorMessage)
@@ -321,7 +322,6 @@ func TestFetchContainersData_WithSidecarContainers(t *testing.T) {
 					Ready:        true,
 					RestartCount: 2,
 				},
-				// Normal init containers should be skipped
 				{
 					Name: initContainerName,
 					State: corev1.ContainerState{
@@ -358,12 +358,21 @@ func TestFetchContainersData_WithSidecarContainers(t *testing.T) {
 
 	sidecarAID := fmt.Sprintf("%s_%s_%s", namespace, podName, sideCarAContainerName)
 	sidecarBID := fmt.Sprintf("%s_%s_%s", namespace, podName, sideCarBContainerName)
-	assert.Equal(t, 2, len(result), "expected only the sidecar containers to be processed")
+	assert.Equal(t, 3, len(result), "expected sidecar and init containers to be processed")
 	assert.Contains(t, result, sidecarAID, "expected sidecar to be present as a key")
 	assert.Contains(t, result, sidecarBID, "expected sidecar-b to be present as a key")
 
+	initID := fmt.Sprintf("%s_%s_%s", namespace, podName, initContainerName)
+	assert.Contains(t, result, initID, "expected normal-init to be present as a key")
+
+	assert.Equal(t, "sidecar", result[sidecarAID]["containerType"])
+	assert.Equal(t, "sidecar", result[sidecarBID]["containerType"])
+	assert.Equal(t, "init", result[initID]["containerType"])
 	assert.Equal(t, "Running", result[sidecarAID]["status"])
 	assert.Equal(t, "Waiting", result[sidecarBID]["status"])
+	assert.Equal(t, "Terminated", result[initID]["status"])
+	assert.Equal(t, "Completed", result[initID]["reason"])
+	assert.Equal(t, int32(0), result[initID]["exitCode"])
 	assert.Equal(t, true, result[sidecarAID]["isReady"])
 	assert.Equal(t, int32(2), result[sidecarAID]["restartCount"])
 	assert.Equal(t, startedAt, result[sidecarAID]["startedAt"])
@@ -409,7 +418,6 @@ func TestFetchContainersData_WithoutOrderOfInitContainers(t *testing.T) {
 			HostIP: "192.168.0.33",
 
 			InitContainerStatuses: []corev1.ContainerStatus{
-				// Normal init containers should be skipped
 				{
 					Name: initContainerName,
 					State: corev1.ContainerState{
@@ -457,7 +465,7 @@ func TestFetchContainersData_WithoutOrderOfInitContainers(t *testing.T) {
 
 	sidecarAID := fmt.Sprintf("%s_%s_%s", namespace, podName, sideCarAContainerName)
 	sidecarBID := fmt.Sprintf("%s_%s_%s", namespace, podName, sideCarBContainerName)
-	assert.Equal(t, 2, len(result), "expected only the sidecar containers to be processed")
+	assert.Equal(t, 3, len(result), "expected sidecar and init containers to be processed")
 	assert.Contains(t, result, sidecarAID, "expected sidecar-a to be present as a key")
 	assert.Contains(t, result, sidecarBID, "expected sidecar-b to be present as a key")
 
@@ -526,7 +534,7 @@ func TestFetchContainersData_WithLessInitContainerStatuses(t *testing.T) {
 
 	sidecarAID := fmt.Sprintf("%s_%s_%s", namespace, podName, sideCarAContainerName)
 	sidecarBID := fmt.Sprintf("%s_%s_%s", namespace, podName, sideCarBContainerName)
-	assert.Equal(t, 2, len(result), "expected only the sidecar containers to be processed")
+	assert.Equal(t, 3, len(result), "expected sidecar and init containers to be processed")
 	assert.Contains(t, result, sidecarAID, "expected sidecar-a to be present as a key")
 	assert.Contains(t, result, sidecarBID, "expected sidecar-b to be present as a key")
 
@@ -800,3 +808,281 @@ func TestFetchContainersData_InPlaceVerticalScaling(t *testing.T) {
 	_, hasLimit := result[sidecarID]["cpuLimitCores"]
 	assert.False(t, hasLimit, "sidecar: no CPU limit in spec, should not be set")
 }
+
+func TestFetchPodData_EphemeralStorage(t *testing.T) {
+	t.Parallel()
+
+	always := corev1.ContainerRestartPolicyAlways
+	ephemeralStorage := func(requests, limits string) corev1.ResourceRequirements {
+		r := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+		if requests != "" {
+			r.Requests[corev1.ResourceEphemeralStorage] = resource.MustParse(requests)
+		}
+		if limits != "" {
+			r.Limits[corev1.ResourceEphemeralStorage] = resource.MustParse(limits)
+		}
+		return r
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "test-node",
+			InitContainers: []corev1.Container{
+				// Regular init containers do not run alongside the app containers, so they are not accounted.
+				{Name: "init", Resources: ephemeralStorage("1Gi", "1Gi")},
+				{Name: "sidecar", RestartPolicy: &always, Resources: ephemeralStorage("", "1Mi")},
+			},
+			Containers: []corev1.Container{
+				{Name: "app", Resources: ephemeralStorage("1Mi", "2Mi")},
+				{Name: "no-limits"},
+			},
+		},
+	}
+
+	podFetcher := &PodsFetcher{
+		logger: logutil.Debug,
+	}
+
+	podData := podFetcher.fetchPodData(pod)
+	assert.Equal(t, int64(1<<20), podData["ephemeralStorageRequestedBytes"])
+	assert.Equal(t, int64(3<<20), podData["ephemeralStorageLimitBytes"])
+
+	containersData := podFetcher.fetchContainersData(pod)
+	assert.Equal(t, int64(1<<20), containersData["default_test-pod_app"]["ephemeralStorageRequestedBytes"])
+	assert.Equal(t, int64(2<<20), containersData["default_test-pod_app"]["ephemeralStorageLimitBytes"])
+	assert.Equal(t, int64(1<<20), containersData["default_test-pod_sidecar"]["ephemeralStorageLimitBytes"])
+	assert.NotContains(t, containersData["default_test-pod_no-limits"], "ephemeralStorageLimitBytes")
+}
+
+func TestFetchPodData_WithoutEphemeralStorage(t *testing.T) {
+	t.Parallel()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app"}},
+		},
+	}
+
+	podFetcher := &PodsFetcher{
+		logger: logutil.Debug,
+	}
+	result := podFetcher.fetchPodData(pod)
+
+	assert.NotContains(t, result, "ephemeralStorageRequestedBytes")
+	assert.NotContains(t, result, "ephemeralStorageLimitBytes")
+}
+
+func TestFetchContainersData_ContainerTypes(t *testing.T) {
+	t.Parallel()
+
+	restartPolicyAlways := corev1.ContainerRestartPolicyAlways
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "migrations", Image: "migrations:latest"},
+				{Name: "proxy", Image: "proxy:latest", RestartPolicy: &restartPolicyAlways},
+			},
+			Containers: []corev1.Container{
+				{Name: "app", Image: "app:latest"},
+			},
+			EphemeralContainers: []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"}},
+			},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: "migrations",
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+					},
+					RestartCount: 4,
+				},
+			},
+			EphemeralContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: "debugger",
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: 130, Reason: "Error"},
+					},
+				},
+			},
+		},
+	}
+
+	podFetcher := &PodsFetcher{}
+	result := podFetcher.fetchContainersData(pod)
+	require.Len(t, result, 4)
+
+	for name, containerType := range map[string]string{
+		"app":        ContainerTypeRegular,
+		"migrations": ContainerTypeInit,
+		"proxy":      ContainerTypeSidecar,
+		"debugger":   ContainerTypeEphemeral,
+	} {
+		assert.Equal(t, containerType, result["default_test-pod_"+name]["containerType"], name)
+	}
+
+	migrations := result["default_test-pod_migrations"]
+	assert.Equal(t, "Waiting", migrations["status"])
+	assert.Equal(t, "CrashLoopBackOff", migrations["reason"])
+	assert.Equal(t, int32(4), migrations["restartCount"])
+	assert.Equal(t, int32(1), migrations["lastTerminatedExitCode"])
+
+	debugger := result["default_test-pod_debugger"]
+	assert.Equal(t, "busybox", debugger["containerImage"])
+	assert.Equal(t, "Terminated", debugger["status"])
+	assert.Equal(t, int32(130), debugger["exitCode"])
+}
+
+func TestFetchPodsData_PendingResize(t *testing.T) {
+	t.Parallel()
+
+	desiredCPU := resource.MustParse("4")
+	desiredMemory := resource.MustParse("8Gi")
+	appliedCPU := resource.MustParse("500m")
+	appliedMemory := resource.MustParse("1Gi")
+	pendingSince := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: desiredCPU, corev1.ResourceMemory: desiredMemory},
+						Limits:   corev1.ResourceList{corev1.ResourceCPU: desiredCPU, corev1.ResourceMemory: desiredMemory},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{
+					Type:               corev1.PodResizePending,
+					Status:             corev1.ConditionTrue,
+					Reason:             corev1.PodReasonInfeasible,
+					Message:            "Node didn't have enough capacity: cpu, requested: 4000, capacity: 2000",
+					LastTransitionTime: metav1.NewTime(pendingSince),
+				},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:               "app",
+					AllocatedResources: corev1.ResourceList{corev1.ResourceCPU: appliedCPU, corev1.ResourceMemory: appliedMemory},
+					Resources: &corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: appliedCPU, corev1.ResourceMemory: appliedMemory},
+						Limits:   corev1.ResourceList{corev1.ResourceCPU: appliedCPU, corev1.ResourceMemory: appliedMemory},
+					},
+				},
+			},
+		},
+	}
+
+	podFetcher := &PodsFetcher{
+		logger: logutil.Debug,
+		now:    func() time.Time { return pendingSince.Add(90 * time.Second) },
+	}
+
+	podData := podFetcher.fetchPodData(pod)
+	assert.Equal(t, "True", podData["isResizePending"])
+	assert.Equal(t, corev1.PodReasonInfeasible, podData["resizePendingReason"])
+	assert.Contains(t, podData["resizePendingMessage"], "enough capacity")
+	assert.Equal(t, pendingSince, podData["resizePendingSince"])
+	assert.Equal(t, float64(90), podData["resizePendingSeconds"])
+	assert.NotContains(t, podData, "isResizeInProgress")
+
+	app := podFetcher.fetchContainersData(pod)["default_test-pod_app"]
+	assert.Equal(t, appliedCPU.MilliValue(), app["cpuRequestedCores"])
+	assert.Equal(t, appliedMemory.Value(), app["memoryLimitBytes"])
+	assert.Equal(t, desiredCPU.MilliValue(), app["desiredCpuRequestedCores"])
+	assert.Equal(t, desiredCPU.MilliValue(), app["desiredCpuLimitCores"])
+	assert.Equal(t, desiredMemory.Value(), app["desiredMemoryRequestedBytes"])
+	assert.Equal(t, desiredMemory.Value(), app["desiredMemoryLimitBytes"])
+	assert.Equal(t, appliedCPU.MilliValue(), app["allocatedCpuCores"])
+	assert.Equal(t, appliedMemory.Value(), app["allocatedMemoryBytes"])
+}
+
+func TestFetchPodData_Conditions(t *testing.T) {
+	t.Parallel()
+
+	transition := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Status: corev1.PodStatus{
+			Phase:    corev1.PodPending,
+			QOSClass: corev1.PodQOSGuaranteed,
+			Conditions: []corev1.PodCondition{
+				{
+					Type:               corev1.PodScheduled,
+					Status:             corev1.ConditionFalse,
+					Reason:             corev1.PodReasonUnschedulable,
+					Message:            "0/10 nodes are available: 3 Insufficient cpu, 7 node(s) had untolerated taint {dedicated: gpu}.",
+					LastTransitionTime: metav1.NewTime(transition),
+				},
+				{
+					Type:   corev1.DisruptionTarget,
+					Status: corev1.ConditionUnknown,
+				},
+			},
+		},
+	}
+
+	podFetcher := &PodsFetcher{
+		logger: logutil.Debug,
+	}
+	result := podFetcher.fetchPodData(pod)
+
+	assert.Equal(t, "Guaranteed", result["qosClass"])
+	assert.Equal(t, map[string]int{"PodScheduled": 0, "DisruptionTarget": -1}, result["conditions"])
+	assert.Equal(t, map[string]int{"PodScheduled": int(transition.Unix())}, result["conditionTransitions"])
+}
+
+func TestFetchPodData_DeploymentNameWithoutResolver(t *testing.T) {
+	t.Parallel()
+
+	controller := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-7d9f8c-abcde",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-7d9f8c", Controller: &controller},
+			},
+		},
+	}
+
+	podFetcher := &PodsFetcher{
+		logger: logutil.Debug,
+	}
+	result := podFetcher.fetchPodData(pod)
+
+	assert.Equal(t, "web-7d9f8c", result["replicasetName"])
+	assert.Equal(t, "web", result["deploymentName"])
+	assert.NotContains(t, result, "workloadKind")
+	assert.NotContains(t, result, "workloadName")
+}
@@ -0,0 +1,114 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/data"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+const (
+	// KubeletProbesMetricsPath is the path where kubelet serves metrics about the probes of the containers.
+	KubeletProbesMetricsPath = "/metrics/probes"
+
+	proberProbeTotal = "prober_probe_total"
+)
+
+// ProbeTypes are the kinds of probes counted by kubelet, as found in the raw metrics of containers.
+var ProbeTypes = []string{"liveness", "readiness", "startup"} //nolint: gochecknoglobals // read-only list.
+
+// ProbesQueries are the queries done to the kubelet probes endpoint.
+var ProbesQueries = []prometheus.Query{ //nolint: gochecknoglobals // read-only list.
+	{MetricName: proberProbeTotal},
+}
+
+// ProbesFetchFunc creates a FetchFunc that fetches the number of successful and failed probes of each container from
+// the kubelet probes metrics path. As the endpoint is missing in old kubelets, failing to get it is recoverable.
+func ProbesFetchFunc(fetchAndFilterPrometheus prometheus.FetchAndFilterMetricsFamilies, queries []prometheus.Query) data.FetchFunc {
+	return func() (definition.RawGroups, error) {
+		g := definition.RawGroups{
+			"container": make(map[string]definition.RawMetrics),
+		}
+
+		families, err := fetchAndFilterPrometheus(queries)
+		if err != nil {
+			return g, data.ErrorGroup{
+				Errors:      []error{fmt.Errorf("error requesting probes metrics endpoint: %w", err)},
+				Recoverable: true,
+			}
+		}
+
+		var errs []error
+
+		for _, f := range families {
+			if f.Name != proberProbeTotal {
+				continue
+			}
+
+			for _, m := range f.Metrics {
+				rawEntityID, err := createRawEntityID(m)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("in probes metrics: %w", err))
+					continue
+				}
+
+				if rawEntityID == "" {
+					continue
+				}
+
+				key, ok := probeRawMetricName(m.Labels["probe_type"], m.Labels["result"])
+				if !ok {
+					continue
+				}
+
+				value, ok := m.Value.(prometheus.CounterValue)
+				if !ok {
+					errs = append(errs, errors.New("unexpected value type for probes metric"))
+					continue
+				}
+
+				metrics, ok := g["container"][rawEntityID]
+				if !ok {
+					metrics = make(definition.RawMetrics)
+					g["container"][rawEntityID] = metrics
+				}
+
+				// Results other than success and failure, like unknown, are added up as failures.
+				current, _ := metrics[key].(float64)
+				metrics[key] = current + float64(value)
+			}
+		}
+
+		if len(errs) > 0 {
+			return g, data.ErrorGroup{
+				Errors:      errs,
+				Recoverable: true,
+			}
+		}
+
+		return g, nil
+	}
+}
+
+// probeRawMetricName returns the name of the raw metric counting the probes of probeType with result, e.g.
+// livenessProbeFailures.
+func probeRawMetricName(probeType, result string) (string, bool) {
+	probeType = strings.ToLower(probeType)
+
+	known := false
+	for _, t := range ProbeTypes {
+		known = known || t == probeType
+	}
+	if !known {
+		return "", false
+	}
+
+	if result == "successful" {
+		return probeType + "ProbeSuccesses", true
+	}
+
+	return probeType + "ProbeFailures", true
+}
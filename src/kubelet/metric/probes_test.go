@@ -0,0 +1,72 @@
+package metric
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/data"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/kubelet/client"
+)
+
+const probesMetrics = `# HELP prober_probe_total [ALPHA] Cumulative number of a liveness, readiness or startup probe for a container by result.
+# TYPE prober_probe_total counter
+prober_probe_total{container="web",namespace="default",pod="web-1",pod_uid="1",probe_type="Liveness",result="successful"} 120
+prober_probe_total{container="web",namespace="default",pod="web-1",pod_uid="1",probe_type="Liveness",result="failed"} 2
+prober_probe_total{container="web",namespace="default",pod="web-1",pod_uid="1",probe_type="Liveness",result="unknown"} 1
+prober_probe_total{container="web",namespace="default",pod="web-1",pod_uid="1",probe_type="Readiness",result="successful"} 60
+prober_probe_total{container="web",namespace="default",pod="web-1",pod_uid="1",probe_type="Startup",result="successful"} 1
+prober_probe_total{container="db",namespace="default",pod="db-0",pod_uid="2",probe_type="Readiness",result="failed"} 5
+`
+
+func TestProbesFetchFunc(t *testing.T) {
+	t.Parallel()
+
+	c := &testClient{
+		handler: readerToHandler(strings.NewReader(probesMetrics)),
+	}
+
+	kubeletClient, err := client.New(client.StaticConnector(c, url.URL{}))
+	require.NoError(t, err)
+
+	g, err := ProbesFetchFunc(kubeletClient.MetricFamiliesGetFunc(KubeletProbesMetricsPath), ProbesQueries)()
+	require.NoError(t, err)
+
+	assert.Equal(t, definition.RawGroups{
+		"container": {
+			"default_web-1_web": {
+				"livenessProbeSuccesses":  float64(120),
+				"livenessProbeFailures":   float64(3),
+				"readinessProbeSuccesses": float64(60),
+				"startupProbeSuccesses":   float64(1),
+			},
+			"default_db-0_db": {
+				"readinessProbeFailures": float64(5),
+			},
+		},
+	}, g)
+}
+
+func TestProbesFetchFunc_EndpointErrorIsRecoverable(t *testing.T) {
+	t.Parallel()
+
+	c := &testClient{
+		handler: func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		},
+	}
+
+	kubeletClient, err := client.New(client.StaticConnector(c, url.URL{}))
+	require.NoError(t, err)
+
+	_, err = ProbesFetchFunc(kubeletClient.MetricFamiliesGetFunc(KubeletProbesMetricsPath), ProbesQueries)()
+
+	var errGroup data.ErrorGroup
+	require.ErrorAs(t, err, &errGroup)
+	assert.True(t, errGroup.Recoverable)
+}
@@ -88,8 +88,10 @@ func OneAttributePerCapacity(rawResources definition.FetchedValue) (definition.F
 // cannot be used — that key only exists as output of the allocatable.* transform,
 // never as a raw group key.
 func AllocatableCPUCores() definition.FetchFunc {
+	fetchAllocatable := definition.FromRaw("allocatable")
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		raw, err := definition.FromRaw("allocatable")(groupLabel, entityID, groups)
+		raw, err := fetchAllocatable(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
@@ -109,8 +111,10 @@ func AllocatableCPUCores() definition.FetchFunc {
 // directly from the "allocatable" ResourceList stored in RawGroups.
 // Same rationale as AllocatableCPUCores.
 func AllocatableMemoryBytes() definition.FetchFunc {
+	fetchAllocatable := definition.FromRaw("allocatable")
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		raw, err := definition.FromRaw("allocatable")(groupLabel, entityID, groups)
+		raw, err := fetchAllocatable(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
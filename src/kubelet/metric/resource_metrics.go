@@ -0,0 +1,199 @@
+package metric
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/data"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+const (
+	// KubeletResourceMetricsPath is the path where kubelet serves the CPU and memory usage of the node, pods and
+	// containers, which is much lighter than the stats summary.
+	KubeletResourceMetricsPath = "/metrics/resource"
+
+	nodeCPUUsageSecondsTotal       = "node_cpu_usage_seconds_total"
+	nodeMemoryWorkingSetBytes      = "node_memory_working_set_bytes"
+	podCPUUsageSecondsTotal        = "pod_cpu_usage_seconds_total"
+	podMemoryWorkingSetBytes       = "pod_memory_working_set_bytes"
+	containerCPUUsageSecondsTotal  = "container_cpu_usage_seconds_total"
+	containerMemoryWorkingSetBytes = "container_memory_working_set_bytes"
+	nanosecondsPerSecond           = 1e9
+)
+
+// ResourceQueries are the queries done to the kubelet resource metrics endpoint.
+var ResourceQueries = []prometheus.Query{ //nolint: gochecknoglobals // read-only list.
+	{MetricName: nodeCPUUsageSecondsTotal},
+	{MetricName: nodeMemoryWorkingSetBytes},
+	{MetricName: podCPUUsageSecondsTotal},
+	{MetricName: podMemoryWorkingSetBytes},
+	{MetricName: containerCPUUsageSecondsTotal},
+	{MetricName: containerMemoryWorkingSetBytes},
+}
+
+// cpuSample is a reading of the cumulative CPU usage of an entity.
+type cpuSample struct {
+	seconds float64
+	at      time.Time
+}
+
+// ResourceFetcher fetches the CPU and memory usage of the node, pods and containers from the kubelet resource metrics
+// path, in the same raw metrics the stats summary would produce. As the endpoint only exposes the cumulative CPU
+// time, the CPU usage is computed from the previous fetch, so it is only reported from the second one onwards.
+type ResourceFetcher struct {
+	nodeName string
+	fetch    prometheus.FetchAndFilterMetricsFamilies
+	queries  []prometheus.Query
+	now      func() time.Time
+
+	lock     sync.Mutex
+	previous map[string]cpuSample
+}
+
+// NewResourceFetcher returns a ResourceFetcher for the node with the given name, as the endpoint does not expose it.
+func NewResourceFetcher(nodeName string, fetch prometheus.FetchAndFilterMetricsFamilies, queries []prometheus.Query) *ResourceFetcher {
+	return &ResourceFetcher{
+		nodeName: nodeName,
+		fetch:    fetch,
+		queries:  queries,
+		now:      time.Now,
+		previous: map[string]cpuSample{},
+	}
+}
+
+// DoResourceFetch implements data.FetchFunc, returning the node, pod and container groups.
+func (f *ResourceFetcher) DoResourceFetch() (definition.RawGroups, error) {
+	families, err := f.fetch(f.queries)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting resource metrics endpoint: %w", err)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := f.now()
+	current := map[string]cpuSample{}
+
+	g := definition.RawGroups{
+		"node": {
+			f.nodeName: {"nodeName": f.nodeName},
+		},
+		"pod":       {},
+		"container": {},
+	}
+
+	var errs []error
+
+	for _, family := range families {
+		for _, m := range family.Metrics {
+			group, rawEntityID, metrics, err := resourceEntity(g, f.nodeName, family.Name, m.Labels)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if metrics == nil {
+				continue
+			}
+
+			value, err := floatValue(m.Value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("parsing %s value: %w", family.Name, err))
+				continue
+			}
+
+			switch family.Name {
+			case nodeCPUUsageSecondsTotal, podCPUUsageSecondsTotal, containerCPUUsageSecondsTotal:
+				key := group + "/" + rawEntityID
+				current[key] = cpuSample{seconds: value, at: now}
+				if group == "node" {
+					metrics["usageCoreNanoSeconds"] = uint64(value * nanosecondsPerSecond)
+				}
+				if usage, ok := cpuUsageNanoCores(f.previous[key], current[key]); ok {
+					metrics["usageNanoCores"] = usage
+				}
+			case nodeMemoryWorkingSetBytes:
+				metrics["memoryWorkingSetBytes"] = uint64(value)
+			case podMemoryWorkingSetBytes, containerMemoryWorkingSetBytes:
+				metrics["workingSetBytes"] = uint64(value)
+			}
+		}
+	}
+
+	// Samples of entities which are gone are dropped.
+	f.previous = current
+
+	if len(errs) > 0 {
+		return g, data.ErrorGroup{
+			Errors:      errs,
+			Recoverable: true,
+		}
+	}
+
+	return g, nil
+}
+
+// resourceEntity returns the raw metrics of the entity a metric of the given family belongs to, creating it if needed.
+// The returned metrics are nil if the metric does not belong to any entity, like the scrape error ones.
+func resourceEntity(g definition.RawGroups, nodeName, family string, labels prometheus.Labels) (string, string, definition.RawMetrics, error) {
+	switch family {
+	case nodeCPUUsageSecondsTotal, nodeMemoryWorkingSetBytes:
+		return "node", nodeName, g["node"][nodeName], nil
+
+	case podCPUUsageSecondsTotal, podMemoryWorkingSetBytes:
+		namespace, podName := labels["namespace"], labels["pod"]
+		if namespace == "" || podName == "" {
+			return "", "", nil, fmt.Errorf("pod not found in %s labels", family) //nolint:err113
+		}
+
+		rawEntityID := fmt.Sprintf("%s_%s", namespace, podName)
+		metrics, ok := g["pod"][rawEntityID]
+		if !ok {
+			metrics = definition.RawMetrics{"podName": podName, "namespace": namespace}
+			g["pod"][rawEntityID] = metrics
+		}
+
+		return "pod", rawEntityID, metrics, nil
+
+	case containerCPUUsageSecondsTotal, containerMemoryWorkingSetBytes:
+		namespace, podName, containerName := labels["namespace"], labels["pod"], labels["container"]
+		if namespace == "" || podName == "" || containerName == "" {
+			return "", "", nil, fmt.Errorf("container not found in %s labels", family) //nolint:err113
+		}
+
+		rawEntityID := fmt.Sprintf("%s_%s_%s", namespace, podName, containerName)
+		metrics, ok := g["container"][rawEntityID]
+		if !ok {
+			metrics = definition.RawMetrics{"containerName": containerName, "podName": podName, "namespace": namespace}
+			g["container"][rawEntityID] = metrics
+		}
+
+		return "container", rawEntityID, metrics, nil
+	}
+
+	return "", "", nil, nil
+}
+
+// cpuUsageNanoCores returns the CPU usage between two samples of cumulative CPU time, if it can be computed.
+func cpuUsageNanoCores(previous, current cpuSample) (uint64, bool) {
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if previous.at.IsZero() || elapsed <= 0 || current.seconds < previous.seconds {
+		return 0, false
+	}
+
+	return uint64((current.seconds - previous.seconds) / elapsed * nanosecondsPerSecond), true
+}
+
+// floatValue returns the value of a counter or gauge.
+func floatValue(v prometheus.Value) (float64, error) {
+	switch value := v.(type) {
+	case prometheus.CounterValue:
+		return float64(value), nil
+	case prometheus.GaugeValue:
+		return float64(value), nil
+	default:
+		return 0, fmt.Errorf("unexpected value type %T", v) //nolint:err113
+	}
+}
@@ -0,0 +1,86 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+func resourceFamilies(nodeCPU, podCPU, containerCPU float64) []prometheus.MetricFamily {
+	podLabels := prometheus.Labels{"namespace": "default", "pod": "web-1"}
+	containerLabels := prometheus.Labels{"namespace": "default", "pod": "web-1", "container": "web"}
+
+	return []prometheus.MetricFamily{
+		{Name: nodeCPUUsageSecondsTotal, Type: "COUNTER", Metrics: []prometheus.Metric{{Value: prometheus.CounterValue(nodeCPU)}}},
+		{Name: nodeMemoryWorkingSetBytes, Type: "GAUGE", Metrics: []prometheus.Metric{{Value: prometheus.GaugeValue(4096)}}},
+		{Name: podCPUUsageSecondsTotal, Type: "COUNTER", Metrics: []prometheus.Metric{{Labels: podLabels, Value: prometheus.CounterValue(podCPU)}}},
+		{Name: podMemoryWorkingSetBytes, Type: "GAUGE", Metrics: []prometheus.Metric{{Labels: podLabels, Value: prometheus.GaugeValue(2048)}}},
+		{Name: containerCPUUsageSecondsTotal, Type: "COUNTER", Metrics: []prometheus.Metric{{Labels: containerLabels, Value: prometheus.CounterValue(containerCPU)}}},
+		{Name: containerMemoryWorkingSetBytes, Type: "GAUGE", Metrics: []prometheus.Metric{{Labels: containerLabels, Value: prometheus.GaugeValue(1024)}}},
+	}
+}
+
+func TestResourceFetcher(t *testing.T) {
+	t.Parallel()
+
+	families := resourceFamilies(100, 10, 8)
+	fetcher := NewResourceFetcher("node-1", func([]prometheus.Query) ([]prometheus.MetricFamily, error) {
+		return families, nil
+	}, ResourceQueries)
+
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	fetcher.now = func() time.Time { return now }
+
+	// CPU usage cannot be computed from the first sample.
+	g, err := fetcher.DoResourceFetch()
+	require.NoError(t, err)
+	assert.Equal(t, definition.RawGroups{
+		"node": {
+			"node-1": {"nodeName": "node-1", "usageCoreNanoSeconds": uint64(100e9), "memoryWorkingSetBytes": uint64(4096)},
+		},
+		"pod": {
+			"default_web-1": {"podName": "web-1", "namespace": "default", "workingSetBytes": uint64(2048)},
+		},
+		"container": {
+			"default_web-1_web": {"containerName": "web", "podName": "web-1", "namespace": "default", "workingSetBytes": uint64(1024)},
+		},
+	}, g)
+
+	families = resourceFamilies(130, 15, 12)
+	now = now.Add(10 * time.Second)
+
+	g, err = fetcher.DoResourceFetch()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3e9), g["node"]["node-1"]["usageNanoCores"])
+	assert.Equal(t, uint64(5e8), g["pod"]["default_web-1"]["usageNanoCores"])
+	assert.Equal(t, uint64(4e8), g["container"]["default_web-1_web"]["usageNanoCores"])
+}
+
+func TestResourceFetcher_CounterReset(t *testing.T) {
+	t.Parallel()
+
+	families := resourceFamilies(100, 10, 8)
+	fetcher := NewResourceFetcher("node-1", func([]prometheus.Query) ([]prometheus.MetricFamily, error) {
+		return families, nil
+	}, ResourceQueries)
+
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	fetcher.now = func() time.Time { return now }
+
+	_, err := fetcher.DoResourceFetch()
+	require.NoError(t, err)
+
+	// The container restarted, so its counter went back.
+	families = resourceFamilies(130, 15, 1)
+	now = now.Add(10 * time.Second)
+
+	g, err := fetcher.DoResourceFetch()
+	require.NoError(t, err)
+	assert.NotContains(t, g["container"]["default_web-1_web"], "usageNanoCores")
+	assert.Contains(t, g["pod"]["default_web-1"], "usageNanoCores")
+}
@@ -0,0 +1,36 @@
+package metric
+
+import (
+	"time"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+)
+
+// startupLatencies are the latencies computed for each pod, from the timestamps of its lifecycle.
+var startupLatencies = []struct { //nolint: gochecknoglobals // read-only list.
+	name     string
+	from, to string
+}{
+	{name: "schedulingLatencySeconds", from: "createdAt", to: "scheduledAt"},
+	{name: "initLatencySeconds", from: "scheduledAt", to: "initializedAt"},
+	{name: "startupLatencySeconds", from: "createdAt", to: "readyAt"},
+}
+
+// addStartupLatencies adds the latencies between the lifecycle timestamps of a pod, if both of them are known. Note
+// readyAt is the last time the pod became ready, so the startup latency of pods which have been unready since they
+// started also accounts for that time.
+func addStartupLatencies(r definition.RawMetrics) {
+	for _, l := range startupLatencies {
+		from, ok := r[l.from].(time.Time)
+		if !ok {
+			continue
+		}
+
+		to, ok := r[l.to].(time.Time)
+		if !ok || to.Before(from) {
+			continue
+		}
+
+		r[l.name] = to.Sub(from).Seconds()
+	}
+}
@@ -0,0 +1,45 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+)
+
+func TestAddStartupLatencies(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	r := definition.RawMetrics{
+		"createdAt":     createdAt,
+		"scheduledAt":   createdAt.Add(2 * time.Second),
+		"initializedAt": createdAt.Add(12 * time.Second),
+		"readyAt":       createdAt.Add(30 * time.Second),
+	}
+
+	addStartupLatencies(r)
+
+	assert.Equal(t, 2.0, r["schedulingLatencySeconds"])
+	assert.Equal(t, 10.0, r["initLatencySeconds"])
+	assert.Equal(t, 30.0, r["startupLatencySeconds"])
+}
+
+func TestAddStartupLatencies_MissingOrUnorderedTimestamps(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	r := definition.RawMetrics{
+		"createdAt":     createdAt,
+		"scheduledAt":   createdAt.Add(2 * time.Second),
+		"initializedAt": createdAt,
+	}
+
+	addStartupLatencies(r)
+
+	assert.Equal(t, 2.0, r["schedulingLatencySeconds"])
+	assert.NotContains(t, r, "initLatencySeconds")
+	assert.NotContains(t, r, "startupLatencySeconds")
+}
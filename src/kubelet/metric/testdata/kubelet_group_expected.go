@@ -16,22 +16,31 @@ var ExpectedGroupData = definition.RawGroups{
 	},
 	"pod": {
 		"kube-system_newrelic-infra-rz225": {
-			"createdKind":   "DaemonSet",
-			"createdBy":     "newrelic-infra",
-			"nodeIP":        "192.168.99.100",
-			"podIP":         "172.17.0.3",
-			"namespace":     "kube-system",
-			"podName":       "newrelic-infra-rz225",
-			"daemonsetName": "newrelic-infra",
-			"nodeName":      "minikube",
-			"startTime":     parseTime("2018-02-14T16:26:33Z"),
-			"status":        "Running",
-			"isReady":       "True",
-			"isScheduled":   "True",
-			"createdAt":     parseTime("2018-02-14T16:26:33Z"),
-			"initializedAt": parseTime("2018-02-14T16:26:33Z"),
-			"readyAt":       parseTime("2018-02-27T15:21:18Z"),
-			"scheduledAt":   parseTime("2018-02-14T16:27:00Z"),
+			"schedulingLatencySeconds":   float64(27),
+			"startupLatencySeconds":      float64(1119285),
+			"conditions":                 map[string]int{"Initialized": 1, "PodScheduled": 1, "Ready": 1},
+			"conditionTransitions":       map[string]int{"Initialized": 1518625593, "PodScheduled": 1518625620, "Ready": 1519744878},
+			"qosClass":                   "Burstable",
+			"createdKind":                "DaemonSet",
+			"createdBy":                  "newrelic-infra",
+			"nodeIP":                     "192.168.99.100",
+			"podIP":                      "172.17.0.3",
+			"namespace":                  "kube-system",
+			"podName":                    "newrelic-infra-rz225",
+			"daemonsetName":              "newrelic-infra",
+			"ephemeralStorageUsedBytes":  uint64(159744),
+			"ephemeralStorageInodesUsed": uint64(36),
+			"workloadKind":               "DaemonSet",
+			"workloadName":               "newrelic-infra",
+			"nodeName":                   "minikube",
+			"startTime":                  parseTime("2018-02-14T16:26:33Z"),
+			"status":                     "Running",
+			"isReady":                    "True",
+			"isScheduled":                "True",
+			"createdAt":                  parseTime("2018-02-14T16:26:33Z"),
+			"initializedAt":              parseTime("2018-02-14T16:26:33Z"),
+			"readyAt":                    parseTime("2018-02-27T15:21:18Z"),
+			"scheduledAt":                parseTime("2018-02-14T16:27:00Z"),
 			"labels": map[string]string{
 				"controller-revision-hash": "3887482659",
 				"name":                     "newrelic-infra",
@@ -61,18 +70,24 @@ var ExpectedGroupData = definition.RawGroups{
 			"memoryLimitBytes":     int64(104857600),
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq": {
-			"createdKind":    "ReplicaSet",
-			"createdBy":      "kube-state-metrics-57f4659995",
-			"nodeIP":         "192.168.99.100",
-			"namespace":      "kube-system",
-			"podName":        "kube-state-metrics-57f4659995-6n2qq",
-			"nodeName":       "minikube",
-			"status":         "Running",
-			"isReady":        "True",
-			"isScheduled":    "True",
-			"createdAt":      parseTime("2018-02-14T16:27:38Z"),
-			"deploymentName": "kube-state-metrics",
-			"replicasetName": "kube-state-metrics-57f4659995",
+			"conditions":                 map[string]int{"PodScheduled": 1},
+			"conditionTransitions":       map[string]int{"PodScheduled": 1518625591},
+			"createdKind":                "ReplicaSet",
+			"createdBy":                  "kube-state-metrics-57f4659995",
+			"nodeIP":                     "192.168.99.100",
+			"namespace":                  "kube-system",
+			"podName":                    "kube-state-metrics-57f4659995-6n2qq",
+			"nodeName":                   "minikube",
+			"status":                     "Running",
+			"isReady":                    "True",
+			"isScheduled":                "True",
+			"createdAt":                  parseTime("2018-02-14T16:27:38Z"),
+			"deploymentName":             "kube-state-metrics",
+			"ephemeralStorageUsedBytes":  uint64(7823360),
+			"ephemeralStorageInodesUsed": uint64(13),
+			"workloadKind":               "Deployment",
+			"workloadName":               "kube-state-metrics",
+			"replicasetName":             "kube-state-metrics-57f4659995",
 			"labels": map[string]string{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
@@ -109,6 +124,8 @@ var ExpectedGroupData = definition.RawGroups{
 			"createdAt":      parseTime("2019-03-13T07:59:00Z"),
 			"startTime":      parseTime("2019-03-13T07:59:00Z"),
 			"deploymentName": "sh",
+			"workloadKind":   "Deployment",
+			"workloadName":   "sh",
 			"replicasetName": "sh-7c95664875",
 			"labels": map[string]string{
 				"pod-template-hash": "3751220431",
@@ -116,15 +133,19 @@ var ExpectedGroupData = definition.RawGroups{
 			},
 		},
 		"kube-system_kube-controller-manager-minikube": {
-			"containersReadyAt": parseTime("2019-10-23T17:10:49Z"),
-			"initializedAt":     parseTime("2019-10-23T17:10:48Z"),
-			"readyAt":           parseTime("2019-10-23T17:10:49Z"),
-			"scheduledAt":       parseTime("2019-10-23T17:10:48Z"),
-			"isReady":           "True",
-			"startTime":         parseTime("2019-10-23T17:10:48Z"),
-			"status":            "Running",
-			"nodeIP":            "192.168.99.100",
-			"podIP":             "10.0.2.15",
+			"initLatencySeconds":   float64(0),
+			"conditions":           map[string]int{"ContainersReady": 1, "Initialized": 1, "PodScheduled": 1, "Ready": 1},
+			"conditionTransitions": map[string]int{"ContainersReady": 1571850649, "Initialized": 1571850648, "PodScheduled": 1571850648, "Ready": 1571850649},
+			"qosClass":             "Burstable",
+			"containersReadyAt":    parseTime("2019-10-23T17:10:49Z"),
+			"initializedAt":        parseTime("2019-10-23T17:10:48Z"),
+			"readyAt":              parseTime("2019-10-23T17:10:49Z"),
+			"scheduledAt":          parseTime("2019-10-23T17:10:48Z"),
+			"isReady":              "True",
+			"startTime":            parseTime("2019-10-23T17:10:48Z"),
+			"status":               "Running",
+			"nodeIP":               "192.168.99.100",
+			"podIP":                "10.0.2.15",
 			"labels": map[string]string{
 				"tier":      "control-plane",
 				"k8s-app":   "kube-controller-manager",
@@ -140,34 +161,42 @@ var ExpectedGroupData = definition.RawGroups{
 	},
 	"container": {
 		"kube-system_newrelic-infra-rz225_newrelic-infra": {
-			"containerName":            "newrelic-infra",
-			"containerID":              "69d7203a8f2d2d027ffa51d61002eac63357f22a17403363ef79e66d1c3146b2",
-			"containerImage":           "newrelic/ohaik:1.0.0-beta3",
-			"containerImageID":         "sha256:1a95d0df2997f93741fbe2a15d2c31a394e752fd942ec29bf16a44163342f6a1",
-			"namespace":                "kube-system",
-			"podName":                  "newrelic-infra-rz225",
-			"daemonsetName":            "newrelic-infra",
-			"nodeName":                 "minikube",
-			"nodeIP":                   "192.168.99.100",
-			"restartCount":             int32(6),
-			"isReady":                  true,
-			"status":                   "Running",
-			"startedAt":                parseTime("2018-02-27T15:21:16Z"),
-			"lastTerminatedExitCode":   int32(0),
-			"lastTerminatedExitReason": "Completed",
-			"lastTerminatedTimestamp":  parseTime("2018-02-27T15:21:10Z"),
-			"cpuRequestedCores":        int64(100),
-			"memoryRequestedBytes":     int64(104857600),
-			"memoryLimitBytes":         int64(104857600),
-			"usageBytes":               uint64(18083840),
-			"workingSetBytes":          uint64(17113088),
-			"usageNanoCores":           uint64(17428240),
-			"fsAvailableBytes":         uint64(14924988416),
-			"fsUsedBytes":              uint64(126976),
-			"fsCapacityBytes":          uint64(17293533184),
-			"fsInodesFree":             uint64(9713372),
-			"fsInodes":                 uint64(9732096),
-			"fsInodesUsed":             uint64(36),
+			"containerName":               "newrelic-infra",
+			"containerID":                 "69d7203a8f2d2d027ffa51d61002eac63357f22a17403363ef79e66d1c3146b2",
+			"containerImage":              "newrelic/ohaik:1.0.0-beta3",
+			"containerType":               "regular",
+			"containerImageID":            "sha256:1a95d0df2997f93741fbe2a15d2c31a394e752fd942ec29bf16a44163342f6a1",
+			"namespace":                   "kube-system",
+			"podName":                     "newrelic-infra-rz225",
+			"daemonsetName":               "newrelic-infra",
+			"workloadKind":                "DaemonSet",
+			"workloadName":                "newrelic-infra",
+			"nodeName":                    "minikube",
+			"nodeIP":                      "192.168.99.100",
+			"restartCount":                int32(6),
+			"isReady":                     true,
+			"status":                      "Running",
+			"startedAt":                   parseTime("2018-02-27T15:21:16Z"),
+			"lastTerminatedExitCode":      int32(0),
+			"lastTerminatedExitReason":    "Completed",
+			"lastTerminatedTimestamp":     parseTime("2018-02-27T15:21:10Z"),
+			"cpuRequestedCores":           int64(100),
+			"desiredCpuRequestedCores":    int64(100),
+			"memoryRequestedBytes":        int64(104857600),
+			"desiredMemoryRequestedBytes": int64(104857600),
+			"memoryLimitBytes":            int64(104857600),
+			"desiredMemoryLimitBytes":     int64(104857600),
+			"usageBytes":                  uint64(18083840),
+			"workingSetBytes":             uint64(17113088),
+			"usageNanoCores":              uint64(17428240),
+			"fsAvailableBytes":            uint64(14924988416),
+			"fsUsedBytes":                 uint64(126976),
+			"logsUsedBytes":               uint64(32768),
+			"logsInodesUsed":              uint64(18724),
+			"fsCapacityBytes":             uint64(17293533184),
+			"fsInodesFree":                uint64(9713372),
+			"fsInodes":                    uint64(9732096),
+			"fsInodesUsed":                uint64(36),
 			"labels": map[string]string{
 				"controller-revision-hash": "3887482659",
 				"name":                     "newrelic-infra",
@@ -178,6 +207,7 @@ var ExpectedGroupData = definition.RawGroups{
 			"containerName":    "kube-state-metrics",
 			"containerID":      "c452821fcf6c5f594d4f98a1426e7a2c51febb65d5d50d92903f9dfb367bfba7",
 			"containerImage":   "quay.io/coreos/kube-state-metrics:v1.1.0",
+			"containerType":    "regular",
 			"containerImageID": "quay.io/coreos/kube-state-metrics@sha256:52a2c47355c873709bb4e37e990d417e9188c2a778a0c38ed4c09776ddc54efb",
 			"namespace":        "kube-system",
 			"podName":          "kube-state-metrics-57f4659995-6n2qq",
@@ -187,21 +217,29 @@ var ExpectedGroupData = definition.RawGroups{
 			// "isReady":              false, // No isReady since there is no isReady in status field in the pod fetched from kubelet /pods.
 			// "status":         "Running", // No Status since there is no ContainerStatuses field in the pod fetched from kubelet /pods.
 			// "startedAt":            parseTime("2018-02-27T15:21:37Z"), // No startedAt since there is no startedAt in status field in the pod fetched from kubelet /pods.
-			"deploymentName":       "kube-state-metrics",
-			"replicasetName":       "kube-state-metrics-57f4659995",
-			"cpuRequestedCores":    int64(101),
-			"cpuLimitCores":        int64(101),
-			"memoryRequestedBytes": int64(106954752),
-			"memoryLimitBytes":     int64(106954752),
-			"usageBytes":           uint64(15568896),
-			"workingSetBytes":      uint64(15110144),
-			"usageNanoCores":       uint64(941138),
-			"fsAvailableBytes":     uint64(14924988416),
-			"fsUsedBytes":          uint64(28672),
-			"fsCapacityBytes":      uint64(17293533184),
-			"fsInodesFree":         uint64(9713372),
-			"fsInodes":             uint64(9732096),
-			"fsInodesUsed":         uint64(7),
+			"deploymentName":              "kube-state-metrics",
+			"workloadKind":                "Deployment",
+			"workloadName":                "kube-state-metrics",
+			"replicasetName":              "kube-state-metrics-57f4659995",
+			"cpuRequestedCores":           int64(101),
+			"desiredCpuRequestedCores":    int64(101),
+			"cpuLimitCores":               int64(101),
+			"desiredCpuLimitCores":        int64(101),
+			"memoryRequestedBytes":        int64(106954752),
+			"desiredMemoryRequestedBytes": int64(106954752),
+			"memoryLimitBytes":            int64(106954752),
+			"desiredMemoryLimitBytes":     int64(106954752),
+			"usageBytes":                  uint64(15568896),
+			"workingSetBytes":             uint64(15110144),
+			"usageNanoCores":              uint64(941138),
+			"fsAvailableBytes":            uint64(14924988416),
+			"fsUsedBytes":                 uint64(28672),
+			"logsUsedBytes":               uint64(5763072),
+			"logsInodesUsed":              uint64(18724),
+			"fsCapacityBytes":             uint64(17293533184),
+			"fsInodesFree":                uint64(9713372),
+			"fsInodes":                    uint64(9732096),
+			"fsInodesUsed":                uint64(7),
 			"labels": map[string]string{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
@@ -211,6 +249,7 @@ var ExpectedGroupData = definition.RawGroups{
 			"containerName":    "addon-resizer",
 			"containerID":      "3328c17bfd22f1a82fcdf8707c2f8f040c462e548c24780079bba95d276d93e1",
 			"containerImage":   "gcr.io/google_containers/addon-resizer:1.0",
+			"containerType":    "regular",
 			"containerImageID": "gcr.io/google_containers/addon-resizer@sha256:e77acf80697a70386c04ae3ab494a7b13917cb30de2326dcf1a10a5118eddabe",
 			"namespace":        "kube-system",
 			"podName":          "kube-state-metrics-57f4659995-6n2qq",
@@ -220,21 +259,29 @@ var ExpectedGroupData = definition.RawGroups{
 			// "isReady":              false, // No isReady since there is no isReady in status field in the pod fetched from kubelet /pods.
 			// "status":         "Running", // No Status since there is no ContainerStatuses field in the pod fetched from kubelet /pods.
 			// "startedAt":            parseTime("2018-02-27T15:21:37Z"), // No startedAt since there is no startedAt in status field in the pod fetched from kubelet /pods.
-			"deploymentName":       "kube-state-metrics",
-			"replicasetName":       "kube-state-metrics-57f4659995",
-			"cpuRequestedCores":    int64(100),
-			"cpuLimitCores":        int64(100),
-			"memoryRequestedBytes": int64(31457280),
-			"memoryLimitBytes":     int64(31457280),
-			"usageBytes":           uint64(6373376),
-			"workingSetBytes":      uint64(6270976),
-			"usageNanoCores":       uint64(131742),
-			"fsAvailableBytes":     uint64(14924988416),
-			"fsUsedBytes":          uint64(24576),
-			"fsCapacityBytes":      uint64(17293533184),
-			"fsInodesFree":         uint64(9713372),
-			"fsInodes":             uint64(9732096),
-			"fsInodesUsed":         uint64(6),
+			"deploymentName":              "kube-state-metrics",
+			"workloadKind":                "Deployment",
+			"workloadName":                "kube-state-metrics",
+			"replicasetName":              "kube-state-metrics-57f4659995",
+			"cpuRequestedCores":           int64(100),
+			"desiredCpuRequestedCores":    int64(100),
+			"cpuLimitCores":               int64(100),
+			"desiredCpuLimitCores":        int64(100),
+			"memoryRequestedBytes":        int64(31457280),
+			"desiredMemoryRequestedBytes": int64(31457280),
+			"memoryLimitBytes":            int64(31457280),
+			"desiredMemoryLimitBytes":     int64(31457280),
+			"usageBytes":                  uint64(6373376),
+			"workingSetBytes":             uint64(6270976),
+			"usageNanoCores":              uint64(131742),
+			"fsAvailableBytes":            uint64(14924988416),
+			"fsUsedBytes":                 uint64(24576),
+			"logsUsedBytes":               uint64(2007040),
+			"logsInodesUsed":              uint64(18724),
+			"fsCapacityBytes":             uint64(17293533184),
+			"fsInodesFree":                uint64(9713372),
+			"fsInodes":                    uint64(9732096),
+			"fsInodesUsed":                uint64(6),
 			"labels": map[string]string{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
@@ -243,12 +290,15 @@ var ExpectedGroupData = definition.RawGroups{
 		"default_sh-7c95664875-4btqh_sh": {
 			"containerName":  "sh",
 			"containerImage": "python",
+			"containerType":  "regular",
 			"namespace":      "default",
 			"podName":        "sh-7c95664875-4btqh",
 			"replicasetName": "sh-7c95664875",
 			"nodeName":       "minikube",
 			"nodeIP":         "192.168.99.100",
 			"deploymentName": "sh",
+			"workloadKind":   "Deployment",
+			"workloadName":   "sh",
 			"labels": map[string]string{
 				"pod-template-hash": "3751220431",
 				"run":               "sh",
@@ -257,8 +307,10 @@ var ExpectedGroupData = definition.RawGroups{
 		"kube-system_kube-controller-manager-minikube_kube-controller-manager": {
 			"containerName":            "kube-controller-manager",
 			"containerImage":           "k8s.gcr.io/kube-controller-manager:v1.16.0",
+			"containerType":            "regular",
 			"nodeIP":                   "192.168.99.100",
 			"cpuRequestedCores":        int64(200),
+			"desiredCpuRequestedCores": int64(200),
 			"status":                   "Running",
 			"lastTerminatedExitCode":   int32(255),
 			"lastTerminatedExitReason": "Error",
@@ -278,32 +330,33 @@ var ExpectedGroupData = definition.RawGroups{
 	},
 	"node": {
 		"minikube": {
-			"nodeName":              "minikube",
-			"errors":                uint64(0),
-			"fsAvailableBytes":      uint64(14924988416),
-			"fsCapacityBytes":       uint64(17293533184),
-			"fsInodes":              uint64(9732096),
-			"fsInodesFree":          uint64(9713372),
-			"fsInodesUsed":          uint64(18724),
-			"fsUsedBytes":           uint64(1355673600),
-			"kubeletVersion":        "v1.22.1",
-			"memoryAvailableBytes":  uint64(791736320),
-			"memoryMajorPageFaults": uint64(0),
-			"memoryPageFaults":      uint64(113947),
-			"memoryRequestedBytes":  int64(243269632),
-			"memoryRssBytes":        uint64(660684800),
-			"memoryUsageBytes":      uint64(1843650560),
-			"memoryWorkingSetBytes": uint64(1305468928),
-			"runtimeAvailableBytes": uint64(14924988416),
-			"runtimeCapacityBytes":  uint64(17293533184),
-			"runtimeInodes":         uint64(9732096),
-			"runtimeInodesFree":     uint64(9713372),
-			"runtimeInodesUsed":     uint64(18724),
-			"runtimeUsedBytes":      uint64(969241979),
-			"rxBytes":               uint64(1507694406),
-			"txBytes":               uint64(120789968),
-			"usageCoreNanoSeconds":  uint64(22332102208229),
-			"usageNanoCores":        uint64(228759290),
+			"nodeName":                      "minikube",
+			"errors":                        uint64(0),
+			"fsAvailableBytes":              uint64(14924988416),
+			"fsCapacityBytes":               uint64(17293533184),
+			"fsInodes":                      uint64(9732096),
+			"fsInodesFree":                  uint64(9713372),
+			"fsInodesUsed":                  uint64(18724),
+			"fsUsedBytes":                   uint64(1355673600),
+			"kubeletVersion":                "v1.22.1",
+			"defaultNetworkInterfaceFamily": "ipv4",
+			"memoryAvailableBytes":          uint64(791736320),
+			"memoryMajorPageFaults":         uint64(0),
+			"memoryPageFaults":              uint64(113947),
+			"memoryRequestedBytes":          int64(243269632),
+			"memoryRssBytes":                uint64(660684800),
+			"memoryUsageBytes":              uint64(1843650560),
+			"memoryWorkingSetBytes":         uint64(1305468928),
+			"runtimeAvailableBytes":         uint64(14924988416),
+			"runtimeCapacityBytes":          uint64(17293533184),
+			"runtimeInodes":                 uint64(9732096),
+			"runtimeInodesFree":             uint64(9713372),
+			"runtimeInodesUsed":             uint64(18724),
+			"runtimeUsedBytes":              uint64(969241979),
+			"rxBytes":                       uint64(1507694406),
+			"txBytes":                       uint64(120789968),
+			"usageCoreNanoSeconds":          uint64(22332102208229),
+			"usageNanoCores":                uint64(228759290),
 			"labels": map[string]string{
 				"kubernetes.io/arch":                    "amd64",
 				"kubernetes.io/hostname":                "minikube",
@@ -0,0 +1,37 @@
+package testdata
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/owner"
+)
+
+// OwnerResolver resolves the ReplicaSets found in the pods payload to the Deployments owning them, as a metadata
+// informer would.
+var OwnerResolver owner.Resolver = ownerResolver{
+	"default/sh-7c95664875":                     {APIVersion: "apps/v1", Kind: "Deployment", Name: "sh"},
+	"kube-system/kube-state-metrics-57f4659995": {APIVersion: "apps/v1", Kind: "Deployment", Name: "kube-state-metrics"},
+}
+
+// ownerResolver maps namespace/name of ReplicaSets to their owner.
+type ownerResolver map[string]owner.Owner
+
+func (r ownerResolver) Resolve(namespace string, refs []metav1.OwnerReference) []owner.Owner {
+	chain := owner.DirectResolver{}.Resolve(namespace, refs)
+	if len(chain) == 0 {
+		return chain
+	}
+
+	// Owners other than ReplicaSets, like DaemonSets, are found without owners of their own.
+	if chain[0].Kind != "ReplicaSet" {
+		chain[0].Unresolved = false
+		return chain
+	}
+
+	if deployment, ok := r[namespace+"/"+chain[0].Name]; ok {
+		chain[0].Unresolved = false
+		chain = append(chain, deployment)
+	}
+
+	return chain
+}
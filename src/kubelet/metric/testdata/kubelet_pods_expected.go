@@ -10,40 +10,51 @@ import (
 var ExpectedRawData = definition.RawGroups{
 	"pod": {
 		"kube-system_kube-controller-manager-minikube": {
-			"nodeName":          "minikube",
-			"isReady":           "True",
-			"isScheduled":       "True",
-			"nodeIP":            "192.168.99.100",
-			"podIP":             "10.0.2.15",
-			"labels":            map[string]string{"k8s-app": "kube-controller-manager", "component": "kube-controller-manager", "tier": "control-plane"},
-			"namespace":         "kube-system",
-			"podName":           "kube-controller-manager-minikube",
-			"priority":          int32(2000000000),
-			"priorityClassName": "system-cluster-critical",
-			"status":            "Running",
-			"startTime":         parseTime("2019-10-23T17:10:48Z"),
-			"containersReadyAt": parseTime("2019-10-23T17:10:49Z"),
-			"initializedAt":     parseTime("2019-10-23T17:10:48Z"),
-			"readyAt":           parseTime("2019-10-23T17:10:49Z"),
-			"scheduledAt":       parseTime("2019-10-23T17:10:48Z"),
+			"initLatencySeconds":   float64(0),
+			"conditions":           map[string]int{"ContainersReady": 1, "Initialized": 1, "PodScheduled": 1, "Ready": 1},
+			"conditionTransitions": map[string]int{"ContainersReady": 1571850649, "Initialized": 1571850648, "PodScheduled": 1571850648, "Ready": 1571850649},
+			"qosClass":             "Burstable",
+			"nodeName":             "minikube",
+			"isReady":              "True",
+			"isScheduled":          "True",
+			"nodeIP":               "192.168.99.100",
+			"podIP":                "10.0.2.15",
+			"labels":               map[string]string{"k8s-app": "kube-controller-manager", "component": "kube-controller-manager", "tier": "control-plane"},
+			"namespace":            "kube-system",
+			"podName":              "kube-controller-manager-minikube",
+			"priority":             int32(2000000000),
+			"priorityClassName":    "system-cluster-critical",
+			"status":               "Running",
+			"startTime":            parseTime("2019-10-23T17:10:48Z"),
+			"containersReadyAt":    parseTime("2019-10-23T17:10:49Z"),
+			"initializedAt":        parseTime("2019-10-23T17:10:48Z"),
+			"readyAt":              parseTime("2019-10-23T17:10:49Z"),
+			"scheduledAt":          parseTime("2019-10-23T17:10:48Z"),
 		},
 		"kube-system_newrelic-infra-rz225": {
-			"createdKind":   "DaemonSet",
-			"createdBy":     "newrelic-infra",
-			"nodeIP":        "192.168.99.100",
-			"podIP":         "172.17.0.3",
-			"namespace":     "kube-system",
-			"daemonsetName": "newrelic-infra",
-			"podName":       "newrelic-infra-rz225",
-			"nodeName":      "minikube",
-			"startTime":     parseTime("2018-02-14T16:26:33Z"),
-			"status":        "Running",
-			"isReady":       "True",
-			"isScheduled":   "True",
-			"createdAt":     parseTime("2018-02-14T16:26:33Z"),
-			"initializedAt": parseTime("2018-02-14T16:26:33Z"),
-			"readyAt":       parseTime("2018-02-27T15:21:18Z"),
-			"scheduledAt":   parseTime("2018-02-14T16:27:00Z"),
+			"schedulingLatencySeconds": float64(27),
+			"startupLatencySeconds":    float64(1119285),
+			"conditions":               map[string]int{"Initialized": 1, "PodScheduled": 1, "Ready": 1},
+			"conditionTransitions":     map[string]int{"Initialized": 1518625593, "PodScheduled": 1518625620, "Ready": 1519744878},
+			"qosClass":                 "Burstable",
+			"createdKind":              "DaemonSet",
+			"createdBy":                "newrelic-infra",
+			"nodeIP":                   "192.168.99.100",
+			"podIP":                    "172.17.0.3",
+			"namespace":                "kube-system",
+			"daemonsetName":            "newrelic-infra",
+			"workloadKind":             "DaemonSet",
+			"workloadName":             "newrelic-infra",
+			"podName":                  "newrelic-infra-rz225",
+			"nodeName":                 "minikube",
+			"startTime":                parseTime("2018-02-14T16:26:33Z"),
+			"status":                   "Running",
+			"isReady":                  "True",
+			"isScheduled":              "True",
+			"createdAt":                parseTime("2018-02-14T16:26:33Z"),
+			"initializedAt":            parseTime("2018-02-14T16:26:33Z"),
+			"readyAt":                  parseTime("2018-02-27T15:21:18Z"),
+			"scheduledAt":              parseTime("2018-02-14T16:27:00Z"),
 			"labels": map[string]string{
 				"controller-revision-hash": "3887482659",
 				"name":                     "newrelic-infra",
@@ -55,18 +66,22 @@ var ExpectedRawData = definition.RawGroups{
 			"memoryRequestedBytes": int64(52428800),
 		},
 		"kube-system_kube-state-metrics-57f4659995-6n2qq": {
-			"createdKind":    "ReplicaSet",
-			"createdBy":      "kube-state-metrics-57f4659995",
-			"nodeIP":         "192.168.99.100",
-			"namespace":      "kube-system",
-			"podName":        "kube-state-metrics-57f4659995-6n2qq",
-			"nodeName":       "minikube",
-			"status":         "Running", // Running because is fake pending pod.
-			"isReady":        "True",
-			"isScheduled":    "True",
-			"createdAt":      parseTime("2018-02-14T16:27:38Z"),
-			"deploymentName": "kube-state-metrics",
-			"replicasetName": "kube-state-metrics-57f4659995",
+			"conditions":           map[string]int{"PodScheduled": 1},
+			"conditionTransitions": map[string]int{"PodScheduled": 1518625591},
+			"createdKind":          "ReplicaSet",
+			"createdBy":            "kube-state-metrics-57f4659995",
+			"nodeIP":               "192.168.99.100",
+			"namespace":            "kube-system",
+			"podName":              "kube-state-metrics-57f4659995-6n2qq",
+			"nodeName":             "minikube",
+			"status":               "Running", // Running because is fake pending pod.
+			"isReady":              "True",
+			"isScheduled":          "True",
+			"createdAt":            parseTime("2018-02-14T16:27:38Z"),
+			"deploymentName":       "kube-state-metrics",
+			"workloadKind":         "Deployment",
+			"workloadName":         "kube-state-metrics",
+			"replicasetName":       "kube-state-metrics-57f4659995",
 			"labels": map[string]string{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
@@ -85,6 +100,8 @@ var ExpectedRawData = definition.RawGroups{
 			"createdAt":      parseTime("2019-03-13T07:59:00Z"),
 			"startTime":      parseTime("2019-03-13T07:59:00Z"),
 			"deploymentName": "sh",
+			"workloadKind":   "Deployment",
+			"workloadName":   "sh",
 			"replicasetName": "sh-7c95664875",
 			"labels": map[string]string{
 				"pod-template-hash": "3751220431",
@@ -96,22 +113,28 @@ var ExpectedRawData = definition.RawGroups{
 		"kube-system_newrelic-infra-rz225_newrelic-infra": {
 			"containerName":  "newrelic-infra",
 			"containerImage": "newrelic/ohaik:1.0.0-beta3",
+			"containerType":  "regular",
 			"namespace":      "kube-system",
 			"podName":        "newrelic-infra-rz225",
 			"daemonsetName":  "newrelic-infra",
+			"workloadKind":   "DaemonSet",
+			"workloadName":   "newrelic-infra",
 			"nodeName":       "minikube",
 			"nodeIP":         "192.168.99.100",
 			"restartCount":   int32(6),
 			"isReady":        true,
 			"status":         "Running",
 			// "reason":               "", // TODO
-			"startedAt":                parseTime("2018-02-27T15:21:16Z"),
-			"lastTerminatedExitCode":   int32(0),
-			"lastTerminatedExitReason": "Completed",
-			"lastTerminatedTimestamp":  parseTime("2018-02-27T15:21:10Z"),
-			"cpuRequestedCores":        int64(100),
-			"memoryRequestedBytes":     int64(104857600),
-			"memoryLimitBytes":         int64(104857600),
+			"startedAt":                   parseTime("2018-02-27T15:21:16Z"),
+			"lastTerminatedExitCode":      int32(0),
+			"lastTerminatedExitReason":    "Completed",
+			"lastTerminatedTimestamp":     parseTime("2018-02-27T15:21:10Z"),
+			"cpuRequestedCores":           int64(100),
+			"desiredCpuRequestedCores":    int64(100),
+			"memoryRequestedBytes":        int64(104857600),
+			"desiredMemoryRequestedBytes": int64(104857600),
+			"memoryLimitBytes":            int64(104857600),
+			"desiredMemoryLimitBytes":     int64(104857600),
 			"labels": map[string]string{
 				"controller-revision-hash": "3887482659",
 				"name":                     "newrelic-infra",
@@ -121,6 +144,7 @@ var ExpectedRawData = definition.RawGroups{
 		"kube-system_kube-state-metrics-57f4659995-6n2qq_kube-state-metrics": {
 			"containerName":  "kube-state-metrics",
 			"containerImage": "quay.io/coreos/kube-state-metrics:v1.1.0",
+			"containerType":  "regular",
 			"namespace":      "kube-system",
 			"podName":        "kube-state-metrics-57f4659995-6n2qq",
 			"replicasetName": "kube-state-metrics-57f4659995",
@@ -130,11 +154,17 @@ var ExpectedRawData = definition.RawGroups{
 			// "isReady":        false,     // No isReady since there is no isReady in status field in the pod.
 			// "status":         "Running", // No Status since there is no ContainerStatuses field in the pod.
 			"deploymentName": "kube-state-metrics",
+			"workloadKind":   "Deployment",
+			"workloadName":   "kube-state-metrics",
 			// "startedAt":            parseTime("2018-02-27T15:21:37Z"), // No startedAt since there is no startedAt in status field in the pod.
-			"cpuRequestedCores":    int64(101),
-			"cpuLimitCores":        int64(101),
-			"memoryRequestedBytes": int64(106954752),
-			"memoryLimitBytes":     int64(106954752),
+			"cpuRequestedCores":           int64(101),
+			"desiredCpuRequestedCores":    int64(101),
+			"cpuLimitCores":               int64(101),
+			"desiredCpuLimitCores":        int64(101),
+			"memoryRequestedBytes":        int64(106954752),
+			"desiredMemoryRequestedBytes": int64(106954752),
+			"memoryLimitBytes":            int64(106954752),
+			"desiredMemoryLimitBytes":     int64(106954752),
 			"labels": map[string]string{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
@@ -143,6 +173,7 @@ var ExpectedRawData = definition.RawGroups{
 		"kube-system_kube-state-metrics-57f4659995-6n2qq_addon-resizer": {
 			"containerName":  "addon-resizer",
 			"containerImage": "gcr.io/google_containers/addon-resizer:1.0",
+			"containerType":  "regular",
 			"namespace":      "kube-system",
 			"podName":        "kube-state-metrics-57f4659995-6n2qq",
 			"replicasetName": "kube-state-metrics-57f4659995",
@@ -152,12 +183,18 @@ var ExpectedRawData = definition.RawGroups{
 			// "isReady":        false,     // No isReady since there is no isReady in status field in the pod.
 			// "status":         "Running", // No Status since there is no ContainerStatuses field in the pod.
 			"deploymentName": "kube-state-metrics",
+			"workloadKind":   "Deployment",
+			"workloadName":   "kube-state-metrics",
 			// "reason":               "",                                // TODO
 			// "startedAt":            parseTime("2018-02-27T15:21:38Z"), // No startedAt since there is no startedAt in status field in the pod.
-			"cpuRequestedCores":    int64(100),
-			"cpuLimitCores":        int64(100),
-			"memoryRequestedBytes": int64(31457280),
-			"memoryLimitBytes":     int64(31457280),
+			"cpuRequestedCores":           int64(100),
+			"desiredCpuRequestedCores":    int64(100),
+			"cpuLimitCores":               int64(100),
+			"desiredCpuLimitCores":        int64(100),
+			"memoryRequestedBytes":        int64(31457280),
+			"desiredMemoryRequestedBytes": int64(31457280),
+			"memoryLimitBytes":            int64(31457280),
+			"desiredMemoryLimitBytes":     int64(31457280),
 			"labels": map[string]string{
 				"k8s-app":           "kube-state-metrics",
 				"pod-template-hash": "1390215551",
@@ -166,12 +203,15 @@ var ExpectedRawData = definition.RawGroups{
 		"default_sh-7c95664875-4btqh_sh": {
 			"containerName":  "sh",
 			"containerImage": "python",
+			"containerType":  "regular",
 			"namespace":      "default",
 			"podName":        "sh-7c95664875-4btqh",
 			"replicasetName": "sh-7c95664875",
 			"nodeName":       "minikube",
 			"nodeIP":         "192.168.99.100",
 			"deploymentName": "sh",
+			"workloadKind":   "Deployment",
+			"workloadName":   "sh",
 			"labels": map[string]string{
 				"pod-template-hash": "3751220431",
 				"run":               "sh",
@@ -188,9 +228,11 @@ var ExpectedRawData = definition.RawGroups{
 			},
 			"podName":                  "kube-controller-manager-minikube",
 			"containerImage":           "k8s.gcr.io/kube-controller-manager:v1.16.0",
+			"containerType":            "regular",
 			"namespace":                "kube-system",
 			"nodeIP":                   "192.168.99.100",
 			"cpuRequestedCores":        int64(200),
+			"desiredCpuRequestedCores": int64(200),
 			"status":                   "Running",
 			"lastTerminatedExitCode":   int32(255),
 			"lastTerminatedExitReason": "Error",
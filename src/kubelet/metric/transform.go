@@ -26,6 +26,23 @@ func PrefixFromMapInt(prefix string) func(mapValue definition.FetchedValue) (def
 	}
 }
 
+// PrefixFromMapString does the same as OneMetricPerLabel but with configurable prefix.
+func PrefixFromMapString(prefix string) func(mapValue definition.FetchedValue) (definition.FetchedValue, error) {
+	return func(value definition.FetchedValue) (definition.FetchedValue, error) {
+		mapValue, ok := value.(map[string]string)
+		if !ok {
+			return value, fmt.Errorf("cannot make prefixes: value is not map[string]string")
+		}
+
+		prefixed := make(definition.FetchedValues, len(mapValue))
+		for k, v := range mapValue {
+			prefixed[fmt.Sprintf("%s%v", prefix, k)] = v
+		}
+
+		return prefixed, nil
+	}
+}
+
 // OneMetricPerLabel transforms a map of labels to FetchedValues type,
 // which will be converted later to one metric per label.
 // It also prefix the labels with 'label.'
@@ -9,11 +9,15 @@ import (
 	"k8s.io/client-go/kubernetes"
 	listersv1 "k8s.io/client-go/listers/core/v1"
 
+	"github.com/newrelic/nri-kubernetes/v3/internal/attributefilter"
 	"github.com/newrelic/nri-kubernetes/v3/internal/config"
 	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
 	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/internal/owner"
+	"github.com/newrelic/nri-kubernetes/v3/internal/topology"
 	"github.com/newrelic/nri-kubernetes/v3/src/client"
 	"github.com/newrelic/nri-kubernetes/v3/src/data"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
 	"github.com/newrelic/nri-kubernetes/v3/src/kubelet/grouper"
 	kubeletMetric "github.com/newrelic/nri-kubernetes/v3/src/kubelet/metric"
 	"github.com/newrelic/nri-kubernetes/v3/src/metric"
@@ -38,11 +42,22 @@ type Scraper struct {
 	cloudClusterID          string
 	k8sVersion              *version.Info
 	defaultNetworkInterface string
+	defaultNetworkFamily    string
 	nodeGetter              listersv1.NodeLister
+	namespaceLister         listersv1.NamespaceLister
 	informerClosers         []chan<- struct{}
 	currentReruns           int
 	Filterer                discovery.NamespaceFilterer
 	interfaceCache          *kubeletMetric.InterfaceCache
+	ownerResolver           owner.Resolver
+	resourceFetcher         *kubeletMetric.ResourceFetcher
+	kubeletMetricsFetcher   *kubeletMetric.KubeletMetricsFetcher
+	kubeletMetricsFailed    bool
+	probesFetcher           data.FetchFunc
+	probesFailed            bool
+	extraSpecs              metric.ExtraSource
+	attributeFilter         attributefilter.Filterer
+	topology                *topology.Resolver
 }
 
 // ScraperOpt are options that can be used to configure the Scraper.
@@ -75,39 +90,101 @@ func NewScraper(config *config.Config, providers Providers, options ...ScraperOp
 		return nil, fmt.Errorf("fetching K8s version: %w", err)
 	}
 
+	if config.Kubelet.UsageFromResourceMetrics && providers.CAdvisor != nil {
+		if config.NodeName == "" {
+			return nil, fmt.Errorf("nodeName must be set to take usage from kubelet resource metrics")
+		}
+
+		// The fetcher keeps the previous CPU samples to compute the usage, so it is shared by all the runs.
+		s.resourceFetcher = kubeletMetric.NewResourceFetcher(
+			config.NodeName,
+			providers.CAdvisor.MetricFamiliesGetFunc(kubeletMetric.KubeletResourceMetricsPath),
+			kubeletMetric.ResourceQueries,
+		)
+	}
+
+	// Like the resource one, this fetcher keeps the previous histograms to compute quantiles, so it is shared by all the runs.
+	if config.Kubelet.HealthMetrics && providers.CAdvisor != nil {
+		s.kubeletMetricsFetcher = kubeletMetric.NewKubeletMetricsFetcher(
+			providers.CAdvisor.MetricFamiliesGetFunc(kubeletMetric.KubeletMetricsPath),
+			kubeletMetric.KubeletQueries,
+		)
+	}
+
+	if config.Kubelet.ProbeMetrics && providers.CAdvisor != nil {
+		s.probesFetcher = kubeletMetric.ProbesFetchFunc(
+			providers.CAdvisor.MetricFamiliesGetFunc(kubeletMetric.KubeletProbesMetricsPath),
+			kubeletMetric.ProbesQueries,
+		)
+	}
+
 	nodeGetter, nodeCloser := discovery.NewNodeLister(providers.K8s)
 	s.nodeGetter = nodeGetter
 	s.informerClosers = append(s.informerClosers, nodeCloser)
 
+	if config.TopologyAttributes {
+		// Samples without a node, like volume ones, are in the node the kubelet runs in.
+		s.topology = topology.NewResolver(nodeGetter, config.NodeName)
+	}
+
+	if len(config.Kubelet.PropagateNamespaceLabels) > 0 && s.namespaceLister == nil {
+		namespaceLister, namespaceCloser := discovery.NewNamespaceLister(providers.K8s)
+		s.namespaceLister = namespaceLister
+		s.informerClosers = append(s.informerClosers, namespaceCloser)
+	}
+
 	// TODO we can add a cache and retrieve the data more frequently if we notice this value can change often
-	s.defaultNetworkInterface, err = network.DefaultInterface(config.Kubelet.NetworkRouteFile)
+	route, err := network.DefaultRoute(config.Kubelet.NetworkRouteFile, config.Kubelet.NetworkRouteFamily)
 	if err != nil {
 		s.logger.Warnf("Error finding default network interface: %v", err)
+	} else {
+		s.logger.Debugf("Using default network interface %q from %s default route with metric %d", route.Interface, route.Family, route.Metric)
+		s.defaultNetworkInterface = route.Interface
+		s.defaultNetworkFamily = route.Family
 	}
 
 	return s, nil
 }
 
-// Run scraper collect the data populating the integration entities
-func (s *Scraper) Run(i *integration.Integration) error {
-	fetchAndFilterPrometheus := s.CAdvisor.MetricFamiliesGetFunc(kubeletMetric.KubeletCAdvisorMetricsPath)
+// fetchKubeletMetrics returns the health metrics of the kubelet. Nodes are still reported if they cannot be fetched,
+// e.g. if the endpoint is forbidden, so the first failure is logged as a warning and the next ones at debug level.
+func (s *Scraper) fetchKubeletMetrics() definition.RawMetrics {
+	kubeletMetrics, err := s.kubeletMetricsFetcher.DoKubeletMetricsFetch()
+	if err != nil {
+		if !s.kubeletMetricsFailed {
+			s.logger.Warnf("Error fetching kubelet health metrics, next errors will be logged at debug level: %v", err)
+		} else {
+			s.logger.Debugf("Error fetching kubelet health metrics: %v", err)
+		}
+		s.kubeletMetricsFailed = true
+	}
+
+	return kubeletMetrics
+}
 
-	kubeletGrouper, err := grouper.New(
-		grouper.Config{
-			Client:     s.Kubelet,
-			NodeGetter: s.nodeGetter,
-			Fetchers: []data.FetchFunc{
-				kubeletMetric.NewPodsFetcher(s.logger, s.Kubelet, s.config).DoPodsFetch,
-				kubeletMetric.CadvisorFetchFunc(fetchAndFilterPrometheus, metric.CadvisorQueries),
-			},
-			DefaultNetworkInterface: s.defaultNetworkInterface,
-		}, grouper.WithLogger(s.logger))
+// fetchProbes returns the probe counters of the containers. Like the health metrics, nodes are still reported if they
+// cannot be fetched, e.g. if the endpoint is forbidden or missing in old kubelets, so the first failure is logged as a
+// warning and the next ones at debug level.
+func (s *Scraper) fetchProbes() (definition.RawGroups, error) {
+	groups, err := s.probesFetcher()
 	if err != nil {
-		return fmt.Errorf("creating Kubelet grouper: %w", err)
+		if !s.probesFailed {
+			s.logger.Warnf("Error fetching kubelet probe metrics, next errors will be logged at debug level: %v", err)
+		} else {
+			s.logger.Debugf("Error fetching kubelet probe metrics: %v", err)
+		}
+		s.probesFailed = true
 	}
 
-	specs := metric.NewKubeletSpecs(s.interfaceCache)
-	job := scrape.NewScrapeJob("kubelet", kubeletGrouper, specs, scrape.JobWithFilterer(s.Filterer))
+	return groups, err
+}
+
+// Run scraper collect the data populating the integration entities
+func (s *Scraper) Run(i *integration.Integration) error {
+	job, err := s.job()
+	if err != nil {
+		return err
+	}
 
 	r := job.Populate(i, s.config.ClusterName, s.cloudClusterID, s.logger, s.k8sVersion)
 	if r.Errors != nil {
@@ -121,6 +198,65 @@ func (s *Scraper) Run(i *integration.Integration) error {
 	return nil
 }
 
+// job returns the scrape job of a run, with the specs and fetchers enabled in the config.
+func (s *Scraper) job() (*scrape.Job, error) {
+	fetchAndFilterPrometheus := s.CAdvisor.MetricFamiliesGetFunc(kubeletMetric.KubeletCAdvisorMetricsPath)
+
+	specs, cadvisorQueries, err := s.extraSpecs.Merge(metric.NewKubeletSpecs(s.interfaceCache), metric.CadvisorQueries)
+	if err != nil {
+		return nil, fmt.Errorf("merging extra specs: %w", err)
+	}
+	if s.resourceFetcher != nil {
+		specs = metric.ResourceMetricsSpecs(specs)
+	}
+
+	var podsFetcherOpts []kubeletMetric.PodsFetcherOpt
+	if s.ownerResolver != nil {
+		podsFetcherOpts = append(podsFetcherOpts, kubeletMetric.WithOwnerResolver(s.ownerResolver))
+	}
+
+	grouperConfig := grouper.Config{
+		Client:     s.Kubelet,
+		NodeGetter: s.nodeGetter,
+		Fetchers: []data.FetchFunc{
+			kubeletMetric.NewPodsFetcher(s.logger, s.Kubelet, s.config, podsFetcherOpts...).DoPodsFetch,
+			kubeletMetric.CadvisorFetchFunc(fetchAndFilterPrometheus, cadvisorQueries),
+		},
+		DefaultNetworkInterface: s.defaultNetworkInterface,
+		DefaultNetworkFamily:    s.defaultNetworkFamily,
+		PodNetworkInterfaces:    s.config.Kubelet.PodNetworkInterfaces,
+		NamespaceLister:         s.namespaceLister,
+		NamespaceLabels:         s.config.Kubelet.PropagateNamespaceLabels,
+		NodeLabels:              s.config.Kubelet.PropagateNodeLabels,
+	}
+	if s.probesFetcher != nil {
+		grouperConfig.Fetchers = append(grouperConfig.Fetchers, s.fetchProbes)
+	}
+	if s.resourceFetcher != nil {
+		grouperConfig.UsageFetcher = s.resourceFetcher.DoResourceFetch
+	}
+	if s.kubeletMetricsFetcher != nil {
+		grouperConfig.KubeletMetricsFetcher = s.fetchKubeletMetrics
+	}
+
+	kubeletGrouper, err := grouper.New(grouperConfig, grouper.WithLogger(s.logger))
+	if err != nil {
+		return nil, fmt.Errorf("creating Kubelet grouper: %w", err)
+	}
+
+	jobOpts := []scrape.JobOpt{
+		scrape.JobWithFilterer(s.Filterer),
+		scrape.JobWithAttributeFilter(s.attributeFilter),
+		scrape.JobWithCustomAttributes(s.config.CustomAttributes),
+		scrape.JobWithPopulateWorkers(s.config.PopulateWorkers),
+	}
+	if s.topology != nil {
+		jobOpts = append(jobOpts, scrape.JobWithNodeAttributes(s.topology))
+	}
+
+	return scrape.NewScrapeJob("kubelet", kubeletGrouper, specs, jobOpts...), nil
+}
+
 // WithLogger returns an OptionFunc to change the logger from the default noop logger.
 func WithLogger(logger *log.Logger) ScraperOpt {
 	return func(s *Scraper) error {
@@ -145,6 +281,31 @@ func WithInterfaceCache(cache *kubeletMetric.InterfaceCache) ScraperOpt {
 	}
 }
 
+// WithNamespaceLister returns an OptionFunc to get the labels of namespaces from an existing lister, instead of
+// starting a new informer.
+func WithNamespaceLister(lister listersv1.NamespaceLister) ScraperOpt {
+	return func(s *Scraper) error {
+		s.namespaceLister = lister
+		return nil
+	}
+}
+
+// WithOwnerResolver returns an OptionFunc to resolve the workloads owning pods and containers.
+func WithOwnerResolver(resolver owner.Resolver) ScraperOpt {
+	return func(s *Scraper) error {
+		s.ownerResolver = resolver
+		return nil
+	}
+}
+
+// WithAttributeFilter returns an OptionFunc to filter the label and annotation attributes reported.
+func WithAttributeFilter(filter attributefilter.Filterer) ScraperOpt {
+	return func(s *Scraper) error {
+		s.attributeFilter = filter
+		return nil
+	}
+}
+
 // WithCloudClusterID returns an OptionFunc to set the cloud-detected cluster id.
 func WithCloudClusterID(id string) ScraperOpt {
 	return func(s *Scraper) error {
@@ -153,6 +314,15 @@ func WithCloudClusterID(id string) ScraperOpt {
 	}
 }
 
+// WithExtraSpecs returns an OptionFunc to add extra specs to the kubelet groups, and extra queries to the cAdvisor
+// endpoint.
+func WithExtraSpecs(extra metric.ExtraSource) ScraperOpt {
+	return func(s *Scraper) error {
+		s.extraSpecs = extra
+		return nil
+	}
+}
+
 // Close will signal internal informers to stop running.
 func (s *Scraper) Close() {
 	for _, ch := range s.informerClosers {
@@ -0,0 +1,142 @@
+package kubelet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+	statsv1alpha1 "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
+	"github.com/newrelic/nri-kubernetes/v3/internal/testutil"
+	kubeletClient "github.com/newrelic/nri-kubernetes/v3/src/kubelet/client"
+	kubeletMetric "github.com/newrelic/nri-kubernetes/v3/src/kubelet/metric"
+)
+
+// resourceMetrics returns the /metrics/resource response holding the usage reported in a stats summary.
+func resourceMetrics(t *testing.T, summary io.Reader) string {
+	t.Helper()
+
+	s := statsv1alpha1.Summary{}
+	require.NoError(t, json.NewDecoder(summary).Decode(&s))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# TYPE node_cpu_usage_seconds_total counter\nnode_cpu_usage_seconds_total %f\n", float64(*s.Node.CPU.UsageCoreNanoSeconds)/1e9)
+	fmt.Fprintf(&b, "# TYPE node_memory_working_set_bytes gauge\nnode_memory_working_set_bytes %d\n", *s.Node.Memory.WorkingSetBytes)
+
+	b.WriteString("# TYPE pod_memory_working_set_bytes gauge\n")
+	for _, pod := range s.Pods {
+		if pod.Memory != nil && pod.Memory.WorkingSetBytes != nil {
+			fmt.Fprintf(&b, "pod_memory_working_set_bytes{namespace=%q,pod=%q} %d\n", pod.PodRef.Namespace, pod.PodRef.Name, *pod.Memory.WorkingSetBytes)
+		}
+	}
+
+	b.WriteString("# TYPE container_memory_working_set_bytes gauge\n")
+	for _, pod := range s.Pods {
+		for _, c := range pod.Containers {
+			if c.Memory != nil && c.Memory.WorkingSetBytes != nil {
+				fmt.Fprintf(&b, "container_memory_working_set_bytes{container=%q,namespace=%q,pod=%q} %d\n", c.Name, pod.PodRef.Namespace, pod.PodRef.Name, *c.Memory.WorkingSetBytes)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// populateKubelet populates the kubelet samples of the latest test data, taking the usage from the resource metrics
+// endpoint if usageFromResourceMetrics is set, in which case the stats summary is forbidden. It returns the errors of
+// the specs.
+func populateKubelet(t *testing.T, usageFromResourceMetrics bool) (*integration.Integration, []string) {
+	t.Helper()
+
+	version := testutil.LatestVersion()
+	testServer, err := version.Server()
+	require.NoError(t, err)
+	t.Cleanup(testServer.Close)
+
+	summary, err := http.Get(testServer.KubeletEndpoint() + kubeletMetric.StatsSummaryPath)
+	require.NoError(t, err)
+	defer summary.Body.Close() // nolint: errcheck
+	resource := resourceMetrics(t, summary.Body)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/kubelet"+kubeletMetric.KubeletResourceMetricsPath:
+			_, _ = io.WriteString(w, resource)
+		case r.URL.Path == "/kubelet"+kubeletMetric.StatsSummaryPath && usageFromResourceMetrics:
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			testServer.Config.Handler.ServeHTTP(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL + "/kubelet")
+	client, err := kubeletClient.New(kubeletClient.StaticConnector(&http.Client{}, *u))
+	require.NoError(t, err)
+
+	k8sData, err := version.K8s()
+	require.NoError(t, err)
+
+	scraper, err := NewScraper(&config.Config{
+		ClusterName: t.Name(),
+		NodeName:    "datagen-1-35",
+		Kubelet:     config.Kubelet{UsageFromResourceMetrics: usageFromResourceMetrics},
+	}, Providers{
+		K8s:      fake.NewSimpleClientset(k8sData.Everything()...),
+		Kubelet:  client,
+		CAdvisor: client,
+	})
+	require.NoError(t, err)
+
+	job, err := scraper.job()
+	require.NoError(t, err)
+
+	i := testutil.NewIntegration(t)
+	r := job.Populate(i, t.Name(), "", scraper.logger, scraper.k8sVersion)
+	require.True(t, r.Populated)
+
+	errs := make([]string, 0, len(r.Errors))
+	for _, err := range r.Errors {
+		errs = append(errs, err.Error())
+	}
+
+	return i, errs
+}
+
+func TestScraper_UsageFromResourceMetrics(t *testing.T) {
+	t.Parallel()
+
+	// Containers which are not running in the test data fail some specs anyway.
+	_, summaryErrs := populateKubelet(t, false)
+	i, errs := populateKubelet(t, true)
+	assert.Subset(t, summaryErrs, errs, "specs of metrics only reported by the stats summary must be optional")
+
+	var containers, nodes int
+	for _, e := range i.Entities {
+		for _, ms := range e.Metrics {
+			switch ms.Metrics["event_type"] {
+			case "K8sContainerSample":
+				if _, ok := ms.Metrics["memoryWorkingSetBytes"]; ok {
+					containers++
+				}
+				assert.NotContains(t, ms.Metrics, "memoryUsedBytes")
+				assert.NotContains(t, ms.Metrics, "fsUsedBytes")
+			case "K8sNodeSample":
+				nodes++
+				assert.Contains(t, ms.Metrics, "memoryWorkingSetBytes")
+			}
+		}
+	}
+	assert.Positive(t, containers, "containers must report their working set")
+	assert.Equal(t, 1, nodes)
+}
@@ -3,6 +3,7 @@ package metric
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	sdkMetric "github.com/newrelic/infra-integrations-sdk/data/metric"
@@ -955,7 +956,14 @@ var KSMSpecs = definition.SpecGroups{
 			{Name: "isReady", ValueFunc: definition.Transform(fetchWithDefault(prometheus.FromLabelValue("kube_pod_status_ready", "condition"), "false"), toNumericBoolean), Type: sdkMetric.GAUGE},
 			{Name: "status", ValueFunc: prometheus.FromLabelValue("kube_pod_status_phase", "phase"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "isScheduled", ValueFunc: definition.Transform(prometheus.FromLabelValue("kube_pod_status_scheduled", "condition"), toNumericBoolean), Type: sdkMetric.GAUGE},
-			{Name: "deploymentName", ValueFunc: ksmMetric.GetDeploymentNameForPod(), Type: sdkMetric.ATTRIBUTE},
+			{Name: "deploymentName", ValueFunc: prometheus.FromLabelValue("apiserver_kube_pod_workload", "deployment"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "workloadKind", ValueFunc: prometheus.FromLabelValue("apiserver_kube_pod_workload", "workload_kind"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "workloadName", ValueFunc: prometheus.FromLabelValue("apiserver_kube_pod_workload", "workload_name"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			// Parsed from the PodScheduled condition of unschedulable pods fetched from the APIServer.
+			{Name: "schedulingFailureSummary", ValueFunc: definition.FromRaw("apiserver_kube_pod_scheduling_failure_summary"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+			{Name: "schedulingAvailableNodes", ValueFunc: definition.FromRaw("apiserver_kube_pod_scheduling_available_nodes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "schedulingTotalNodes", ValueFunc: definition.FromRaw("apiserver_kube_pod_scheduling_total_nodes"), Type: sdkMetric.GAUGE, Optional: true},
+			{Name: "schedulingFailure.*", ValueFunc: definition.Transform(definition.FromRaw("apiserver_kube_pod_scheduling_failures"), kubeletMetric.PrefixFromMapInt("schedulingFailure.")), Type: sdkMetric.GAUGE, Optional: true},
 			{Name: "priorityClassName", ValueFunc: prometheus.FromLabelValue("kube_pod_info", "priority_class"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 			{Name: "label.*", ValueFunc: prometheus.FromMetricWithPrefixedLabels("kube_pod_labels", "label"), Type: sdkMetric.ATTRIBUTE},
 			{Name: "annotation.*", ValueFunc: prometheus.FromMetricWithPrefixedLabels("kube_pod_annotations", "annotation"), Type: sdkMetric.ATTRIBUTE},
@@ -1337,7 +1345,7 @@ var CadvisorQueries = []prometheus.Query{
 //
 //nolint:funlen // Large spec definition is acceptable - it's configuration, not logic
 func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.SpecGroups {
-	return definition.SpecGroups{
+	specs := definition.SpecGroups{
 		"pod": {
 			IDGenerator:     kubeletMetric.FromRawEntityIDGroupEntityIDGenerator("namespace"),
 			TypeGenerator:   kubeletMetric.FromRawGroupsEntityTypeGenerator,
@@ -1350,6 +1358,9 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				{Name: "net.rxBytesPerSecond", ValueFunc: kubeletMetric.FromRawWithFallbackToDefaultInterface("rxBytes", interfaceCache), Type: sdkMetric.RATE},
 				{Name: "net.txBytesPerSecond", ValueFunc: kubeletMetric.FromRawWithFallbackToDefaultInterface("txBytes", interfaceCache), Type: sdkMetric.RATE},
 				{Name: "net.errorsPerSecond", ValueFunc: kubeletMetric.FromRawWithFallbackToDefaultInterface("errors", interfaceCache), Type: sdkMetric.RATE},
+				{Name: "ephemeralStorageUsedBytes", ValueFunc: definition.FromRaw("ephemeralStorageUsedBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "ephemeralStorageInodesUsed", ValueFunc: definition.FromRaw("ephemeralStorageInodesUsed"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "processCount", ValueFunc: definition.FromRaw("processCount"), Type: sdkMetric.GAUGE, Optional: true},
 
 				// /pods endpoint
 				{Name: "createdAt", ValueFunc: definition.Transform(definition.FromRaw("createdAt"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
@@ -1357,6 +1368,9 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				{Name: "initializedAt", ValueFunc: definition.Transform(definition.FromRaw("initializedAt"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "containersReadyAt", ValueFunc: definition.Transform(definition.FromRaw("containersReadyAt"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "readyAt", ValueFunc: definition.Transform(definition.FromRaw("readyAt"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "schedulingLatencySeconds", ValueFunc: definition.FromRaw("schedulingLatencySeconds"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "initLatencySeconds", ValueFunc: definition.FromRaw("initLatencySeconds"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "startupLatencySeconds", ValueFunc: definition.FromRaw("startupLatencySeconds"), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "startTime", ValueFunc: definition.Transform(definition.FromRaw("startTime"), toTimestamp), Type: sdkMetric.GAUGE},
 				{Name: "createdKind", ValueFunc: definition.FromRaw("createdKind"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "createdBy", ValueFunc: definition.FromRaw("createdBy"), Type: sdkMetric.ATTRIBUTE, Optional: true},
@@ -1369,11 +1383,16 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				{Name: "isReady", ValueFunc: definition.Transform(definition.FromRaw("isReady"), toNumericBoolean), Type: sdkMetric.GAUGE},
 				{Name: "status", ValueFunc: definition.FromRaw("status"), Type: sdkMetric.ATTRIBUTE},
 				{Name: "isScheduled", ValueFunc: definition.Transform(definition.FromRaw("isScheduled"), toNumericBoolean), Type: sdkMetric.GAUGE},
+				{Name: "qosClass", ValueFunc: definition.FromRaw("qosClass"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "condition.*", ValueFunc: definition.Transform(definition.FromRaw("conditions"), kubeletMetric.PrefixFromMapInt("condition.")), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "conditionLastTransitionTime.*", ValueFunc: definition.Transform(definition.FromRaw("conditionTransitions"), kubeletMetric.PrefixFromMapInt("conditionLastTransitionTime.")), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "deploymentName", ValueFunc: definition.FromRaw("deploymentName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "daemonsetName", ValueFunc: definition.FromRaw("daemonsetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "jobName", ValueFunc: definition.FromRaw("jobName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "replicasetName", ValueFunc: definition.FromRaw("replicasetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "statefulsetName", ValueFunc: definition.FromRaw("statefulsetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "workloadKind", ValueFunc: definition.FromRaw("workloadKind"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "workloadName", ValueFunc: definition.FromRaw("workloadName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "priority", ValueFunc: definition.FromRaw("priority"), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "priorityClassName", ValueFunc: definition.FromRaw("priorityClassName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "label.*", ValueFunc: definition.Transform(definition.FromRaw("labels"), kubeletMetric.OneMetricPerLabel), Type: sdkMetric.ATTRIBUTE},
@@ -1383,12 +1402,27 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				{Name: attrCPULimit, ValueFunc: cpuLimitCores, Type: sdkMetric.GAUGE, Optional: true},
 				{Name: attrMemoryRequests, ValueFunc: definition.FromRaw("memoryRequestedBytes"), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: attrMemoryLimit, ValueFunc: definition.FromRaw("memoryLimitBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "ephemeralStorageRequestedBytes", ValueFunc: definition.FromRaw("ephemeralStorageRequestedBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "ephemeralStorageLimitBytes", ValueFunc: definition.FromRaw("ephemeralStorageLimitBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "allocatedCpuCores", ValueFunc: definition.Transform(definition.FromRaw("allocatedCpuCores"), toCores), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "allocatedMemoryBytes", ValueFunc: definition.FromRaw("allocatedMemoryBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "isResizePending", ValueFunc: definition.Transform(definition.FromRaw("isResizePending"), toNumericBoolean), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "resizePendingReason", ValueFunc: definition.FromRaw("resizePendingReason"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "resizePendingMessage", ValueFunc: definition.FromRaw("resizePendingMessage"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "resizePendingSince", ValueFunc: definition.Transform(definition.FromRaw("resizePendingSince"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "resizePendingSeconds", ValueFunc: definition.FromRaw("resizePendingSeconds"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "isResizeInProgress", ValueFunc: definition.Transform(definition.FromRaw("isResizeInProgress"), toNumericBoolean), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "resizeInProgressReason", ValueFunc: definition.FromRaw("resizeInProgressReason"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "resizeInProgressMessage", ValueFunc: definition.FromRaw("resizeInProgressMessage"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "resizeInProgressSince", ValueFunc: definition.Transform(definition.FromRaw("resizeInProgressSince"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "resizeInProgressSeconds", ValueFunc: definition.FromRaw("resizeInProgressSeconds"), Type: sdkMetric.GAUGE, Optional: true},
 
 				// computed
 				{Name: "requestedCpuCoresUtilization", ValueFunc: toUtilization(_cpuUsedCores, cpuRequestedCores), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "cpuCoresUtilization", ValueFunc: toUtilization(_cpuUsedCores, cpuLimitCores), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "requestedMemoryUtilization", ValueFunc: toUtilization(definition.FromRaw("usageBytes"), definition.FromRaw("memoryRequestedBytes")), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "memoryUtilization", ValueFunc: toUtilization(definition.FromRaw("usageBytes"), definition.FromRaw("memoryLimitBytes")), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "ephemeralStorageLimitUtilization", ValueFunc: toUtilization(definition.FromRaw("ephemeralStorageUsedBytes"), definition.FromRaw("ephemeralStorageLimitBytes")), Type: sdkMetric.GAUGE, Optional: true},
 			},
 		},
 		"container": {
@@ -1407,6 +1441,8 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				{Name: "fsInodesFree", ValueFunc: definition.FromRaw("fsInodesFree"), Type: sdkMetric.GAUGE},
 				{Name: "fsInodes", ValueFunc: definition.FromRaw("fsInodes"), Type: sdkMetric.GAUGE},
 				{Name: "fsInodesUsed", ValueFunc: definition.FromRaw("fsInodesUsed"), Type: sdkMetric.GAUGE},
+				{Name: "logsUsedBytes", ValueFunc: definition.FromRaw("logsUsedBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "logsInodesUsed", ValueFunc: definition.FromRaw("logsInodesUsed"), Type: sdkMetric.GAUGE, Optional: true},
 
 				// /metrics/cadvisor endpoint
 				{Name: "containerID", ValueFunc: definition.FromRaw("containerID"), Type: sdkMetric.ATTRIBUTE},
@@ -1424,11 +1460,14 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				// /pods endpoint
 				{Name: "containerName", ValueFunc: definition.FromRaw("containerName"), Type: sdkMetric.ATTRIBUTE},
 				{Name: "containerImage", ValueFunc: definition.FromRaw("containerImage"), Type: sdkMetric.ATTRIBUTE},
+				{Name: "containerType", ValueFunc: definition.FromRaw("containerType"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "deploymentName", ValueFunc: definition.FromRaw("deploymentName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "daemonsetName", ValueFunc: definition.FromRaw("daemonsetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "jobName", ValueFunc: definition.FromRaw("jobName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "replicasetName", ValueFunc: definition.FromRaw("replicasetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "statefulsetName", ValueFunc: definition.FromRaw("statefulsetName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "workloadKind", ValueFunc: definition.FromRaw("workloadKind"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "workloadName", ValueFunc: definition.FromRaw("workloadName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "namespace", ValueFunc: definition.FromRaw("namespace"), Type: sdkMetric.ATTRIBUTE},
 				{Name: "namespaceName", ValueFunc: definition.FromRaw("namespace"), Type: sdkMetric.ATTRIBUTE},
 				{Name: "podName", ValueFunc: definition.FromRaw("podName"), Type: sdkMetric.ATTRIBUTE},
@@ -1440,9 +1479,19 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				{Name: attrCPULimit, ValueFunc: cpuLimitCores, Type: sdkMetric.GAUGE, Optional: true},
 				{Name: attrMemoryRequests, ValueFunc: definition.FromRaw("memoryRequestedBytes"), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: attrMemoryLimit, ValueFunc: definition.FromRaw("memoryLimitBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "ephemeralStorageRequestedBytes", ValueFunc: definition.FromRaw("ephemeralStorageRequestedBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "ephemeralStorageLimitBytes", ValueFunc: definition.FromRaw("ephemeralStorageLimitBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				// With in-place resize, the attributes above are the applied resources, and these the ones in the pod spec.
+				{Name: "desiredCpuRequestedCores", ValueFunc: definition.Transform(definition.FromRaw("desiredCpuRequestedCores"), toCores), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "desiredCpuLimitCores", ValueFunc: definition.Transform(definition.FromRaw("desiredCpuLimitCores"), toCores), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "desiredMemoryRequestedBytes", ValueFunc: definition.FromRaw("desiredMemoryRequestedBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "desiredMemoryLimitBytes", ValueFunc: definition.FromRaw("desiredMemoryLimitBytes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "allocatedCpuCores", ValueFunc: definition.Transform(definition.FromRaw("allocatedCpuCores"), toCores), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "allocatedMemoryBytes", ValueFunc: definition.FromRaw("allocatedMemoryBytes"), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "status", ValueFunc: definition.FromRaw("status"), Type: sdkMetric.ATTRIBUTE},
 				{Name: "isReady", ValueFunc: definition.Transform(definition.FromRaw("isReady"), toNumericBoolean), Type: sdkMetric.GAUGE},
 				{Name: "reason", ValueFunc: definition.FromRaw("reason"), Type: sdkMetric.ATTRIBUTE, Optional: true}, // Previously called statusWaitingReason
+				{Name: "exitCode", ValueFunc: definition.FromRaw("exitCode"), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "lastTerminatedExitCode", ValueFunc: definition.FromRaw("lastTerminatedExitCode"), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "lastTerminatedExitReason", ValueFunc: definition.FromRaw("lastTerminatedExitReason"), Type: sdkMetric.ATTRIBUTE, Optional: true},
 				{Name: "lastTerminatedTimestamp", ValueFunc: definition.Transform(definition.FromRaw("lastTerminatedTimestamp"), toTimestamp), Type: sdkMetric.GAUGE, Optional: true},
@@ -1457,6 +1506,21 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				{Name: "requestedMemoryUtilization", ValueFunc: toUtilization(definition.FromRaw("usageBytes"), definition.FromRaw("memoryRequestedBytes")), Type: sdkMetric.GAUGE, Optional: true},
 			},
 		},
+		kubeletMetric.PodNetworkGroup: {
+			TypeGenerator:   kubeletMetric.FromRawGroupsEntityTypeGenerator,
+			NamespaceGetter: kubeletMetric.FromLabelGetNamespace,
+			Specs: []definition.Spec{
+				{Name: "podName", ValueFunc: definition.FromRaw("podName"), Type: sdkMetric.ATTRIBUTE},
+				{Name: "namespace", ValueFunc: definition.FromRaw("namespace"), Type: sdkMetric.ATTRIBUTE},
+				{Name: "namespaceName", ValueFunc: definition.FromRaw("namespace"), Type: sdkMetric.ATTRIBUTE},
+				{Name: "nodeName", ValueFunc: definition.FromRaw("nodeName"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "interfaceName", ValueFunc: definition.FromRaw("interfaceName"), Type: sdkMetric.ATTRIBUTE},
+				{Name: "isPrimary", ValueFunc: kubeletMetric.IsPrimaryPodInterface(interfaceCache), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				{Name: "net.rxBytesPerSecond", ValueFunc: definition.FromRaw("rxBytes"), Type: sdkMetric.RATE, Optional: true},
+				{Name: "net.txBytesPerSecond", ValueFunc: definition.FromRaw("txBytes"), Type: sdkMetric.RATE, Optional: true},
+				{Name: "net.errorsPerSecond", ValueFunc: definition.FromRaw("errors"), Type: sdkMetric.RATE, Optional: true},
+			},
+		},
 		"node": {
 			TypeGenerator: kubeletMetric.FromRawGroupsEntityTypeGenerator,
 			Specs: []definition.Spec{
@@ -1492,6 +1556,17 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 				{Name: attrMemoryRequests, ValueFunc: definition.FromRaw("memoryRequestedBytes"), Type: sdkMetric.GAUGE},
 				{Name: attrCPURequests, ValueFunc: cpuRequestedCores, Type: sdkMetric.GAUGE},
 				{Name: "kubeletVersion", ValueFunc: definition.FromRaw("kubeletVersion"), Type: sdkMetric.ATTRIBUTE},
+				{Name: "defaultNetworkInterfaceFamily", ValueFunc: definition.FromRaw("defaultNetworkInterfaceFamily"), Type: sdkMetric.ATTRIBUTE, Optional: true},
+				// kubelet /metrics endpoint
+				{Name: "kubeletPlegRelistDurationSecondsP50", ValueFunc: definition.FromRaw("kubeletPlegRelistDurationSecondsP50"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "kubeletPlegRelistDurationSecondsP99", ValueFunc: definition.FromRaw("kubeletPlegRelistDurationSecondsP99"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "kubeletPlegLastSeenAgeSeconds", ValueFunc: definition.FromRaw("kubeletPlegLastSeenAgeSeconds"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "kubeletPodStartDurationSecondsP50", ValueFunc: definition.FromRaw("kubeletPodStartDurationSecondsP50"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "kubeletPodStartDurationSecondsP99", ValueFunc: definition.FromRaw("kubeletPodStartDurationSecondsP99"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "kubeletRuntimeOperationsErrorsDelta", ValueFunc: definition.FromRaw("kubeletRuntimeOperationsErrors"), Type: sdkMetric.PDELTA, Optional: true},
+				{Name: "kubeletEvictionsDelta", ValueFunc: definition.FromRaw("kubeletEvictions"), Type: sdkMetric.PDELTA, Optional: true},
+				{Name: "kubeletVolumeManagerDesiredVolumes", ValueFunc: definition.FromRaw("kubeletVolumeManagerDesiredVolumes"), Type: sdkMetric.GAUGE, Optional: true},
+				{Name: "kubeletVolumeManagerActualVolumes", ValueFunc: definition.FromRaw("kubeletVolumeManagerActualVolumes"), Type: sdkMetric.GAUGE, Optional: true},
 				{Name: "runningPods", ValueFunc: definition.FromRaw("runningPods"), Type: sdkMetric.GAUGE},
 				// computed
 				{Name: "fsCapacityUtilization", ValueFunc: toUtilization(definition.FromRaw("fsUsedBytes"), definition.FromRaw("fsCapacityBytes")), Type: sdkMetric.GAUGE},
@@ -1521,6 +1596,75 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 			},
 		},
 	}
+
+	// Swap and PSI are reported by /stats/summary for nodes, pods and containers alike.
+	for _, group := range []string{"node", "pod", "container"} {
+		sg := specs[group]
+		sg.Specs = append(sg.Specs, saturationSpecs()...)
+		specs[group] = sg
+	}
+
+	for _, group := range []string{"pod", "container", "volume"} {
+		sg := specs[group]
+		sg.Specs = append(sg.Specs, propagatedLabelSpecs()...)
+		specs[group] = sg
+	}
+
+	container := specs["container"]
+	container.Specs = append(container.Specs, probeSpecs()...)
+	specs["container"] = container
+
+	return specs
+}
+
+// saturationSpecs returns the specs for swap usage and for the Pressure Stall Information (PSI) of CPU, memory and
+// IO. They are optional, as swap is only reported when enabled in the node and PSI requires cgroup v2 and the
+// KubeletPSI feature.
+func saturationSpecs() []definition.Spec {
+	specs := []definition.Spec{
+		{Name: "swapUsedBytes", ValueFunc: definition.FromRaw("swapUsedBytes"), Type: sdkMetric.GAUGE, Optional: true},
+		{Name: "swapAvailableBytes", ValueFunc: definition.FromRaw("swapAvailableBytes"), Type: sdkMetric.GAUGE, Optional: true},
+	}
+
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		for _, kind := range []string{"Some", "Full"} {
+			prefix := resource + "Pressure" + kind
+			specs = append(specs,
+				definition.Spec{Name: prefix + "Avg10", ValueFunc: definition.FromRaw(prefix + "Avg10"), Type: sdkMetric.GAUGE, Optional: true},
+				definition.Spec{Name: prefix + "Avg60", ValueFunc: definition.FromRaw(prefix + "Avg60"), Type: sdkMetric.GAUGE, Optional: true},
+				definition.Spec{Name: prefix + "Avg300", ValueFunc: definition.FromRaw(prefix + "Avg300"), Type: sdkMetric.GAUGE, Optional: true},
+				// Total stalled time is cumulative, so the time stalled since the previous sample is reported instead.
+				definition.Spec{Name: prefix + "StalledSecondsDelta", ValueFunc: definition.Transform(definition.FromRaw(prefix+"Total"), fromNanoToSeconds), Type: sdkMetric.PDELTA, Optional: true},
+			)
+		}
+	}
+
+	return specs
+}
+
+// propagatedLabelSpecs returns the specs for the labels of the namespace and node added to pods, containers and volumes.
+func propagatedLabelSpecs() []definition.Spec {
+	return []definition.Spec{
+		{Name: "namespaceLabel.*", ValueFunc: definition.Transform(definition.FromRaw("namespaceLabels"), kubeletMetric.PrefixFromMapString("namespaceLabel.")), Type: sdkMetric.ATTRIBUTE, Optional: true},
+		{Name: "nodeLabel.*", ValueFunc: definition.Transform(definition.FromRaw("nodeLabels"), kubeletMetric.PrefixFromMapString("nodeLabel.")), Type: sdkMetric.ATTRIBUTE, Optional: true},
+	}
+}
+
+// probeSpecs returns the specs for the number of successful and failed probes of containers, from /metrics/probes.
+func probeSpecs() []definition.Spec {
+	var specs []definition.Spec
+
+	for _, probeType := range kubeletMetric.ProbeTypes {
+		for _, result := range []string{"Successes", "Failures"} {
+			name := probeType + "Probe" + result
+			specs = append(specs,
+				definition.Spec{Name: name + "Total", ValueFunc: definition.FromRaw(name), Type: sdkMetric.GAUGE, Optional: true},
+				definition.Spec{Name: name + "Delta", ValueFunc: definition.FromRaw(name), Type: sdkMetric.PDELTA, Optional: true},
+			)
+		}
+	}
+
+	return specs
 }
 
 // KubeletSpecs is the default metric specifications for Kubelet with no interface cache.
@@ -1528,9 +1672,52 @@ func NewKubeletSpecs(interfaceCache *kubeletMetric.InterfaceCache) definition.Sp
 //nolint:gochecknoglobals // Backward compatibility - used by tests and static tooling
 var KubeletSpecs = NewKubeletSpecs(nil)
 
+// statsSummaryOnlyMetrics are the metrics of the node, pods and containers which are not reported when their usage is
+// taken from the kubelet resource metrics endpoint, as it only exposes their working set and cumulative CPU time. The
+// CPU usage is computed from two fetches of it, so it is missing in the first one.
+var statsSummaryOnlyMetrics = map[string][]string{ //nolint: gochecknoglobals // read-only map.
+	"node": {
+		attrCPUUsage, attrMemoryUsage, "memoryAvailableBytes", "memoryRssBytes", "memoryPageFaults", "memoryMajorPageFaultsPerSecond",
+		"net.rxBytesPerSecond", "net.txBytesPerSecond", "net.errorsPerSecond",
+		"fsAvailableBytes", "fsCapacityBytes", "fsUsedBytes", "fsInodesFree", "fsInodes", "fsInodesUsed",
+		"runtimeAvailableBytes", "runtimeCapacityBytes", "runtimeUsedBytes", "runtimeInodesFree", "runtimeInodes", "runtimeInodesUsed",
+		"fsCapacityUtilization", "allocatableCpuCoresUtilization",
+	},
+	"pod": {
+		"net.rxBytesPerSecond", "net.txBytesPerSecond", "net.errorsPerSecond",
+	},
+	"container": {
+		attrCPUUsage, attrMemoryUsage,
+		"fsAvailableBytes", "fsCapacityBytes", "fsUsedBytes", "fsUsedPercent", "fsInodesFree", "fsInodes", "fsInodesUsed",
+	},
+}
+
+// ResourceMetricsSpecs returns a copy of the kubelet specs where the ones of metrics only exposed by the stats summary
+// are optional, for when the usage is taken from the kubelet resource metrics endpoint instead.
+func ResourceMetricsSpecs(specs definition.SpecGroups) definition.SpecGroups {
+	optional := make(definition.SpecGroups, len(specs))
+	for groupName, group := range specs {
+		names := statsSummaryOnlyMetrics[groupName]
+		if len(names) > 0 {
+			group.Specs = slices.Clone(group.Specs)
+			for n := range group.Specs {
+				if slices.Contains(names, group.Specs[n].Name) {
+					group.Specs[n].Optional = true
+				}
+			}
+		}
+
+		optional[groupName] = group
+	}
+
+	return optional
+}
+
 func isPersistentVolume() definition.FetchFunc {
+	fetchPVCName := definition.FromRaw("pvcName")
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		name, err := definition.FromRaw("pvcName")(groupLabel, entityID, groups)
+		name, err := fetchPVCName(groupLabel, entityID, groups)
 		if err == nil && name != "" {
 			return "true", nil
 		}
@@ -1586,13 +1773,16 @@ func convertValue(v interface{}) (float64, error) {
 }
 
 func toComplementPercentage(desiredMetric, complementMetric string) definition.FetchFunc {
+	fetchComplement := definition.FromRaw(complementMetric)
+	fetchDesired := definition.FromRaw(desiredMetric)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		complement, err := definition.FromRaw(complementMetric)(groupLabel, entityID, groups)
+		complement, err := fetchComplement(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
 
-		desired, err := definition.FromRaw(desiredMetric)(groupLabel, entityID, groups)
+		desired, err := fetchDesired(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
@@ -1646,6 +1836,15 @@ func fromNanoToMilli(value definition.FetchedValue) (definition.FetchedValue, er
 	return float64(v) / 1000000, nil
 }
 
+func fromNanoToSeconds(value definition.FetchedValue) (definition.FetchedValue, error) {
+	v, ok := value.(uint64)
+	if !ok {
+		return nil, errors.New("error transforming nanoseconds to seconds")
+	}
+
+	return float64(v) / 1e9, nil
+}
+
 func toTimestamp(value definition.FetchedValue) (definition.FetchedValue, error) {
 	v, ok := value.(time.Time)
 	if !ok {
@@ -0,0 +1,249 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/data/metric"
+	"sigs.k8s.io/yaml"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+// ExtraSpecs are specs and queries loaded from a file and added to the built-in ones, so metrics can be added without
+// changing the integration. For example, the following adds a KSM family to K8sPodSample and a cAdvisor metric to
+// K8sContainerSample:
+//
+//	ksm:
+//	  queries:
+//	    - metricName: kube_pod_status_qos_class
+//	  groups:
+//	    pod:
+//	      - name: qosClass
+//	        type: attribute
+//	        fetch: labelValue
+//	        metric: kube_pod_status_qos_class
+//	        label: qos_class
+//	kubelet:
+//	  queries:
+//	    - metricName: container_fs_reads_total
+//	  groups:
+//	    container:
+//	      - name: fsReadsDelta
+//	        type: pdelta
+//	        fetch: raw
+//	        metric: container_fs_reads_total
+type ExtraSpecs struct {
+	// KSM holds the queries done to KSM and the specs added to the KSM groups.
+	KSM ExtraSource `json:"ksm"`
+	// Kubelet holds the queries done to the cAdvisor endpoint of the kubelet and the specs added to the container
+	// group, the only one where values of cAdvisor metrics are found, in its raw metrics under the name of the metric.
+	Kubelet ExtraSource `json:"kubelet"`
+}
+
+// ExtraSource are the extra queries and specs for a data source.
+type ExtraSource struct {
+	Queries []ExtraQuery `json:"queries"`
+	// Groups maps the name of existing groups, like pod or container, to the specs added to them.
+	Groups map[string][]ExtraSpec `json:"groups"`
+}
+
+// ExtraQuery is a query for a prometheus metric family.
+type ExtraQuery struct {
+	MetricName string `json:"metricName"`
+	// Labels, if set, keeps only the metrics having all of these label values.
+	Labels map[string]string `json:"labels"`
+}
+
+// ExtraSpec describes a spec, mapped to one of the existing FetchFunc builders by Fetch:
+//   - raw: the raw metric named Metric, as definition.FromRaw.
+//   - value: the value of the metric family Metric, as prometheus.FromValue.
+//   - labelValue: the value of Label in the metric family Metric, as prometheus.FromLabelValue.
+//   - flattened: one metric per value of Label in the metric family Metric, as prometheus.FromFlattenedMetrics.
+//   - prefixedLabels: all the labels of the metric family Metric with Prefix, as prometheus.FromMetricWithPrefixedLabels.
+//   - summary: the count, sum and quantiles of the summary Metric, as prometheus.FromSummary.
+type ExtraSpec struct {
+	Name string `json:"name"`
+	// Type is one of gauge, rate, delta, prate, pdelta or attribute.
+	Type     string `json:"type"`
+	Optional bool   `json:"optional"`
+	Fetch    string `json:"fetch"`
+	Metric   string `json:"metric"`
+	Label    string `json:"label"`
+	Prefix   string `json:"prefix"`
+}
+
+var (
+	errUnknownGroup            = errors.New("unknown group")
+	errUnsupportedKubeletGroup = errors.New("extra kubelet specs can only be added to the container group")
+)
+
+// LoadExtraSpecs reads the extra specs from a YAML file and checks they can be merged into the built-in KSM and kubelet
+// specs. Kubelet specs for groups other than container are rejected, as cAdvisor metrics are not found in them.
+func LoadExtraSpecs(path string) (ExtraSpecs, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ExtraSpecs{}, fmt.Errorf("reading extra specs file: %w", err)
+	}
+
+	var extra ExtraSpecs
+	if err := yaml.UnmarshalStrict(content, &extra); err != nil {
+		return ExtraSpecs{}, fmt.Errorf("parsing extra specs file %s: %w", path, err)
+	}
+
+	if _, _, err := extra.KSM.Merge(KSMSpecs, KSMQueries); err != nil {
+		return ExtraSpecs{}, fmt.Errorf("merging extra KSM specs: %w", err)
+	}
+
+	for groupName := range extra.Kubelet.Groups {
+		if groupName != "container" {
+			return ExtraSpecs{}, fmt.Errorf("%w, got %q", errUnsupportedKubeletGroup, groupName)
+		}
+	}
+
+	if _, _, err := extra.Kubelet.Merge(KubeletSpecs, CadvisorQueries); err != nil {
+		return ExtraSpecs{}, fmt.Errorf("merging extra kubelet specs: %w", err)
+	}
+
+	return extra, nil
+}
+
+// Merge returns a copy of specs and queries with the extra ones added. Specs can only be added to existing groups, and
+// cannot replace built-in ones. Queries for families which are already queried are skipped.
+func (s ExtraSource) Merge(specs definition.SpecGroups, queries []prometheus.Query) (definition.SpecGroups, []prometheus.Query, error) {
+	merged := make(definition.SpecGroups, len(specs))
+	for name, group := range specs {
+		merged[name] = group
+	}
+
+	for groupName, extraSpecs := range s.Groups {
+		group, ok := merged[groupName]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w %q", errUnknownGroup, groupName)
+		}
+
+		existing := map[string]bool{}
+		for _, spec := range group.Specs {
+			existing[spec.Name] = true
+		}
+
+		groupSpecs := make([]definition.Spec, len(group.Specs), len(group.Specs)+len(extraSpecs))
+		copy(groupSpecs, group.Specs)
+
+		for _, extra := range extraSpecs {
+			spec, err := extra.spec()
+			if err != nil {
+				return nil, nil, fmt.Errorf("building spec %q of group %q: %w", extra.Name, groupName, err)
+			}
+
+			if existing[spec.Name] {
+				return nil, nil, fmt.Errorf("spec %q already exists in group %q", spec.Name, groupName) //nolint:err113
+			}
+			existing[spec.Name] = true
+
+			groupSpecs = append(groupSpecs, spec)
+		}
+
+		group.Specs = groupSpecs
+		merged[groupName] = group
+	}
+
+	mergedQueries := make([]prometheus.Query, len(queries), len(queries)+len(s.Queries))
+	copy(mergedQueries, queries)
+
+	queried := map[string]bool{}
+	for _, q := range queries {
+		if q.CustomName == "" {
+			queried[q.MetricName] = true
+		}
+	}
+
+	for _, extra := range s.Queries {
+		if extra.MetricName == "" {
+			return nil, nil, errors.New("query without metricName") //nolint:err113
+		}
+
+		if queried[extra.MetricName] {
+			continue
+		}
+		queried[extra.MetricName] = true
+
+		q := prometheus.Query{MetricName: extra.MetricName}
+		if len(extra.Labels) > 0 {
+			q.Labels = prometheus.QueryLabels{Operator: prometheus.QueryOpAnd, Labels: extra.Labels}
+		}
+		mergedQueries = append(mergedQueries, q)
+	}
+
+	return merged, mergedQueries, nil
+}
+
+// spec builds the definition.Spec described by s.
+func (s ExtraSpec) spec() (definition.Spec, error) {
+	if s.Name == "" {
+		return definition.Spec{}, errors.New("name is required") //nolint:err113
+	}
+
+	sourceType, err := extraSourceType(s.Type)
+	if err != nil {
+		return definition.Spec{}, err
+	}
+
+	fetch, err := s.fetchFunc()
+	if err != nil {
+		return definition.Spec{}, err
+	}
+
+	return definition.Spec{Name: s.Name, ValueFunc: fetch, Type: sourceType, Optional: s.Optional}, nil
+}
+
+func (s ExtraSpec) fetchFunc() (definition.FetchFunc, error) {
+	if s.Metric == "" {
+		return nil, errors.New("metric is required") //nolint:err113
+	}
+
+	switch s.Fetch {
+	case "raw":
+		return definition.FromRaw(s.Metric), nil
+	case "value":
+		return prometheus.FromValue(s.Metric), nil
+	case "labelValue":
+		if s.Label == "" {
+			return nil, errors.New("label is required for labelValue") //nolint:err113
+		}
+		return prometheus.FromLabelValue(s.Metric, s.Label), nil
+	case "flattened":
+		if s.Label == "" {
+			return nil, errors.New("label is required for flattened") //nolint:err113
+		}
+		return prometheus.FromFlattenedMetrics(s.Metric, s.Label), nil
+	case "prefixedLabels":
+		return prometheus.FromMetricWithPrefixedLabels(s.Metric, s.Prefix), nil
+	case "summary":
+		return prometheus.FromSummary(s.Metric), nil
+	default:
+		return nil, fmt.Errorf("unknown fetch %q", s.Fetch) //nolint:err113
+	}
+}
+
+func extraSourceType(t string) (sdkMetric.SourceType, error) {
+	switch strings.ToLower(t) {
+	case "gauge":
+		return sdkMetric.GAUGE, nil
+	case "rate":
+		return sdkMetric.RATE, nil
+	case "delta":
+		return sdkMetric.DELTA, nil
+	case "prate":
+		return sdkMetric.PRATE, nil
+	case "pdelta":
+		return sdkMetric.PDELTA, nil
+	case "attribute":
+		return sdkMetric.ATTRIBUTE, nil
+	default:
+		return 0, fmt.Errorf("unknown type %q", t) //nolint:err113
+	}
+}
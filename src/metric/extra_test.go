@@ -0,0 +1,113 @@
+package metric
+
+import (
+	"testing"
+
+	sdkMetric "github.com/newrelic/infra-integrations-sdk/data/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+func TestLoadExtraSpecs(t *testing.T) {
+	t.Parallel()
+
+	extra, err := LoadExtraSpecs("testdata/extra_specs.yaml")
+	require.NoError(t, err)
+
+	specs, queries, err := extra.KSM.Merge(KSMSpecs, KSMQueries)
+	require.NoError(t, err)
+
+	podSpecs := specs["pod"].Specs
+	last := podSpecs[len(podSpecs)-1]
+	assert.Equal(t, "qosClass", last.Name)
+	assert.Equal(t, sdkMetric.ATTRIBUTE, last.Type)
+	assert.Len(t, podSpecs, len(KSMSpecs["pod"].Specs)+1)
+	assert.Len(t, KSMSpecs["pod"].Specs, len(podSpecs)-1, "built-in specs must not be modified")
+
+	// kube_pod_status_phase is already queried.
+	assert.Len(t, queries, len(KSMQueries)+1)
+	assert.Equal(t, prometheus.Query{MetricName: "kube_pod_status_qos_class"}, queries[len(queries)-1])
+
+	specs, queries, err = extra.Kubelet.Merge(KubeletSpecs, CadvisorQueries)
+	require.NoError(t, err)
+
+	containerSpecs := specs["container"].Specs
+	last = containerSpecs[len(containerSpecs)-1]
+	assert.Equal(t, "fsReadsDelta", last.Name)
+	assert.Equal(t, sdkMetric.PDELTA, last.Type)
+	assert.True(t, last.Optional)
+
+	value, err := last.ValueFunc("container", "c1", definition.RawGroups{
+		"container": {"c1": {"container_fs_reads_total": 42.0}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 42.0, value)
+
+	assert.Equal(t, prometheus.Query{
+		MetricName: "container_fs_reads_total",
+		Labels: prometheus.QueryLabels{
+			Operator: prometheus.QueryOpAnd,
+			Labels:   prometheus.Labels{"device": "/dev/sda"},
+		},
+	}, queries[len(queries)-1])
+}
+
+func TestLoadExtraSpecs_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := LoadExtraSpecs("testdata/non_existing.yaml")
+	assert.Error(t, err)
+
+	_, err = LoadExtraSpecs("testdata/extra_specs_kubelet_pod.yaml")
+	assert.ErrorIs(t, err, errUnsupportedKubeletGroup)
+}
+
+func TestExtraSource_Merge_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		extra ExtraSource
+	}{
+		{
+			name:  "unknown_group",
+			extra: ExtraSource{Groups: map[string][]ExtraSpec{"unknown": {{Name: "foo", Type: "gauge", Fetch: "raw", Metric: "foo"}}}},
+		},
+		{
+			name:  "existing_spec",
+			extra: ExtraSource{Groups: map[string][]ExtraSpec{"pod": {{Name: "podName", Type: "attribute", Fetch: "raw", Metric: "podName"}}}},
+		},
+		{
+			name:  "unknown_type",
+			extra: ExtraSource{Groups: map[string][]ExtraSpec{"pod": {{Name: "foo", Type: "histogram", Fetch: "raw", Metric: "foo"}}}},
+		},
+		{
+			name:  "unknown_fetch",
+			extra: ExtraSource{Groups: map[string][]ExtraSpec{"pod": {{Name: "foo", Type: "gauge", Fetch: "unknown", Metric: "foo"}}}},
+		},
+		{
+			name:  "missing_label",
+			extra: ExtraSource{Groups: map[string][]ExtraSpec{"pod": {{Name: "foo", Type: "gauge", Fetch: "labelValue", Metric: "foo"}}}},
+		},
+		{
+			name:  "missing_metric",
+			extra: ExtraSource{Groups: map[string][]ExtraSpec{"pod": {{Name: "foo", Type: "gauge", Fetch: "raw"}}}},
+		},
+		{
+			name:  "missing_query_metric_name",
+			extra: ExtraSource{Queries: []ExtraQuery{{Labels: map[string]string{"foo": "bar"}}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, err := tc.extra.Merge(KSMSpecs, KSMQueries)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -1,6 +1,34 @@
 package network
 
+// Address families of the default routes.
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+// Route is the default route chosen among the ones in the routing tables of the OS.
+type Route struct {
+	// Interface is the name of the interface the route goes through.
+	Interface string
+	// Family is the address family of the route, either FamilyIPv4 or FamilyIPv6.
+	Family string
+	// Metric is the priority of the route, lower values being preferred.
+	Metric uint32
+}
+
 // DefaultInterface returns the default interface named used by the OS.
 func DefaultInterface(routeFile string) (string, error) {
-	return getDefaultInterface(routeFile)
+	route, err := getDefaultRoute(routeFile, "")
+	if err != nil {
+		return "", err
+	}
+
+	return route.Interface, nil
+}
+
+// DefaultRoute returns the default route of the given family of the OS, in the first routing table looked up by the
+// policy routing rules that has any, and the one with the lowest metric if there are several. If family is empty, IPv4
+// default routes are preferred over IPv6 ones, as metrics of different families are not comparable.
+func DefaultRoute(routeFile, family string) (Route, error) {
+	return getDefaultRoute(routeFile, family)
 }
@@ -2,6 +2,10 @@
 
 package network
 
-func getDefaultInterface(_ string) (string, error) {
-	return "eth0", nil
+func getDefaultRoute(_, family string) (Route, error) {
+	if family == "" {
+		family = FamilyIPv4
+	}
+
+	return Route{Interface: "eth0", Family: family}, nil
 }
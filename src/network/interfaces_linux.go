@@ -13,30 +13,107 @@ import (
 )
 
 const (
-	defaultRouteFile   = "/proc/net/route"
-	sep                = "\t" // routes file field separator
-	destinationField   = 1    // routes file field containing hex destination address
-	interfaceNameField = 0    // routes file field containing interface name
+	defaultRouteFile     = "/proc/net/route"
+	ipv6RouteFileName    = "ipv6_route" // name of the IPv6 routes file, next to the IPv4 one
+	sep                  = "\t"         // routes file field separator
+	destinationField     = 1            // routes file field containing hex destination address
+	interfaceNameField   = 0            // routes file field containing interface name
+	flagsField           = 3            // routes file field containing hex route flags
+	metricField          = 6            // routes file field containing the route metric
+	maskField            = 7            // routes file field containing hex destination mask
+	ipv6DestinationField = 0            // ipv6 routes file field containing hex destination address
+	ipv6PrefixLenField   = 1            // ipv6 routes file field containing hex destination prefix length
+	ipv6MetricField      = 5            // ipv6 routes file field containing hex route metric
+	ipv6FlagsField       = 8            // ipv6 routes file field containing hex route flags
+	ipv6InterfaceField   = 9            // ipv6 routes file field containing interface name
+	ipv6Fields           = 10           // number of fields of ipv6 routes file rows
+
+	routeFlagUp     = 0x0001 // RTF_UP, the route is usable
+	routeFlagReject = 0x0200 // RTF_REJECT, the route rejects packets, like unreachable routes
 )
 
+// errNoDefaultRoute is returned when no usable default route is found in the routes files.
+var errNoDefaultRoute = errors.New("couldn't find interface with default destination")
+
+// errInvalidFamily is returned when the address family of the default route is not FamilyIPv4 nor FamilyIPv6.
+var errInvalidFamily = errors.New("invalid address family")
+
 // errRouteFilePathNotAllowed is returned when the configured networkRouteFile
 // path is not under a permitted directory prefix.
 var errRouteFilePathNotAllowed = errors.New("networkRouteFile is not under an allowed path")
 
-func getDefaultInterface(routeFile string) (string, error) {
+// getDefaultRoute returns the default route of the given family in the network namespace of the process whose routes
+// are in routeFile, which is in the /proc/net/route format. It is read through netlink, following the routing policy
+// rules across all the routing tables. If netlink cannot be used in that namespace, like when the integration lacks
+// the privileges to, it is read from routeFile or the ipv6_route file in the same directory instead, which only
+// contain the main routing table. If family is empty, IPv4 routes are preferred.
+func getDefaultRoute(routeFile, family string) (Route, error) {
 	if routeFile == "" {
 		routeFile = defaultRouteFile
 	}
 
 	if err := validateRouteFilePath(routeFile); err != nil {
-		return "", err
+		return Route{}, err
 	}
 
-	routes, err := routeFileContent(routeFile)
+	if family != "" && family != FamilyIPv4 && family != FamilyIPv6 {
+		return Route{}, fmt.Errorf("%w: %q", errInvalidFamily, family)
+	}
+
+	route, netlinkErr := defaultRouteFromNetlink(netnsPath(routeFile), family)
+	if netlinkErr == nil {
+		return route, nil
+	}
+
+	route, err := defaultRouteFromFiles(routeFile, filepath.Join(filepath.Dir(routeFile), ipv6RouteFileName), family)
 	if err != nil {
-		return "", fmt.Errorf("getting routes content from file %s: %w", routeFile, err)
+		return Route{}, errors.Join(fmt.Errorf("reading routes through netlink: %w", netlinkErr), err)
+	}
+
+	return route, nil
+}
+
+type routeFile struct {
+	path  string
+	parse func([]byte) ([]Route, error)
+}
+
+// defaultRouteFromFiles returns the default route of the given family in the IPv4 or IPv6 routes files. If family is
+// empty, the IPv4 one is preferred, and any of the files can be missing as long as the other one has a default route.
+func defaultRouteFromFiles(ipv4RouteFile, ipv6RouteFile, family string) (Route, error) {
+	ipv4 := routeFile{path: ipv4RouteFile, parse: parseIPv4DefaultRoutes}
+	ipv6 := routeFile{path: ipv6RouteFile, parse: parseIPv6DefaultRoutes}
+
+	var files []routeFile
+	switch family {
+	case "":
+		files = []routeFile{ipv4, ipv6}
+	case FamilyIPv4:
+		files = []routeFile{ipv4}
+	case FamilyIPv6:
+		files = []routeFile{ipv6}
+	default:
+		return Route{}, fmt.Errorf("%w: %q", errInvalidFamily, family)
+	}
+
+	var errs []error
+	for _, f := range files {
+		routes, err := routeFileContent(f.path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("getting routes content from file %s: %w", f.path, err))
+			continue
+		}
+
+		route, err := findDefaultRoute(routes, f.parse)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("finding default route in file %s: %w", f.path, err))
+			continue
+		}
+
+		return route, nil
 	}
-	return findDefaultInterface(routes)
+
+	return Route{}, errors.Join(errs...)
 }
 
 // validateRouteFilePath checks that the route file path is under an allowed prefix
@@ -73,7 +150,41 @@ func routeFileContent(routeFile string) ([]byte, error) {
 
 // findDefaultInterface parses the route file and returns the name
 // of the default interface, that is, the interface with Destination = 0
+// having the lowest metric.
 func findDefaultInterface(route []byte) (string, error) {
+	r, err := findDefaultRoute(route, parseIPv4DefaultRoutes)
+	if err != nil {
+		return "", err
+	}
+
+	return r.Interface, nil
+}
+
+// findDefaultRoute returns the default route with the lowest metric among the ones parsed from the routes file. The
+// first one in the file is returned on ties, as the kernel does.
+func findDefaultRoute(routes []byte, parse func([]byte) ([]Route, error)) (Route, error) {
+	defaults, err := parse(routes)
+	if err != nil {
+		return Route{}, err
+	}
+
+	if len(defaults) == 0 {
+		return Route{}, errNoDefaultRoute
+	}
+
+	best := defaults[0]
+	for _, r := range defaults[1:] {
+		if r.Metric < best.Metric {
+			best = r
+		}
+	}
+
+	return best, nil
+}
+
+// parseIPv4DefaultRoutes parses the IPv4 route file and returns the usable
+// routes with Destination = 0.
+func parseIPv4DefaultRoutes(route []byte) ([]Route, error) {
 	/* /proc/net/route file:
 	   Iface   Destination Gateway     Flags   RefCnt  Use Metric  Mask
 	   eno1    00000000    C900A8C0    0003    0   0   100 00000000    0   00
@@ -83,14 +194,15 @@ func findDefaultInterface(route []byte) (string, error) {
 
 	// Skip header line
 	if !scanner.Scan() {
-		return "", fmt.Errorf("invalid linux route file: %s", route)
+		return nil, fmt.Errorf("invalid linux route file: %s", route)
 	}
 
+	var routes []Route
 	for scanner.Scan() {
 		row := scanner.Text()
 		tokens := strings.Split(row, sep)
 		if len(tokens) <= destinationField {
-			return "", fmt.Errorf("invalid row '%s' in route file", row)
+			return nil, fmt.Errorf("invalid row '%s' in route file", row)
 		}
 
 		destinationHex := "0x" + tokens[destinationField]
@@ -98,13 +210,88 @@ func findDefaultInterface(route []byte) (string, error) {
 		// Cast hex address to int
 		destination, err := strconv.ParseInt(destinationHex, 0, 64)
 		if err != nil {
-			return "", fmt.Errorf("parsing destination field hex '%s' in row '%s': %w", destinationHex, row, err)
+			return nil, fmt.Errorf("parsing destination field hex '%s' in row '%s': %w", destinationHex, row, err)
 		}
 
 		// The default interface is the one that's 0
-		if destination == 0 {
-			return tokens[interfaceNameField], nil
+		if destination != 0 {
+			continue
+		}
+
+		// Older files may lack some of the fields, in which case the route is considered usable.
+		if len(tokens) > maskField && strings.TrimSpace(tokens[maskField]) != "00000000" {
+			continue
+		}
+
+		if len(tokens) > flagsField {
+			flags, err := strconv.ParseUint(strings.TrimSpace(tokens[flagsField]), 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("parsing flags field '%s' in row '%s': %w", tokens[flagsField], row, err)
+			}
+			if !usableRoute(flags) {
+				continue
+			}
+		}
+
+		var metric uint64
+		if len(tokens) > metricField {
+			metric, err = strconv.ParseUint(strings.TrimSpace(tokens[metricField]), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("parsing metric field '%s' in row '%s': %w", tokens[metricField], row, err)
+			}
 		}
+
+		routes = append(routes, Route{Interface: tokens[interfaceNameField], Family: FamilyIPv4, Metric: uint32(metric)})
 	}
-	return "", errors.New("couldn't find interface with default destination")
+
+	return routes, nil
+}
+
+// parseIPv6DefaultRoutes parses the IPv6 route file and returns the usable
+// routes with a ::/0 destination.
+func parseIPv6DefaultRoutes(route []byte) ([]Route, error) {
+	/* /proc/net/ipv6_route file, which has no header, with fields destination, destination prefix length, source,
+	   source prefix length, next hop, metric, reference count, use count, flags and interface name:
+	   00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe80000000000000020c29fffe5a3b8c 00000400 00000001 00000000 00450003 eth0
+	   00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200       lo
+	*/
+	scanner := bufio.NewScanner(bytes.NewReader(route))
+
+	var routes []Route
+	for scanner.Scan() {
+		row := scanner.Text()
+		tokens := strings.Fields(row)
+		if len(tokens) == 0 {
+			continue
+		}
+		if len(tokens) < ipv6Fields {
+			return nil, fmt.Errorf("invalid row '%s' in ipv6 route file", row)
+		}
+
+		if strings.Trim(tokens[ipv6DestinationField], "0") != "" || tokens[ipv6PrefixLenField] != "00" {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(tokens[ipv6FlagsField], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parsing flags field '%s' in row '%s': %w", tokens[ipv6FlagsField], row, err)
+		}
+		if !usableRoute(flags) {
+			continue
+		}
+
+		metric, err := strconv.ParseUint(tokens[ipv6MetricField], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parsing metric field '%s' in row '%s': %w", tokens[ipv6MetricField], row, err)
+		}
+
+		routes = append(routes, Route{Interface: tokens[ipv6InterfaceField], Family: FamilyIPv6, Metric: uint32(metric)})
+	}
+
+	return routes, nil
+}
+
+// usableRoute returns whether a route with the given flags is up and does not reject packets.
+func usableRoute(flags uint64) bool {
+	return flags&routeFlagUp != 0 && flags&routeFlagReject == 0
 }
@@ -2,11 +2,14 @@ package network
 
 import (
 	"errors"
+	"net"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 func TestDefaultInterface(t *testing.T) {
@@ -24,6 +27,89 @@ func TestDefaultInterface(t *testing.T) {
 	assert.Equal(t, "ens5", i)
 }
 
+func TestDefaultRoute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		dir      string
+		family   string
+		expected Route
+	}{
+		{
+			name:     "ipv4",
+			dir:      "./testdata",
+			expected: Route{Interface: "ens5", Family: FamilyIPv4, Metric: 100},
+		},
+		{
+			name:     "lowest_metric_usable_route",
+			dir:      "./testdata/multiple",
+			expected: Route{Interface: "ens6", Family: FamilyIPv4, Metric: 50},
+		},
+		{
+			name:     "dual_stack_prefers_ipv4",
+			dir:      "./testdata/dualstack",
+			expected: Route{Interface: "ens5", Family: FamilyIPv4, Metric: 100},
+		},
+		{
+			name:     "ipv6_only",
+			dir:      "./testdata/ipv6only",
+			expected: Route{Interface: "eth0", Family: FamilyIPv6, Metric: 100},
+		},
+		{
+			name:     "dual_stack_ipv6_family",
+			dir:      "./testdata/dualstack",
+			family:   FamilyIPv6,
+			expected: Route{Interface: "eth0", Family: FamilyIPv6, Metric: 100},
+		},
+		{
+			name:     "dual_stack_ipv4_family",
+			dir:      "./testdata/dualstack",
+			family:   FamilyIPv4,
+			expected: Route{Interface: "ens5", Family: FamilyIPv4, Metric: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Files are read directly, as the test files are not under /proc/ or /sys/.
+			route, err := defaultRouteFromFiles(filepath.Join(tt.dir, "route"), filepath.Join(tt.dir, ipv6RouteFileName), tt.family)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, route)
+		})
+	}
+}
+
+func TestDefaultRoute_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := defaultRouteFromFiles("./testdata/ipv6only/route", "./testdata/missing/ipv6_route", "")
+	assert.ErrorIs(t, err, errNoDefaultRoute)
+
+	// IPv6 routes are not used when the IPv4 family is configured.
+	_, err = defaultRouteFromFiles("./testdata/ipv6only/route", "./testdata/ipv6only/ipv6_route", FamilyIPv4)
+	assert.ErrorIs(t, err, errNoDefaultRoute)
+
+	_, err = defaultRouteFromFiles("./testdata/dualstack/route", "./testdata/dualstack/ipv6_route", "ipv5")
+	assert.ErrorIs(t, err, errInvalidFamily)
+}
+
+func TestParseIPv6DefaultRoutes_SkipsRejectRoutes(t *testing.T) {
+	t.Parallel()
+
+	content, err := routeFileContent("./testdata/ipv6only/ipv6_route")
+	require.NoError(t, err)
+
+	routes, err := parseIPv6DefaultRoutes(content)
+	require.NoError(t, err)
+	assert.Equal(t, []Route{
+		{Interface: "eth1", Family: FamilyIPv6, Metric: 1024},
+		{Interface: "eth0", Family: FamilyIPv6, Metric: 100},
+	}, routes)
+}
+
 func TestValidateRouteFilePath(t *testing.T) {
 	t.Parallel()
 
@@ -62,3 +148,109 @@ func TestDefaultInterfaceRejectsUnsafePath(t *testing.T) {
 	_, err := DefaultInterface("/etc/passwd")
 	assert.True(t, errors.Is(err, errRouteFilePathNotAllowed))
 }
+
+// rule returns a routing rule looking up table, as parsed by netlink.
+func rule(priority, table int) netlink.Rule {
+	r := netlink.NewRule()
+	r.Priority = priority
+	r.Table = table
+
+	return *r
+}
+
+func defaultRoute(table, linkIndex, metric int) netlink.Route {
+	return netlink.Route{Table: table, LinkIndex: linkIndex, Priority: metric, Type: unix.RTN_UNICAST}
+}
+
+func TestSelectDefaultRoute(t *testing.T) {
+	t.Parallel()
+
+	const policyTable = 100
+
+	// Rules of a system without policy routing, as listed by `ip rule`.
+	local, main, fallback := rule(0, unix.RT_TABLE_LOCAL), rule(32766, unix.RT_TABLE_MAIN), rule(32767, unix.RT_TABLE_DEFAULT)
+
+	fromSource := rule(100, policyTable)
+	fromSource.Src = &net.IPNet{IP: net.IPv4(10, 0, 0, 1), Mask: net.CIDRMask(32, 32)}
+
+	suppressedMain := rule(100, unix.RT_TABLE_MAIN)
+	suppressedMain.SuppressPrefixlen = 0
+
+	gotoMain := rule(50, unix.RT_TABLE_UNSPEC)
+	gotoMain.Goto = 32766
+
+	mainRoutes := []netlink.Route{
+		defaultRoute(unix.RT_TABLE_MAIN, 2, 100),
+		defaultRoute(unix.RT_TABLE_MAIN, 3, 50),
+		defaultRoute(unix.RT_TABLE_MAIN, 4, 50),
+		{Table: unix.RT_TABLE_MAIN, LinkIndex: 5, Type: unix.RTN_UNREACHABLE},
+		{Table: unix.RT_TABLE_MAIN, LinkIndex: 6, Type: unix.RTN_UNICAST, Dst: &net.IPNet{IP: net.IPv4(10, 0, 0, 0), Mask: net.CIDRMask(8, 32)}},
+	}
+	policyRoutes := []netlink.Route{
+		{Table: policyTable, Type: unix.RTN_UNICAST, MultiPath: []*netlink.NexthopInfo{{LinkIndex: 7}, {LinkIndex: 8}}, Priority: 200},
+	}
+
+	tests := []struct {
+		name      string
+		rules     []netlink.Rule
+		routes    []netlink.Route
+		linkIndex int
+	}{
+		{
+			name:      "main_table",
+			rules:     []netlink.Rule{main, fallback, local},
+			routes:    mainRoutes,
+			linkIndex: 3,
+		},
+		{
+			name:      "table_selected_by_rule",
+			rules:     []netlink.Rule{local, rule(100, policyTable), main, fallback},
+			routes:    append(append([]netlink.Route{}, mainRoutes...), policyRoutes...),
+			linkIndex: 7,
+		},
+		{
+			name:      "rule_restricted_to_source",
+			rules:     []netlink.Rule{local, fromSource, main, fallback},
+			routes:    append(append([]netlink.Route{}, mainRoutes...), policyRoutes...),
+			linkIndex: 3,
+		},
+		{
+			name:      "main_default_routes_suppressed",
+			rules:     []netlink.Rule{local, suppressedMain, rule(200, policyTable), main, fallback},
+			routes:    append(append([]netlink.Route{}, mainRoutes...), policyRoutes...),
+			linkIndex: 7,
+		},
+		{
+			name:      "goto_skips_rules",
+			rules:     []netlink.Rule{local, gotoMain, rule(100, policyTable), main, fallback},
+			routes:    append(append([]netlink.Route{}, mainRoutes...), policyRoutes...),
+			linkIndex: 3,
+		},
+		{
+			name:      "table_without_default_route",
+			rules:     []netlink.Rule{local, rule(100, policyTable), main, fallback},
+			routes:    mainRoutes,
+			linkIndex: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			route, ok := selectDefaultRoute(tt.rules, tt.routes)
+			require.True(t, ok)
+			assert.Equal(t, tt.linkIndex, routeLinkIndex(route))
+		})
+	}
+
+	_, ok := selectDefaultRoute([]netlink.Rule{local, main}, mainRoutes[3:])
+	assert.False(t, ok, "unreachable and non-default routes must not be selected")
+}
+
+func TestNetnsPath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/host/proc/1/ns/net", netnsPath("/host/proc/1/net/route"))
+	assert.Equal(t, "/proc/self/ns/net", netnsPath("/proc/net/route"))
+}
@@ -0,0 +1,180 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// netnsPath returns the path of the network namespace of the process whose routes are in routeFile, like
+// /host/proc/1/ns/net for /host/proc/1/net/route, or the one of the integration if routeFile is not in a process
+// directory, like /proc/net/route.
+func netnsPath(routeFile string) string {
+	procDir := filepath.Dir(filepath.Dir(routeFile))
+	if filepath.Base(procDir) == "proc" {
+		procDir = filepath.Join(procDir, "self")
+	}
+
+	return filepath.Join(procDir, "ns", "net")
+}
+
+// defaultRouteFromNetlink returns the default route of the given family in the network namespace in nsPath, read
+// through netlink from the routing policy rules and all the routing tables. If family is empty, the IPv4 one is
+// preferred.
+func defaultRouteFromNetlink(nsPath, family string) (Route, error) {
+	ns, err := netns.GetFromPath(nsPath)
+	if err != nil {
+		return Route{}, fmt.Errorf("opening network namespace %s: %w", nsPath, err)
+	}
+	defer func() {
+		_ = ns.Close()
+	}()
+
+	handle, err := netlinkHandle(ns)
+	if err != nil {
+		return Route{}, fmt.Errorf("opening netlink socket in network namespace %s: %w", nsPath, err)
+	}
+	defer handle.Close()
+
+	families := []string{FamilyIPv4, FamilyIPv6}
+	if family != "" {
+		families = []string{family}
+	}
+
+	for _, f := range families {
+		netlinkFamily := unix.AF_INET
+		if f == FamilyIPv6 {
+			netlinkFamily = unix.AF_INET6
+		}
+
+		rules, err := handle.RuleList(netlinkFamily)
+		if err != nil {
+			return Route{}, fmt.Errorf("listing %s routing rules: %w", f, err)
+		}
+
+		routes, err := handle.RouteListFiltered(netlinkFamily, &netlink.Route{Table: unix.RT_TABLE_UNSPEC}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			return Route{}, fmt.Errorf("listing %s routes: %w", f, err)
+		}
+
+		route, ok := selectDefaultRoute(rules, routes)
+		if !ok {
+			continue
+		}
+
+		link, err := handle.LinkByIndex(routeLinkIndex(route))
+		if err != nil {
+			return Route{}, fmt.Errorf("getting interface of %s default route: %w", f, err)
+		}
+
+		return Route{Interface: link.Attrs().Name, Family: f, Metric: uint32(route.Priority)}, nil
+	}
+
+	return Route{}, errNoDefaultRoute
+}
+
+// netlinkHandle returns a netlink handle in ns, without switching namespaces if it is the one of the integration,
+// which requires more privileges.
+func netlinkHandle(ns netns.NsHandle) (*netlink.Handle, error) {
+	current, err := netns.Get()
+	if err == nil {
+		defer func() {
+			_ = current.Close()
+		}()
+
+		if current.Equal(ns) {
+			return netlink.NewHandle(unix.NETLINK_ROUTE)
+		}
+	}
+
+	return netlink.NewHandleAt(ns, unix.NETLINK_ROUTE)
+}
+
+// selectDefaultRoute returns the default route the kernel would use for traffic of the node, walking the rules by
+// priority as the kernel does, and returning the usable default route with the lowest metric in the first table they
+// look up that has any. Rules restricted to some traffic, like the one from a source or with a mark, are skipped, as
+// well as rules without a table, like unreachable ones.
+func selectDefaultRoute(rules []netlink.Rule, routes []netlink.Route) (netlink.Route, bool) {
+	sorted := make([]netlink.Rule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority < sorted[j].Priority })
+
+	for n := 0; n < len(sorted); n++ {
+		rule := sorted[n]
+		if !appliesToAllTraffic(rule) {
+			continue
+		}
+
+		if rule.Goto >= 0 {
+			for n+1 < len(sorted) && sorted[n+1].Priority < rule.Goto {
+				n++
+			}
+			continue
+		}
+
+		// Rules like `lookup main suppress_prefixlength 0` ignore the default routes of the table.
+		if rule.Table == unix.RT_TABLE_UNSPEC || rule.SuppressPrefixlen >= 0 {
+			continue
+		}
+
+		if route, ok := lowestMetricDefaultRoute(routes, rule.Table); ok {
+			return route, true
+		}
+	}
+
+	return netlink.Route{}, false
+}
+
+// appliesToAllTraffic returns whether the rule has no selector restricting the traffic it applies to.
+func appliesToAllTraffic(rule netlink.Rule) bool {
+	return !rule.Invert &&
+		anyNet(rule.Src) && anyNet(rule.Dst) &&
+		rule.IifName == "" && rule.OifName == "" &&
+		rule.Mark == 0 && rule.Mask == nil &&
+		rule.Tos == 0 && rule.TunID == 0 && rule.IPProto == 0 &&
+		rule.Sport == nil && rule.Dport == nil && rule.UIDRange == nil
+}
+
+// lowestMetricDefaultRoute returns the usable default route in table with the lowest metric. The first one is returned
+// on ties, as the kernel does.
+func lowestMetricDefaultRoute(routes []netlink.Route, table int) (netlink.Route, bool) {
+	var best netlink.Route
+	found := false
+
+	for _, r := range routes {
+		if r.Table != table || r.Type != unix.RTN_UNICAST || !anyNet(r.Dst) || routeLinkIndex(r) == 0 {
+			continue
+		}
+
+		if !found || r.Priority < best.Priority {
+			best = r
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// routeLinkIndex returns the index of the interface of the route, or of its first next hop for multipath routes.
+func routeLinkIndex(r netlink.Route) int {
+	if r.LinkIndex == 0 && len(r.MultiPath) > 0 {
+		return r.MultiPath[0].LinkIndex
+	}
+
+	return r.LinkIndex
+}
+
+// anyNet returns whether the network is unset or matches any address.
+func anyNet(n *net.IPNet) bool {
+	if n == nil {
+		return true
+	}
+
+	ones, _ := n.Mask.Size()
+	return ones == 0
+}
@@ -0,0 +1,133 @@
+package populator
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+)
+
+// entityKey identifies an entity by its name and type, as the populator does not set ID attributes.
+type entityKey struct {
+	name       string
+	entityType string
+}
+
+// bufferedEntity is an entity created by a worker, along with the attributes added to it, which are added again to the
+// integration entity it is merged into, if any.
+type bufferedEntity struct {
+	entity     *integration.Entity
+	attributes []attribute.Attribute
+}
+
+// entityCreator creates the entities of the workers with Integration.Entity, so they get the storer and SDK arguments
+// of the integration.
+//
+// Integration.Entity looks for an existing entity among all the ones in the integration before creating one, so
+// creating n entities through it is quadratic. The entities of the integration are instead set aside while populating,
+// and its entity list is emptied before each call, which are serialized as they all share it. Workers look entities up
+// in the index of their own entityBuffer, and restore merges them with the ones set aside.
+type entityCreator struct {
+	lock        sync.Mutex
+	integration *integration.Integration
+	entities    []*integration.Entity
+}
+
+func newEntityCreator(i *integration.Integration) *entityCreator {
+	c := &entityCreator{integration: i, entities: i.Entities}
+	// The integration must not share the backing array of its entities while populating, as Entity appends to it.
+	i.Entities = make([]*integration.Entity, 0, 1)
+
+	return c
+}
+
+func (c *entityCreator) create(name, entityType string) (*integration.Entity, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.integration.Entities = c.integration.Entities[:0]
+
+	return c.integration.Entity(name, entityType)
+}
+
+// restore sets the entities of the integration back, and adds the ones in buffers as in mergeEntityBuffers.
+func (c *entityCreator) restore(buffers []*entityBuffer) {
+	c.integration.Entities = c.entities
+	mergeEntityBuffers(c.integration, buffers)
+}
+
+// entityBuffer holds the entities created by a worker until they are merged into the integration.
+type entityBuffer struct {
+	creator  *entityCreator
+	entities []bufferedEntity
+	index    map[entityKey]int
+}
+
+func newEntityBuffer(creator *entityCreator) *entityBuffer {
+	return &entityBuffer{
+		creator: creator,
+		index:   map[entityKey]int{},
+	}
+}
+
+// entity returns the entity with the given name and type, creating it if it is not in the buffer, and adds attributes
+// to it.
+func (b *entityBuffer) entity(name, entityType string, attributes []attribute.Attribute) (*integration.Entity, error) {
+	key := entityKey{name: name, entityType: entityType}
+
+	n, ok := b.index[key]
+	if !ok {
+		e, err := b.creator.create(name, entityType)
+		if err != nil {
+			return nil, err
+		}
+
+		n = len(b.entities)
+		b.entities = append(b.entities, bufferedEntity{entity: e})
+		b.index[key] = n
+	}
+
+	buffered := &b.entities[n]
+	buffered.entity.AddAttributes(attributes...)
+	buffered.attributes = append(buffered.attributes, attributes...)
+
+	return buffered.entity, nil
+}
+
+// mergeEntityBuffers adds the entities in buffers to the integration. Entities already in it, either created before
+// populating or by another worker, get the metric sets and attributes of the buffered ones.
+func mergeEntityBuffers(i *integration.Integration, buffers []*entityBuffer) {
+	var count int
+	for _, b := range buffers {
+		count += len(b.entities)
+	}
+	if count == 0 {
+		return
+	}
+
+	existing := make(map[entityKey]*integration.Entity, len(i.Entities)+count)
+	for _, e := range i.Entities {
+		if e.Metadata == nil || len(e.Metadata.IDAttrs) > 0 {
+			continue
+		}
+		existing[entityKey{name: e.Metadata.Name, entityType: e.Metadata.Namespace}] = e
+	}
+
+	i.Entities = slices.Grow(i.Entities, count)
+	for _, b := range buffers {
+		for _, buffered := range b.entities {
+			key := entityKey{name: buffered.entity.Metadata.Name, entityType: buffered.entity.Metadata.Namespace}
+
+			e, ok := existing[key]
+			if !ok {
+				existing[key] = buffered.entity
+				i.Entities = append(i.Entities, buffered.entity)
+				continue
+			}
+
+			e.AddAttributes(buffered.attributes...)
+			e.Metrics = append(e.Metrics, buffered.entity.Metrics...)
+		}
+	}
+}
@@ -3,11 +3,14 @@ package populator
 import (
 	"errors"
 	"fmt"
+	"runtime"
 	"strconv"
+	"sync"
 
 	"github.com/newrelic/infra-integrations-sdk/data/attribute"
 	"github.com/newrelic/infra-integrations-sdk/data/metric"
 	"github.com/newrelic/infra-integrations-sdk/integration"
+	"github.com/newrelic/nri-kubernetes/v3/internal/attributefilter"
 	"github.com/newrelic/nri-kubernetes/v3/src/definition"
 	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
 )
@@ -25,45 +28,79 @@ type processingUnit struct {
 	entityID         string // The final entity ID (after IDGenerator), used for entity creation
 	entityType       string
 	rawMetrics       definition.RawMetrics
+	// split is set for the units of a group split by a label, whose rawMetrics only hold the metrics of the unit.
+	split bool
+}
+
+// workItem is an entity of a group, as returned by the grouper.
+type workItem struct {
+	groupLabel string
+	entityID   string
+	rawMetrics definition.RawMetrics
+}
+
+// workerResult is what a worker populated into its entity buffer.
+type workerResult struct {
+	buffer    *entityBuffer
+	populated bool
+	errs      []error
 }
 
 // IntegrationPopulator is the main orchestrator that populates an integration.Integration
 // object from the grouped metric data. It prepares "processing units" for each entity
 // or sub-entity and then populates them.
+//
+// Entities are split among parallel workers, each creating them in its own entityBuffer, which are merged into the
+// integration once all of them are done. Spec functions must then be safe for concurrent use, which they are as long
+// as they only read the raw groups, and the integration must not be used by anything else meanwhile, as its entities
+// are set aside by the entityCreator.
 func IntegrationPopulator(config *definition.IntegrationPopulateConfig) (bool, []error) {
-	var populated bool
-	var errs []error
-
-	for groupLabel, entities := range config.Groups {
-		for entityID, rawMetrics := range entities {
-			specGroup, ok := config.Specs[groupLabel]
-			if !ok {
-				continue
-			}
+	items := workItems(config)
 
-			extraAttributes, skip := filterGroup(config, specGroup, groupLabel, rawMetrics)
-			if skip {
-				continue
-			}
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// Small groups are not worth the overhead of starting workers.
+	workers = min(workers, (len(items)+minItemsPerWorker-1)/minItemsPerWorker)
+	workers = max(workers, 1)
+
+	creator := newEntityCreator(config.Integration)
+	results := make([]workerResult, workers)
+	for w := range results {
+		results[w].buffer = newEntityBuffer(creator)
+	}
 
-			unitsToProcess, err := prepareProcessingUnits(config, groupLabel, entityID, rawMetrics)
-			if err != nil {
-				errs = append(errs, err)
-				continue
+	var wg sync.WaitGroup
+	chunk := (len(items) + workers - 1) / workers
+	for w := range results {
+		first := min(w*chunk, len(items))
+		last := min(first+chunk, len(items))
+
+		wg.Add(1)
+		go func(result *workerResult, items []workItem) {
+			defer wg.Done()
+			for _, item := range items {
+				populated, errs := populateItem(config, result.buffer, item)
+				result.populated = result.populated || populated
+				result.errs = append(result.errs, errs...)
 			}
+		}(&results[w], items[first:last])
+	}
+	wg.Wait()
 
-			pop, perr := processEntities(unitsToProcess, config, specGroup, groupLabel, extraAttributes)
-			if len(perr) > 0 {
-				errs = append(errs, perr...)
-			}
-			if pop {
-				populated = true
-			}
-		}
+	var populated bool
+	var errs []error
+	buffers := make([]*entityBuffer, 0, len(results))
+	for _, result := range results {
+		populated = populated || result.populated
+		errs = append(errs, result.errs...)
+		buffers = append(buffers, result.buffer)
 	}
+	creator.restore(buffers)
 
 	if populated {
-		if err := populateCluster(config.Integration, config.ClusterName, config.CloudClusterID, config.K8sVersion); err != nil {
+		if err := populateCluster(config.Integration, config.ClusterName, config.CloudClusterID, config.K8sVersion, config.CustomAttributes); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -71,6 +108,48 @@ func IntegrationPopulator(config *definition.IntegrationPopulateConfig) (bool, [
 	return populated, errs
 }
 
+// minItemsPerWorker is the minimum number of entities given to each worker.
+const minItemsPerWorker = 256
+
+// workItems returns the entities of the groups with specs.
+func workItems(config *definition.IntegrationPopulateConfig) []workItem {
+	var count int
+	for groupLabel, entities := range config.Groups {
+		if _, ok := config.Specs[groupLabel]; ok {
+			count += len(entities)
+		}
+	}
+
+	items := make([]workItem, 0, count)
+	for groupLabel, entities := range config.Groups {
+		if _, ok := config.Specs[groupLabel]; !ok {
+			continue
+		}
+		for entityID, rawMetrics := range entities {
+			items = append(items, workItem{groupLabel: groupLabel, entityID: entityID, rawMetrics: rawMetrics})
+		}
+	}
+
+	return items
+}
+
+// populateItem populates the entities of a work item into buffer.
+func populateItem(config *definition.IntegrationPopulateConfig, buffer *entityBuffer, item workItem) (bool, []error) {
+	specGroup := config.Specs[item.groupLabel]
+
+	extraAttributes, skip := filterGroup(config, specGroup, item.groupLabel, item.rawMetrics)
+	if skip {
+		return false, nil
+	}
+
+	unitsToProcess, err := prepareProcessingUnits(config, item.groupLabel, item.entityID, item.rawMetrics)
+	if err != nil {
+		return false, []error{err}
+	}
+
+	return processEntities(unitsToProcess, config, buffer, specGroup, item.groupLabel, extraAttributes)
+}
+
 // filterGroup checks if an entity group should be filtered by namespace.
 // It returns true if the group should be filtered. For namespace-group entities,
 // it returns extra attributes to be added.
@@ -96,28 +175,35 @@ func filterGroup(
 }
 
 // processEntities handles the creation and population of a single entity (or sub-entity).
-func processEntities(unitsToProcess []processingUnit, config *definition.IntegrationPopulateConfig, specGroup definition.SpecGroup, groupLabel string, extraAttributes []attribute.Attribute) (bool, []error) {
+func processEntities(
+	unitsToProcess []processingUnit,
+	config *definition.IntegrationPopulateConfig,
+	buffer *entityBuffer,
+	specGroup definition.SpecGroup,
+	groupLabel string,
+	extraAttributes []attribute.Attribute,
+) (bool, []error) {
 	var populated bool
 	var errs []error
 
 	for _, unit := range unitsToProcess {
-		e, err := config.Integration.Entity(unit.entityID, unit.entityType)
-		if err != nil {
-			errs = append(errs, err)
-			continue
-		}
-
-		additionalAttributeCount := 3 // clusterName, displayName, cloud.resource_id (optional).
+		additionalAttributeCount := 3 + len(config.CustomAttributes) // clusterName, displayName, cloud.resource_id (optional).
 		attrs := make([]attribute.Attribute, len(extraAttributes), len(extraAttributes)+additionalAttributeCount)
 		copy(attrs, extraAttributes)
 		attrs = append(attrs,
 			attribute.Attr("clusterName", config.ClusterName),
-			attribute.Attr("displayName", e.Metadata.Name),
+			attribute.Attr("displayName", unit.entityID),
 		)
 		if config.CloudClusterID != "" {
 			attrs = append(attrs, attribute.Attr("cloud.resource_id", config.CloudClusterID))
 		}
-		e.AddAttributes(attrs...)
+		attrs = append(attrs, config.CustomAttributes...)
+
+		e, err := buffer.entity(unit.entityID, unit.entityType, attrs)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
 
 		msTypeGuesser := config.MsTypeGuesser
 		if customGuesser := specGroup.MsTypeGuesser; customGuesser != nil {
@@ -130,15 +216,20 @@ func processEntities(unitsToProcess []processingUnit, config *definition.Integra
 		}
 		ms := e.NewMetricSet(msType)
 
-		groupsForThisEntity := definition.RawGroups{}
-		for groupName, groupValue := range config.Groups {
-			groupsForThisEntity[groupName] = groupValue
+		// The metrics of units split from a group are looked up in a copy of the groups only holding them. Otherwise,
+		// they are the ones in the groups, which are shared by all the workers without copying them.
+		groupsForThisEntity := config.Groups
+		if unit.split {
+			groupsForThisEntity = make(definition.RawGroups, len(config.Groups))
+			for groupName, groupValue := range config.Groups {
+				groupsForThisEntity[groupName] = groupValue
+			}
+			// Use originalEntityID for RawGroups key to match grouper's format
+			groupsForThisEntity[groupLabel] = map[string]definition.RawMetrics{unit.originalEntityID: unit.rawMetrics}
 		}
-		// Use originalEntityID for RawGroups key to match grouper's format
-		groupsForThisEntity[groupLabel] = map[string]definition.RawMetrics{unit.originalEntityID: unit.rawMetrics}
 
 		// Use originalEntityID for metric lookups (InheritAllLabelsFrom needs this)
-		wasPopulated, populateErrs := metricSetPopulate(ms, groupLabel, unit.originalEntityID, groupsForThisEntity, config.Specs)
+		wasPopulated, populateErrs := metricSetPopulate(ms, groupLabel, unit.originalEntityID, groupsForThisEntity, config.Specs, config.AttributeFilter)
 		if len(populateErrs) > 0 {
 			for _, err := range populateErrs {
 				errs = append(errs, fmt.Errorf("error populating metric for entity ID %s: %w", unit.entityID, err))
@@ -146,11 +237,30 @@ func processEntities(unitsToProcess []processingUnit, config *definition.Integra
 		}
 		if wasPopulated {
 			populated = true
+			errs = append(errs, setNodeAttributes(ms, config.NodeAttributes)...)
 		}
 	}
 	return populated, errs
 }
 
+// setNodeAttributes adds to a populated metric set the attributes of the node in its nodeName attribute.
+func setNodeAttributes(ms *metric.Set, getter definition.NodeAttributesGetter) []error {
+	if getter == nil {
+		return nil
+	}
+
+	nodeName, _ := ms.Metrics["nodeName"].(string)
+
+	var errs []error
+	for name, value := range getter.NodeAttributes(nodeName) {
+		if err := ms.SetMetric(name, value, metric.ATTRIBUTE); err != nil {
+			errs = append(errs, fmt.Errorf("%w %q: %w", ErrSetMetric, name, err))
+		}
+	}
+
+	return errs
+}
+
 // prepareProcessingUnits takes a raw entity group and, based on its SpecGroup rules,
 // returns a slice of one or more processingUnits. This is the core of the sub-grouping
 // logic: it either prepares a single unit for a standard entity or multiple units if
@@ -175,6 +285,7 @@ func prepareProcessingUnits(config *definition.IntegrationPopulateConfig, groupL
 				entityID:         subEntityID, // Use sub-entity ID for entity creation
 				entityType:       entityType,
 				rawMetrics:       subGroupMetrics,
+				split:            true,
 			})
 		}
 		return units, nil
@@ -288,8 +399,9 @@ func splitGroup(rawMetrics definition.RawMetrics, sliceMetricName, groupLabel, s
 	return subGroups, nil
 }
 
-// metricSetPopulate acts as a dispatcher, populating a metric set based on the spec definitions.
-func metricSetPopulate(ms *metric.Set, groupLabel, entityID string, groups definition.RawGroups, specs definition.SpecGroups) (bool, []error) {
+// metricSetPopulate acts as a dispatcher, populating a metric set based on the spec definitions. Attributes rejected
+// by the filter are not set, and their number is reported in the metric set.
+func metricSetPopulate(ms *metric.Set, groupLabel, entityID string, groups definition.RawGroups, specs definition.SpecGroups, filter attributefilter.Filterer) (bool, []error) {
 	var populated bool
 	var errs []error
 
@@ -300,6 +412,8 @@ func metricSetPopulate(ms *metric.Set, groupLabel, entityID string, groups defin
 		return false, nil
 	}
 
+	setter := &metricSetter{ms: ms, groupLabel: groupLabel, filter: filter}
+
 	// 2. The rest of the logic remains the same, using 'specGroup' which we just found.
 	for _, spec := range specGroup.Specs {
 		val, err := spec.ValueFunc(groupLabel, entityID, groups)
@@ -313,7 +427,7 @@ func metricSetPopulate(ms *metric.Set, groupLabel, entityID string, groups defin
 			continue
 		}
 
-		p, e := populateValue(ms, &spec, val)
+		p, e := setter.populateValue(&spec, val)
 		if e != nil && !spec.Optional {
 			errs = append(errs, fmt.Errorf("populating entity %q: %w", entityID, e))
 		}
@@ -321,26 +435,44 @@ func metricSetPopulate(ms *metric.Set, groupLabel, entityID string, groups defin
 			populated = true
 		}
 	}
+
+	if setter.dropped > 0 {
+		if err := ms.SetMetric(definition.DroppedAttributesMetric, setter.dropped, metric.GAUGE); err != nil {
+			errs = append(errs, fmt.Errorf("%w %q: %w", ErrSetMetric, definition.DroppedAttributesMetric, err))
+		}
+	}
+
 	return populated, errs
 }
 
+// metricSetter sets metrics in a metric set, skipping the attributes rejected by the filter.
+type metricSetter struct {
+	ms         *metric.Set
+	groupLabel string
+	filter     attributefilter.Filterer
+	dropped    int
+}
+
 // populateValue is a helper that adds a fetched value to a metric set by determining its type.
-func populateValue(ms *metric.Set, spec *definition.Spec, val definition.FetchedValue) (bool, error) {
+func (s *metricSetter) populateValue(spec *definition.Spec, val definition.FetchedValue) (bool, error) {
 	switch v := val.(type) {
 	case definition.FetchedValues:
-		return populateMetricsFromMap(ms, v, spec.Type)
+		return s.populateMetricsFromMap(v, spec.Type)
 	default:
-		return populateSingleMetric(ms, spec.Name, v, spec.Type)
+		return s.populateSingleMetric(spec.Name, v, spec.Type)
 	}
 }
 
 // populateMetricsFromMap adds multiple metrics that all share a single type from the spec.
-func populateMetricsFromMap(ms *metric.Set, metrics definition.FetchedValues, sourceType metric.SourceType) (bool, error) {
+func (s *metricSetter) populateMetricsFromMap(metrics definition.FetchedValues, sourceType metric.SourceType) (bool, error) {
 	if len(metrics) == 0 {
 		return false, nil
 	}
 	for k, v := range metrics {
-		if err := ms.SetMetric(k, v, sourceType); err != nil {
+		if !s.allowed(k, sourceType) {
+			continue
+		}
+		if err := s.ms.SetMetric(k, v, sourceType); err != nil {
 			return false, fmt.Errorf("%w %q: %w", ErrSetMetric, k, err)
 		}
 	}
@@ -348,15 +480,28 @@ func populateMetricsFromMap(ms *metric.Set, metrics definition.FetchedValues, so
 }
 
 // populateSingleMetric adds a single metric to the metric set.
-func populateSingleMetric(ms *metric.Set, name string, value interface{}, sourceType metric.SourceType) (bool, error) {
-	if err := ms.SetMetric(name, value, sourceType); err != nil {
+func (s *metricSetter) populateSingleMetric(name string, value interface{}, sourceType metric.SourceType) (bool, error) {
+	if !s.allowed(name, sourceType) {
+		return false, nil
+	}
+	if err := s.ms.SetMetric(name, value, sourceType); err != nil {
 		return false, fmt.Errorf("%w %q: %w", ErrSetMetric, name, err)
 	}
 	return true, nil
 }
 
+// allowed returns whether a metric passes the filter, counting the dropped attributes.
+func (s *metricSetter) allowed(name string, sourceType metric.SourceType) bool {
+	if s.filter == nil || sourceType != metric.ATTRIBUTE || s.filter.IsAllowed(s.groupLabel, name) {
+		return true
+	}
+
+	s.dropped++
+	return false
+}
+
 // populateCluster fills cluster-level data.
-func populateCluster(i *integration.Integration, clusterName, cloudClusterID string, k8sVersion fmt.Stringer) error {
+func populateCluster(i *integration.Integration, clusterName, cloudClusterID string, k8sVersion fmt.Stringer, customAttributes []attribute.Attribute) error {
 	e, err := i.Entity(clusterName, "k8s:cluster")
 	if err != nil {
 		// Add context to the error from the SDK.
@@ -400,5 +545,11 @@ func populateCluster(i *integration.Integration, clusterName, cloudClusterID str
 		return fmt.Errorf("could not set clusterK8sVersion metric: %w", err)
 	}
 
+	for _, attr := range customAttributes {
+		if err = ms.SetMetric(attr.Key, attr.Value, metric.ATTRIBUTE); err != nil {
+			return fmt.Errorf("could not set custom attribute %q: %w", attr.Key, err)
+		}
+	}
+
 	return nil
 }
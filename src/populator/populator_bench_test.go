@@ -0,0 +1,366 @@
+package populator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"testing"
+
+	sdkArgs "github.com/newrelic/infra-integrations-sdk/args"
+	"github.com/newrelic/infra-integrations-sdk/integration"
+	model "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
+	"github.com/newrelic/nri-kubernetes/v3/src/definition"
+	"github.com/newrelic/nri-kubernetes/v3/src/metric"
+	"github.com/newrelic/nri-kubernetes/v3/src/prometheus"
+)
+
+// generateKSMFamilies returns the KSM metric families of a cluster with the given number of pods, which are
+// unscheduled so KSM reports them, in deployments of 10 replicas and namespaces of 100 pods. As in KSM, families of an
+// object without allowlisted labels or annotations have the same label set.
+func generateKSMFamilies(pods int) []prometheus.MetricFamily {
+	family := func(name string) *prometheus.MetricFamily {
+		return &prometheus.MetricFamily{Name: name, Type: "GAUGE"}
+	}
+
+	namespaceCreated := family("kube_namespace_created")
+	namespaceLabels := family("kube_namespace_labels")
+	namespaceAnnotations := family("kube_namespace_annotations")
+	namespacePhase := family("kube_namespace_status_phase")
+	deploymentCreated := family("kube_deployment_created")
+	deploymentReplicas := family("kube_deployment_spec_replicas")
+	deploymentLabels := family("kube_deployment_labels")
+	deploymentStatus := []*prometheus.MetricFamily{
+		family("kube_deployment_status_replicas"),
+		family("kube_deployment_status_replicas_available"),
+		family("kube_deployment_status_replicas_unavailable"),
+		family("kube_deployment_status_replicas_updated"),
+	}
+	podInfo := family("kube_pod_info")
+	podCreated := family("kube_pod_created")
+	podPhase := family("kube_pod_status_phase")
+	podScheduled := family("kube_pod_status_scheduled")
+	podLabels := family("kube_pod_labels")
+	podAnnotations := family("kube_pod_annotations")
+
+	for n := 0; n < pods; n++ {
+		namespace := fmt.Sprintf("namespace-%d", n/100)
+		deployment := fmt.Sprintf("deployment-%d", n/10)
+		pod := fmt.Sprintf("%s-%d", deployment, n%10)
+
+		if n%100 == 0 {
+			labels := prometheus.Labels{"namespace": namespace}
+			namespaceCreated.Metrics = append(namespaceCreated.Metrics, prometheus.Metric{Labels: labels, Value: prometheus.GaugeValue(1.6e9)})
+			namespaceAnnotations.Metrics = append(namespaceAnnotations.Metrics, prometheus.Metric{
+				Labels: prometheus.Labels{"namespace": namespace},
+				Value:  prometheus.GaugeValue(1),
+			})
+			namespaceLabels.Metrics = append(namespaceLabels.Metrics, prometheus.Metric{
+				Labels: prometheus.Labels{"namespace": namespace, "label_team": "team-" + namespace},
+				Value:  prometheus.GaugeValue(1),
+			})
+			namespacePhase.Metrics = append(namespacePhase.Metrics, prometheus.Metric{
+				Labels: prometheus.Labels{"namespace": namespace, "phase": "Active"},
+				Value:  prometheus.GaugeValue(1),
+			})
+		}
+
+		if n%10 == 0 {
+			labels := prometheus.Labels{"namespace": namespace, "deployment": deployment}
+			deploymentCreated.Metrics = append(deploymentCreated.Metrics, prometheus.Metric{Labels: labels, Value: prometheus.GaugeValue(1.6e9)})
+			deploymentReplicas.Metrics = append(deploymentReplicas.Metrics, prometheus.Metric{Labels: labels, Value: prometheus.GaugeValue(10)})
+			for _, status := range deploymentStatus {
+				status.Metrics = append(status.Metrics, prometheus.Metric{
+					Labels: prometheus.Labels{"namespace": namespace, "deployment": deployment},
+					Value:  prometheus.GaugeValue(10),
+				})
+			}
+			deploymentLabels.Metrics = append(deploymentLabels.Metrics, prometheus.Metric{
+				Labels: prometheus.Labels{"namespace": namespace, "deployment": deployment, "label_app": deployment},
+				Value:  prometheus.GaugeValue(1),
+			})
+		}
+
+		podInfo.Metrics = append(podInfo.Metrics, prometheus.Metric{
+			Labels: prometheus.Labels{
+				"namespace":       namespace,
+				"pod":             pod,
+				"created_by_kind": "ReplicaSet",
+				"created_by_name": deployment + "-abc",
+				"priority_class":  "default",
+			},
+			Value: prometheus.GaugeValue(1),
+		})
+		podCreated.Metrics = append(podCreated.Metrics, prometheus.Metric{
+			Labels: prometheus.Labels{"namespace": namespace, "pod": pod},
+			Value:  prometheus.GaugeValue(1.6e9),
+		})
+		podPhase.Metrics = append(podPhase.Metrics, prometheus.Metric{
+			Labels: prometheus.Labels{"namespace": namespace, "pod": pod, "phase": "Pending"},
+			Value:  prometheus.GaugeValue(1),
+		})
+		podScheduled.Metrics = append(podScheduled.Metrics, prometheus.Metric{
+			Labels: prometheus.Labels{"namespace": namespace, "pod": pod, "condition": "false"},
+			Value:  prometheus.GaugeValue(1),
+		})
+		podLabels.Metrics = append(podLabels.Metrics, prometheus.Metric{
+			Labels: prometheus.Labels{"namespace": namespace, "pod": pod, "label_app": deployment, "label_version": "v1"},
+			Value:  prometheus.GaugeValue(1),
+		})
+		podAnnotations.Metrics = append(podAnnotations.Metrics, prometheus.Metric{
+			Labels: prometheus.Labels{"namespace": namespace, "pod": pod},
+			Value:  prometheus.GaugeValue(1),
+		})
+	}
+
+	families := []prometheus.MetricFamily{
+		*namespaceCreated, *namespaceLabels, *namespaceAnnotations, *namespacePhase,
+		*deploymentCreated, *deploymentReplicas, *deploymentLabels,
+		*podInfo, *podCreated, *podPhase, *podScheduled, *podLabels, *podAnnotations,
+	}
+	for _, status := range deploymentStatus {
+		families = append(families, *status)
+	}
+
+	return families
+}
+
+// ksmResponses returns doers serving the KSM response of a cluster with the given number of pods, by the name of the
+// format it is encoded in, and the queries for its families.
+func ksmResponses(tb testing.TB, pods int) (map[string]responseDoer, []prometheus.Query) {
+	tb.Helper()
+
+	var families []*model.MetricFamily
+	var queries []prometheus.Query
+	for _, f := range generateKSMFamilies(pods) {
+		mf := &model.MetricFamily{Name: proto.String(f.Name), Type: model.MetricType_GAUGE.Enum()}
+		for _, m := range f.Metrics {
+			metric := &model.Metric{Gauge: &model.Gauge{Value: proto.Float64(float64(m.Value.(prometheus.GaugeValue)))}}
+			names := make([]string, 0, len(m.Labels))
+			for name := range m.Labels {
+				names = append(names, name)
+			}
+			// Labels are sorted, as Prometheus clients do.
+			sort.Strings(names)
+			for _, name := range names {
+				metric.Label = append(metric.Label, &model.LabelPair{Name: proto.String(name), Value: proto.String(m.Labels[name])})
+			}
+			mf.Metric = append(mf.Metric, metric)
+		}
+
+		families = append(families, mf)
+		queries = append(queries, prometheus.Query{MetricName: f.Name})
+	}
+
+	responses := map[string]responseDoer{}
+	for name, format := range map[string]expfmt.Format{
+		"protobuf": expfmt.NewFormat(expfmt.TypeProtoDelim),
+		"text":     expfmt.NewFormat(expfmt.TypeTextPlain),
+	} {
+		var body bytes.Buffer
+		encoder := expfmt.NewEncoder(&body, format)
+		for _, mf := range families {
+			require.NoError(tb, encoder.Encode(mf))
+		}
+		responses[name] = responseDoer{format: format, body: body.Bytes()}
+	}
+
+	return responses, queries
+}
+
+// responseDoer responds to every request with a body in the given format.
+type responseDoer struct {
+	format expfmt.Format
+	body   []byte
+}
+
+func (d responseDoer) Do(*http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{string(d.format)}},
+		Body:       io.NopCloser(bytes.NewReader(d.body)),
+	}, nil
+}
+
+// parseAndGroup parses the KSM response served by doer and groups its metrics.
+func parseAndGroup(tb testing.TB, doer responseDoer, queries []prometheus.Query) definition.RawGroups {
+	tb.Helper()
+
+	families, err := prometheus.GetFilteredMetricFamilies(doer, "http://ksm/metrics", prometheus.AcceptHeader, queries, logutil.Discard)
+	require.NoError(tb, err)
+
+	groups, _ := prometheus.GroupMetricsBySpec(metric.KSMSpecs, families)
+
+	return groups
+}
+
+// retainedBytes returns the bytes of the heap in use after a garbage collection.
+func retainedBytes() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+
+	return stats.HeapAlloc
+}
+
+func ksmPopulateConfig(tb testing.TB, groups definition.RawGroups, workers int) *definition.IntegrationPopulateConfig {
+	tb.Helper()
+
+	i, err := integration.New("nr.test", "1.0.0", integration.InMemoryStore())
+	require.NoError(tb, err)
+
+	return &definition.IntegrationPopulateConfig{
+		Integration:   i,
+		ClusterName:   defaultNS,
+		K8sVersion:    &version.Info{GitVersion: "v1.15.42"},
+		MsTypeGuesser: definition.K8sMetricSetTypeGuesser,
+		Groups:        groups,
+		Specs:         metric.KSMSpecs,
+		Workers:       workers,
+	}
+}
+
+// populatedSamples returns the samples of every entity, by entity type and name.
+func populatedSamples(i *integration.Integration) map[string][]map[string]interface{} {
+	samples := map[string][]map[string]interface{}{}
+	for _, e := range i.Entities {
+		key := e.Metadata.Namespace + "/" + e.Metadata.Name
+		for _, ms := range e.Metrics {
+			samples[key] = append(samples[key], ms.Metrics)
+		}
+	}
+
+	return samples
+}
+
+func TestIntegrationPopulator_Workers(t *testing.T) {
+	groups, _ := prometheus.GroupMetricsBySpec(metric.KSMSpecs, generateKSMFamilies(5000))
+
+	serial := ksmPopulateConfig(t, groups, 1)
+	populated, _ := IntegrationPopulator(serial)
+	require.True(t, populated)
+
+	parallel := ksmPopulateConfig(t, groups, 8)
+	populated, _ = IntegrationPopulator(parallel)
+	require.True(t, populated)
+
+	// Pods, deployments, namespaces and the cluster.
+	assert.Len(t, parallel.Integration.Entities, 5000+500+50+1)
+	assert.Equal(t, populatedSamples(serial.Integration), populatedSamples(parallel.Integration))
+}
+
+func TestIntegrationPopulator_WorkersMergeExistingEntities(t *testing.T) {
+	groups, _ := prometheus.GroupMetricsBySpec(metric.KSMSpecs, generateKSMFamilies(1000))
+
+	config := ksmPopulateConfig(t, groups, 4)
+	existing, err := config.Integration.Entity("namespace-0", "k8s:playground:namespace")
+	require.NoError(t, err)
+	existing.NewMetricSet("OtherSample")
+
+	populated, _ := IntegrationPopulator(config)
+	require.True(t, populated)
+
+	assert.Len(t, config.Integration.Entities, 1000+100+10+1, "entities already in the integration must not be duplicated")
+	require.Len(t, existing.Metrics, 2)
+	assert.Equal(t, "K8sNamespaceSample", existing.Metrics[1].Metrics["event_type"])
+	assert.Equal(t, "namespace-0", existing.Metrics[1].Metrics["displayName"])
+}
+
+func TestIntegrationPopulator_WorkersCreateEntitiesWithIntegrationArgs(t *testing.T) {
+	groups, _ := prometheus.GroupMetricsBySpec(metric.KSMSpecs, generateKSMFamilies(1000))
+
+	var args sdkArgs.DefaultArgumentList
+	i, err := integration.New("nr.test", "1.0.0", integration.InMemoryStore(), integration.Args(&args))
+	require.NoError(t, err)
+	// Set after creating the integration, as it parses them from flags and environment variables.
+	args.NriCluster = "nri-cluster"
+
+	config := ksmPopulateConfig(t, groups, 4)
+	config.Integration = i
+
+	populated, _ := IntegrationPopulator(config)
+	require.True(t, populated)
+
+	for key, samples := range populatedSamples(i) {
+		for _, sample := range samples {
+			assert.Equal(t, "nri-cluster", sample[integration.CustomAttrCluster], key)
+		}
+	}
+}
+
+func BenchmarkGroupMetricsBySpec(b *testing.B) {
+	for _, pods := range []int{1000, 10000, 60000} {
+		families := generateKSMFamilies(pods)
+
+		b.Run(fmt.Sprintf("pods=%d", pods), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				prometheus.GroupMetricsBySpec(metric.KSMSpecs, families)
+			}
+		})
+	}
+}
+
+// BenchmarkParseAndGroup parses the KSM response of large clusters and groups its metrics, reporting the bytes of the
+// groups kept in memory while they are populated. Interning the label sets of the samples when parsing, so the metrics
+// of an object in different families share its labels, and the label names and values of protobuf responses, changed
+// them as follows, at the cost of hashing every label set:
+//
+//	                            ns/op            B/op             allocs/op          retained-B
+//	pods=60000/format=protobuf  769ms -> 935ms   522MB -> 521MB   10.65M -> 10.47M   215MB -> 148MB
+//	pods=60000/format=text      697ms -> 747ms   255MB -> 244MB    2.23M ->  2.05M   179MB -> 148MB
+func BenchmarkParseAndGroup(b *testing.B) {
+	for _, pods := range []int{10000, 60000} {
+		responses, queries := ksmResponses(b, pods)
+
+		for _, format := range []string{"protobuf", "text"} {
+			doer := responses[format]
+
+			b.Run(fmt.Sprintf("pods=%d/format=%s", pods, format), func(b *testing.B) {
+				b.ReportAllocs()
+				for n := 0; n < b.N; n++ {
+					parseAndGroup(b, doer, queries)
+				}
+
+				b.StopTimer()
+				before := retainedBytes()
+				groups := parseAndGroup(b, doer, queries)
+				b.ReportMetric(float64(retainedBytes()-before), "retained-B")
+				runtime.KeepAlive(groups)
+			})
+		}
+	}
+}
+
+func BenchmarkIntegrationPopulator(b *testing.B) {
+	for _, pods := range []int{1000, 10000, 60000} {
+		groups, _ := prometheus.GroupMetricsBySpec(metric.KSMSpecs, generateKSMFamilies(pods))
+
+		workerCounts := []int{1}
+		if cpus := runtime.GOMAXPROCS(0); cpus > 1 {
+			workerCounts = append(workerCounts, cpus)
+		}
+
+		for _, workers := range workerCounts {
+			b.Run(fmt.Sprintf("pods=%d/workers=%d", pods, workers), func(b *testing.B) {
+				b.ReportAllocs()
+				for n := 0; n < b.N; n++ {
+					b.StopTimer()
+					config := ksmPopulateConfig(b, groups, workers)
+					b.StartTimer()
+
+					IntegrationPopulator(config)
+				}
+			})
+		}
+	}
+}
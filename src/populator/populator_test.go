@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
 	"github.com/newrelic/infra-integrations-sdk/data/inventory"
 	"github.com/newrelic/infra-integrations-sdk/data/metric"
 	"github.com/newrelic/infra-integrations-sdk/integration"
@@ -307,6 +308,58 @@ func TestIntegrationPopulator_SetsCloudResourceIDAttribute(t *testing.T) {
 	assert.Equal(t, 3, len(intgr.Entities), "expected all data entities to be populated")
 }
 
+// nodeAttributes is a definition.NodeAttributesGetter returning the zone of nodes, and no attributes for samples
+// without a node.
+type nodeAttributes map[string]string
+
+func (n nodeAttributes) NodeAttributes(nodeName string) map[string]string {
+	if zone, ok := n[nodeName]; ok {
+		return map[string]string{"zone": zone}
+	}
+	return nil
+}
+
+func TestIntegrationPopulator_CustomAndNodeAttributes(t *testing.T) {
+	intgr, err := integration.New("nr.test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	config := testConfig(intgr)
+	config.Specs = definition.SpecGroups{
+		"test": definition.SpecGroup{
+			TypeGenerator: fromGroupEntityTypeGuessFunc,
+			Specs: []definition.Spec{
+				{Name: "nodeName", ValueFunc: definition.FromRaw("nodeName"), Type: metric.ATTRIBUTE, Optional: true},
+			},
+		},
+	}
+	config.Groups = definition.RawGroups{
+		"test": {
+			"scheduled": {"nodeName": "node-a"},
+			"pending":   {},
+		},
+	}
+	config.CustomAttributes = []attribute.Attribute{attribute.Attr("environment", "production")}
+	config.NodeAttributes = nodeAttributes{"node-a": "us-east-1a"}
+
+	populated, errs := IntegrationPopulator(config)
+	require.True(t, populated)
+	require.Empty(t, errs)
+	require.Len(t, intgr.Entities, 2+1, "expected the cluster entity besides the data ones")
+
+	for _, e := range intgr.Entities {
+		require.Len(t, e.Metrics, 1)
+		ms := e.Metrics[0].Metrics
+		assert.Equal(t, "production", ms["environment"], "custom attributes must be in all samples")
+
+		switch e.Metadata.Name {
+		case "scheduled":
+			assert.Equal(t, "us-east-1a", ms["zone"])
+		default:
+			assert.NotContains(t, ms, "zone")
+		}
+	}
+}
+
 // TestIntegrationPopulator_OmitsCloudResourceIDWhenEmpty verifies that no
 // cloud.resource_id attribute is emitted when no cloud resource id is configured.
 func TestIntegrationPopulator_OmitsCloudResourceIDWhenEmpty(t *testing.T) {
@@ -467,8 +520,8 @@ func TestIntegrationPopulator_MetricsSetsNotPopulated_OnlyEntity(t *testing.T) {
 
 	// Define the expected error strings.
 	expectedErrorStrings := []string{
-		"error populating metric for entity ID entity_id_1: cannot fetch value for metric \"useless\": metric \"nonExistentMetric\" not found",
-		"error populating metric for entity ID entity_id_2: cannot fetch value for metric \"useless\": metric \"nonExistentMetric\" not found",
+		"error populating metric for entity ID entity_id_1: cannot fetch value for metric \"useless\": metric not found: nonExistentMetric",
+		"error populating metric for entity ID entity_id_2: cannot fetch value for metric \"useless\": metric not found: nonExistentMetric",
 	}
 
 	actualErrorStrings := make([]string, len(errs))
@@ -1049,7 +1102,7 @@ func TestPopulateCluster(t *testing.T) {
 	k8sVersion := mockVersion{version: k8sVersionStr}
 
 	// --- 2. Execute the function under test ---
-	err = populateCluster(intgr, clusterName, "", k8sVersion)
+	err = populateCluster(intgr, clusterName, "", k8sVersion, nil)
 
 	// --- 3. Assertions ---
 
@@ -1118,7 +1171,7 @@ func TestMetricSetPopulate_SkipsNilValues(t *testing.T) {
 	groups := definition.RawGroups{"test": {"test-entity": {}}}
 
 	// 2. Execute
-	populated, errs := metricSetPopulate(ms, "test", "test-entity", groups, specs)
+	populated, errs := metricSetPopulate(ms, "test", "test-entity", groups, specs, nil)
 
 	// 3. Assert
 	assert.True(t, populated, "Expected populated to be true because one metric was set")
@@ -1129,6 +1182,49 @@ func TestMetricSetPopulate_SkipsNilValues(t *testing.T) {
 	assert.NotContains(t, ms.Metrics, "nil_metric")
 }
 
+// denyAnnotations is an attributefilter.Filterer dropping the annotations of pods.
+type denyAnnotations struct{}
+
+func (denyAnnotations) IsAllowed(groupLabel, name string) bool {
+	return groupLabel != "pod" || !strings.HasPrefix(name, "annotation.")
+}
+
+func TestMetricSetPopulate_AttributeFilter(t *testing.T) {
+	intgr, err := integration.New("nr.test", "1.0.0", integration.InMemoryStore())
+	require.NoError(t, err)
+
+	specs := definition.SpecGroups{
+		"pod": {
+			Specs: []definition.Spec{
+				{Name: "podName", ValueFunc: definition.FromRaw("podName"), Type: metric.ATTRIBUTE},
+				{Name: "annotation.single", ValueFunc: definition.FromRaw("annotation"), Type: metric.ATTRIBUTE},
+				{
+					Name: "annotation.*",
+					ValueFunc: func(_, _ string, _ definition.RawGroups) (definition.FetchedValue, error) {
+						return definition.FetchedValues{"annotation.a": "1", "annotation.b": "2", "label.app": "foo"}, nil
+					},
+					Type: metric.ATTRIBUTE,
+				},
+			},
+		},
+	}
+
+	e, _ := intgr.Entity("test-entity", "k8s:pod")
+	ms := e.NewMetricSet("K8sPodSample")
+	groups := definition.RawGroups{"pod": {"test-entity": {"podName": "test", "annotation": "value"}}}
+
+	populated, errs := metricSetPopulate(ms, "pod", "test-entity", groups, specs, denyAnnotations{})
+	assert.True(t, populated)
+	assert.Empty(t, errs)
+
+	assert.Equal(t, "test", ms.Metrics["podName"])
+	assert.Equal(t, "foo", ms.Metrics["label.app"])
+	assert.NotContains(t, ms.Metrics, "annotation.single")
+	assert.NotContains(t, ms.Metrics, "annotation.a")
+	assert.NotContains(t, ms.Metrics, "annotation.b")
+	assert.Equal(t, 3.0, ms.Metrics[definition.DroppedAttributesMetric])
+}
+
 func TestIntegrationPopulator_WithCrossGroupDependency2(t *testing.T) {
 	// Spec for a "pod" that needs to look up its "service" to generate a full entity ID.
 	podSpecWithDependency := definition.SpecGroup{
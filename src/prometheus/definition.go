@@ -229,71 +229,84 @@ func GroupEntityMetricsBySpec(
 // GroupMetricsBySpec groups metrics coming from Prometheus by a given metric spec.
 // Example: grouping by K8s pod, container, etc.
 func GroupMetricsBySpec(specs definition.SpecGroups, families []MetricFamily) (g definition.RawGroups, errs []error) {
-	g = make(definition.RawGroups)
-	for groupLabel := range specs {
-		for _, f := range families {
-			for _, m := range f.Metrics {
-				if !m.Labels.Has(groupLabel) {
+	g = make(definition.RawGroups, len(specs))
+
+	// Metrics are walked once, adding them to the groups of their labels, instead of walking them once per group, as
+	// metrics have far fewer labels than there are groups.
+	for _, f := range families {
+		for _, m := range f.Metrics {
+			for groupLabel, groupValue := range m.Labels {
+				if _, ok := specs[groupLabel]; !ok {
 					continue
 				}
 
-				rawEntityID := ""
-
-				// Skip adding too specific metrics for higher level groups. E.g. don't add Pod metrics to Node group,
-				// as there will be 1 to many relationship between them and those metrics will be overwritten anyway,
-				// as we use namespace name or node name as a key.
-				if groupLabel == "node" && m.Labels.Has("pod") {
-					continue
-				}
-
-				if groupLabel == "namespace" && (m.Labels.Has("daemonset") ||
-					m.Labels.Has("pod") ||
-					m.Labels.Has("endpoint") ||
-					m.Labels.Has("service") ||
-					m.Labels.Has("deployment") || m.Labels.Has("replicaset")) {
+				rawEntityID, ok := rawEntityIDForGroup(groupLabel, groupValue, m.Labels)
+				if !ok {
 					continue
 				}
 
-				switch groupLabel {
-				case "namespace", "node", "persistentvolume":
-					rawEntityID = m.Labels[groupLabel]
-				case "container":
-					rawEntityID = fmt.Sprintf("%v_%v_%v", m.Labels["namespace"], m.Labels["pod"], m.Labels[groupLabel])
-				default:
-					rawEntityID = fmt.Sprintf("%v_%v", m.Labels["namespace"], m.Labels[groupLabel])
-				}
-
-				if _, ok := g[groupLabel]; !ok {
-					g[groupLabel] = make(map[string]definition.RawMetrics)
+				group, ok := g[groupLabel]
+				if !ok {
+					group = make(map[string]definition.RawMetrics)
+					g[groupLabel] = group
 				}
 
-				if _, ok := g[groupLabel][rawEntityID]; !ok {
-					g[groupLabel][rawEntityID] = make(definition.RawMetrics)
+				entity, ok := group[rawEntityID]
+				if !ok {
+					entity = make(definition.RawMetrics)
+					group[rawEntityID] = entity
 				}
 
-				if v, ok := g[groupLabel][rawEntityID][f.Name]; ok {
-					if oldMetric, ok := v.(Metric); ok {
-						g[groupLabel][rawEntityID][f.Name] = []Metric{oldMetric}
-					}
-
-					g[groupLabel][rawEntityID][f.Name] = append(g[groupLabel][rawEntityID][f.Name].([]Metric), m)
-
-					continue
+				switch v := entity[f.Name].(type) {
+				case nil:
+					entity[f.Name] = m
+				case Metric:
+					entity[f.Name] = []Metric{v, m}
+				case []Metric:
+					entity[f.Name] = append(v, m)
 				}
-
-				g[groupLabel][rawEntityID][f.Name] = m
 			}
 		}
+	}
 
+	for groupLabel := range specs {
 		if len(g[groupLabel]) == 0 {
 			errs = append(errs, fmt.Errorf("no data found for %s object", groupLabel))
-			continue
 		}
 	}
 
 	return g, errs
 }
 
+// rawEntityIDForGroup returns the ID of the entity of the given group a metric belongs to, or false if the metric
+// should not be added to the group.
+func rawEntityIDForGroup(groupLabel, groupValue string, labels Labels) (string, bool) {
+	// Skip adding too specific metrics for higher level groups. E.g. don't add Pod metrics to Node group,
+	// as there will be 1 to many relationship between them and those metrics will be overwritten anyway,
+	// as we use namespace name or node name as a key.
+	if groupLabel == "node" && labels.Has("pod") {
+		return "", false
+	}
+
+	if groupLabel == "namespace" && (labels.Has("daemonset") ||
+		labels.Has("pod") ||
+		labels.Has("endpoint") ||
+		labels.Has("service") ||
+		labels.Has("deployment") || labels.Has("replicaset")) {
+		return "", false
+	}
+
+	// IDs are concatenated instead of formatted, as this runs for every metric.
+	switch groupLabel {
+	case "namespace", "node", "persistentvolume":
+		return groupValue, true
+	case "container":
+		return labels["namespace"] + "_" + labels["pod"] + "_" + groupValue, true
+	default:
+		return labels["namespace"] + "_" + groupValue, true
+	}
+}
+
 // LabelsFilter are functions used to filter labels when executing some
 // definition.FetchFunc.
 type LabelsFilter func(Labels) Labels
@@ -447,8 +460,10 @@ func FromValue(metricName string, labelsFilter ...LabelsFilter) definition.Fetch
 // FromValueWithOverriddenName creates a FetchFunc that fetches values from prometheus metrics values.
 // If there are multiple values returned, and nameOverride is not empty, this name will be used as a prefix instead of the metricName.
 func FromValueWithOverriddenName(metricName string, nameOverride string, labelsFilter ...LabelsFilter) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(metricName)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		value, err := definition.FromRaw(metricName)(groupLabel, entityID, groups)
+		value, err := fetchRaw(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
@@ -480,8 +495,10 @@ func FromValueWithOverriddenName(metricName string, nameOverride string, labelsF
 // It returns an error if the source metric is not found, if the data is of an
 // unexpected type, or if the specified label does not exist on the metric.
 func FromLabelValue(key, label string) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(key)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		value, err := definition.FromRaw(key)(groupLabel, entityID, groups)
+		value, err := fetchRaw(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err // Propagate errors from the underlying fetcher.
 		}
@@ -536,8 +553,10 @@ func FromLabelValue(key, label string) definition.FetchFunc {
 //	  "used": 8,
 //	}
 func FromFlattenedMetrics(metricName, metricKeyLabel string) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(metricName)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		rawMetrics, err := definition.FromRaw(metricName)(groupLabel, entityID, groups)
+		rawMetrics, err := fetchRaw(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
@@ -583,8 +602,10 @@ func FromFlattenedMetrics(metricName, metricKeyLabel string) definition.FetchFun
 //
 // For cross-entity inheritance (e.g., deployment inheriting from namespace), use InheritAllLabelsFrom instead.
 func FromMetricWithPrefixedLabels(metricName, prefix string) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(metricName)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		rawMetric, err := definition.FromRaw(metricName)(groupLabel, entityID, groups)
+		rawMetric, err := fetchRaw(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, nil //nolint:nilerr // Gracefully handle if the metric is not present.
 		}
@@ -645,8 +666,10 @@ func suffixLabelsInOrder(metricName string, labels Labels) string {
 // Since it expects the RawValue to be of type []Metric it should be
 // used when grouping with GroupEntityMetricsBySpec.
 func FromSummary(key string) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(key)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		value, err := definition.FromRaw(key)(groupLabel, entityID, groups)
+		value, err := fetchRaw(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
@@ -697,8 +720,10 @@ func FromSummary(key string) definition.FetchFunc {
 // FromValueWithLabelsFilter creates a FetchFunc that fetches values from prometheus metrics values given specific
 // labels filter.
 func FromValueWithLabelsFilter(metricName string, nameOverride string, labelsFilter ...LabelsFilter) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(metricName)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		value, err := definition.FromRaw(metricName)(groupLabel, entityID, groups)
+		value, err := fetchRaw(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
@@ -783,12 +808,14 @@ func fetchedValuesFromRawMetricsWithLabels(
 // For example, counting addresses with ready="true" should return 0 if there are no ready addresses,
 // rather than returning an empty result (which would be treated as "metric not found").
 func CountFromValueWithLabelsFilter(metricName string, nameOverride string, labelsFilter ...LabelsFilter) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(metricName)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		value, err := definition.FromRaw(metricName)(groupLabel, entityID, groups)
+		value, err := fetchRaw(groupLabel, entityID, groups)
 		if err != nil {
 			// Only handle "metric not found" errors by returning 0
 			// Other errors (group not found, entity not found, parsing errors) should be propagated
-			if strings.Contains(err.Error(), "metric") && strings.Contains(err.Error(), "not found") {
+			if errors.Is(err, definition.ErrMetricNotFound) {
 				// For count metrics, when the underlying metric doesn't exist at all, treat it as an empty array
 				// and let fetchedValuesFromRawMetricsWithLabels handle it (will return 0).
 				// This handles cases where KSM >= v2.14 doesn't report individual address metrics when
@@ -906,8 +933,10 @@ func getRandomMetricWithLabels(metrics definition.RawMetrics, labels ...string)
 }
 
 func fetchMetric(metricKey string) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(metricKey)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
-		value, err := definition.FromRaw(metricKey)(groupLabel, entityID, groups)
+		value, err := fetchRaw(groupLabel, entityID, groups)
 		if err != nil {
 			return nil, err
 		}
@@ -924,12 +953,14 @@ func fetchMetric(metricKey string) definition.FetchFunc {
 // InheritSpecificLabelValuesFrom gets the specified label values from a related metric.
 // Related metric means any metric you can get with the info that you have in your own metric.
 func InheritSpecificLabelValuesFrom(parentGroupLabel, relatedMetricKey string, labelsToRetrieve map[string]string) definition.FetchFunc {
+	fetchRaw := definition.FromRaw(relatedMetricKey)
+
 	return func(groupLabel, entityID string, groups definition.RawGroups) (definition.FetchedValue, error) {
 		rawEntityID, err := getRawEntityID(parentGroupLabel, groupLabel, entityID, groups)
 		if err != nil {
 			return nil, fmt.Errorf("cannot retrieve the entity ID of metrics to inherit value from, got error: %w", err)
 		}
-		parent, err := definition.FromRaw(relatedMetricKey)(parentGroupLabel, rawEntityID, groups)
+		parent, err := fetchRaw(parentGroupLabel, rawEntityID, groups)
 		if err != nil {
 			return nil, fmt.Errorf("related metric not found. Metric: %s %s:%s", relatedMetricKey, parentGroupLabel, rawEntityID)
 		}
@@ -1052,7 +1052,7 @@ func TestFetchFunc_RawMetricNotFound(t *testing.T) {
 				testCase.rawGroups,
 			)
 			assert.Nil(t, fetchedValue)
-			assert.EqualError(t, err, "metric \"nope\" not found")
+			assert.EqualError(t, err, "metric not found: nope")
 		})
 	}
 }
@@ -1150,7 +1150,7 @@ func TestFromRawValue_CorrectValue(t *testing.T) {
 func TestFromRawValue_RawMetricNotFound(t *testing.T) {
 	fetchedValue, err := FromValue("foo")("pod", "fluentd-elasticsearch-jnqb7", rawGroups)
 	assert.Nil(t, fetchedValue)
-	assert.EqualError(t, err, "metric \"foo\" not found")
+	assert.EqualError(t, err, "metric not found: foo")
 }
 
 func TestFromRawValue_IncompatibleType(t *testing.T) {
@@ -1178,7 +1178,7 @@ func TestFromRawLabelValue_CorrectValue(t *testing.T) {
 func TestFromRawLabelValue_RawMetricNotFound(t *testing.T) {
 	fetchedValue, err := FromLabelValue("foo", "namespace")("pod", "fluentd-elasticsearch-jnqb7", rawGroups)
 	assert.Nil(t, fetchedValue)
-	assert.EqualError(t, err, "metric \"foo\" not found")
+	assert.EqualError(t, err, "metric not found: foo")
 }
 
 func TestFromRawLabelValue_IncompatibleType(t *testing.T) {
@@ -1324,7 +1324,7 @@ func TestFromLabelValueEntityIDGenerator(t *testing.T) {
 func TestFromLabelValueEntityIDGenerator_NotFound(t *testing.T) {
 	fetchedValue, err := FromLabelValueEntityIDGenerator("non-existent-metric-key", "pod")("pod", "fluentd-elasticsearch-jnqb7", rawGroups)
 	assert.Empty(t, fetchedValue)
-	assert.EqualError(t, err, "cannot fetch label \"pod\" for metric \"non-existent-metric-key\": metric \"non-existent-metric-key\" not found")
+	assert.EqualError(t, err, "cannot fetch label \"pod\" for metric \"non-existent-metric-key\": metric not found: non-existent-metric-key")
 }
 
 // --------------- FromLabelsValueEntityIDGeneratorForPendingPods ---------------.
@@ -1975,7 +1975,7 @@ func TestFromFlattenedMetrics(t *testing.T) {
 				},
 			},
 			expectedValue: nil,
-			expectedErr:   `metric "non_existent_metric" not found`,
+			expectedErr:   `metric not found: non_existent_metric`,
 		},
 		{
 			name:           "Wrong_Data_Type",
@@ -2097,7 +2097,7 @@ func TestFromLabelValue(t *testing.T) {
 				"pod": {"test-entity": {}},
 			},
 			expectedValue: nil,
-			expectedErr:   `metric "non_existent_metric" not found`,
+			expectedErr:   `metric not found: non_existent_metric`,
 		},
 		{
 			name:  "Error_when_label_not_found_in_single_metric",
@@ -2763,6 +2763,69 @@ func TestCountFromValueWithLabelsFilter_MetricDoesNotExist(t *testing.T) {
 	assert.Equal(t, GaugeValue(0), gaugeValue, "Should return 0 when metric doesn't exist")
 }
 
+// TestCountFromValueWithLabelsFilter_GroupOrEntityNotFound tests that CountFromValueWithLabelsFilter
+// propagates the errors of missing groups and entities instead of returning 0.
+func TestCountFromValueWithLabelsFilter_GroupOrEntityNotFound(t *testing.T) {
+	t.Parallel()
+
+	rawGroups := definition.RawGroups{
+		"endpoint": {
+			"kube-system_k8s.io-minikube-hostpath": {
+				"kube_endpoint_info": Metric{Value: GaugeValue(1)},
+			},
+		},
+	}
+
+	countFunc := CountFromValueWithLabelsFilter(
+		"kube_endpoint_address",
+		"addressAvailable",
+		IncludeOnlyWhenLabelMatchFilter(map[string]string{"ready": "true"}),
+	)
+
+	testCases := []struct {
+		name       string
+		groupLabel string
+		entityID   string
+		err        string
+	}{
+		{
+			name:       "group not found",
+			groupLabel: "service",
+			entityID:   "kube-system_k8s.io-minikube-hostpath",
+			err:        `group "service" not found`,
+		},
+		{
+			name:       "group with metric in its name not found",
+			groupLabel: "metrics",
+			entityID:   "kube-system_k8s.io-minikube-hostpath",
+			err:        `group "metrics" not found`,
+		},
+		{
+			name:       "entity not found",
+			groupLabel: "endpoint",
+			entityID:   "kube-system_missing",
+			err:        `entity "kube-system_missing" not found`,
+		},
+		{
+			name:       "entity with metric in its name not found",
+			groupLabel: "endpoint",
+			entityID:   "kube-system_metrics-server",
+			err:        `entity "kube-system_metrics-server" not found`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := countFunc(tc.groupLabel, tc.entityID, rawGroups)
+			assert.Nil(t, result)
+			require.EqualError(t, err, tc.err)
+			assert.NotErrorIs(t, err, definition.ErrMetricNotFound)
+		})
+	}
+}
+
 // TestCountFromValueWithLabelsFilter_NoMatchingLabels tests that CountFromValueWithLabelsFilter
 // returns 0 when the metric exists but no entries match the label filter.
 // Real-world scenario: Endpoint has addresses, but all are ready=true, so filtering for ready=false returns 0.
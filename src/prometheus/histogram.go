@@ -0,0 +1,88 @@
+package prometheus
+
+import (
+	"math"
+	"sort"
+
+	model "github.com/prometheus/client_model/go"
+)
+
+// Bucket is a bucket of a histogram, holding the number of observations less or equal than its upper bound.
+type Bucket struct {
+	UpperBound      float64
+	CumulativeCount float64
+}
+
+// HistogramBuckets returns the buckets of a histogram sorted by upper bound, ending with the +Inf one.
+func HistogramBuckets(h *model.Histogram) []Bucket {
+	if h == nil {
+		return nil
+	}
+
+	buckets := make([]Bucket, 0, len(h.GetBucket())+1)
+	for _, b := range h.GetBucket() {
+		if math.IsInf(b.GetUpperBound(), 1) {
+			continue
+		}
+		buckets = append(buckets, Bucket{UpperBound: b.GetUpperBound(), CumulativeCount: float64(b.GetCumulativeCount())})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].UpperBound < buckets[j].UpperBound })
+
+	// The +Inf bucket is implicit in the exposition format, holding all the observations.
+	return append(buckets, Bucket{UpperBound: math.Inf(1), CumulativeCount: float64(h.GetSampleCount())})
+}
+
+// SubtractBuckets returns the observations in buckets which are not in previous, so the quantiles of the observations
+// between two scrapes can be computed. It returns false if the buckets do not match or the counters were reset.
+func SubtractBuckets(buckets, previous []Bucket) ([]Bucket, bool) {
+	if len(buckets) != len(previous) {
+		return nil, false
+	}
+
+	delta := make([]Bucket, len(buckets))
+	for i, b := range buckets {
+		if b.UpperBound != previous[i].UpperBound || b.CumulativeCount < previous[i].CumulativeCount {
+			return nil, false
+		}
+		delta[i] = Bucket{UpperBound: b.UpperBound, CumulativeCount: b.CumulativeCount - previous[i].CumulativeCount}
+	}
+
+	return delta, true
+}
+
+// HistogramQuantile estimates the q quantile (0 <= q <= 1) of the observations in buckets, assuming they are linearly
+// distributed within each bucket, as the histogram_quantile function of PromQL does. It returns false if there are no
+// observations.
+func HistogramQuantile(q float64, buckets []Bucket) (float64, bool) {
+	if len(buckets) < 2 || !math.IsInf(buckets[len(buckets)-1].UpperBound, 1) {
+		return 0, false
+	}
+
+	total := buckets[len(buckets)-1].CumulativeCount
+	if total == 0 {
+		return 0, false
+	}
+
+	rank := q * total
+	i := sort.Search(len(buckets)-1, func(i int) bool { return buckets[i].CumulativeCount >= rank })
+
+	// Observations in the +Inf bucket are reported as the highest finite upper bound.
+	if i == len(buckets)-1 {
+		return buckets[len(buckets)-2].UpperBound, true
+	}
+
+	start, startCount := 0.0, 0.0
+	if i > 0 {
+		start, startCount = buckets[i-1].UpperBound, buckets[i-1].CumulativeCount
+	} else if buckets[0].UpperBound <= 0 {
+		return buckets[0].UpperBound, true
+	}
+
+	count := buckets[i].CumulativeCount - startCount
+	if count == 0 {
+		return buckets[i].UpperBound, true
+	}
+
+	return start + (buckets[i].UpperBound-start)*((rank-startCount)/count), true
+}
@@ -0,0 +1,92 @@
+package prometheus
+
+import (
+	"math"
+	"testing"
+
+	model "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHistogramBuckets(t *testing.T) {
+	t.Parallel()
+
+	h := &model.Histogram{
+		SampleCount: proto.Uint64(12),
+		Bucket: []*model.Bucket{
+			{UpperBound: proto.Float64(1), CumulativeCount: proto.Uint64(10)},
+			{UpperBound: proto.Float64(0.5), CumulativeCount: proto.Uint64(4)},
+			{UpperBound: proto.Float64(math.Inf(1)), CumulativeCount: proto.Uint64(12)},
+		},
+	}
+
+	assert.Equal(t, []Bucket{
+		{UpperBound: 0.5, CumulativeCount: 4},
+		{UpperBound: 1, CumulativeCount: 10},
+		{UpperBound: math.Inf(1), CumulativeCount: 12},
+	}, HistogramBuckets(h))
+	assert.Nil(t, HistogramBuckets(nil))
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	t.Parallel()
+
+	buckets := []Bucket{
+		{UpperBound: 1, CumulativeCount: 50},
+		{UpperBound: 2, CumulativeCount: 90},
+		{UpperBound: 4, CumulativeCount: 100},
+		{UpperBound: math.Inf(1), CumulativeCount: 100},
+	}
+
+	tests := []struct {
+		name     string
+		q        float64
+		expected float64
+	}{
+		{name: "first_bucket", q: 0.25, expected: 0.5},
+		{name: "bucket_boundary", q: 0.5, expected: 1},
+		{name: "interpolated", q: 0.7, expected: 1.5},
+		{name: "last_finite_bucket", q: 0.95, expected: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, ok := HistogramQuantile(tc.q, buckets)
+			require.True(t, ok)
+			assert.InDelta(t, tc.expected, v, 1e-9)
+		})
+	}
+}
+
+func TestHistogramQuantile_InfBucket(t *testing.T) {
+	t.Parallel()
+
+	v, ok := HistogramQuantile(0.99, []Bucket{
+		{UpperBound: 1, CumulativeCount: 1},
+		{UpperBound: math.Inf(1), CumulativeCount: 10},
+	})
+	require.True(t, ok)
+	assert.Equal(t, 1.0, v)
+
+	_, ok = HistogramQuantile(0.5, []Bucket{{UpperBound: 1}, {UpperBound: math.Inf(1)}})
+	assert.False(t, ok)
+}
+
+func TestSubtractBuckets(t *testing.T) {
+	t.Parallel()
+
+	previous := []Bucket{{UpperBound: 1, CumulativeCount: 5}, {UpperBound: math.Inf(1), CumulativeCount: 6}}
+	current := []Bucket{{UpperBound: 1, CumulativeCount: 8}, {UpperBound: math.Inf(1), CumulativeCount: 10}}
+
+	delta, ok := SubtractBuckets(current, previous)
+	require.True(t, ok)
+	assert.Equal(t, []Bucket{{UpperBound: 1, CumulativeCount: 3}, {UpperBound: math.Inf(1), CumulativeCount: 4}}, delta)
+
+	// Counters reset when the kubelet restarts.
+	_, ok = SubtractBuckets(previous, current)
+	assert.False(t, ok)
+}
@@ -4,17 +4,22 @@ import (
 	"net/http"
 )
 
-// AcceptHeader starting with ksm 1.5 only plain text encoding is supported.
-const AcceptHeader = `text/plain`
+// AcceptHeader prefers the delimited protobuf format, which is faster to decode and allows skipping whole families, and
+// falls back to the plain text one, which is the only one supported starting with ksm 1.5.
+const AcceptHeader = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3`
 
-// NewRequest returns a new Request given a method, URL, setting the required header for accepting protobuf.
-func NewRequest(url string) (*http.Request, error) {
+// TextAcceptHeader only accepts the plain text format, for when the protobuf one is disabled.
+const TextAcceptHeader = `text/plain;version=0.0.4`
+
+// NewRequest returns a new Request given a method, URL, setting the header negotiating the exposition format, which is
+// AcceptHeader or TextAcceptHeader.
+func NewRequest(url, acceptHeader string) (*http.Request, error) {
 	r, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	r.Header.Set("Accept", AcceptHeader)
+	r.Header.Set("Accept", acceptHeader)
 
 	return r, nil
 }
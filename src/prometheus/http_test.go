@@ -9,10 +9,17 @@ import (
 )
 
 func TestNewRequest(t *testing.T) {
-	r, err := NewRequest("http://example.com")
+	r, err := NewRequest("http://example.com", AcceptHeader)
 	require.NoError(t, err)
 
 	assert.Equal(t, AcceptHeader, r.Header.Get("Accept"))
 	assert.Equal(t, "http://example.com", r.URL.String())
 	assert.Equal(t, http.MethodGet, r.Method)
+
+	r, err = NewRequest("http://example.com", TextAcceptHeader)
+	require.NoError(t, err)
+
+	assert.Equal(t, TextAcceptHeader, r.Header.Get("Accept"))
+	assert.Equal(t, "http://example.com", r.URL.String())
+	assert.Equal(t, http.MethodGet, r.Method)
 }
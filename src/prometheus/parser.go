@@ -0,0 +1,688 @@
+package prometheus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	model "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	prometheusmodel "github.com/prometheus/common/model"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// readerSize is the size of the buffer used to read responses. Lines and protobuf messages are not limited by it.
+const readerSize = 64 * 1024
+
+// maxMessageBytes caps the size of protobuf messages at 100MB, so corrupt lengths do not allocate unbounded buffers.
+// Each message holds a single family, which is far smaller even in the largest KSM responses.
+const maxMessageBytes = 100 * 1024 * 1024
+
+// labelSeparator separates label names and values in the hashes of label sets.
+const labelSeparator = 0xff
+
+// errMessageTooLarge is returned when the length of a protobuf message exceeds maxMessageBytes.
+var errMessageTooLarge = errors.New("protobuf message too large")
+
+// Suffixes of the samples of histograms and summaries, which belong to the family named without them.
+var complexSampleSuffixes = []string{"_bucket", "_sum", "_count"} //nolint: gochecknoglobals // read-only slice.
+
+// familyQuery is a query along with the metrics it matched so far.
+type familyQuery struct {
+	query   Query
+	value   string
+	metrics []Metric
+}
+
+// queriedFamily is a metric family named in at least one query.
+type queriedFamily struct {
+	name       string
+	metricType model.MetricType
+	queries    []*familyQuery
+	seen       bool
+}
+
+// labelPair is a label of the sample being parsed, pointing to the line it is in unless its value is escaped.
+type labelPair struct {
+	name  []byte
+	value []byte
+}
+
+// responseParser decodes a Prometheus response in a single pass, applying the queries while decoding it, so only the
+// metrics matching them are kept in memory.
+//
+// Families not named in any query are skipped without decoding them: text lines are only read up to the metric name,
+// and protobuf messages up to the family name. Samples of queried counters, gauges and untyped families are decoded
+// directly, and label names and values are interned as most of them are repeated across families. So are the label
+// sets, so the metrics of an object in different families share the same Labels. Histograms and summaries, which are
+// reported in several samples, are left to the text parser of Prometheus.
+type responseParser struct {
+	logger *log.Logger
+
+	families map[string]*queriedFamily
+	order    []*queriedFamily
+
+	// types holds the types declared for the families which are not counters, gauges or untyped, to find the family of
+	// histogram and summary samples and to skip the ones with unsupported types.
+	types map[string]string
+	// complex holds the text of queried histograms and summaries.
+	complex    bytes.Buffer
+	complexSet map[string]bool
+	skipped    []string
+
+	interned map[string]string
+	// labelSets holds the labels built so far by the hash of their label set. Label sets with the same hash as a
+	// different one replace it, which only makes them not shared.
+	labelSets map[uint64]Labels
+	hash      maphash.Hash
+	pairs     []labelPair
+}
+
+func newResponseParser(queries []Query, logger *log.Logger) *responseParser {
+	p := &responseParser{
+		logger:     logger,
+		families:   map[string]*queriedFamily{},
+		types:      map[string]string{},
+		complexSet: map[string]bool{},
+		interned:   map[string]string{},
+		labelSets:  map[uint64]Labels{},
+	}
+
+	for _, q := range queries {
+		f, ok := p.families[q.MetricName]
+		if !ok {
+			f = &queriedFamily{name: q.MetricName, metricType: model.MetricType_UNTYPED}
+			p.families[q.MetricName] = f
+		}
+
+		fq := &familyQuery{query: q}
+		if q.Value.Value != nil {
+			fq.value = q.Value.Value.String()
+		}
+		f.queries = append(f.queries, fq)
+	}
+
+	return p
+}
+
+// parse decodes the body of resp, in the delimited protobuf format if the server chose it, or in the text one
+// otherwise.
+func (p *responseParser) parse(resp *http.Response) error {
+	if expfmt.ResponseFormat(resp.Header) == expfmt.FmtProtoDelim {
+		return p.parseProtobuf(resp.Body)
+	}
+
+	return p.parseText(resp.Body)
+}
+
+// metricFamilies returns the non-empty results of the queries, in the order their families were found.
+func (p *responseParser) metricFamilies() []MetricFamily {
+	metrics := make([]MetricFamily, 0, len(p.order))
+	for _, f := range p.order {
+		for _, fq := range f.queries {
+			name := fq.query.CustomName
+			if name == "" {
+				name = f.name
+			}
+
+			mf := MetricFamily{
+				Name:    name,
+				Type:    f.metricType.String(),
+				Metrics: fq.metrics,
+			}
+			if mf.valid() {
+				metrics = append(metrics, mf)
+			}
+		}
+	}
+
+	return metrics
+}
+
+// family returns the queried family with the given name, or nil if it is not queried.
+func (p *responseParser) family(name []byte) *queriedFamily {
+	f, ok := p.families[string(name)]
+	if !ok {
+		return nil
+	}
+
+	if !f.seen {
+		f.seen = true
+		p.order = append(p.order, f)
+	}
+
+	return f
+}
+
+// addFamily runs the queries of a family decoded by the Prometheus parsers.
+func (p *responseParser) addFamily(mf *model.MetricFamily) {
+	f := p.family([]byte(mf.GetName()))
+	if f == nil {
+		return
+	}
+
+	f.metricType = mf.GetType()
+	for _, fq := range f.queries {
+		fq.metrics = append(fq.metrics, fq.query.execute(mf, p.prometheusLabels).Metrics...)
+	}
+}
+
+// parseProtobuf decodes a stream of length-delimited MetricFamily messages.
+func (p *responseParser) parseProtobuf(body io.Reader) error {
+	r := bufio.NewReaderSize(body, readerSize)
+
+	var message []byte
+	for {
+		size, err := binary.ReadUvarint(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading message length: %w", err)
+		}
+		if size > maxMessageBytes {
+			return fmt.Errorf("%w: %d bytes", errMessageTooLarge, size)
+		}
+
+		// The name is the first field written by Prometheus clients, so families not queried can be skipped without
+		// reading them. Messages where it is not are decoded to find it.
+		name, found := peekFamilyName(r, size)
+		if found && p.families[string(name)] == nil {
+			if _, err := r.Discard(int(size)); err != nil {
+				return fmt.Errorf("skipping message: %w", err)
+			}
+			continue
+		}
+
+		if uint64(cap(message)) < size {
+			message = make([]byte, size)
+		}
+		message = message[:size]
+		if _, err := io.ReadFull(r, message); err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+
+		mf := &model.MetricFamily{}
+		if err := proto.Unmarshal(message, mf); err != nil {
+			return fmt.Errorf("decoding message: %w", err)
+		}
+
+		p.addFamily(mf)
+	}
+}
+
+// peekFamilyName returns the name of the MetricFamily message of the given size at the start of r, if it is its first
+// field, without consuming it.
+func peekFamilyName(r *bufio.Reader, size uint64) ([]byte, bool) {
+	head, _ := r.Peek(int(min(size, uint64(r.Size()))))
+
+	num, typ, n := protowire.ConsumeTag(head)
+	if n < 0 || num != 1 || typ != protowire.BytesType {
+		return nil, false
+	}
+
+	name, m := protowire.ConsumeBytes(head[n:])
+	if m < 0 {
+		return nil, false
+	}
+
+	return name, true
+}
+
+// parseText decodes a response in the text format line by line.
+func (p *responseParser) parseText(body io.Reader) error {
+	r := bufio.NewReaderSize(body, readerSize)
+
+	var long []byte
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := readLine(r, &long)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("reading line %d: %w", lineNumber, err)
+		}
+
+		if parseErr := p.parseLine(line); parseErr != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, parseErr)
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	if p.complex.Len() == 0 {
+		return nil
+	}
+
+	parser := expfmt.NewTextParser(prometheusmodel.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(&p.complex)
+	for _, mf := range families {
+		p.addFamily(mf)
+	}
+	if err != nil {
+		return fmt.Errorf("reading histograms and summaries: %w", err)
+	}
+
+	return nil
+}
+
+// readLine returns the next line in r. It points to the buffer of r unless it is longer than it, in which case it is
+// copied to long, which is reused for the next long lines.
+func readLine(r *bufio.Reader, long *[]byte) ([]byte, error) {
+	buf := (*long)[:0]
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			buf = append(buf, chunk...)
+			continue
+		}
+
+		if len(buf) == 0 {
+			return chunk, err
+		}
+
+		*long = append(buf, chunk...)
+		return *long, err
+	}
+}
+
+func (p *responseParser) parseLine(line []byte) error {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil
+	}
+
+	if line[0] == '#' {
+		p.parseComment(line[1:])
+		return nil
+	}
+
+	name, rest := splitName(line)
+	if len(name) == 0 {
+		return fmt.Errorf("invalid metric name in %q", truncate(line))
+	}
+
+	familyName := p.familyName(name)
+	switch metricType := p.types[string(familyName)]; {
+	case metricType == "":
+	case isComplexType(metricType):
+		if p.family(familyName) != nil {
+			p.complex.Write(line)
+			p.complex.WriteByte('\n')
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	f := p.family(familyName)
+	if f == nil {
+		return nil
+	}
+
+	return p.parseSample(f, rest)
+}
+
+// parseComment handles the TYPE declarations, ignoring HELP lines and any other comment.
+func (p *responseParser) parseComment(comment []byte) {
+	fields := bytes.Fields(comment)
+	if len(fields) < 3 || string(fields[0]) != "TYPE" {
+		return
+	}
+
+	name, metricType := fields[1], string(fields[2])
+	if !isSimpleType(metricType) {
+		p.types[string(name)] = metricType
+	}
+
+	if !isSimpleType(metricType) && !isComplexType(metricType) {
+		// The text parser of Prometheus fails on OpenMetrics types like info or stateset.
+		p.logger.Debugf("Skipping unsupported metric type '%s' for metric '%s'", metricType, name)
+		p.skipped = append(p.skipped, string(name))
+		return
+	}
+
+	f := p.family(name)
+	if f == nil {
+		return
+	}
+
+	f.metricType = model.MetricType(model.MetricType_value[strings.ToUpper(metricType)])
+	if isComplexType(metricType) && !p.complexSet[f.name] {
+		p.complexSet[f.name] = true
+		fmt.Fprintf(&p.complex, "# TYPE %s %s\n", f.name, metricType)
+	}
+}
+
+// familyName returns the name of the family a sample belongs to, which is the name of the sample unless it is one of
+// the samples of a histogram or summary.
+func (p *responseParser) familyName(name []byte) []byte {
+	for _, suffix := range complexSampleSuffixes {
+		base, ok := bytes.CutSuffix(name, []byte(suffix))
+		if ok && isComplexType(p.types[string(base)]) {
+			return base
+		}
+	}
+
+	return name
+}
+
+// parseSample decodes the labels and value of a counter, gauge or untyped sample, and adds it to the queries it
+// matches.
+func (p *responseParser) parseSample(f *queriedFamily, rest []byte) error {
+	rest = bytes.TrimLeft(rest, " \t")
+
+	p.pairs = p.pairs[:0]
+	if len(rest) > 0 && rest[0] == '{' {
+		var err error
+		if rest, err = p.parseLabels(rest[1:]); err != nil {
+			return err
+		}
+	}
+
+	rawValue, _ := splitField(bytes.TrimLeft(rest, " \t"))
+	floatValue, err := strconv.ParseFloat(string(rawValue), 64)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", f.name, err)
+	}
+
+	var value Value
+	switch f.metricType {
+	case model.MetricType_COUNTER:
+		value = CounterValue(floatValue)
+	case model.MetricType_GAUGE:
+		value = GaugeValue(floatValue)
+	default:
+		value = EmptyValue
+	}
+
+	var labels Labels
+	for _, fq := range f.queries {
+		if !fq.matches(p.pairs, value) {
+			continue
+		}
+
+		// Labels are shared by the metrics of all the queries matching the sample.
+		if labels == nil {
+			labels = p.labels()
+		}
+
+		fq.metrics = append(fq.metrics, Metric{Labels: labels, Value: value})
+	}
+
+	return nil
+}
+
+// parseLabels decodes the labels of a sample into p.pairs, returning the rest of the line after them.
+func (p *responseParser) parseLabels(line []byte) ([]byte, error) {
+	for {
+		line = bytes.TrimLeft(line, " \t")
+		if len(line) == 0 {
+			return nil, errors.New("unterminated label set")
+		}
+		if line[0] == '}' {
+			return line[1:], nil
+		}
+
+		end := bytes.IndexAny(line, "= \t")
+		if end <= 0 {
+			return nil, fmt.Errorf("invalid label name in %q", truncate(line))
+		}
+		name := line[:end]
+
+		line = bytes.TrimLeft(line[end:], " \t")
+		if len(line) < 2 || line[0] != '=' {
+			return nil, fmt.Errorf("expected '=' after label %s", name)
+		}
+		line = bytes.TrimLeft(line[1:], " \t")
+		if len(line) == 0 || line[0] != '"' {
+			return nil, fmt.Errorf("expected quoted value for label %s", name)
+		}
+
+		value, rest, err := unquote(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("label %s: %w", name, err)
+		}
+		p.pairs = append(p.pairs, labelPair{name: name, value: value})
+
+		line = bytes.TrimLeft(rest, " \t")
+		if len(line) > 0 && line[0] == ',' {
+			line = line[1:]
+		}
+	}
+}
+
+// unquote returns the value of a label up to its closing quote, and the rest of the line after it.
+func unquote(line []byte) ([]byte, []byte, error) {
+	end := bytes.IndexAny(line, `"\`)
+	if end >= 0 && line[end] == '"' {
+		return line[:end], line[end+1:], nil
+	}
+
+	// Values with escape sequences are rare, so they are unescaped into a new slice.
+	var value []byte
+	for n := 0; n < len(line); n++ {
+		switch c := line[n]; c {
+		case '"':
+			return value, line[n+1:], nil
+		case '\\':
+			n++
+			if n == len(line) {
+				return nil, nil, errors.New("unterminated escape sequence")
+			}
+			switch line[n] {
+			case 'n':
+				value = append(value, '\n')
+			case '\\', '"':
+				value = append(value, line[n])
+			default:
+				return nil, nil, fmt.Errorf("invalid escape sequence '\\%c'", line[n])
+			}
+		default:
+			value = append(value, c)
+		}
+	}
+
+	return nil, nil, errors.New("unterminated label value")
+}
+
+// matches returns whether a sample with the given labels and value matches the query, with the same semantics as
+// Execute.
+func (fq *familyQuery) matches(pairs []labelPair, value Value) bool {
+	if len(fq.query.Labels.Labels) > 0 {
+		switch fq.query.Labels.Operator {
+		case QueryOpAnd:
+			if !labelsAreIn(fq.query.Labels.Labels, pairs) {
+				return false
+			}
+		case QueryOpNor:
+			if labelsAreIn(fq.query.Labels.Labels, pairs) {
+				return false
+			}
+		}
+	}
+
+	if fq.query.Value.Value != nil {
+		switch fq.query.Value.Operator {
+		case QueryOpAnd:
+			if fq.value != value.String() {
+				return false
+			}
+		case QueryOpNor:
+			if fq.value == value.String() {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// labelsAreIn says if the labels are included in the labels of a sample being parsed.
+func labelsAreIn(l Labels, pairs []labelPair) bool {
+	for name, value := range l {
+		var found bool
+		for _, pair := range pairs {
+			if string(pair.name) == name && string(pair.value) == value {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// intern returns a string with the contents of b, which is the same one for all the calls with the same contents.
+func (p *responseParser) intern(b []byte) string {
+	if s, ok := p.interned[string(b)]; ok {
+		return s
+	}
+
+	s := string(b)
+	p.interned[s] = s
+
+	return s
+}
+
+// internString returns the string interned with the contents of s.
+func (p *responseParser) internString(s string) string {
+	if interned, ok := p.interned[s]; ok {
+		return interned
+	}
+
+	p.interned[s] = s
+
+	return s
+}
+
+// labels returns the labels in p.pairs, which are the same Labels for all the samples with the same label set. They
+// must not be modified, as the metrics of different families share them.
+func (p *responseParser) labels() Labels {
+	p.hash.Reset()
+	for _, pair := range p.pairs {
+		_, _ = p.hash.Write(pair.name)
+		_ = p.hash.WriteByte(labelSeparator)
+		_, _ = p.hash.Write(pair.value)
+		_ = p.hash.WriteByte(labelSeparator)
+	}
+	sum := p.hash.Sum64()
+
+	if labels, ok := p.labelSets[sum]; ok && labelsEqual(labels, p.pairs) {
+		return labels
+	}
+
+	labels := make(Labels, len(p.pairs))
+	for _, pair := range p.pairs {
+		labels[p.intern(pair.name)] = p.intern(pair.value)
+	}
+	p.labelSets[sum] = labels
+
+	return labels
+}
+
+// prometheusLabels returns the labels of a sample decoded by the Prometheus parsers as labels does.
+func (p *responseParser) prometheusLabels(pairs []*model.LabelPair) Labels {
+	p.hash.Reset()
+	for _, pair := range pairs {
+		_, _ = p.hash.WriteString(pair.GetName())
+		_ = p.hash.WriteByte(labelSeparator)
+		_, _ = p.hash.WriteString(pair.GetValue())
+		_ = p.hash.WriteByte(labelSeparator)
+	}
+	sum := p.hash.Sum64()
+
+	if labels, ok := p.labelSets[sum]; ok && prometheusLabelsEqual(labels, pairs) {
+		return labels
+	}
+
+	labels := make(Labels, len(pairs))
+	for _, pair := range pairs {
+		labels[p.internString(pair.GetName())] = p.internString(pair.GetValue())
+	}
+	p.labelSets[sum] = labels
+
+	return labels
+}
+
+// labelsEqual says if the labels are the ones of a sample being parsed.
+func labelsEqual(l Labels, pairs []labelPair) bool {
+	if len(l) != len(pairs) {
+		return false
+	}
+
+	for _, pair := range pairs {
+		if value, ok := l[string(pair.name)]; !ok || value != string(pair.value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// prometheusLabelsEqual says if the labels are the ones of a sample decoded by the Prometheus parsers.
+func prometheusLabelsEqual(l Labels, pairs []*model.LabelPair) bool {
+	if len(l) != len(pairs) {
+		return false
+	}
+
+	for _, pair := range pairs {
+		if value, ok := l[pair.GetName()]; !ok || value != pair.GetValue() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSimpleType returns whether the text parser of the package decodes the samples of families of the given type.
+func isSimpleType(metricType string) bool {
+	return metricType == "counter" || metricType == "gauge" || metricType == "untyped"
+}
+
+// isComplexType returns whether samples of families of the given type are left to the text parser of Prometheus.
+func isComplexType(metricType string) bool {
+	return metricType == "histogram" || metricType == "summary"
+}
+
+// splitName returns the metric name at the start of a sample line, and the rest of it.
+func splitName(line []byte) ([]byte, []byte) {
+	end := bytes.IndexAny(line, " \t{")
+	if end < 0 {
+		return line, nil
+	}
+
+	return line[:end], line[end:]
+}
+
+// splitField returns the first field of line, up to a blank, and the rest of it.
+func splitField(line []byte) ([]byte, []byte) {
+	end := bytes.IndexAny(line, " \t")
+	if end < 0 {
+		return line, nil
+	}
+
+	return line[:end], line[end:]
+}
+
+// truncate returns the start of a line to be shown in errors.
+func truncate(line []byte) string {
+	const maxLength = 64
+	if len(line) > maxLength {
+		return string(line[:maxLength]) + "..."
+	}
+
+	return string(line)
+}
@@ -1,16 +1,10 @@
 package prometheus
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
-	"io"
 	"net/http"
-	"strings"
 
 	model "github.com/prometheus/client_model/go"
-	"github.com/prometheus/common/expfmt"
-	prometheusmodel "github.com/prometheus/common/model"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/newrelic/nri-kubernetes/v3/src/client"
@@ -35,6 +29,9 @@ type Query struct {
 	MetricName string
 	Labels     QueryLabels
 	Value      QueryValue // TODO Only supported Counter and Gauge
+	// Histogram makes the metrics of histogram families have their *model.Histogram as value, as histograms are not
+	// supported otherwise.
+	Histogram bool
 }
 
 // QueryValue represents the query for a value.
@@ -51,6 +48,11 @@ type QueryLabels struct {
 
 // Execute runs the query.
 func (q Query) Execute(promMetricFamily *model.MetricFamily) (metricFamily MetricFamily) {
+	return q.execute(promMetricFamily, labelsFromPrometheus)
+}
+
+// execute runs the query as Execute, building the labels of the matching metrics with the given function.
+func (q Query) execute(promMetricFamily *model.MetricFamily, labels func([]*model.LabelPair) Labels) (metricFamily MetricFamily) {
 	if promMetricFamily.GetName() != q.MetricName {
 		return
 	}
@@ -76,6 +78,9 @@ func (q Query) Execute(promMetricFamily *model.MetricFamily) (metricFamily Metri
 		}
 
 		value := valueFromPrometheus(promMetricFamily.GetType(), promMetric)
+		if q.Histogram && promMetricFamily.GetType() == model.MetricType_HISTOGRAM {
+			value = promMetric.Histogram
+		}
 
 		if q.Value.Value != nil {
 			switch q.Value.Operator {
@@ -91,7 +96,7 @@ func (q Query) Execute(promMetricFamily *model.MetricFamily) (metricFamily Metri
 		}
 
 		m := Metric{
-			Labels: labelsFromPrometheus(promMetric.Label),
+			Labels: labels(promMetric.Label),
 			Value:  value,
 		}
 
@@ -133,170 +138,6 @@ func valueFromPrometheus(metricType model.MetricType, metric *model.Metric) Valu
 	}
 }
 
-const (
-	// Minimum number of fields in a TYPE declaration: "# TYPE metric_name metric_type".
-	minTypeFields = 4
-	// Minimum number of fields in a HELP declaration: "# HELP metric_name description".
-	minHelpFields = 3
-)
-
-// isUnsupportedMetricType checks if a metric type is unsupported by prometheus/client_model.
-// OpenMetrics 1.0 types "info" and "stateset" are not supported.
-func isUnsupportedMetricType(metricType string) bool {
-	return metricType == "info" || metricType == "stateset"
-}
-
-// handleTypeDeclaration processes a TYPE declaration line and determines if the metric family
-// should be skipped. Returns (shouldSkip, metricName).
-func handleTypeDeclaration(line string, logger *log.Logger) (bool, string) {
-	parts := strings.Fields(line)
-	if len(parts) < minTypeFields {
-		return false, ""
-	}
-
-	metricName := parts[2]
-	metricType := parts[3]
-
-	if isUnsupportedMetricType(metricType) {
-		logger.Debugf("Skipping unsupported metric type '%s' for metric '%s'", metricType, metricName)
-		return true, metricName
-	}
-
-	return false, ""
-}
-
-// handleHelpDeclaration processes a HELP declaration line and updates skip state if we
-// encounter a different metric family. Returns (shouldSkip, currentMetricName).
-func handleHelpDeclaration(line string, currentlySkipping bool, currentMetricName string) (bool, string) {
-	parts := strings.Fields(line)
-	if len(parts) < minHelpFields {
-		return currentlySkipping, currentMetricName
-	}
-
-	metricName := parts[2]
-	// If we were skipping and now see a different metric, stop skipping
-	if currentlySkipping && currentMetricName != "" && metricName != currentMetricName {
-		return false, ""
-	}
-
-	return currentlySkipping, currentMetricName
-}
-
-// shouldSkipLine determines if a line should be skipped when filtering an unsupported metric family.
-func shouldSkipLine(line string, currentMetricName string) bool {
-	// Skip HELP line for the current unsupported metric
-	if strings.HasPrefix(line, "# HELP ") {
-		parts := strings.Fields(line)
-		if len(parts) >= minHelpFields && parts[2] == currentMetricName {
-			return true
-		}
-	}
-	// Skip metric data lines (non-comment lines)
-	return !strings.HasPrefix(line, "#")
-}
-
-// filterUnsupportedMetrics preprocesses Prometheus exposition format text to remove
-// metric families with unsupported types (e.g., OpenMetrics "info", "stateset").
-//
-// The Prometheus TextParser (expfmt.TextParser) fails when it encounters unknown metric types,
-// stopping parsing and losing all subsequent metrics. This function removes those problematic
-// metric families before parsing.
-//
-// Returns a new io.Reader with filtered content and a list of skipped metric names.
-func filterUnsupportedMetrics(body io.Reader, logger *log.Logger) (io.Reader, []string, error) {
-	scanner := bufio.NewScanner(body)
-
-	const maxScanTokenSize = 1024 * 1024
-	buf := make([]byte, maxScanTokenSize)
-	scanner.Buffer(buf, maxScanTokenSize)
-
-	var filteredLines []string
-	var skippedMetrics []string
-	var skipUntilNextFamily bool
-	var currentMetricName string
-
-	for scanner.Scan() {
-		line := scanner.Text() // Preserve original formatting
-
-		trimmedLine := strings.TrimSpace(line)
-
-		// Preserve empty lines
-		if trimmedLine == "" {
-			if !skipUntilNextFamily {
-				filteredLines = append(filteredLines, line)
-			}
-			continue
-		}
-
-		// Check if this is a TYPE declaration
-		if strings.HasPrefix(trimmedLine, "# TYPE ") {
-			skipUntilNextFamily, currentMetricName = handleTypeDeclaration(trimmedLine, logger)
-			if skipUntilNextFamily {
-				skippedMetrics = append(skippedMetrics, currentMetricName)
-				continue
-			}
-		}
-
-		// Check if this is a HELP declaration for a new metric family
-		if strings.HasPrefix(trimmedLine, "# HELP ") {
-			skipUntilNextFamily, currentMetricName = handleHelpDeclaration(
-				trimmedLine, skipUntilNextFamily, currentMetricName,
-			)
-		}
-
-		// Skip metric data lines if we're in an unsupported family
-		if skipUntilNextFamily && shouldSkipLine(trimmedLine, currentMetricName) {
-			continue
-		}
-
-		// Keep this line
-		filteredLines = append(filteredLines, line)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, skippedMetrics, fmt.Errorf("reading metrics body: %w", err)
-	}
-
-	// Reconstruct the filtered text
-	filtered := strings.Join(filteredLines, "\n") + "\n" // Add trailing newline
-	return bytes.NewBufferString(filtered), skippedMetrics, nil
-}
-
-/**
- * Try our best to parse a response. Even if an error is encountered
- * midway through parsing we will put into the receiving channel any
- * metric families found along the way. We also return any error that
- * we did come along. Fail-fast, best attempt behavior.
- */
-func parseResponse(resp *http.Response, ch chan<- *model.MetricFamily, logger *log.Logger) error {
-	defer close(ch)
-
-	// Filter out unsupported metric types before parsing to prevent parser from failing.
-	// This solves issue #1293 where OpenMetrics "info" types cause complete data loss.
-	filtered, skippedMetrics, err := filterUnsupportedMetrics(resp.Body, logger)
-	if err != nil {
-		return fmt.Errorf("filtering unsupported metrics: %w", err)
-	}
-
-	if len(skippedMetrics) > 0 {
-		logger.Infof("Skipped %d metric families with unsupported OpenMetrics types: %v", len(skippedMetrics), skippedMetrics)
-	}
-
-	// Use NewTextParser with UTF8 validation scheme instead of zero-value TextParser.
-	// The zero-value TextParser has an unset validation scheme which causes a panic.
-	parser := expfmt.NewTextParser(prometheusmodel.UTF8Validation)
-	metricFamilies, err := parser.TextToMetricFamilies(filtered)
-	if err != nil {
-		err = fmt.Errorf("reading text format failed: %w", err)
-	}
-
-	for _, mf := range metricFamilies {
-		ch <- mf
-	}
-
-	return err
-}
-
 func handleResponseWithFilter(resp *http.Response, queries []Query, logger *log.Logger) ([]MetricFamily, error) {
 	if resp == nil {
 		return nil, fmt.Errorf("response cannot be nil")
@@ -308,25 +149,22 @@ func handleResponseWithFilter(resp *http.Response, queries []Query, logger *log.
 		return nil, fmt.Errorf("error calling prometheus exposed metrics endpoint. Got status code: %d", resp.StatusCode)
 	}
 
-	metrics := make([]MetricFamily, 0)
-	ch := make(chan *model.MetricFamily)
-
-	var err error
-	go func() {
-		err = parseResponse(resp, ch, logger)
-	}()
-
-	for promMetricFamily := range ch {
-		for _, q := range queries {
-			f := q.Execute(promMetricFamily)
-			if f.valid() {
-				metrics = append(metrics, f)
-			}
-		}
+	// The parser is lenient: even if an error is found midway through the response, the metrics found along the way
+	// are returned. Families with unsupported OpenMetrics types, which the text parser of Prometheus fails on, are
+	// skipped (see issue #1293).
+	parser := newResponseParser(queries, logger)
+	err := parser.parse(resp)
+	if err != nil {
+		err = fmt.Errorf("reading metrics failed: %w", err)
 	}
 
-	// parseResponse does some lenient parsing so metrics may be non-empty
-	// even when err is non-nil. We handle the cases here
+	if len(parser.skipped) > 0 {
+		logger.Infof("Skipped %d metric families with unsupported OpenMetrics types: %v", len(parser.skipped), parser.skipped)
+	}
+
+	metrics := parser.metricFamilies()
+
+	// We handle the cases of lenient parsing here.
 	if err != nil && len(metrics) > 0 {
 		// be lenient: log error case but don't bubble up failure
 		logger.Errorf("Failed while trying to parse metrics: %v", err)
@@ -340,6 +178,30 @@ func handleResponseWithFilter(resp *http.Response, queries []Query, logger *log.
 	return metrics, nil
 }
 
+// FilterMetricFamilies runs the given queries against already parsed Prometheus metric families and returns the
+// resulting, non-empty, MetricFamily objects. It allows sources other than an HTTP endpoint to be filtered with the
+// same semantics used when scraping.
+func FilterMetricFamilies(families []*model.MetricFamily, queries []Query) []MetricFamily {
+	metrics := make([]MetricFamily, 0)
+	for _, promMetricFamily := range families {
+		metrics = append(metrics, executeQueries(promMetricFamily, queries)...)
+	}
+
+	return metrics
+}
+
+func executeQueries(promMetricFamily *model.MetricFamily, queries []Query) []MetricFamily {
+	var metrics []MetricFamily
+	for _, q := range queries {
+		f := q.Execute(promMetricFamily)
+		if f.valid() {
+			metrics = append(metrics, f)
+		}
+	}
+
+	return metrics
+}
+
 // MetricFamiliesGetFunc is the interface satisfied by prometheus Client.
 // TODO: This whole flow is too convoluted, we should refactor and rename this.
 type MetricFamiliesGetFunc interface {
@@ -350,11 +212,13 @@ type MetricFamiliesGetFunc interface {
 
 type FetchAndFilterMetricsFamilies func([]Query) ([]MetricFamily, error)
 
-func GetFilteredMetricFamilies(httpClient client.HTTPDoer, url string, queries []Query, logger *log.Logger) ([]MetricFamily, error) {
+// GetFilteredMetricFamilies fetches the metric families matching queries from the endpoint at url, negotiating the
+// exposition format with acceptHeader, as in NewRequest.
+func GetFilteredMetricFamilies(httpClient client.HTTPDoer, url, acceptHeader string, queries []Query, logger *log.Logger) ([]MetricFamily, error) {
 	logger.Debugf("Calling a prometheus endpoint: %s", url)
 
 	// todo it would be nice to have context with deadline
-	req, err := NewRequest(url)
+	req, err := NewRequest(url, acceptHeader)
 	if err != nil {
 		return nil, fmt.Errorf("building request: %w", err)
 	}
@@ -1,16 +1,23 @@
 package prometheus
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/newrelic/nri-kubernetes/v3/internal/logutil"
 	model "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	prometheusmodel "github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -192,283 +199,540 @@ func TestQueryMatch_CustomName(t *testing.T) {
 	assert.Equal(t, expectedMetrics, q.Execute(&r))
 }
 
-//nolint:bodyclose
-func TestParseResponse(t *testing.T) {
+func TestFilterMetricFamilies(t *testing.T) {
 	t.Parallel()
 
-	chOne := make(chan *model.MetricFamily)
-	chTwo := make(chan *model.MetricFamily)
+	gauge := model.MetricType_GAUGE
+	families := []*model.MetricFamily{
+		{
+			Name: proto.String("kube_deployment_created"),
+			Type: &gauge,
+			Metric: []*model.Metric{
+				{
+					Gauge: &model.Gauge{Value: proto.Float64(1234)},
+					Label: []*model.LabelPair{{Name: proto.String("deployment"), Value: proto.String("nginx")}},
+				},
+			},
+		},
+		{
+			Name: proto.String("kube_deployment_spec_paused"),
+			Type: &gauge,
+			Metric: []*model.Metric{
+				{
+					Gauge: &model.Gauge{Value: proto.Float64(0)},
+					Label: []*model.LabelPair{{Name: proto.String("deployment"), Value: proto.String("nginx")}},
+				},
+			},
+		},
+	}
 
-	handlerOne := func(w http.ResponseWriter) {
-		_, err := io.WriteString(w,
-			`# HELP kube_pod_status_phase The pods current phase. 
+	queries := []Query{
+		{MetricName: "kube_deployment_created"},
+		{MetricName: "kube_deployment_spec_paused", Value: QueryValue{Value: GaugeValue(1)}},
+	}
+
+	expected := []MetricFamily{
+		{
+			Name: "kube_deployment_created",
+			Type: "GAUGE",
+			Metrics: []Metric{
+				{Labels: Labels{"deployment": "nginx"}, Value: GaugeValue(1234)},
+			},
+		},
+	}
+
+	assert.Equal(t, expected, FilterMetricFamilies(families, queries))
+}
+
+// textResponse returns a response with body in the text format, as KSM sends it.
+func textResponse(body string) *http.Response {
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = io.WriteString(w, body)
+
+	return w.Result()
+}
+
+// familyNames returns the names of families.
+func familyNames(families []MetricFamily) []string {
+	names := make([]string, 0, len(families))
+	for _, f := range families {
+		names = append(names, f.Name)
+	}
+
+	return names
+}
+
+func TestHandleResponseWithFilter_UnsupportedTypes(t *testing.T) {
+	t.Parallel()
+
+	queries := []Query{
+		{MetricName: "kube_pod_status_phase"},
+		{MetricName: "kube_custom_elasticsearch_health_status"},
+	}
+
+	for name, body := range map[string]string{
+		"stateset last": `# HELP kube_pod_status_phase The pods current phase. 
 			 # TYPE kube_pod_status_phase gauge
 			 kube_pod_status_phase{namespace="default",pod="123456789"} 1
 			 # HELP kube_custom_elasticsearch_health_status Elasticsearch CRD health status
 			 # TYPE kube_custom_elasticsearch_health_status stateset
 			 kube_custom_elasticsearch_health_status {customresource_group="elasticsearch.k8s.elastic.co"} 1
-			`)
-		assert.Nil(t, err)
-	}
-	handlerTwo := func(w http.ResponseWriter) {
-		_, err := io.WriteString(w,
-			`# HELP kube_custom_elasticsearch_health_status Elasticsearch CRD health status
+			`,
+		"stateset first": `# HELP kube_custom_elasticsearch_health_status Elasticsearch CRD health status
 			 # TYPE kube_custom_elasticsearch_health_status stateset
 			 kube_custom_elasticsearch_health_status {customresource_group="elasticsearch.k8s.elastic.co"} 1
 			 # HELP kube_pod_status_phase The pods current phase.
 			 # TYPE kube_pod_status_phase gauge
 			 kube_pod_status_phase{namespace="default",pod="123456789"} 1
-			`)
-		assert.Nil(t, err)
-	}
-
-	wOne := httptest.NewRecorder()
-	wTwo := httptest.NewRecorder()
-
-	handlerOne(wOne)
-	handlerTwo(wTwo)
-	responseOne := wOne.Result()
-	responseTwo := wTwo.Result()
-
-	defer responseOne.Body.Close()
-	defer responseTwo.Body.Close()
-
-	logger := logutil.Discard
-
-	var errOne error
-	var errTwo error
-	go func() {
-		errOne = parseResponse(responseOne, chOne, logger)
-	}()
-	go func() {
-		errTwo = parseResponse(responseTwo, chTwo, logger)
-	}()
-
-	var oneFamilies int
-	var twoFamilies int
-	for mf := range chOne {
-		_ = mf
-		oneFamilies++
-	}
-	for mf := range chTwo {
-		_ = mf
-		twoFamilies++
+			`,
+	} {
+		body := body
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			families, err := handleResponseWithFilter(textResponse(body), queries, logutil.Discard) //nolint:bodyclose
+			require.NoError(t, err, "unsupported types must be skipped")
+			assert.Equal(t, []MetricFamily{{
+				Name:    "kube_pod_status_phase",
+				Type:    "GAUGE",
+				Metrics: []Metric{{Labels: Labels{"namespace": "default", "pod": "123456789"}, Value: GaugeValue(1)}},
+			}}, families)
+		})
 	}
-
-	// With the new filtering logic, unsupported metric types are filtered out,
-	// so parsing should succeed and we should get all supported metrics regardless of position.
-	assert.Equal(t, 1, oneFamilies, "Should parse gauge metric before stateset")
-	assert.Equal(t, 1, twoFamilies, "Should filter out stateset and parse gauge metric that comes after")
-	assert.Nil(t, errOne, "Should not error when stateset comes after supported types")
-	assert.Nil(t, errTwo, "Should not error when stateset is filtered out")
 }
 
-// verifyReplicaSetMetrics is a helper function that verifies metric families contain
-// the expected ReplicaSet metrics with correct names, values, and no info metrics.
-func verifyReplicaSetMetrics(t *testing.T, metricFamilies []*model.MetricFamily, expectedMetricNames map[string]bool) {
-	t.Helper()
-
-	for _, mf := range metricFamilies {
-		assert.True(t, expectedMetricNames[mf.GetName()], "Unexpected metric family: %s", mf.GetName())
-		assert.NotEqual(t, "kube_gitrepository_resource_info", mf.GetName(), "Info metric should have been filtered out")
-
-		// Verify metrics have the expected labels and values
-		if mf.GetName() == "kube_replicaset_created" {
-			assert.Len(t, mf.GetMetric(), 1, "Should have 1 metric")
-			assert.Equal(t, float64(1620000000), mf.GetMetric()[0].GetGauge().GetValue())
-		} else if mf.GetName() == "kube_replicaset_status_replicas" {
-			assert.Len(t, mf.GetMetric(), 1, "Should have 1 metric")
-			assert.Equal(t, float64(3), mf.GetMetric()[0].GetGauge().GetValue())
-		}
-	}
-}
-
-// TestParseResponseWithInfoMetric tests that "info" type metrics (OpenMetrics 1.0)
-// are filtered out gracefully without losing subsequent metrics.
-// This test reproduces and validates the fix for issue #1293 where FluxCD info metrics
-// appear before ReplicaSet metrics, causing complete data loss.
-func TestParseResponseWithInfoMetric(t *testing.T) {
+// TestHandleResponseWithFilter_InfoMetric tests that "info" type metrics (OpenMetrics 1.0) are skipped gracefully
+// without losing subsequent metrics. This validates the fix for issue #1293 where FluxCD info metrics appear before
+// ReplicaSet metrics, causing complete data loss.
+func TestHandleResponseWithFilter_InfoMetric(t *testing.T) {
 	t.Parallel()
 
-	chOne := make(chan *model.MetricFamily)
-	chTwo := make(chan *model.MetricFamily)
+	queries := []Query{
+		{MetricName: "kube_gitrepository_resource_info"},
+		{MetricName: "kube_replicaset_created"},
+		{MetricName: "kube_replicaset_status_replicas"},
+	}
 
-	// Scenario 1: info metric BEFORE replicaset metrics (reproduces issue #1293)
-	handlerInfoFirst := func(w http.ResponseWriter) {
-		_, err := io.WriteString(w,
-			`# HELP kube_gitrepository_resource_info The current state of a GitOps Toolkit resource
+	info := `# HELP kube_gitrepository_resource_info The current state of a GitOps Toolkit resource
 			 # TYPE kube_gitrepository_resource_info info
 			 kube_gitrepository_resource_info{name="podinfo",exported_namespace="flux-system",ready="True",suspended="false"} 1
-			 # HELP kube_replicaset_created ReplicaSet creation timestamp
+			`
+	replicaSet := `# HELP kube_replicaset_created ReplicaSet creation timestamp
 			 # TYPE kube_replicaset_created gauge
 			 kube_replicaset_created{namespace="default",replicaset="nginx-123"} 1620000000
 			 # HELP kube_replicaset_status_replicas Number of replicas
 			 # TYPE kube_replicaset_status_replicas gauge
 			 kube_replicaset_status_replicas{namespace="default",replicaset="nginx-123"} 3
-			`)
-		assert.Nil(t, err)
-	}
+			`
 
-	// Scenario 2: info metric AFTER replicaset metrics
-	handlerInfoLast := func(w http.ResponseWriter) {
-		_, err := io.WriteString(w,
-			`# HELP kube_replicaset_created ReplicaSet creation timestamp
-			 # TYPE kube_replicaset_created gauge
-			 kube_replicaset_created{namespace="default",replicaset="nginx-123"} 1620000000
-			 # HELP kube_replicaset_status_replicas Number of replicas
-			 # TYPE kube_replicaset_status_replicas gauge
-			 kube_replicaset_status_replicas{namespace="default",replicaset="nginx-123"} 3
-			 # HELP kube_gitrepository_resource_info The current state of a GitOps Toolkit resource
-			 # TYPE kube_gitrepository_resource_info info
-			 kube_gitrepository_resource_info{name="podinfo",exported_namespace="flux-system",ready="True",suspended="false"} 1
-			`)
-		assert.Nil(t, err)
+	expected := []MetricFamily{
+		{
+			Name:    "kube_replicaset_created",
+			Type:    "GAUGE",
+			Metrics: []Metric{{Labels: Labels{"namespace": "default", "replicaset": "nginx-123"}, Value: GaugeValue(1620000000)}},
+		},
+		{
+			Name:    "kube_replicaset_status_replicas",
+			Type:    "GAUGE",
+			Metrics: []Metric{{Labels: Labels{"namespace": "default", "replicaset": "nginx-123"}, Value: GaugeValue(3)}},
+		},
 	}
 
-	wOne := httptest.NewRecorder()
-	wTwo := httptest.NewRecorder()
+	for name, body := range map[string]string{
+		"info first": info + replicaSet,
+		"info last":  replicaSet + info,
+	} {
+		body := body
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			families, err := handleResponseWithFilter(textResponse(body), queries, logutil.Discard) //nolint:bodyclose
+			require.NoError(t, err, "info types must be skipped")
+			assert.Equal(t, expected, families)
+		})
+	}
+}
 
-	handlerInfoFirst(wOne)
-	handlerInfoLast(wTwo)
-	responseOne := wOne.Result()
-	responseTwo := wTwo.Result()
+// TestHandleResponseWithFilter_LargeLines tests that lines exceeding the buffer used to read the response are parsed.
+func TestHandleResponseWithFilter_LargeLines(t *testing.T) {
+	t.Parallel()
 
-	defer responseOne.Body.Close()
-	defer responseTwo.Body.Close()
+	largeLabelValue1 := strings.Repeat("a", 70000)
+	largeLabelValue2 := strings.Repeat("b", 150000)
+	body := fmt.Sprintf(`# TYPE first_metric gauge
+# HELP first_metric First test metric
+first_metric{large_label="%s",namespace="default"} 1.0
+# TYPE unsupported_metric info
+# HELP unsupported_metric This should be filtered out
+unsupported_metric{label="%s"} 1
+# TYPE second_metric counter
+# HELP second_metric Second test metric
+second_metric{large_label="%s"} 2.0
+second_metric{label="value"} 42
+`, largeLabelValue1, largeLabelValue2, largeLabelValue2)
 
-	logger := logutil.Discard
+	queries := []Query{{MetricName: "first_metric"}, {MetricName: "unsupported_metric"}, {MetricName: "second_metric"}}
+	families, err := handleResponseWithFilter(textResponse(body), queries, logutil.Discard) //nolint:bodyclose
+	require.NoError(t, err, "Should handle large metric lines without error")
 
-	var errOne error
-	var errTwo error
-	go func() {
-		errOne = parseResponse(responseOne, chOne, logger)
-	}()
-	go func() {
-		errTwo = parseResponse(responseTwo, chTwo, logger)
-	}()
+	assert.Equal(t, []MetricFamily{
+		{
+			Name:    "first_metric",
+			Type:    "GAUGE",
+			Metrics: []Metric{{Labels: Labels{"large_label": largeLabelValue1, "namespace": "default"}, Value: GaugeValue(1)}},
+		},
+		{
+			Name: "second_metric",
+			Type: "COUNTER",
+			Metrics: []Metric{
+				{Labels: Labels{"large_label": largeLabelValue2}, Value: CounterValue(2)},
+				{Labels: Labels{"label": "value"}, Value: CounterValue(42)},
+			},
+		},
+	}, families)
+}
 
-	// Pre-allocate slices with expected capacity
-	metricFamiliesOne := make([]*model.MetricFamily, 0, 2)
-	metricFamiliesTwo := make([]*model.MetricFamily, 0, 2)
+func TestHandleResponseWithFilter_Matchers(t *testing.T) {
+	t.Parallel()
 
-	for mf := range chOne {
-		metricFamiliesOne = append(metricFamiliesOne, mf)
+	body := `# HELP kube_pod_status_phase The pods current phase.
+# TYPE kube_pod_status_phase gauge
+kube_pod_status_phase{namespace="default",pod="a",phase="Running"} 1
+kube_pod_status_phase{namespace="default",pod="a",phase="Pending"} 0
+kube_pod_status_phase { namespace = "kube-system" , pod="b", phase="Running", } 1 1620000000000
+# A comment which is not a declaration.
+kube_pod_labels{namespace="default",pod="a",label_description="say \"hi\"\nand \\ bye"} 1
+kube_untyped{pod="a"} 7
+`
+
+	queries := []Query{
+		{
+			CustomName: "running",
+			MetricName: "kube_pod_status_phase",
+			Labels:     QueryLabels{Labels: Labels{"phase": "Running"}},
+			Value:      QueryValue{Value: GaugeValue(1)},
+		},
+		{
+			MetricName: "kube_pod_status_phase",
+			Labels:     QueryLabels{Operator: QueryOpNor, Labels: Labels{"namespace": "kube-system"}},
+			Value:      QueryValue{Operator: QueryOpNor, Value: GaugeValue(1)},
+		},
+		{MetricName: "kube_pod_labels"},
+		{MetricName: "kube_untyped"},
 	}
-	for mf := range chTwo {
-		metricFamiliesTwo = append(metricFamiliesTwo, mf)
+
+	families, err := handleResponseWithFilter(textResponse(body), queries, logutil.Discard) //nolint:bodyclose
+	require.NoError(t, err)
+
+	assert.Equal(t, []MetricFamily{
+		{
+			Name: "running",
+			Type: "GAUGE",
+			Metrics: []Metric{
+				{Labels: Labels{"namespace": "default", "pod": "a", "phase": "Running"}, Value: GaugeValue(1)},
+				{Labels: Labels{"namespace": "kube-system", "pod": "b", "phase": "Running"}, Value: GaugeValue(1)},
+			},
+		},
+		{
+			Name:    "kube_pod_status_phase",
+			Type:    "GAUGE",
+			Metrics: []Metric{{Labels: Labels{"namespace": "default", "pod": "a", "phase": "Pending"}, Value: GaugeValue(0)}},
+		},
+		{
+			Name: "kube_pod_labels",
+			Type: "UNTYPED",
+			Metrics: []Metric{{
+				Labels: Labels{"namespace": "default", "pod": "a", "label_description": "say \"hi\"\nand \\ bye"},
+				Value:  EmptyValue,
+			}},
+		},
+		{
+			Name:    "kube_untyped",
+			Type:    "UNTYPED",
+			Metrics: []Metric{{Labels: Labels{"pod": "a"}, Value: EmptyValue}},
+		},
+	}, families)
+}
+
+func TestHandleResponseWithFilter_MalformedLine(t *testing.T) {
+	t.Parallel()
+
+	body := `# TYPE kube_pod_created gauge
+kube_pod_created{namespace="default",pod="a"} 1
+kube_pod_created{namespace="default",pod="b} 2
+kube_pod_created{namespace="default",pod="c"} 3
+`
+
+	families, err := handleResponseWithFilter(textResponse(body), []Query{{MetricName: "kube_pod_created"}}, logutil.Discard) //nolint:bodyclose
+	require.NoError(t, err, "metrics found before the error must be returned")
+	require.Len(t, families, 1)
+	assert.Len(t, families[0].Metrics, 1)
+
+	_, err = handleResponseWithFilter(textResponse(body), []Query{{MetricName: "kube_pod_created", Value: QueryValue{Value: GaugeValue(3)}}}, logutil.Discard) //nolint:bodyclose
+	assert.Error(t, err, "errors must be returned if no metrics were found")
+
+	// Lines of families which are not queried are not decoded.
+	families, err = handleResponseWithFilter(textResponse(body+"kube_pod_info{pod=\"a} 1\n"), []Query{{MetricName: "kube_pod_info"}, {MetricName: "other"}}, logutil.Discard) //nolint:bodyclose
+	assert.Error(t, err)
+	assert.Empty(t, families)
+}
+
+func TestHandleResponseWithFilter_MessageTooLarge(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", string(expfmt.FmtProtoDelim))
+	_, _ = w.Write(protowire.AppendVarint(nil, 1<<40))
+	_, _ = w.Write([]byte{0x0a, 0x01, 'a'})
+
+	_, err := handleResponseWithFilter(w.Result(), []Query{{MetricName: "a"}}, logutil.Discard) //nolint:bodyclose
+	assert.ErrorIs(t, err, errMessageTooLarge)
+}
+
+// expectedFamilies returns the families in body decoded by the text parser of Prometheus.
+func expectedFamilies(t *testing.T, body []byte) []*model.MetricFamily {
+	t.Helper()
+
+	parser := expfmt.NewTextParser(prometheusmodel.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	require.NoError(t, err)
+
+	result := make([]*model.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		result = append(result, mf)
 	}
 
-	// Both scenarios should succeed and return ReplicaSet metrics.
-	// The info metrics should be filtered out transparently before parsing.
-	// This validates the fix for issue #1293.
-	assert.Nil(t, errOne, "Should not error when info type is filtered out")
-	assert.Nil(t, errTwo, "Should not error when info type is filtered out")
+	return result
+}
 
-	// Verify we got exactly 2 metric families (ReplicaSet metrics only, info filtered out)
-	assert.Len(t, metricFamiliesOne, 2, "Should parse both ReplicaSet metrics even when info comes first (issue #1293)")
-	assert.Len(t, metricFamiliesTwo, 2, "Should parse both ReplicaSet metrics when info comes last")
+// allQueries returns a query for each family, plus some with label and value matchers on the first ones.
+func allQueries(families []*model.MetricFamily) []Query {
+	var queries []Query
+	for _, mf := range families {
+		queries = append(queries, Query{MetricName: mf.GetName()})
+		if len(mf.GetMetric()[0].GetLabel()) == 0 {
+			continue
+		}
 
-	// Verify the metric families are the expected ReplicaSet metrics
-	expectedMetricNames := map[string]bool{
-		"kube_replicaset_created":         true,
-		"kube_replicaset_status_replicas": true,
+		pair := mf.GetMetric()[0].GetLabel()[0]
+		queries = append(queries,
+			Query{
+				CustomName: mf.GetName() + "_and",
+				MetricName: mf.GetName(),
+				Labels:     QueryLabels{Labels: Labels{pair.GetName(): pair.GetValue()}},
+			},
+			Query{
+				CustomName: mf.GetName() + "_nor",
+				MetricName: mf.GetName(),
+				Labels:     QueryLabels{Operator: QueryOpNor, Labels: Labels{pair.GetName(): pair.GetValue()}},
+				Value:      QueryValue{Operator: QueryOpNor, Value: GaugeValue(0)},
+			},
+		)
 	}
 
-	verifyReplicaSetMetrics(t, metricFamiliesOne, expectedMetricNames)
-	verifyReplicaSetMetrics(t, metricFamiliesTwo, expectedMetricNames)
+	return queries
 }
 
-// TestFilterUnsupportedMetrics_LargeLines tests that the filter can handle metric lines
-// exceeding the default bufio.Scanner buffer size (64KB).
-func TestFilterUnsupportedMetrics_LargeLines(t *testing.T) {
+func TestHandleResponseWithFilter_SameAsPrometheusParser(t *testing.T) {
 	t.Parallel()
 
-	// Create a metric line > 64KB with many labels
-	largeLabelValue := strings.Repeat("x", 70000)
-	input := fmt.Sprintf(`# TYPE test_metric gauge
-# HELP test_metric Test metric with large label value
-test_metric{large_label="%s",namespace="default"} 1.0
-# TYPE another_metric counter
-# HELP another_metric Another test metric
-another_metric{label="value"} 42
-`, largeLabelValue)
-
-	reader := strings.NewReader(input)
-	logger := logutil.Discard
-
-	// This should fail with the current implementation due to 64KB buffer limit
-	filtered, skipped, err := filterUnsupportedMetrics(reader, logger)
-
-	// After the fix, this should succeed
-	assert.NoError(t, err, "Should handle large metric lines without error")
-	assert.Empty(t, skipped, "No metrics should be skipped")
-
-	// Verify the lines were processed correctly
-	filteredContent, err := io.ReadAll(filtered)
-	assert.NoError(t, err)
-	assert.Contains(t, string(filteredContent), "test_metric")
-	assert.Contains(t, string(filteredContent), "another_metric")
-	assert.Contains(t, string(filteredContent), largeLabelValue, "Large label value should be preserved")
+	for _, file := range []string{
+		"testdata/metrics_plain.txt",
+		"../kubelet/metric/testdata/k8s_v1_16_kubelet_metrics_cadvisor_payload_plain.txt",
+	} {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+
+			body, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			families := expectedFamilies(t, body)
+			queries := allQueries(families)
+			expected := FilterMetricFamilies(families, queries)
+
+			text, err := handleResponseWithFilter(textResponse(string(body)), queries, logutil.Discard) //nolint:bodyclose
+			require.NoError(t, err)
+			assert.ElementsMatch(t, expected, text)
+
+			// Only some families are decoded, but the result of their queries must be the same.
+			text, err = handleResponseWithFilter(textResponse(string(body)), queries[:len(queries)/2], logutil.Discard) //nolint:bodyclose
+			require.NoError(t, err)
+			assert.ElementsMatch(t, FilterMetricFamilies(families, queries[:len(queries)/2]), text)
+
+			w := httptest.NewRecorder()
+			w.Header().Set("Content-Type", string(expfmt.FmtProtoDelim))
+			encoder := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeProtoDelim))
+			for _, mf := range families {
+				require.NoError(t, encoder.Encode(mf))
+			}
+
+			protobuf, err := handleResponseWithFilter(w.Result(), queries, logutil.Discard) //nolint:bodyclose
+			require.NoError(t, err)
+			assert.ElementsMatch(t, expected, protobuf)
+		})
+	}
 }
 
-// TestFilterUnsupportedMetrics_MultipleLargeLines tests handling of multiple large metric lines.
-func TestFilterUnsupportedMetrics_MultipleLargeLines(t *testing.T) {
+func TestGetFilteredMetricFamilies_TextFormat(t *testing.T) {
 	t.Parallel()
 
-	// Create multiple metric lines that exceed 64KB
-	largeLabelValue1 := strings.Repeat("a", 70000)
-	largeLabelValue2 := strings.Repeat("b", 80000)
-	input := fmt.Sprintf(`# TYPE first_metric gauge
-# HELP first_metric First test metric
-first_metric{large_label="%s"} 1.0
-# TYPE second_metric gauge
-# HELP second_metric Second test metric
-second_metric{large_label="%s"} 2.0
-`, largeLabelValue1, largeLabelValue2)
+	body, err := os.ReadFile("testdata/metrics_plain.txt")
+	require.NoError(t, err)
+
+	families := expectedFamilies(t, body)
+	queries := allQueries(families)
 
-	reader := strings.NewReader(input)
-	logger := logutil.Discard
+	// The server serves the same families in the format negotiated with the Accept header.
+	served := make(chan expfmt.FormatType, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := expfmt.Negotiate(r.Header)
+		served <- format.FormatType()
 
-	filtered, skipped, err := filterUnsupportedMetrics(reader, logger)
+		w.Header().Set("Content-Type", string(format))
+		encoder := expfmt.NewEncoder(w, format)
+		for _, mf := range families {
+			if err := encoder.Encode(mf); err != nil {
+				t.Error(err)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	protobuf, err := GetFilteredMetricFamilies(server.Client(), server.URL, AcceptHeader, queries, logutil.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, expfmt.TypeProtoDelim, <-served)
 
-	assert.NoError(t, err, "Should handle multiple large metric lines without error")
-	assert.Empty(t, skipped, "No metrics should be skipped")
+	text, err := GetFilteredMetricFamilies(server.Client(), server.URL, TextAcceptHeader, queries, logutil.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, expfmt.TypeTextPlain, <-served)
 
-	filteredContent, err := io.ReadAll(filtered)
-	assert.NoError(t, err)
-	assert.Contains(t, string(filteredContent), "first_metric")
-	assert.Contains(t, string(filteredContent), "second_metric")
+	assert.NotEmpty(t, text)
+	assert.ElementsMatch(t, protobuf, text, "the text format must give the same metrics as the protobuf one")
 }
 
-// TestFilterUnsupportedMetrics_LargeLinesWithUnsupportedTypes tests that large lines
-// work correctly when mixed with unsupported metric types that need to be filtered.
-func TestFilterUnsupportedMetrics_LargeLinesWithUnsupportedTypes(t *testing.T) {
+func TestHandleResponseWithFilter_SharedLabels(t *testing.T) {
 	t.Parallel()
 
-	largeLabelValue := strings.Repeat("x", 70000)
-	input := fmt.Sprintf(`# TYPE supported_metric gauge
-# HELP supported_metric Supported metric with large label
-supported_metric{large_label="%s"} 1.0
-# TYPE unsupported_metric info
-# HELP unsupported_metric This should be filtered out
-unsupported_metric{label="value"} 1
-# TYPE another_supported_metric counter
-# HELP another_supported_metric Another supported metric
-another_supported_metric{label="test"} 42
-`, largeLabelValue)
-
-	reader := strings.NewReader(input)
-	logger := logutil.Discard
-
-	filtered, skipped, err := filterUnsupportedMetrics(reader, logger)
-
-	assert.NoError(t, err, "Should handle large lines with unsupported types")
-	assert.Len(t, skipped, 1, "Should skip one unsupported metric")
-	assert.Equal(t, "unsupported_metric", skipped[0])
-
-	filteredContent, err := io.ReadAll(filtered)
-	assert.NoError(t, err)
-	assert.Contains(t, string(filteredContent), "supported_metric")
-	assert.Contains(t, string(filteredContent), "another_supported_metric")
-	assert.NotContains(t, string(filteredContent), "unsupported_metric")
+	body := []byte(`# TYPE kube_pod_created gauge
+kube_pod_created{namespace="default",pod="a"} 1
+kube_pod_created{namespace="default",pod="b"} 2
+# TYPE kube_pod_annotations gauge
+kube_pod_annotations{namespace="default",pod="a"} 1
+kube_pod_annotations{namespace="default",pod="b",annotation_team="x"} 1
+`)
+	families := expectedFamilies(t, body)
+	var queries []Query
+	for _, mf := range families {
+		queries = append(queries, Query{MetricName: mf.GetName()})
+	}
+
+	responses := map[string]*http.Response{"text": textResponse(string(body))}
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeProtoDelim)))
+	encoder := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	for _, mf := range families {
+		require.NoError(t, encoder.Encode(mf))
+	}
+	responses["protobuf"] = w.Result() //nolint:bodyclose
+
+	for format, resp := range responses {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := handleResponseWithFilter(resp, queries, logutil.Discard)
+			require.NoError(t, err)
+
+			byName := map[string]MetricFamily{}
+			for _, mf := range result {
+				byName[mf.Name] = mf
+			}
+			created, annotations := byName["kube_pod_created"].Metrics, byName["kube_pod_annotations"].Metrics
+			require.Len(t, created, 2)
+			require.Len(t, annotations, 2)
+
+			assert.Equal(t, Labels{"namespace": "default", "pod": "a"}, annotations[0].Labels)
+			assert.Equal(t, reflect.ValueOf(created[0].Labels).Pointer(), reflect.ValueOf(annotations[0].Labels).Pointer(),
+				"metrics with the same label set must share their labels")
+			assert.Equal(t, Labels{"namespace": "default", "pod": "b", "annotation_team": "x"}, annotations[1].Labels)
+			assert.NotEqual(t, reflect.ValueOf(created[1].Labels).Pointer(), reflect.ValueOf(annotations[1].Labels).Pointer())
+		})
+	}
+}
+
+func TestHandleResponseWithFilter_Histogram(t *testing.T) {
+	t.Parallel()
+
+	body := `# HELP kubelet_pleg_relist_duration_seconds Duration in seconds for relisting pods in PLEG.
+# TYPE kubelet_pleg_relist_duration_seconds histogram
+kubelet_pleg_relist_duration_seconds_bucket{le="0.005"} 1
+kubelet_pleg_relist_duration_seconds_bucket{le="0.01"} 3
+kubelet_pleg_relist_duration_seconds_bucket{le="+Inf"} 4
+kubelet_pleg_relist_duration_seconds_sum 0.5
+kubelet_pleg_relist_duration_seconds_count 4
+# HELP kubelet_running_pods Number of pods that have a running pod sandbox
+# TYPE kubelet_running_pods gauge
+kubelet_running_pods 12
+kubelet_running_pods_count{pod="a"} 1
+`
+
+	queries := []Query{{MetricName: "kubelet_pleg_relist_duration_seconds", Histogram: true}, {MetricName: "kubelet_running_pods"}}
+	families, err := handleResponseWithFilter(textResponse(body), queries, logutil.Discard) //nolint:bodyclose
+	require.NoError(t, err)
+	require.Len(t, families, 2)
+
+	assert.Equal(t, "HISTOGRAM", families[0].Type)
+	require.Len(t, families[0].Metrics, 1)
+	h, ok := families[0].Metrics[0].Value.(*model.Histogram)
+	require.True(t, ok)
+	assert.Equal(t, uint64(4), h.GetSampleCount())
+	assert.Len(t, h.GetBucket(), 3)
+
+	assert.Equal(t, MetricFamily{
+		Name:    "kubelet_running_pods",
+		Type:    "GAUGE",
+		Metrics: []Metric{{Labels: Labels{}, Value: GaugeValue(12)}},
+	}, families[1])
+
+	// Histograms are only decoded for the queries asking for them.
+	families, err = handleResponseWithFilter(textResponse(body), []Query{{MetricName: "kubelet_pleg_relist_duration_seconds"}}, logutil.Discard) //nolint:bodyclose
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Len(t, families[0].Metrics, 1)
+	_, ok = families[0].Metrics[0].Value.(*model.Histogram)
+	assert.False(t, ok)
+}
+
+// generateKSMResponse returns a response in the text format with the given number of pods, and families which are not
+// queried with ten times as many samples.
+func generateKSMResponse(pods int) []byte {
+	var b bytes.Buffer
+	b.WriteString("# HELP kube_pod_info Information about pod.\n# TYPE kube_pod_info gauge\n")
+	for n := 0; n < pods; n++ {
+		fmt.Fprintf(&b, "kube_pod_info{namespace=\"namespace-%d\",pod=\"pod-%d\",uid=\"%08d-0000-0000-0000-000000000000\",node=\"node-%d\",created_by_kind=\"ReplicaSet\"} 1\n", n/100, n, n, n%50)
+	}
+	b.WriteString("# HELP kube_pod_container_status_waiting_reason Describes the reason the container is currently in waiting state.\n# TYPE kube_pod_container_status_waiting_reason gauge\n")
+	for n := 0; n < pods*10; n++ {
+		fmt.Fprintf(&b, "kube_pod_container_status_waiting_reason{namespace=\"namespace-%d\",pod=\"pod-%d\",container=\"container\",reason=\"reason-%d\"} 0\n", n/1000, n/10, n%10)
+	}
+
+	return b.Bytes()
+}
+
+func BenchmarkHandleResponseWithFilter(b *testing.B) {
+	body := generateKSMResponse(20000)
+	queries := []Query{{MetricName: "kube_pod_info"}}
+
+	b.Run("text", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for n := 0; n < b.N; n++ {
+			resp := textResponse("")
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			if _, err := handleResponseWithFilter(resp, queries, logutil.Discard); err != nil { //nolint:bodyclose
+				b.Fatal(err)
+			}
+		}
+	})
 }
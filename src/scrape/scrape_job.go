@@ -3,12 +3,16 @@ package scrape
 import (
 	"errors"
 
+	"github.com/newrelic/infra-integrations-sdk/data/attribute"
 	"github.com/newrelic/infra-integrations-sdk/integration"
 	"github.com/newrelic/nri-kubernetes/v3/src/populator"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/version"
 
+	"github.com/newrelic/nri-kubernetes/v3/internal/attributefilter"
+	"github.com/newrelic/nri-kubernetes/v3/internal/config"
 	"github.com/newrelic/nri-kubernetes/v3/internal/discovery"
+	"github.com/newrelic/nri-kubernetes/v3/internal/sample"
 	"github.com/newrelic/nri-kubernetes/v3/src/data"
 	"github.com/newrelic/nri-kubernetes/v3/src/definition"
 )
@@ -33,10 +37,14 @@ func NewScrapeJob(name string, grouper data.Grouper, specs definition.SpecGroups
 
 // Job hold all information specific to a certain Scrape Job, e.g.: where do I get the data from, and what data
 type Job struct {
-	Name     string
-	Grouper  data.Grouper
-	Specs    definition.SpecGroups
-	Filterer discovery.NamespaceFilterer
+	Name             string
+	Grouper          data.Grouper
+	Specs            definition.SpecGroups
+	Filterer         discovery.NamespaceFilterer
+	AttributeFilter  attributefilter.Filterer
+	CustomAttributes []attribute.Attribute
+	NodeAttributes   definition.NodeAttributesGetter
+	PopulateWorkers  int
 }
 
 // JobWithFilterer returns an OptionFunc to add a Filterer.
@@ -46,6 +54,34 @@ func JobWithFilterer(filterer discovery.NamespaceFilterer) JobOpt {
 	}
 }
 
+// JobWithAttributeFilter returns an OptionFunc to filter the label and annotation attributes reported.
+func JobWithAttributeFilter(filter attributefilter.Filterer) JobOpt {
+	return func(j *Job) {
+		j.AttributeFilter = filter
+	}
+}
+
+// JobWithCustomAttributes returns an OptionFunc to add static attributes to all samples.
+func JobWithCustomAttributes(attributes []config.CustomAttribute) JobOpt {
+	return func(j *Job) {
+		j.CustomAttributes = sample.CustomAttributes(attributes)
+	}
+}
+
+// JobWithNodeAttributes returns an OptionFunc to add the attributes of their node to samples.
+func JobWithNodeAttributes(getter definition.NodeAttributesGetter) JobOpt {
+	return func(j *Job) {
+		j.NodeAttributes = getter
+	}
+}
+
+// JobWithPopulateWorkers returns an OptionFunc to change the number of entities populated in parallel.
+func JobWithPopulateWorkers(workers int) JobOpt {
+	return func(j *Job) {
+		j.PopulateWorkers = workers
+	}
+}
+
 // Populate will get the data using the given Group, transform it, and push it to the given Integration.
 func (s *Job) Populate(
 	i *integration.Integration,
@@ -65,17 +101,21 @@ func (s *Job) Populate(
 		logger.Tracef("%s", errs)
 	}
 
-	config := &definition.IntegrationPopulateConfig{
-		Integration:    i,
-		ClusterName:    clusterName,
-		CloudClusterID: cloudClusterID,
-		K8sVersion:     k8sVersion,
-		Specs:          s.Specs,
-		MsTypeGuesser:  definition.K8sMetricSetTypeGuesser,
-		Groups:         groups,
-		Filterer:       s.Filterer,
+	populateConfig := &definition.IntegrationPopulateConfig{
+		Integration:      i,
+		ClusterName:      clusterName,
+		CloudClusterID:   cloudClusterID,
+		K8sVersion:       k8sVersion,
+		Specs:            s.Specs,
+		MsTypeGuesser:    definition.K8sMetricSetTypeGuesser,
+		Groups:           groups,
+		Filterer:         s.Filterer,
+		AttributeFilter:  s.AttributeFilter,
+		CustomAttributes: s.CustomAttributes,
+		NodeAttributes:   s.NodeAttributes,
+		Workers:          s.PopulateWorkers,
 	}
-	ok, populateErrs := populator.IntegrationPopulator(config)
+	ok, populateErrs := populator.IntegrationPopulator(populateConfig)
 
 	if len(populateErrs) > 0 {
 		return data.PopulateResult{Errors: populateErrs, Populated: ok}
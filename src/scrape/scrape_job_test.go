@@ -59,43 +59,56 @@ var expectedEntities = []*integration.Entity{
 		Metrics: []*sdkMetric.Set{
 			{
 				Metrics: map[string]interface{}{
-					"event_type":                     "K8sPodSample",
-					"net.rxBytesPerSecond":           0., // 106175985, but is RATE
-					"net.txBytesPerSecond":           0., // 35714359, but is RATE
-					"net.errorsPerSecond":            0.,
-					"createdAt":                      float64(parseTime("2018-02-14T16:26:33Z").Unix()),
-					"startTime":                      float64(parseTime("2018-02-14T16:26:33Z").Unix()),
-					"initializedAt":                  float64(parseTime("2018-02-14T16:26:33Z").Unix()),
-					"readyAt":                        float64(parseTime("2018-02-27T15:21:18Z").Unix()),
-					"scheduledAt":                    float64(parseTime("2018-02-14T16:27:00Z").Unix()),
-					"createdKind":                    "DaemonSet",
-					"createdBy":                      "newrelic-infra",
-					"nodeIP":                         "192.168.99.100",
-					"podIP":                          "172.17.0.3",
-					"namespace":                      "kube-system",
-					"namespaceName":                  "kube-system",
-					"nodeName":                       "minikube",
-					"podName":                        "newrelic-infra-rz225",
-					"daemonsetName":                  "newrelic-infra",
-					"isReady":                        float64(1),
-					"status":                         "Running",
-					"isScheduled":                    float64(1),
-					"label.controller-revision-hash": "3887482659",
-					"label.name":                     "newrelic-infra",
-					"label.pod-template-generation":  "1",
-					"displayName":                    "newrelic-infra-rz225", // From entity attributes
-					"clusterName":                    "test-cluster",         // From entity attributes
-					"cpuCoresUtilization":            float64(1.6874347),
-					"cpuLimitCores":                  float64(1),
-					"cpuRequestedCores":              float64(0.5),
-					"cpuUsedCores":                   float64(0.016874347),
-					"memoryLimitBytes":               float64(1.048576e+08),
-					"memoryRequestedBytes":           float64(5.24288e+07),
-					"memoryUsedBytes":                float64(5.2617216e+07),
-					"memoryUtilization":              float64(50.1796875),
-					"memoryWorkingSetBytes":          float64(5.0044928e+07),
-					"requestedCpuCoresUtilization":   float64(3.3748694),
-					"requestedMemoryUtilization":     float64(100.359375),
+					"event_type":                 "K8sPodSample",
+					"net.rxBytesPerSecond":       0., // 106175985, but is RATE
+					"net.txBytesPerSecond":       0., // 35714359, but is RATE
+					"net.errorsPerSecond":        0.,
+					"ephemeralStorageUsedBytes":  float64(159744),
+					"ephemeralStorageInodesUsed": float64(36),
+					"createdAt":                  float64(parseTime("2018-02-14T16:26:33Z").Unix()),
+					"startTime":                  float64(parseTime("2018-02-14T16:26:33Z").Unix()),
+					"initializedAt":              float64(parseTime("2018-02-14T16:26:33Z").Unix()),
+					"readyAt":                    float64(parseTime("2018-02-27T15:21:18Z").Unix()),
+					"scheduledAt":                float64(parseTime("2018-02-14T16:27:00Z").Unix()),
+					"schedulingLatencySeconds":   float64(27),
+					"startupLatencySeconds":      float64(1119285),
+					"createdKind":                "DaemonSet",
+					"createdBy":                  "newrelic-infra",
+					"nodeIP":                     "192.168.99.100",
+					"podIP":                      "172.17.0.3",
+					"namespace":                  "kube-system",
+					"namespaceName":              "kube-system",
+					"nodeName":                   "minikube",
+					"podName":                    "newrelic-infra-rz225",
+					"daemonsetName":              "newrelic-infra",
+					"workloadKind":               "DaemonSet",
+					"workloadName":               "newrelic-infra",
+					"isReady":                    float64(1),
+					"status":                     "Running",
+					"isScheduled":                float64(1),
+					"qosClass":                   "Burstable",
+					"condition.Initialized":      float64(1),
+					"condition.PodScheduled":     float64(1),
+					"condition.Ready":            float64(1),
+					"conditionLastTransitionTime.Initialized":  float64(parseTime("2018-02-14T16:26:33Z").Unix()),
+					"conditionLastTransitionTime.PodScheduled": float64(parseTime("2018-02-14T16:27:00Z").Unix()),
+					"conditionLastTransitionTime.Ready":        float64(parseTime("2018-02-27T15:21:18Z").Unix()),
+					"label.controller-revision-hash":           "3887482659",
+					"label.name":                               "newrelic-infra",
+					"label.pod-template-generation":            "1",
+					"displayName":                              "newrelic-infra-rz225", // From entity attributes
+					"clusterName":                              "test-cluster",         // From entity attributes
+					"cpuCoresUtilization":                      float64(1.6874347),
+					"cpuLimitCores":                            float64(1),
+					"cpuRequestedCores":                        float64(0.5),
+					"cpuUsedCores":                             float64(0.016874347),
+					"memoryLimitBytes":                         float64(1.048576e+08),
+					"memoryRequestedBytes":                     float64(5.24288e+07),
+					"memoryUsedBytes":                          float64(5.2617216e+07),
+					"memoryUtilization":                        float64(50.1796875),
+					"memoryWorkingSetBytes":                    float64(5.0044928e+07),
+					"requestedCpuCoresUtilization":             float64(3.3748694),
+					"requestedMemoryUtilization":               float64(100.359375),
 				},
 			},
 		},
@@ -111,36 +124,44 @@ var expectedEntities = []*integration.Entity{
 		Metrics: []*sdkMetric.Set{
 			{
 				Metrics: map[string]interface{}{
-					"event_type":                 "K8sContainerSample",
-					"memoryUsedBytes":            float64(18083840),
-					"memoryWorkingSetBytes":      float64(17113088),
-					"memoryUtilization":          float64(17.24609375),
-					"cpuUsedCores":               0.01742824,
-					"fsAvailableBytes":           float64(14924988416),
-					"fsUsedBytes":                float64(126976),
-					"fsUsedPercent":              0.0008507538914443524,
-					"fsCapacityBytes":            float64(17293533184),
-					"fsInodesFree":               float64(9713372),
-					"requestedMemoryUtilization": float64(17.24609375),
-					"fsInodes":                   float64(9732096),
-					"fsInodesUsed":               float64(36),
-					"containerName":              "newrelic-infra",
-					"containerID":                "69d7203a8f2d2d027ffa51d61002eac63357f22a17403363ef79e66d1c3146b2",
-					"containerImage":             "newrelic/ohaik:1.0.0-beta3",
-					"containerImageID":           "sha256:1a95d0df2997f93741fbe2a15d2c31a394e752fd942ec29bf16a44163342f6a1",
-					"namespace":                  "kube-system",
-					"namespaceName":              "kube-system",
-					"podName":                    "newrelic-infra-rz225",
-					"daemonsetName":              "newrelic-infra",
-					"nodeName":                   "minikube",
-					"nodeIP":                     "192.168.99.100",
-					"restartCount":               float64(6),
-					"restartCountDelta":          float64(0), // 0 the first time as it is PDELTA
-					"cpuRequestedCores":          0.1,
-					"memoryRequestedBytes":       float64(104857600),
-					"memoryLimitBytes":           float64(104857600),
-					"status":                     "Running",
-					"isReady":                    float64(1),
+					"event_type":                  "K8sContainerSample",
+					"memoryUsedBytes":             float64(18083840),
+					"memoryWorkingSetBytes":       float64(17113088),
+					"memoryUtilization":           float64(17.24609375),
+					"cpuUsedCores":                0.01742824,
+					"fsAvailableBytes":            float64(14924988416),
+					"fsUsedBytes":                 float64(126976),
+					"fsUsedPercent":               0.0008507538914443524,
+					"fsCapacityBytes":             float64(17293533184),
+					"fsInodesFree":                float64(9713372),
+					"requestedMemoryUtilization":  float64(17.24609375),
+					"fsInodes":                    float64(9732096),
+					"fsInodesUsed":                float64(36),
+					"logsUsedBytes":               float64(32768),
+					"logsInodesUsed":              float64(18724),
+					"containerName":               "newrelic-infra",
+					"containerID":                 "69d7203a8f2d2d027ffa51d61002eac63357f22a17403363ef79e66d1c3146b2",
+					"containerImage":              "newrelic/ohaik:1.0.0-beta3",
+					"containerType":               "regular",
+					"containerImageID":            "sha256:1a95d0df2997f93741fbe2a15d2c31a394e752fd942ec29bf16a44163342f6a1",
+					"namespace":                   "kube-system",
+					"namespaceName":               "kube-system",
+					"podName":                     "newrelic-infra-rz225",
+					"daemonsetName":               "newrelic-infra",
+					"workloadKind":                "DaemonSet",
+					"workloadName":                "newrelic-infra",
+					"nodeName":                    "minikube",
+					"nodeIP":                      "192.168.99.100",
+					"restartCount":                float64(6),
+					"restartCountDelta":           float64(0), // 0 the first time as it is PDELTA
+					"cpuRequestedCores":           0.1,
+					"memoryRequestedBytes":        float64(104857600),
+					"memoryLimitBytes":            float64(104857600),
+					"desiredCpuRequestedCores":    0.1,
+					"desiredMemoryRequestedBytes": float64(104857600),
+					"desiredMemoryLimitBytes":     float64(104857600),
+					"status":                      "Running",
+					"isReady":                     float64(1),
 					//"reason":               "",      // TODO ?
 					"lastTerminatedExitCode":         float64(0),
 					"lastTerminatedExitReason":       "Completed",